@@ -0,0 +1,158 @@
+-- Neovim front end for `irg serve --msgpack`: runs searches through irg and
+-- shows the matches in a floating window.
+local M = {}
+
+local config = {
+  cmd = "irg",
+  case = "smart",
+  limit = 1000,
+}
+
+local chan
+
+-- channel starts irg on first use and returns its RPC channel
+local function channel()
+  if chan then
+    return chan
+  end
+  local id = vim.fn.jobstart({ config.cmd, "serve", "--msgpack", "--stdio" }, {
+    rpc = true,
+    on_exit = function()
+      chan = nil
+    end,
+  })
+  if id <= 0 then
+    error("irg: could not start " .. config.cmd)
+  end
+  chan = id
+  return chan
+end
+
+function M.setup(opts)
+  config = vim.tbl_extend("force", config, opts or {})
+end
+
+-- search runs a search and returns {matches, count}, or nil on failure.
+-- opts may set path (default: the working directory), case, types and limit.
+function M.search(pattern, opts)
+  opts = opts or {}
+  local ok, result = pcall(function()
+    return vim.rpcrequest(channel(), "search", {
+      pattern = pattern,
+      path = opts.path or vim.fn.getcwd(),
+      case = opts.case or config.case,
+      types = opts.types,
+      limit = opts.limit or config.limit,
+    })
+  end)
+  if not ok then
+    vim.notify("irg: " .. tostring(result), vim.log.levels.ERROR)
+    return nil
+  end
+  return result
+end
+
+local function jump(m)
+  vim.cmd.edit(vim.fn.fnameescape(m.path))
+  local col = m.submatches[1] and m.submatches[1].start - 1 or 0
+  vim.api.nvim_win_set_cursor(0, { m.line, col })
+end
+
+local function to_quickfix(pattern, matches)
+  local items = {}
+  for _, m in ipairs(matches) do
+    table.insert(items, {
+      filename = m.path,
+      lnum = m.line,
+      col = m.submatches[1] and m.submatches[1].start or 1,
+      text = m.text,
+    })
+  end
+  vim.fn.setqflist({}, " ", { title = "irg " .. pattern, items = items })
+  vim.cmd.copen()
+end
+
+-- show lists matches in a floating window: <CR> jumps to a match, <C-q>
+-- sends them all to the quickfix list, q or <Esc> closes
+local function show(pattern, result)
+  if result.count == 0 then
+    vim.notify("irg: no matches for " .. pattern)
+    return
+  end
+
+  local lines = {}
+  for i, m in ipairs(result.matches) do
+    lines[i] = string.format("%s:%d: %s", vim.fn.fnamemodify(m.path, ":~:."), m.line, m.text)
+  end
+
+  local buf = vim.api.nvim_create_buf(false, true)
+  vim.api.nvim_buf_set_lines(buf, 0, -1, false, lines)
+  vim.bo[buf].modifiable = false
+  vim.bo[buf].bufhidden = "wipe"
+
+  -- Submatch columns are 1-based byte offsets into the text
+  local ns = vim.api.nvim_create_namespace("irg")
+  for i, m in ipairs(result.matches) do
+    local prefix = #lines[i] - #m.text
+    for _, sm in ipairs(m.submatches) do
+      vim.api.nvim_buf_add_highlight(buf, ns, "Search", i - 1, prefix + sm.start - 1, prefix + sm["end"] - 1)
+    end
+  end
+
+  local width = math.floor(vim.o.columns * 0.8)
+  local height = math.min(#lines, math.floor(vim.o.lines * 0.6))
+  local win = vim.api.nvim_open_win(buf, true, {
+    relative = "editor",
+    width = width,
+    height = height,
+    row = math.floor((vim.o.lines - height) / 2),
+    col = math.floor((vim.o.columns - width) / 2),
+    style = "minimal",
+    border = "rounded",
+    title = string.format(" irg: %s (%d) ", pattern, result.count),
+    title_pos = "center",
+  })
+  vim.wo[win].cursorline = true
+
+  local function close()
+    if vim.api.nvim_win_is_valid(win) then
+      vim.api.nvim_win_close(win, true)
+    end
+  end
+  local function map(key, fn)
+    vim.keymap.set("n", key, fn, { buffer = buf, nowait = true })
+  end
+
+  map("<CR>", function()
+    local m = result.matches[vim.api.nvim_win_get_cursor(win)[1]]
+    close()
+    jump(m)
+  end)
+  map("<C-q>", function()
+    close()
+    to_quickfix(pattern, result.matches)
+  end)
+  map("q", close)
+  map("<Esc>", close)
+  vim.api.nvim_create_autocmd("WinLeave", { buffer = buf, once = true, callback = close })
+end
+
+-- grep searches for pattern, prompting for it (defaulting to the word under
+-- the cursor) when nil, and shows the results
+function M.grep(pattern, opts)
+  if pattern == nil or pattern == "" then
+    vim.ui.input({ prompt = "irg: ", default = vim.fn.expand("<cword>") }, function(input)
+      if input and input ~= "" then
+        M.grep(input, opts)
+      end
+    end)
+    return
+  end
+
+  local result = M.search(pattern, opts)
+  if result then
+    show(pattern, result)
+  end
+end
+
+return M
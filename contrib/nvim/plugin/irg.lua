@@ -0,0 +1,8 @@
+if vim.g.loaded_irg then
+  return
+end
+vim.g.loaded_irg = true
+
+vim.api.nvim_create_user_command("Irg", function(args)
+  require("irg").grep(args.args)
+end, { nargs = "?", desc = "Search with irg" })
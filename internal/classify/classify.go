@@ -0,0 +1,86 @@
+// Package classify labels search results by path, such as matches in tests or
+// generated code, so the UI can dim, tag or hide them.
+package classify
+
+import (
+	"path"
+	"strings"
+)
+
+// Style is how results of a class are shown
+type Style string
+
+const (
+	StyleDim Style = "dim" // Greyed out
+	StyleTag Style = "tag" // Prefixed with the class name
+)
+
+// Class is a named set of path globs
+type Class struct {
+	Name  string
+	Globs []string
+	Style Style
+}
+
+// Defaults are used when the config defines no classes
+var Defaults = []Class{
+	{Name: "generated", Style: StyleTag, Globs: []string{"*.pb.go", "*.pb.gw.go", "*_generated.go", "zz_generated*.go", "*.gen.go", "*_pb2.py", "*.min.js"}},
+	{Name: "test", Style: StyleDim, Globs: []string{"*_test.go", "test_*.py", "*_test.py", "*.test.js", "*.test.ts", "*.spec.js", "*.spec.ts", "testdata/*"}},
+}
+
+// Classifier finds the class of a path. A nil Classifier classifies nothing.
+type Classifier struct {
+	classes []Class
+}
+
+// New returns a classifier that tries classes in order
+func New(classes []Class) *Classifier {
+	if len(classes) == 0 {
+		return nil
+	}
+	return &Classifier{classes: classes}
+}
+
+// Classes returns the classes in the order they are tried
+func (c *Classifier) Classes() []Class {
+	if c == nil {
+		return nil
+	}
+	return c.classes
+}
+
+// Classify returns the first class with a glob matching p, or nil
+func (c *Classifier) Classify(p string) *Class {
+	if c == nil {
+		return nil
+	}
+	p = strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "./")
+	for i := range c.classes {
+		for _, glob := range c.classes[i].Globs {
+			if Match(glob, p) {
+				return &c.classes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Match reports whether glob matches the slash-separated path p. Globs
+// without a slash match the file name; globs with one match the trailing
+// components of p, so "testdata/*" matches "pkg/testdata/in.txt".
+func Match(glob, p string) bool {
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(p))
+		return ok
+	}
+	depth := strings.Count(glob, "/") + 1
+	parts := strings.Split(p, "/")
+	// Try the glob against every run of trailing components long enough for
+	// it, and against every directory prefix for globs naming a directory
+	for start := 0; start+depth <= len(parts); start++ {
+		if ok, _ := path.Match(glob, strings.Join(parts[start:start+depth], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
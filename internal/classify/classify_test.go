@@ -0,0 +1,52 @@
+package classify
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*_test.go", "internal/ui/model_test.go", true},
+		{"*_test.go", "internal/ui/model.go", false},
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"testdata/*", "internal/search/testdata/big.log", true},
+		{"testdata/*", "testdata/in.txt", true},
+		{"testdata/*", "internal/testdata.go", false},
+		{"testdata/*", "testdata/nested/in.txt", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.glob, tt.path); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestClassify_FirstMatchingClass(t *testing.T) {
+	c := New([]Class{
+		{Name: "generated", Globs: []string{"*.pb.go"}, Style: StyleTag},
+		{Name: "test", Globs: []string{"*_test.go", "*.pb.go"}, Style: StyleDim},
+	})
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"./api/service.pb.go", "generated"},
+		{`internal\ui\model_test.go`, "test"},
+		{"main.go", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if class := c.Classify(tt.path); class != nil {
+			got = class.Name
+		}
+		if got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	var none *Classifier
+	if none.Classify("a_test.go") != nil || none.Classes() != nil {
+		t.Error("nil classifier classified a path")
+	}
+}
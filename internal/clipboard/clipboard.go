@@ -0,0 +1,78 @@
+// Package clipboard copies text to the system clipboard through whichever
+// backend the platform offers: pbcopy, wl-copy, xclip/xsel, the Windows
+// clipboard, or the OSC 52 terminal escape sequence as a universal fallback.
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Backend copies text to a clipboard
+type Backend interface {
+	// Name identifies the backend in status and error messages
+	Name() string
+	Copy(text string) error
+}
+
+// Detect returns the best available backend for the current environment,
+// falling back to OSC 52 when no clipboard command is installed (e.g. over SSH)
+func Detect() Backend {
+	for _, b := range candidates() {
+		if _, err := exec.LookPath(b.path); err == nil {
+			return b
+		}
+	}
+	return NewOSC52(os.Stderr)
+}
+
+// candidates lists command backends in order of preference for this platform
+func candidates() []*commandBackend {
+	switch runtime.GOOS {
+	case "darwin":
+		return []*commandBackend{{path: "pbcopy"}}
+	case "windows":
+		return []*commandBackend{{
+			path: "powershell.exe",
+			args: []string{"-NoProfile", "-NonInteractive", "-Command", "[Console]::In.ReadToEnd() | Set-Clipboard"},
+		}}
+	}
+
+	var backends []*commandBackend
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		backends = append(backends, &commandBackend{path: "wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		backends = append(backends,
+			&commandBackend{path: "xclip", args: []string{"-selection", "clipboard"}},
+			&commandBackend{path: "xsel", args: []string{"--clipboard", "--input"}},
+		)
+	}
+	return backends
+}
+
+// commandBackend pipes text into an external clipboard command
+type commandBackend struct {
+	path string
+	args []string
+}
+
+func (b *commandBackend) Name() string {
+	return strings.TrimSuffix(b.path, ".exe")
+}
+
+func (b *commandBackend) Copy(text string) error {
+	cmd := exec.Command(b.path, b.args...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg != "" {
+			return fmt.Errorf("%s: %w: %s", b.Name(), err, msg)
+		}
+		return fmt.Errorf("%s: %w", b.Name(), err)
+	}
+	return nil
+}
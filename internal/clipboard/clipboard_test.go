@@ -0,0 +1,56 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestOSC52_Copy(t *testing.T) {
+	var buf bytes.Buffer
+	o := &OSC52{w: &buf}
+	if err := o.Copy("main.go:12"); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	want := "\x1b]52;c;bWFpbi5nbzoxMg==\a"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestOSC52_TmuxPassthrough(t *testing.T) {
+	var buf bytes.Buffer
+	o := &OSC52{w: &buf, tmux: true}
+	if err := o.Copy("hi"); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	want := "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var b Backend = m
+	b.Copy("one")
+	b.Copy("two")
+
+	if got := m.Last(); got != "two" {
+		t.Errorf("Last() = %q, want two", got)
+	}
+	if got := m.Copied(); len(got) != 2 || got[0] != "one" {
+		t.Errorf("Copied() = %v", got)
+	}
+
+	m.Err = errors.New("no clipboard")
+	if err := b.Copy("three"); err == nil {
+		t.Error("expected configured error")
+	}
+}
+
+func TestDetect_ReturnsBackend(t *testing.T) {
+	if b := Detect(); b == nil || b.Name() == "" {
+		t.Errorf("Detect() = %v, want a named backend", b)
+	}
+}
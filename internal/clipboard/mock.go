@@ -0,0 +1,43 @@
+package clipboard
+
+import "sync"
+
+// Mock records copied text instead of touching a real clipboard
+type Mock struct {
+	mu     sync.Mutex
+	copied []string
+
+	// Err, if set, is returned from every Copy call
+	Err error
+}
+
+func (m *Mock) Name() string {
+	return "mock"
+}
+
+func (m *Mock) Copy(text string) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.copied = append(m.copied, text)
+	return nil
+}
+
+// Copied returns everything copied so far, oldest first
+func (m *Mock) Copied() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.copied...)
+}
+
+// Last returns the most recently copied text, or "" if nothing was copied
+func (m *Mock) Last() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.copied) == 0 {
+		return ""
+	}
+	return m.copied[len(m.copied)-1]
+}
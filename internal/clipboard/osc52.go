@@ -0,0 +1,42 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// OSC52 copies text by asking the terminal to set the clipboard via the OSC 52
+// escape sequence. It works over SSH and inside tmux (with set-clipboard on),
+// but only if the terminal emulator supports it.
+type OSC52 struct {
+	w    io.Writer
+	tmux bool
+}
+
+// NewOSC52 creates a backend writing escape sequences to w
+func NewOSC52(w io.Writer) *OSC52 {
+	return &OSC52{w: w, tmux: os.Getenv("TMUX") != ""}
+}
+
+func (o *OSC52) Name() string {
+	return "osc52"
+}
+
+func (o *OSC52) Copy(text string) error {
+	if _, err := io.WriteString(o.w, o.sequence(text)); err != nil {
+		return fmt.Errorf("osc52: %w", err)
+	}
+	return nil
+}
+
+func (o *OSC52) sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if o.tmux {
+		// tmux passthrough: wrap in DCS and double every ESC inside it
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
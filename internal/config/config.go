@@ -0,0 +1,487 @@
+// Package config loads irg's user configuration from a TOML file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/William9923/irg/internal/contrast"
+)
+
+// Config is the contents of config.toml. Every field is optional.
+type Config struct {
+	Hooks   Hooks   `toml:"hooks"`
+	Replace Replace `toml:"replace"`
+	Paths   Paths   `toml:"paths"`
+	Search  Search  `toml:"search"`
+	Preview Preview `toml:"preview"`
+	Editor  Editor  `toml:"editor"`
+	Diff    Diff    `toml:"diff"`
+	Stats   Stats   `toml:"stats"`
+	History History `toml:"history"`
+	Colors  Colors  `toml:"colors"`
+	UI      UI      `toml:"ui"`
+	Todos   Todos   `toml:"todos"`
+
+	// Types defines extra ripgrep file types, such as
+	// web = ["*.ts", "*.tsx", "*.css"]
+	Types map[string][]string `toml:"types"`
+
+	// Classes label results by path, such as tests or generated code. When
+	// set they replace the default test and generated classes.
+	Classes map[string]Class `toml:"classes"`
+
+	// Bookmarks name directories the path input can switch to, such as
+	// work = "~/src/work"; a leading ~ stands for the home directory
+	Bookmarks map[string]string `toml:"bookmarks"`
+
+	// Profiles add search profiles, or replace the built-in ones of the
+	// same name
+	Profiles map[string]Profile `toml:"profiles"`
+
+	// Lint lists the linters run on previewed files, each written as a
+	// [[lint]] table; the first whose globs match a file lints it
+	Lint []Linter `toml:"lint"`
+}
+
+// Hooks are shell commands run on lifecycle events, with match details in
+// IRG_* environment variables
+type Hooks struct {
+	OnOpen              string `toml:"on-open"`
+	OnSearchComplete    string `toml:"on-search-complete"`
+	OnExitWithSelection string `toml:"on-exit-with-selection"`
+}
+
+// Replace configures the external tool used by the replace action
+type Replace struct {
+	// Command is a shell command template; see replace.Tool
+	Command string `toml:"command"`
+}
+
+// Paths tunes the index behind the path suggestions
+type Paths struct {
+	// MaxDepth is how many directory levels are indexed; 0 keeps the default
+	MaxDepth int `toml:"max-depth"`
+	// Skip lists glob patterns for file and directory names left out of the
+	// index. When set it replaces the default of node_modules and vendor.
+	Skip []string `toml:"skip"`
+}
+
+func (p Paths) validate() error {
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("paths.max-depth must not be negative, got %d", p.MaxDepth)
+	}
+	for _, pattern := range p.Skip {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("paths.skip pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Search sets defaults for the search flags; flags given on the command
+// line take precedence
+type Search struct {
+	// Case is smart, sensitive or insensitive; "" keeps smart
+	Case          string `toml:"case"`
+	GitTracked    bool   `toml:"git-tracked"`
+	NoIgnore      bool   `toml:"no-ignore"`
+	Multiline     bool   `toml:"multiline"`
+	WordRegexp    bool   `toml:"word-regexp"`
+	Normalize     bool   `toml:"normalize"`
+	StableOrder   bool   `toml:"stable-order"`
+	InlineContext bool   `toml:"inline-context"`
+	FileInfo      bool   `toml:"file-info"`
+	SortRecent    bool   `toml:"sort-recent"`
+	RepoRoot      bool   `toml:"repo-root"`
+	Shards        int    `toml:"shards"`
+	// MaxDepth limits how many directory levels below the search path are
+	// searched; 0 searches at any depth
+	MaxDepth int `toml:"max-depth"`
+	// MaxLineLength cuts longer result lines down to an excerpt around the
+	// match; 0 keeps the default of 1000 bytes
+	MaxLineLength int `toml:"max-line-length"`
+	// Profile names the search profile applied at startup, such as "go";
+	// "auto" picks one from the files in the search path
+	Profile string `toml:"profile"`
+	// Timeout stops searches running longer, such as "10s", keeping the
+	// results found so far; "" lets them run to the end
+	Timeout string `toml:"timeout"`
+	// ConfirmFiles asks before searching a tree of more files than this;
+	// 0 asks only before searching the filesystem root or home directory
+	ConfirmFiles int `toml:"confirm-files"`
+	// BatchSize is how many matches of a running search are shown at once;
+	// 0 keeps the default of 100
+	BatchSize int `toml:"batch-size"`
+	// BatchInterval is how often the matches found so far are shown, such
+	// as "50ms"; "" keeps the default
+	BatchInterval string `toml:"batch-interval"`
+	// BatchTuning adjusts the batches to how fast the screen keeps up,
+	// starting from BatchSize and BatchInterval; nil keeps it on
+	BatchTuning *bool `toml:"batch-tuning"`
+}
+
+func (s Search) validate() error {
+	switch s.Case {
+	case "", "smart", "sensitive", "insensitive":
+	default:
+		return fmt.Errorf("search.case must be smart, sensitive or insensitive, got %q", s.Case)
+	}
+	if s.Shards < 0 {
+		return fmt.Errorf("search.shards must not be negative, got %d", s.Shards)
+	}
+	if s.MaxDepth < 0 {
+		return fmt.Errorf("search.max-depth must not be negative, got %d", s.MaxDepth)
+	}
+	if s.MaxLineLength < 0 {
+		return fmt.Errorf("search.max-line-length must not be negative, got %d", s.MaxLineLength)
+	}
+	if s.ConfirmFiles < 0 {
+		return fmt.Errorf("search.confirm-files must not be negative, got %d", s.ConfirmFiles)
+	}
+	if s.Timeout != "" {
+		if d, err := time.ParseDuration(s.Timeout); err != nil || d < 0 {
+			return fmt.Errorf("search.timeout must be a duration such as \"10s\", got %q", s.Timeout)
+		}
+	}
+	if s.BatchSize < 0 {
+		return fmt.Errorf("search.batch-size must not be negative, got %d", s.BatchSize)
+	}
+	if s.BatchInterval != "" {
+		if d, err := time.ParseDuration(s.BatchInterval); err != nil || d <= 0 {
+			return fmt.Errorf("search.batch-interval must be a duration such as \"50ms\", got %q", s.BatchInterval)
+		}
+	}
+	return nil
+}
+
+// SearchTimeout returns the parsed Timeout, or 0 when it is unset
+func (s Search) SearchTimeout() time.Duration {
+	d, _ := time.ParseDuration(s.Timeout)
+	return d
+}
+
+// SearchBatchInterval returns the parsed BatchInterval, or 0 when it is unset
+func (s Search) SearchBatchInterval() time.Duration {
+	d, _ := time.ParseDuration(s.BatchInterval)
+	return d
+}
+
+// Preview configures the preview pane
+type Preview struct {
+	// Theme is a chroma style name, such as "monokai" or "dracula"
+	Theme string `toml:"theme"`
+	// Syntax turns syntax highlighting on or off; nil keeps it on
+	Syntax *bool `toml:"syntax"`
+	// Context is the number of lines shown around a match; 0 keeps the
+	// default of 5
+	Context int `toml:"context"`
+	// Contexts override Context for some files, such as more lines for logs
+	// and fewer for dense configs. The first with a matching glob is used.
+	Contexts []PreviewContext `toml:"contexts"`
+}
+
+// PreviewContext is the context shown around matches in files matching
+// Globs, written as for classes
+type PreviewContext struct {
+	Globs []string `toml:"globs"`
+	Lines int      `toml:"lines"`
+}
+
+func (p Preview) validate() error {
+	if p.Context < 0 {
+		return fmt.Errorf("preview.context must not be negative, got %d", p.Context)
+	}
+	for i, c := range p.Contexts {
+		if len(c.Globs) == 0 {
+			return fmt.Errorf("preview.contexts[%d]: no globs", i)
+		}
+		for _, glob := range c.Globs {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("preview.contexts[%d] glob %q: %w", i, glob, err)
+			}
+		}
+		if c.Lines < 0 {
+			return fmt.Errorf("preview.contexts[%d].lines must not be negative, got %d", i, c.Lines)
+		}
+	}
+	return nil
+}
+
+// Editor chooses the editor results are opened in
+type Editor struct {
+	// Command is the editor and its arguments, such as "code --wait"; ""
+	// falls back to $EDITOR and $VISUAL
+	Command string `toml:"command"`
+}
+
+// Diff configures the tool the diff-head action compares files with
+type Diff struct {
+	// Command is a shell command template; see difftool.Tool
+	Command string `toml:"command"`
+}
+
+// Stats controls the local usage statistics shown by `irg stats`
+type Stats struct {
+	// Record counts finished searches in the stats file; on when unset
+	Record *bool `toml:"record"`
+}
+
+// Todos configures the comments `irg todos` collects
+type Todos struct {
+	// Tags are the words marking the comments, such as "XXX" or "NOTE";
+	// TODO, FIXME and HACK when unset
+	Tags []string `toml:"tags"`
+}
+
+func (t Todos) validate() error {
+	for _, tag := range t.Tags {
+		if tag == "" || strings.ContainsFunc(tag, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }) {
+			return fmt.Errorf("todos.tags: %q is not a word", tag)
+		}
+	}
+	return nil
+}
+
+// History configures the search history recalled in the pattern input
+type History struct {
+	// Record saves finished searches to the history file; on when unset
+	Record *bool `toml:"record"`
+}
+
+// Colors overrides the highlight colors, each an ANSI 256-color number
+// ("237") or a hex code ("#ffd700")
+type Colors struct {
+	SelectedBackground     string `toml:"selected-background"`
+	Match                  string `toml:"match"`
+	PreviewMatchForeground string `toml:"preview-match-foreground"`
+	PreviewMatchBackground string `toml:"preview-match-background"`
+	// Background is the terminal's background for contrast checks; ""
+	// asks the terminal
+	Background string `toml:"background"`
+	// Contrast is "adjust" to fix colors that would be unreadable, "warn" to
+	// only report them, or "off"; "" means adjust
+	Contrast string `toml:"contrast"`
+}
+
+func (c Colors) validate() error {
+	for _, color := range []struct{ key, value string }{
+		{"selected-background", c.SelectedBackground},
+		{"match", c.Match},
+		{"preview-match-foreground", c.PreviewMatchForeground},
+		{"preview-match-background", c.PreviewMatchBackground},
+		{"background", c.Background},
+	} {
+		if color.value == "" {
+			continue
+		}
+		if _, err := contrast.Parse(color.value); err != nil {
+			return fmt.Errorf("colors.%s: %w", color.key, err)
+		}
+	}
+	switch c.Contrast {
+	case "", "adjust", "warn", "off":
+	default:
+		return fmt.Errorf("colors.contrast must be adjust, warn or off, got %q", c.Contrast)
+	}
+	return nil
+}
+
+// UI configures how irg uses the terminal
+type UI struct {
+	// Mouse captures the mouse for scrolling the results and copying
+	// preview lines; off, the terminal selects text as usual. On when unset.
+	Mouse *bool `toml:"mouse"`
+	// Bind maps keys to actions with the syntax of --bind, such as
+	// "ctrl-o:open-editor,ctrl-j:down". --bind flags apply after it.
+	Bind string `toml:"bind"`
+}
+
+// Class labels the results whose paths match one of its globs
+type Class struct {
+	// Globs match the file name, or the path when they contain a slash
+	Globs []string `toml:"globs"`
+	// Style is "dim" to grey the results out or "tag" to prefix them with
+	// the class name; "" means dim
+	Style string `toml:"style"`
+}
+
+// validateClasses rejects classes with no or malformed globs or an unknown
+// style
+func validateClasses(classes map[string]Class) error {
+	for name, class := range classes {
+		if name == "" {
+			return fmt.Errorf("classes: empty class name")
+		}
+		if len(class.Globs) == 0 {
+			return fmt.Errorf("classes.%s: no globs", name)
+		}
+		for _, glob := range class.Globs {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("classes.%s glob %q: %w", name, glob, err)
+			}
+		}
+		switch class.Style {
+		case "", "dim", "tag":
+		default:
+			return fmt.Errorf("classes.%s: style must be dim or tag, got %q", name, class.Style)
+		}
+	}
+	return nil
+}
+
+// Profile is a named set of file types to search and files to leave out,
+// curated for a language ecosystem
+type Profile struct {
+	Types []string `toml:"types"`
+	// Exclude lists globs for files and directories left out at any depth,
+	// such as "node_modules" or "*.min.js"
+	Exclude []string `toml:"exclude"`
+}
+
+// Linter is a linter for the files matching Globs, written as for classes
+type Linter struct {
+	Globs []string `toml:"globs"`
+	// Command is a shell command template printing path:line:column:
+	// message lines; see lint.Linter
+	Command string `toml:"command"`
+}
+
+// validateLinters rejects linters without a command or with malformed globs
+func validateLinters(linters []Linter) error {
+	for i, l := range linters {
+		if strings.TrimSpace(l.Command) == "" {
+			return fmt.Errorf("lint[%d]: no command", i)
+		}
+		if len(l.Globs) == 0 {
+			return fmt.Errorf("lint[%d]: no globs", i)
+		}
+		for _, glob := range l.Globs {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("lint[%d] glob %q: %w", i, glob, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateProfiles rejects profiles with malformed exclude globs
+func validateProfiles(profiles map[string]Profile) error {
+	for name, profile := range profiles {
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("profiles: invalid profile name %q", name)
+		}
+		if name == "auto" {
+			return fmt.Errorf("profiles: auto is reserved for picking a profile from the files searched")
+		}
+		for _, glob := range profile.Exclude {
+			if _, err := filepath.Match(glob, ""); glob == "" || err != nil {
+				return fmt.Errorf("profiles.%s: invalid exclude glob %q", name, glob)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTypes rejects type definitions rg's --type-add can't express
+func validateTypes(types map[string][]string) error {
+	for name, globs := range types {
+		if name == "" || strings.ContainsAny(name, ":, \t") {
+			return fmt.Errorf("types: invalid type name %q", name)
+		}
+		if len(globs) == 0 {
+			return fmt.Errorf("types.%s: no globs", name)
+		}
+		for _, glob := range globs {
+			if glob == "" || strings.Contains(glob, ",") {
+				return fmt.Errorf("types.%s: invalid glob %q (list each glob separately)", name, glob)
+			}
+		}
+	}
+	return nil
+}
+
+// Path returns the config file location: $IRG_CONFIG, else
+// $XDG_CONFIG_HOME/irg/config.toml, else ~/.config/irg/config.toml
+func Path() (string, error) {
+	if path := os.Getenv("IRG_CONFIG"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "irg", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "irg", "config.toml"), nil
+}
+
+// Load reads the config from Path. A missing file yields an empty config.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads the config at path. A missing file yields an empty config.
+func LoadFile(path string) (*Config, error) {
+	cfg := &Config{}
+	meta, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("load %s: unknown key %q", path, undecoded[0].String())
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if err := c.Paths.validate(); err != nil {
+		return err
+	}
+	if err := c.Search.validate(); err != nil {
+		return err
+	}
+	if err := c.Preview.validate(); err != nil {
+		return err
+	}
+	if err := c.Colors.validate(); err != nil {
+		return err
+	}
+	if err := c.Todos.validate(); err != nil {
+		return err
+	}
+	if err := validateTypes(c.Types); err != nil {
+		return err
+	}
+	if err := validateProfiles(c.Profiles); err != nil {
+		return err
+	}
+	if err := validateLinters(c.Lint); err != nil {
+		return err
+	}
+	for name, path := range c.Bookmarks {
+		if path == "" {
+			return fmt.Errorf("bookmarks.%s: empty path", name)
+		}
+	}
+	return validateClasses(c.Classes)
+}
@@ -0,0 +1,432 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFile_MissingIsEmpty(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !reflect.DeepEqual(*cfg, Config{}) {
+		t.Errorf("got %+v, want empty config", cfg)
+	}
+}
+
+func TestLoadFile_Hooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[hooks]
+on-open = "echo $IRG_PATH >> ~/.irg_history"
+on-exit-with-selection = "notify-send irg"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Hooks.OnOpen != "echo $IRG_PATH >> ~/.irg_history" || cfg.Hooks.OnExitWithSelection != "notify-send irg" {
+		t.Errorf("got %+v", cfg.Hooks)
+	}
+}
+
+func TestLoadFile_UnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[hooks]\non-close = \"true\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "on-close") {
+		t.Errorf("err = %v, want unknown key error", err)
+	}
+}
+
+func TestLoadFile_Paths(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Paths
+		wantErr string
+	}{
+		{"depth and skip", "[paths]\nmax-depth = 12\nskip = [\"bazel-*\", \"target\"]\n", Paths{MaxDepth: 12, Skip: []string{"bazel-*", "target"}}, ""},
+		{"negative depth", "[paths]\nmax-depth = -1\n", Paths{}, "max-depth"},
+		{"bad pattern", "[paths]\nskip = [\"[a-\"]\n", Paths{}, "[a-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Paths, tt.want) {
+				t.Errorf("got %+v, want %+v", cfg.Paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_SearchTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    time.Duration
+		wantErr string
+	}{
+		{"seconds", "[search]\ntimeout = \"10s\"\n", 10 * time.Second, ""},
+		{"unset", "[search]\ncase = \"smart\"\n", 0, ""},
+		{"no unit", "[search]\ntimeout = \"10\"\n", 0, "timeout"},
+		{"negative", "[search]\ntimeout = \"-1m\"\n", 0, "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if got := cfg.Search.SearchTimeout(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_Types(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string][]string
+		wantErr string
+	}{
+		{"table", "[types]\nweb = [\"*.ts\", \"*.tsx\", \"*.css\"]\n", map[string][]string{"web": {"*.ts", "*.tsx", "*.css"}}, ""},
+		{"dotted key", "types.proto = [\"*.proto\"]\n", map[string][]string{"proto": {"*.proto"}}, ""},
+		{"colon in name", "[types]\n\"a:b\" = [\"*.x\"]\n", nil, "invalid type name"},
+		{"no globs", "[types]\nweb = []\n", nil, "no globs"},
+		{"comma in glob", "[types]\nweb = [\"*.{ts,tsx}\"]\n", nil, "invalid glob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Types, tt.want) {
+				t.Errorf("got %v, want %v", cfg.Types, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_Classes(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]Class
+		wantErr string
+	}{
+		{"table", "[classes.generated]\nglobs = [\"*.pb.go\"]\nstyle = \"tag\"\n", map[string]Class{"generated": {Globs: []string{"*.pb.go"}, Style: "tag"}}, ""},
+		{"default style", "[classes.test]\nglobs = [\"*_test.go\", \"testdata/*\"]\n", map[string]Class{"test": {Globs: []string{"*_test.go", "testdata/*"}}}, ""},
+		{"no globs", "[classes.test]\nstyle = \"dim\"\n", nil, "no globs"},
+		{"bad glob", "[classes.test]\nglobs = [\"[a-\"]\n", nil, "[a-"},
+		{"unknown style", "[classes.test]\nglobs = [\"*_test.go\"]\nstyle = \"bold\"\n", nil, "dim or tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Classes, tt.want) {
+				t.Errorf("got %v, want %v", cfg.Classes, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_Profiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]Profile
+		wantErr string
+	}{
+		{"table", "[profiles.web]\ntypes = [\"ts\", \"css\"]\nexclude = [\"node_modules\", \"*.min.js\"]\n", map[string]Profile{"web": {Types: []string{"ts", "css"}, Exclude: []string{"node_modules", "*.min.js"}}}, ""},
+		{"exclude only", "[profiles.go]\nexclude = [\"vendor\"]\n", map[string]Profile{"go": {Exclude: []string{"vendor"}}}, ""},
+		{"bad glob", "[profiles.web]\nexclude = [\"[a-\"]\n", nil, "[a-"},
+		{"reserved name", "[profiles.auto]\ntypes = [\"go\"]\n", nil, "reserved"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Profiles, tt.want) {
+				t.Errorf("got %v, want %v", cfg.Profiles, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_ConfirmFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[search]\nconfirm-files = 200000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := LoadFile(path); err != nil || cfg.Search.ConfirmFiles != 200000 {
+		t.Errorf("LoadFile = %+v, %v; want confirm-files 200000", cfg, err)
+	}
+
+	if err := os.WriteFile(path, []byte("[search]\nconfirm-files = -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "confirm-files") {
+		t.Errorf("err = %v, want a negative confirm-files rejected", err)
+	}
+}
+
+func TestLoadFile_Batching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[search]\nbatch-size = 500\nbatch-interval = \"200ms\"\nbatch-tuning = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Search.BatchSize != 500 || cfg.Search.SearchBatchInterval() != 200*time.Millisecond || cfg.Search.BatchTuning == nil || *cfg.Search.BatchTuning {
+		t.Errorf("search = %+v, want batches of 500 every 200ms, untuned", cfg.Search)
+	}
+
+	for _, bad := range []string{"batch-size = -1", "batch-interval = \"0s\"", "batch-interval = \"soon\""} {
+		if err := os.WriteFile(path, []byte("[search]\n"+bad+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "batch-") {
+			t.Errorf("%s: err = %v, want it rejected", bad, err)
+		}
+	}
+}
+
+func TestLoadFile_TodosTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[todos]\ntags = [\"TODO\", \"XXX\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"TODO", "XXX"}; !reflect.DeepEqual(cfg.Todos.Tags, want) {
+		t.Errorf("tags = %q, want %q", cfg.Todos.Tags, want)
+	}
+
+	if err := os.WriteFile(path, []byte("[todos]\ntags = [\"TODO(\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "not a word") {
+		t.Errorf("err = %v, want the tag rejected", err)
+	}
+}
+
+func TestLoadFile_Lint(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Linter
+		wantErr string
+	}{
+		{"in order", "[[lint]]\nglobs = [\"*.py\"]\ncommand = \"ruff check --output-format=concise {file}\"\n\n[[lint]]\nglobs = [\"*.sh\"]\ncommand = \"shellcheck -f gcc {file}\"\n",
+			[]Linter{{Globs: []string{"*.py"}, Command: "ruff check --output-format=concise {file}"}, {Globs: []string{"*.sh"}, Command: "shellcheck -f gcc {file}"}}, ""},
+		{"no command", "[[lint]]\nglobs = [\"*.py\"]\n", nil, "no command"},
+		{"no globs", "[[lint]]\ncommand = \"ruff check {file}\"\n", nil, "no globs"},
+		{"bad glob", "[[lint]]\nglobs = [\"[a-\"]\ncommand = \"ruff check {file}\"\n", nil, "[a-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Lint, tt.want) {
+				t.Errorf("got %v, want %v", cfg.Lint, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_PreviewContexts(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []PreviewContext
+		wantErr string
+	}{
+		{"in order", "[[preview.contexts]]\nglobs = [\"*.log\"]\nlines = 20\n\n[[preview.contexts]]\nglobs = [\"*.json\", \"config/*\"]\nlines = 1\n",
+			[]PreviewContext{{Globs: []string{"*.log"}, Lines: 20}, {Globs: []string{"*.json", "config/*"}, Lines: 1}}, ""},
+		{"no globs", "[[preview.contexts]]\nlines = 20\n", nil, "no globs"},
+		{"bad glob", "[[preview.contexts]]\nglobs = [\"[a-\"]\n", nil, "[a-"},
+		{"negative lines", "[[preview.contexts]]\nglobs = [\"*.log\"]\nlines = -1\n", nil, "negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Preview.Contexts, tt.want) {
+				t.Errorf("got %v, want %v", cfg.Preview.Contexts, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_Colors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[colors]
+selected-background = "#303030"
+match = "214"
+contrast = "warn"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Colors.SelectedBackground != "#303030" || cfg.Colors.Match != "214" || cfg.Colors.Contrast != "warn" {
+		t.Errorf("got %+v", cfg.Colors)
+	}
+
+	for _, bad := range []string{"match = \"yellow\"", "contrast = \"loud\""} {
+		if err := os.WriteFile(path, []byte("[colors]\n"+bad+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "colors.") {
+			t.Errorf("LoadFile(%s) = %v, want a colors error", bad, err)
+		}
+	}
+}
+
+func TestLoadFile_UIBind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[ui]
+bind = "ctrl-o:open-editor,ctrl-q:ignore"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.UI.Bind != "ctrl-o:open-editor,ctrl-q:ignore" {
+		t.Errorf("bind = %q", cfg.UI.Bind)
+	}
+}
+
+func TestPath_Precedence(t *testing.T) {
+	t.Setenv("IRG_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if got, _ := Path(); got != filepath.Join("/xdg", "irg", "config.toml") {
+		t.Errorf("XDG path = %s", got)
+	}
+
+	t.Setenv("IRG_CONFIG", "/custom.toml")
+	if got, _ := Path(); got != "/custom.toml" {
+		t.Errorf("IRG_CONFIG path = %s", got)
+	}
+}
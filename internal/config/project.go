@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFile is the name of the per-project config file, found by walking
+// up from the search root
+const ProjectFile = ".irg.toml"
+
+// FindProject returns the ProjectFile in dir or the nearest of its parents,
+// or "" when there is none
+func FindProject(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("find %s: %w", ProjectFile, err)
+	}
+	for d := abs; ; {
+		path := filepath.Join(d, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", nil
+		}
+		d = parent
+	}
+}
+
+// Merge overlays the settings of a project config onto c: set scalars and
+// lists replace c's, and types, classes and profiles are merged by name.
+// Hooks, linters and the replace command run shell commands, so a project
+// file, which arrives with a repository, can't set them, nor the editor and
+// diff commands; colors and mouse capture are left to the user, whose
+// terminal they must suit, and so is recording stats. Merge returns the keys
+// it ignored.
+func (c *Config) Merge(project *Config) (ignored []string) {
+	if project.Hooks != (Hooks{}) {
+		ignored = append(ignored, "hooks")
+	}
+	if project.Replace != (Replace{}) {
+		ignored = append(ignored, "replace")
+	}
+	if project.Editor != (Editor{}) {
+		ignored = append(ignored, "editor")
+	}
+	if project.Diff != (Diff{}) {
+		ignored = append(ignored, "diff")
+	}
+	if project.Colors != (Colors{}) {
+		ignored = append(ignored, "colors")
+	}
+	if project.UI != (UI{}) {
+		ignored = append(ignored, "ui")
+	}
+	if project.Stats != (Stats{}) {
+		ignored = append(ignored, "stats")
+	}
+	if project.History != (History{}) {
+		ignored = append(ignored, "history")
+	}
+	if len(project.Bookmarks) > 0 {
+		ignored = append(ignored, "bookmarks")
+	}
+	if len(project.Lint) > 0 {
+		ignored = append(ignored, "lint")
+	}
+
+	if project.Paths.MaxDepth != 0 {
+		c.Paths.MaxDepth = project.Paths.MaxDepth
+	}
+	if project.Paths.Skip != nil {
+		c.Paths.Skip = project.Paths.Skip
+	}
+	if project.Search.Case != "" {
+		c.Search.Case = project.Search.Case
+	}
+	c.Search.GitTracked = c.Search.GitTracked || project.Search.GitTracked
+	c.Search.NoIgnore = c.Search.NoIgnore || project.Search.NoIgnore
+	c.Search.Multiline = c.Search.Multiline || project.Search.Multiline
+	c.Search.WordRegexp = c.Search.WordRegexp || project.Search.WordRegexp
+	c.Search.Normalize = c.Search.Normalize || project.Search.Normalize
+	c.Search.StableOrder = c.Search.StableOrder || project.Search.StableOrder
+	c.Search.InlineContext = c.Search.InlineContext || project.Search.InlineContext
+	c.Search.FileInfo = c.Search.FileInfo || project.Search.FileInfo
+	c.Search.SortRecent = c.Search.SortRecent || project.Search.SortRecent
+	c.Search.RepoRoot = c.Search.RepoRoot || project.Search.RepoRoot
+	if project.Search.Shards != 0 {
+		c.Search.Shards = project.Search.Shards
+	}
+	if project.Search.MaxDepth != 0 {
+		c.Search.MaxDepth = project.Search.MaxDepth
+	}
+	if project.Search.MaxLineLength != 0 {
+		c.Search.MaxLineLength = project.Search.MaxLineLength
+	}
+	if project.Search.Timeout != "" {
+		c.Search.Timeout = project.Search.Timeout
+	}
+	if project.Search.ConfirmFiles != 0 {
+		c.Search.ConfirmFiles = project.Search.ConfirmFiles
+	}
+	if project.Search.BatchSize != 0 {
+		c.Search.BatchSize = project.Search.BatchSize
+	}
+	if project.Search.BatchInterval != "" {
+		c.Search.BatchInterval = project.Search.BatchInterval
+	}
+	if project.Search.BatchTuning != nil {
+		c.Search.BatchTuning = project.Search.BatchTuning
+	}
+	if project.Search.Profile != "" {
+		c.Search.Profile = project.Search.Profile
+	}
+	if project.Preview.Theme != "" {
+		c.Preview.Theme = project.Preview.Theme
+	}
+	if project.Preview.Syntax != nil {
+		c.Preview.Syntax = project.Preview.Syntax
+	}
+	if project.Preview.Context != 0 {
+		c.Preview.Context = project.Preview.Context
+	}
+	if project.Preview.Contexts != nil {
+		c.Preview.Contexts = project.Preview.Contexts
+	}
+	if project.Todos.Tags != nil {
+		c.Todos.Tags = project.Todos.Tags
+	}
+	if len(project.Types) > 0 {
+		if c.Types == nil {
+			c.Types = make(map[string][]string)
+		}
+		maps.Copy(c.Types, project.Types)
+	}
+	if len(project.Classes) > 0 {
+		if c.Classes == nil {
+			c.Classes = make(map[string]Class)
+		}
+		maps.Copy(c.Classes, project.Classes)
+	}
+	if len(project.Profiles) > 0 {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]Profile)
+		}
+		maps.Copy(c.Profiles, project.Profiles)
+	}
+	return ignored
+}
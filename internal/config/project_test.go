@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindProject_WalksUp(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "repo", "internal", "ui")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(root, "repo", ProjectFile)
+	if err := os.WriteFile(want, []byte("[types]\nweb = [\"*.ts\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindProject(nested)
+	if err != nil || got != want {
+		t.Errorf("FindProject = %q, %v; want %q", got, err, want)
+	}
+
+	// A directory named like the file doesn't count
+	other := filepath.Join(root, "other")
+	if err := os.MkdirAll(filepath.Join(other, ProjectFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FindProject(other); err != nil || got != "" {
+		t.Errorf("FindProject(no file) = %q, %v; want none", got, err)
+	}
+}
+
+func TestMerge_ProjectOverridesGlobal(t *testing.T) {
+	global := &Config{
+		Hooks:   Hooks{OnOpen: "echo open"},
+		Replace: Replace{Command: "sd"},
+		Paths:   Paths{MaxDepth: 3, Skip: []string{"vendor"}},
+		Search:  Search{Case: "insensitive", Shards: 4},
+		Editor:  Editor{Command: "vim"},
+		Types:   map[string][]string{"web": {"*.js"}, "proto": {"*.proto"}},
+	}
+	project := &Config{
+		Hooks:    Hooks{OnExitWithSelection: "curl example.com"},
+		Replace:  Replace{Command: "rm -rf {files}"},
+		Paths:    Paths{Skip: []string{"bazel-*"}},
+		Search:   Search{GitTracked: true, Shards: 8, Profile: "monorepo"},
+		Editor:   Editor{Command: "sh -c 'curl example.com'"},
+		Diff:     Diff{Command: "curl example.com"},
+		UI:       UI{Mouse: new(bool)},
+		Todos:    Todos{Tags: []string{"TODO", "XXX"}},
+		Types:    map[string][]string{"web": {"*.ts", "*.tsx"}},
+		Classes:  map[string]Class{"generated": {Globs: []string{"*_gen.go"}, Style: "tag"}},
+		Profiles: map[string]Profile{"monorepo": {Types: []string{"go", "ts"}, Exclude: []string{"third_party"}}},
+		Lint:     []Linter{{Globs: []string{"*.go"}, Command: "curl example.com"}},
+	}
+
+	ignored := global.Merge(project)
+
+	want := &Config{
+		Hooks:    Hooks{OnOpen: "echo open"},
+		Replace:  Replace{Command: "sd"},
+		Paths:    Paths{MaxDepth: 3, Skip: []string{"bazel-*"}},
+		Search:   Search{Case: "insensitive", GitTracked: true, Shards: 8, Profile: "monorepo"},
+		Editor:   Editor{Command: "vim"},
+		Todos:    Todos{Tags: []string{"TODO", "XXX"}},
+		Types:    map[string][]string{"web": {"*.ts", "*.tsx"}, "proto": {"*.proto"}},
+		Classes:  map[string]Class{"generated": {Globs: []string{"*_gen.go"}, Style: "tag"}},
+		Profiles: map[string]Profile{"monorepo": {Types: []string{"go", "ts"}, Exclude: []string{"third_party"}}},
+	}
+	if !reflect.DeepEqual(global, want) {
+		t.Errorf("merged = %+v, want %+v", global, want)
+	}
+	if !reflect.DeepEqual(ignored, []string{"hooks", "replace", "editor", "diff", "ui", "lint"}) {
+		t.Errorf("ignored = %v, want [hooks replace editor diff ui lint]", ignored)
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/William9923/irg/internal/store"
+)
+
+// Setting is one value written back to the config file, such as
+// {Section: "search", Key: "git-tracked", Value: true}. A nil Value removes
+// the key.
+type Setting struct {
+	Section string
+	Key     string
+	Value   any
+}
+
+// Save writes settings into the config file at path, creating it when it
+// doesn't exist. Existing keys are rewritten in place and new ones are added
+// to the end of their section, so comments and the rest of the file are
+// kept. The result must still load, or the file is left untouched.
+func Save(path string, settings []Setting) error {
+	return store.Update(path, 0o644, func(data []byte) ([]byte, error) {
+		lines := splitLines(string(data))
+		for _, s := range settings {
+			if s.Value == nil {
+				lines = removeLine(lines, s.Section, s.Key)
+				continue
+			}
+			line, err := formatSetting(s)
+			if err != nil {
+				return nil, fmt.Errorf("save %s: %w", path, err)
+			}
+			lines = setLine(lines, s.Section, s.Key, line)
+		}
+		out := strings.Join(lines, "\n") + "\n"
+
+		cfg := &Config{}
+		if _, err := toml.Decode(out, cfg); err != nil {
+			return nil, fmt.Errorf("save %s: %w", path, err)
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("save %s: %w", path, err)
+		}
+		return []byte(out), nil
+	})
+}
+
+func splitLines(data string) []string {
+	data = strings.TrimRight(data, "\n")
+	if data == "" {
+		return nil
+	}
+	return strings.Split(data, "\n")
+}
+
+// formatSetting renders s as a `key = value` line
+func formatSetting(s Setting) (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{s.Key: s.Value}); err != nil {
+		return "", fmt.Errorf("%s.%s: %w", s.Section, s.Key, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// setLine replaces the line setting key in section, or adds line after the
+// last entry of the section, appending the section when it is missing
+func setLine(lines []string, section, key, line string) []string {
+	current := ""
+	found := false
+	insert := -1
+	for i, l := range lines {
+		if name, ok := sectionHeader(l); ok {
+			current = name
+			if name == section {
+				found = true
+				insert = i + 1
+			}
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if lineKey(l) == key {
+			lines[i] = line
+			return lines
+		}
+		if trimmed := strings.TrimSpace(l); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			insert = i + 1
+		}
+	}
+
+	if !found {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		return append(lines, "["+section+"]", line)
+	}
+	return append(lines[:insert], append([]string{line}, lines[insert:]...)...)
+}
+
+// removeLine drops the line setting key in section, if there is one
+func removeLine(lines []string, section, key string) []string {
+	current := ""
+	for i, l := range lines {
+		if name, ok := sectionHeader(l); ok {
+			current = name
+			continue
+		}
+		if current == section && lineKey(l) == key {
+			return append(lines[:i], lines[i+1:]...)
+		}
+	}
+	return lines
+}
+
+// sectionHeader returns the table name of a `[name]` line. A `[[name]]`
+// line, such as [[preview.contexts]], ends the table before it but is never
+// one settings are saved to, so its whole line is returned as the name.
+func sectionHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	if strings.HasPrefix(line, "[[") {
+		return line, true
+	}
+	return strings.Trim(strings.TrimSpace(line[1:len(line)-1]), `"`), true
+}
+
+// lineKey returns the bare key a `key = value` line sets
+func lineKey(line string) string {
+	key, _, ok := strings.Cut(line, "=")
+	if !ok {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(key), `"`)
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSave_KeepsCommentsAndUpdatesInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `# My irg config
+[search]
+# Only what git knows about
+git-tracked = false
+shards = 2
+
+[paths]
+skip = ["node_modules"]
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Save(path, []Setting{
+		{Section: "search", Key: "git-tracked", Value: true},
+		{Section: "search", Key: "case", Value: "insensitive"},
+		{Section: "editor", Key: "command", Value: `code --wait "x"`},
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# My irg config
+[search]
+# Only what git knows about
+git-tracked = true
+shards = 2
+case = "insensitive"
+
+[paths]
+skip = ["node_modules"]
+
+[editor]
+command = "code --wait \"x\""
+`
+	if string(got) != want {
+		t.Errorf("saved:\n%s\nwant:\n%s", got, want)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !cfg.Search.GitTracked || cfg.Search.Case != "insensitive" || cfg.Search.Shards != 2 || cfg.Editor.Command != `code --wait "x"` {
+		t.Errorf("loaded %+v %+v", cfg.Search, cfg.Editor)
+	}
+}
+
+func TestSave_SkipsArrayTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[preview]
+context = 5
+
+[[preview.contexts]]
+globs = ["*.log"]
+lines = 20
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, []Setting{{Section: "preview", Key: "theme", Value: "dracula"}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := []PreviewContext{{Globs: []string{"*.log"}, Lines: 20}}
+	if cfg.Preview.Theme != "dracula" || !reflect.DeepEqual(cfg.Preview.Contexts, want) {
+		t.Errorf("loaded %+v, want theme dracula after [[preview.contexts]] %+v", cfg.Preview, want)
+	}
+}
+
+func TestSave_AddsAndRemovesKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "[bookmarks]\nwork = \"~/src/work\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := Save(path, []Setting{
+		{Section: "bookmarks", Key: "my notes", Value: "/home/me/notes"},
+		{Section: "bookmarks", Key: "work", Value: nil},
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := map[string]string{"my notes": "/home/me/notes"}
+	if !reflect.DeepEqual(cfg.Bookmarks, want) {
+		t.Errorf("bookmarks = %v, want %v", cfg.Bookmarks, want)
+	}
+
+	// A quoted key is found again
+	if err := Save(path, []Setting{{Section: "bookmarks", Key: "my notes", Value: nil}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if cfg, err = LoadFile(path); err != nil || len(cfg.Bookmarks) != 0 {
+		t.Errorf("bookmarks = %v, %v; want none", cfg.Bookmarks, err)
+	}
+}
+
+func TestSave_CreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "irg", "config.toml")
+	if err := Save(path, []Setting{{Section: "preview", Key: "theme", Value: "dracula"}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Preview.Theme != "dracula" {
+		t.Errorf("theme = %q, want dracula", cfg.Preview.Theme)
+	}
+}
+
+func TestSave_RejectsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "[search]\ncase = \"smart\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Save(path, []Setting{{Section: "search", Key: "case", Value: "loud"}})
+	if err == nil || !strings.Contains(err.Error(), "search.case") {
+		t.Fatalf("Save = %v, want a search.case error", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != data {
+		t.Errorf("file changed to %q", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Package contrast checks that text colors stay readable on their
+// background, using the WCAG contrast ratio, and nudges colors that aren't.
+package contrast
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MinRatio is the contrast ratio below which colored text counts as
+// unreadable; WCAG asks for 3:1 for bold and large text
+const MinRatio = 3.0
+
+// RGB is a 24-bit color
+type RGB struct {
+	R, G, B uint8
+}
+
+var (
+	Black = RGB{}
+	White = RGB{255, 255, 255}
+)
+
+// ansi16 is the xterm palette for the 16 basic ANSI colors; terminals may
+// use other shades, so checks against them are approximate
+var ansi16 = [16]RGB{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Parse reads a color as lipgloss accepts it: an ANSI 256-color number
+// ("226") or a hex code ("#ffd700" or "#fd0")
+func Parse(s string) (RGB, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return RGB{}, fmt.Errorf("invalid color %q: want #rrggbb", s)
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return RGB{}, fmt.Errorf("invalid color %q: want #rrggbb", s)
+		}
+		return RGB{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 255 {
+		return RGB{}, fmt.Errorf("invalid color %q: want 0-255 or #rrggbb", s)
+	}
+	switch {
+	case n < 16:
+		return ansi16[n], nil
+	case n < 232:
+		// 6x6x6 color cube
+		n -= 16
+		level := func(i int) uint8 {
+			if i == 0 {
+				return 0
+			}
+			return uint8(55 + i*40)
+		}
+		return RGB{level(n / 36), level(n / 6 % 6), level(n % 6)}, nil
+	default:
+		gray := uint8(8 + (n-232)*10)
+		return RGB{gray, gray, gray}, nil
+	}
+}
+
+// Hex formats c as #rrggbb
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Luminance returns the relative luminance of c, from 0 for black to 1 for
+// white
+func Luminance(c RGB) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// Ratio returns the contrast ratio of two colors, from 1 for identical
+// colors to 21 for black on white
+func Ratio(a, b RGB) float64 {
+	la, lb := Luminance(a), Luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// Adjust returns fg moved toward white or black, whichever gets there with
+// less change, until it reaches a contrast of min against bg. A color that
+// already does is returned as is.
+func Adjust(fg, bg RGB, min float64) RGB {
+	if Ratio(fg, bg) >= min {
+		return fg
+	}
+
+	best, bestStep := fg, math.MaxInt
+	for _, target := range []RGB{White, Black} {
+		for step := 1; step <= 20; step++ {
+			c := blend(fg, target, float64(step)/20)
+			if Ratio(c, bg) >= min {
+				if step < bestStep {
+					best, bestStep = c, step
+				}
+				break
+			}
+		}
+	}
+	if bestStep == math.MaxInt {
+		// Neither reaches min; take the extreme that comes closest
+		if Ratio(White, bg) > Ratio(Black, bg) {
+			return White
+		}
+		return Black
+	}
+	return best
+}
+
+// blend mixes a and b, with t from 0 (all a) to 1 (all b)
+func blend(a, b RGB, t float64) RGB {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return RGB{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B)}
+}
@@ -0,0 +1,60 @@
+package contrast
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RGB
+	}{
+		{"#ffd700", RGB{255, 215, 0}},
+		{"#fd0", RGB{255, 221, 0}},
+		{"11", RGB{255, 255, 0}},
+		{"226", RGB{255, 255, 0}},
+		{"62", RGB{95, 95, 215}},
+		{"237", RGB{58, 58, 58}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "256", "-1", "#12", "#gggggg", "yellow"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestRatio(t *testing.T) {
+	if got := Ratio(Black, White); math.Abs(got-21) > 0.01 {
+		t.Errorf("black on white = %.2f, want 21", got)
+	}
+	if got := Ratio(White, White); got != 1 {
+		t.Errorf("white on white = %.2f, want 1", got)
+	}
+}
+
+func TestAdjust_FixesYellowOnYellow(t *testing.T) {
+	yellow, _ := Parse("11")
+	gold, _ := Parse("#ffd700")
+
+	got := Adjust(yellow, gold, MinRatio)
+	if Ratio(got, gold) < MinRatio {
+		t.Errorf("Adjust = %s with contrast %.2f, want at least %.1f", got.Hex(), Ratio(got, gold), MinRatio)
+	}
+	// On a light background the color has to darken
+	if Luminance(got) >= Luminance(yellow) {
+		t.Errorf("Adjust = %s, want a darker color", got.Hex())
+	}
+
+	dark, _ := Parse("237")
+	if got := Adjust(yellow, dark, MinRatio); got != yellow {
+		t.Errorf("readable color changed to %s", got.Hex())
+	}
+}
@@ -0,0 +1,321 @@
+// Package dashboard shows a live table of match counts for a list of
+// patterns, for tracking migrations such as how many callers of an old API
+// remain.
+package dashboard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// parallelism bounds the patterns counted at once
+const parallelism = 4
+
+// maxPatternWidth bounds the pattern column
+const maxPatternWidth = 50
+
+// Row is one pattern's count
+type Row struct {
+	Pattern  string
+	Count    search.Count
+	Previous *search.Count // Count of the last finished run, for the change column
+	Running  bool
+	Err      error
+}
+
+// countMsg reports progress on one row of a run
+type countMsg struct {
+	row   int
+	count search.Count
+	done  bool
+	err   error
+}
+
+// countBatchMsg carries the count updates received since the last one
+type countBatchMsg struct {
+	run     int
+	updates []countMsg
+	source  <-chan countMsg
+	closed  bool // Every pattern of the run has finished
+}
+
+type tickMsg struct{ run int }
+
+// Model is the Bubble Tea model of the dashboard
+type Model struct {
+	rows     []Row
+	path     string
+	opts     search.Options
+	interval time.Duration
+
+	run      int // Counts from older runs are dropped
+	cancel   context.CancelFunc
+	started  time.Time
+	finished time.Time
+	width    int
+}
+
+// New returns a dashboard counting patterns under path, refreshed every
+// interval, or only on request when interval is 0
+func New(patterns []string, path string, opts search.Options, interval time.Duration) Model {
+	rows := make([]Row, len(patterns))
+	for i, p := range patterns {
+		rows[i] = Row{Pattern: p}
+	}
+	if path == "" {
+		path = "."
+	}
+	return Model{rows: rows, path: path, opts: opts, interval: interval, width: 80}
+}
+
+// Rows returns the current counts
+func (m Model) Rows() []Row {
+	return m.rows
+}
+
+// Init starts the first count through Update, where the run can be recorded
+func (m Model) Init() tea.Cmd {
+	return func() tea.Msg { return tickMsg{run: 0} }
+}
+
+// refresh starts counting every pattern again, keeping the finished counts
+// to show the change
+func (m *Model) refresh() tea.Cmd {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.run++
+	m.started = time.Now()
+	m.finished = time.Time{}
+	for i := range m.rows {
+		row := &m.rows[i]
+		if !row.Running && row.Err == nil && m.run > 1 {
+			prev := row.Count
+			row.Previous = &prev
+		}
+		row.Count = search.Count{}
+		row.Running = true
+		row.Err = nil
+	}
+
+	patterns := make([]string, len(m.rows))
+	for i, row := range m.rows {
+		patterns[i] = row.Pattern
+	}
+	updates := make(chan countMsg, 64)
+	go countAll(ctx, patterns, m.path, m.opts, updates)
+	return waitForCounts(m.run, updates)
+}
+
+// countAll counts each pattern, a few at a time, sending progress to updates
+// and closing it when all are done
+func countAll(ctx context.Context, patterns []string, path string, opts search.Options, updates chan<- countMsg) {
+	defer close(updates)
+	send := func(msg countMsg) {
+		select {
+		case updates <- msg:
+		case <-ctx.Done():
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+	for i, pattern := range patterns {
+		wg.Add(1)
+		go func(i int, pattern string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			// Each count runs its own rg, so each needs its own searcher
+			count, err := search.NewRipgrepSearcher().Count(ctx, pattern, path, opts, func(c search.Count) {
+				send(countMsg{row: i, count: c})
+			})
+			send(countMsg{row: i, count: count, done: true, err: err})
+		}(i, pattern)
+	}
+	wg.Wait()
+}
+
+// waitForCounts reads the next updates of run, coalescing those already
+// queued so fast counts don't flood the UI
+func waitForCounts(run int, updates <-chan countMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return countBatchMsg{run: run, source: updates, closed: true}
+		}
+		batch := countBatchMsg{run: run, updates: []countMsg{msg}, source: updates}
+		for {
+			select {
+			case msg, ok := <-updates:
+				if !ok {
+					batch.closed = true
+					return batch
+				}
+				batch.updates = append(batch.updates, msg)
+			default:
+				return batch
+			}
+		}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			if m.cancel != nil {
+				m.cancel()
+			}
+			return m, tea.Quit
+		case "r":
+			return m, m.refresh()
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+
+	case countBatchMsg:
+		if msg.run != m.run {
+			return m, nil
+		}
+		for _, u := range msg.updates {
+			row := &m.rows[u.row]
+			row.Count = u.count
+			if u.done {
+				row.Running = false
+				row.Err = u.err
+			}
+		}
+		if !msg.closed {
+			return m, waitForCounts(msg.run, msg.source)
+		}
+		m.finished = time.Now()
+		if m.interval > 0 {
+			run := m.run
+			return m, tea.Tick(m.interval, func(time.Time) tea.Msg { return tickMsg{run: run} })
+		}
+
+	case tickMsg:
+		if msg.run == m.run {
+			return m, m.refresh()
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	downStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	upStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+
+	patternWidth := len("Pattern")
+	for _, row := range m.rows {
+		patternWidth = max(patternWidth, ansi.StringWidth(row.Pattern))
+	}
+	patternWidth = min(patternWidth, maxPatternWidth, max(m.width-40, 10))
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("irg count: %d patterns in %s", len(m.rows), m.path)))
+	sb.WriteString("\n\n")
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("%-*s %10s %8s %8s", patternWidth, "Pattern", "Matches", "Files", "Change")))
+	sb.WriteString("\n")
+
+	var total search.Count
+	running := 0
+	for _, row := range m.rows {
+		total.Matches += row.Count.Matches
+		total.Files += row.Count.Files
+		pattern := ansi.Truncate(row.Pattern, patternWidth, "…")
+		pattern += strings.Repeat(" ", patternWidth-ansi.StringWidth(pattern))
+		if row.Err != nil {
+			// rg's regex errors span several lines
+			msg := strings.Join(strings.Fields(row.Err.Error()), " ")
+			sb.WriteString(pattern + " " + errStyle.Render(msg))
+			sb.WriteString("\n")
+			continue
+		}
+
+		line := fmt.Sprintf("%s %10d %8d", pattern, row.Count.Matches, row.Count.Files)
+		change := ""
+		switch {
+		case row.Running:
+			running++
+			change = dimStyle.Render(fmt.Sprintf("%8s", "…"))
+		case row.Previous != nil && row.Count.Matches < row.Previous.Matches:
+			change = downStyle.Render(fmt.Sprintf("%8d", row.Count.Matches-row.Previous.Matches))
+		case row.Previous != nil && row.Count.Matches > row.Previous.Matches:
+			change = upStyle.Render(fmt.Sprintf("%8s", fmt.Sprintf("+%d", row.Count.Matches-row.Previous.Matches)))
+		}
+		sb.WriteString(line)
+		if change != "" {
+			sb.WriteString(" " + change)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("%-*s %10d %8s", patternWidth, "Total", total.Matches, "")))
+	sb.WriteString("\n\n")
+
+	var status string
+	if running > 0 {
+		status = fmt.Sprintf("Counting... (%d/%d patterns done)", len(m.rows)-running, len(m.rows))
+	} else {
+		status = fmt.Sprintf("Counted in %s", m.finished.Sub(m.started).Round(time.Millisecond))
+		if m.interval > 0 {
+			status += fmt.Sprintf(", refreshing every %s", m.interval)
+		}
+	}
+	sb.WriteString(dimStyle.Render(status + " | r (refresh) | q (quit)"))
+	return sb.String()
+}
+
+// Print counts each pattern once and writes tab-separated match counts, file
+// counts and patterns to w, for scripts and CI
+func Print(ctx context.Context, w io.Writer, patterns []string, path string, opts search.Options) error {
+	s := search.NewRipgrepSearcher()
+	for _, pattern := range patterns {
+		count, err := s.Count(ctx, pattern, path, opts, nil)
+		if err != nil {
+			return fmt.Errorf("count %q: %w", pattern, err)
+		}
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%s\n", count.Matches, count.Files, pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPatterns reads one pattern per line, skipping blank lines and lines
+// starting with #
+func ReadPatterns(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading patterns: %w", err)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,89 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestReadPatterns_SkipsBlanksAndComments(t *testing.T) {
+	patterns, err := ReadPatterns(strings.NewReader("# remaining callers\nOldAPI\\(\r\n\n  \nlegacy\\.Client\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`OldAPI\(`, `legacy\.Client`}
+	if !reflect.DeepEqual(patterns, want) {
+		t.Errorf("ReadPatterns = %q, want %q", patterns, want)
+	}
+}
+
+func TestUpdate_ShowsCountsAndChange(t *testing.T) {
+	m := New([]string{"OldAPI", "Legacy", "bad("}, ".", search.Options{}, 0)
+	m.run = 1
+	for i := range m.rows {
+		m.rows[i].Running = true
+	}
+	finish := func(m Model, counts ...int) Model {
+		updates := []countMsg{{row: 2, done: true, err: errors.New("rg: regex parse error:\n    bad(\n")}}
+		for i, n := range counts {
+			updates = append(updates, countMsg{row: i, count: search.Count{Matches: n, Files: 1}, done: true})
+		}
+		updated, _ := m.Update(countBatchMsg{run: m.run, updates: updates, closed: true})
+		return updated.(Model)
+	}
+
+	m = finish(m, 12, 3)
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"OldAPI", "12", "Total", "15", "rg: regex parse error: bad("} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// A refresh keeps the finished counts to show the change
+	m.refresh()
+	m.cancel()
+	if m.rows[0].Previous == nil || m.rows[0].Previous.Matches != 12 || m.rows[0].Count.Matches != 0 {
+		t.Fatalf("after refresh row = %+v", m.rows[0])
+	}
+	m = finish(m, 9, 5)
+	view = ansi.Strip(m.View())
+	if !strings.Contains(view, "-3") || !strings.Contains(view, "+2") {
+		t.Errorf("view missing changes -3 and +2:\n%s", view)
+	}
+
+	// Counts from a replaced run are dropped
+	updated, _ := m.Update(countBatchMsg{run: m.run - 1, updates: []countMsg{{row: 0, count: search.Count{Matches: 99}}}})
+	if got := updated.(Model).rows[0].Count.Matches; got != 9 {
+		t.Errorf("stale run changed the count to %d", got)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("OldAPI()\nOldAPI()\nNewAPI()\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Print(context.Background(), &out, []string{"OldAPI", "NewAPI", "Gone"}, dir, search.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "2\t1\tOldAPI\n1\t1\tNewAPI\n0\t0\tGone\n"
+	if out.String() != want {
+		t.Errorf("Print wrote %q, want %q", out.String(), want)
+	}
+}
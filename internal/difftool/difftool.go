@@ -0,0 +1,107 @@
+// Package difftool opens a file against its version in git's HEAD commit in
+// an external diff tool (delta, difftastic, vimdiff, ...).
+package difftool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/William9923/irg/internal/editor"
+)
+
+// DefaultCommand leaves the choice of tool to git: git difftool runs the
+// diff.tool from the git config, or the first of its known tools it finds
+const DefaultCommand = "git difftool --no-prompt HEAD -- {new}"
+
+// Tool is a diff command template, run with sh (cmd.exe on Windows). The
+// HEAD version of the file is substituted for {old}, the file itself for
+// {new} and the line of the match for {line}. Paths are quoted.
+type Tool struct {
+	Command string
+}
+
+// New returns a tool for command, or the default when command is empty
+func New(command string) Tool {
+	if strings.TrimSpace(command) == "" {
+		command = DefaultCommand
+	}
+	return Tool{Command: command}
+}
+
+// NeedsHead reports whether the command compares against a copy of the HEAD
+// version, which then has to be written with Head first
+func (t Tool) NeedsHead() bool {
+	return strings.Contains(t.Command, "{old}")
+}
+
+// Cmd builds the command that compares old with new, positioned at line
+func (t Tool) Cmd(old, new string, line int) *exec.Cmd {
+	command := strings.NewReplacer(
+		"{old}", editor.ShellQuote(old),
+		"{new}", editor.ShellQuote(new),
+		"{line}", strconv.Itoa(line),
+	).Replace(t.Command)
+	return editor.ShellCommand(context.Background(), command)
+}
+
+// Head writes the version of path in the HEAD commit of its repository to a
+// temporary file with the same base name, so tools still pick a language by
+// extension, and returns its name. A file that isn't in HEAD yet is compared
+// against an empty file, and added is true. The caller removes the file.
+func Head(ctx context.Context, path string) (name string, added bool, err error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return "", false, fmt.Errorf("%s: not in a git repository with commits", path)
+	}
+
+	listed, err := git(ctx, dir, "ls-tree", "--name-only", "HEAD", "--", base)
+	if err != nil {
+		return "", false, err
+	}
+	var content []byte
+	added = len(bytes.TrimSpace(listed)) == 0
+	if !added {
+		if content, err = git(ctx, dir, "show", "HEAD:./"+base); err != nil {
+			return "", false, err
+		}
+	}
+
+	f, err := os.CreateTemp("", "irg-head-*-"+base)
+	if err != nil {
+		return "", false, fmt.Errorf("create temp file: %w", err)
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", false, fmt.Errorf("write %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", false, fmt.Errorf("write %s: %w", f.Name(), err)
+	}
+	return f.Name(), added, nil
+}
+
+// git runs a git command in dir and returns its output
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return output, nil
+}
@@ -0,0 +1,110 @@
+package difftool
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// gitRepo creates a repository with a.go committed, then changes it on disk
+// and adds the untracked b.go
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	write("a.go", "package a\n")
+	run("add", "a.go")
+	run("commit", "-q", "-m", "init")
+	write("a.go", "package a\n\nvar x = 1\n")
+	write("b.go", "package b\n")
+	return dir
+}
+
+func TestHead(t *testing.T) {
+	dir := gitRepo(t)
+
+	name, added, err := Head(context.Background(), filepath.Join(dir, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(name)
+	if added {
+		t.Error("a.go reported as added")
+	}
+	if filepath.Ext(name) != ".go" {
+		t.Errorf("copy %s lost the extension", name)
+	}
+	if got, _ := os.ReadFile(name); string(got) != "package a\n" {
+		t.Errorf("HEAD version = %q, want the committed content", got)
+	}
+
+	name, added, err = Head(context.Background(), filepath.Join(dir, "b.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(name)
+	if !added {
+		t.Error("untracked b.go not reported as added")
+	}
+	if got, _ := os.ReadFile(name); len(got) != 0 {
+		t.Errorf("HEAD version of a new file = %q, want empty", got)
+	}
+}
+
+func TestHead_OutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Head(context.Background(), path); err == nil {
+		t.Error("Head succeeded outside a repository")
+	}
+}
+
+func TestTool_Cmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool := New(`printf '%s|%s|%s' {old} {new} {line}`)
+	if !tool.NeedsHead() {
+		t.Error("NeedsHead = false for a command with {old}")
+	}
+	var out bytes.Buffer
+	cmd := tool.Cmd("/tmp/old file.go", "it's.go", 42)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/tmp/old file.go|it's.go|42"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	if New("").NeedsHead() {
+		t.Error("the default command doesn't need a HEAD copy")
+	}
+}
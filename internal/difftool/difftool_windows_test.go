@@ -0,0 +1,14 @@
+//go:build windows
+
+package difftool
+
+import "testing"
+
+func TestTool_QuotesPathsForCmd(t *testing.T) {
+	cmd := New(`delta {old} {new}`).Cmd(`C:\Temp\irg-head-1-a b.go`, `a b.go`, 3)
+
+	want := `/d /s /c "delta "C:\Temp\irg-head-1-a b.go" "a b.go""`
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CmdLine != want {
+		t.Errorf("command line = %+v, want %q", cmd.SysProcAttr, want)
+	}
+}
@@ -1,6 +1,7 @@
 package editor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -39,6 +40,12 @@ func GetEditor() (*Editor, error) {
 	return getPlatformDefault()
 }
 
+// FromCommand returns the editor for a configured command such as
+// "code --wait", in place of the environment
+func FromCommand(command string) (*Editor, error) {
+	return parseEditorString(command)
+}
+
 // parseEditorString parses an editor string that may contain arguments
 // Examples: "vim", "code --wait", "nvim -a -b"
 func parseEditorString(editorStr string) (*Editor, error) {
@@ -77,16 +84,15 @@ func getEditorName(path string) string {
 		name = name[lastBackslash+1:]
 	}
 
-	// Remove common extensions on Windows
+	// Remove common extensions on Windows, where file names are
+	// case-insensitive (CODE.EXE, Code.cmd)
 	if runtime.GOOS == "windows" {
-		if strings.HasSuffix(name, ".exe") {
-			name = name[:len(name)-4]
-		}
-		if strings.HasSuffix(name, ".cmd") {
-			name = name[:len(name)-4]
-		}
-		if strings.HasSuffix(name, ".bat") {
-			name = name[:len(name)-4]
+		lower := strings.ToLower(name)
+		for _, ext := range []string{".exe", ".cmd", ".bat"} {
+			if strings.HasSuffix(lower, ext) {
+				name = strings.ToLower(name[:len(name)-len(ext)])
+				break
+			}
 		}
 	}
 
@@ -180,13 +186,81 @@ func (e *Editor) BuildCommandWithSpecialHandling(filename string, lineNumber int
 		args = append(args, e.getLineNumberArgs(lineNumber)...)
 		args = append(args, filename)
 	}
+	return e.command(args)
+}
+
+// Location is a file to open at a line
+type Location struct {
+	Path string
+	Line int
+}
+
+// BuildMultiCommand creates an exec.Cmd opening every location at once: in
+// tabs for vim, as file:line arguments for the editors that take them, and
+// as a line number argument before each file otherwise
+func (e *Editor) BuildMultiCommand(locations []Location) *exec.Cmd {
+	if len(locations) == 1 {
+		return e.BuildCommand(locations[0].Path, locations[0].Line)
+	}
+	args := make([]string, len(e.Args))
+	copy(args, e.Args)
+
+	switch e.Name {
+	case "vim", "vi", "nvim":
+		// +N only applies to the first file, so the others are opened
+		// from a command, each in a tab at its line
+		first := locations[0]
+		args = append(args, fmt.Sprintf("+%d", first.Line), first.Path)
+		var tabs []string
+		for _, loc := range locations[1:] {
+			tabs = append(tabs, fmt.Sprintf("tabedit +%d %s", loc.Line, vimEscape(loc.Path)))
+		}
+		args = append(args, "-c", strings.Join(append(tabs, "tabfirst"), " | "))
+	case "code", "vscode":
+		args = append(args, "--goto")
+		for _, loc := range locations {
+			args = append(args, fmt.Sprintf("%s:%d", loc.Path, loc.Line))
+		}
+	case "hx", "helix", "subl", "sublime_text", "atom":
+		for _, loc := range locations {
+			args = append(args, fmt.Sprintf("%s:%d", loc.Path, loc.Line))
+		}
+	default:
+		for _, loc := range locations {
+			args = append(args, e.getLineNumberArgs(loc.Line)...)
+			args = append(args, loc.Path)
+		}
+	}
+	return e.command(args)
+}
+
+// vimEscape escapes the characters of a file name that an Ex command such
+// as :tabedit would read as special, as vim's fnameescape() does. Windows
+// paths keep their backslashes, which vim reads as separators there.
+func vimEscape(name string) string {
+	special := " \t\n*?[{`$%#'\"|!<"
+	if runtime.GOOS != "windows" {
+		special += "\\"
+	}
+	var b strings.Builder
+	for i, r := range name {
+		if strings.ContainsRune(special, r) || (i == 0 && (r == '+' || r == '-')) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
 
+// command runs the editor with args, through the shell or open(1) when the
+// editor needs them
+func (e *Editor) command(args []string) *exec.Cmd {
 	if e.UsesShell {
-		if runtime.GOOS == "windows" {
-			return exec.Command("cmd.exe", "/c", e.Path+" "+strings.Join(args, " "))
-		} else {
-			return exec.Command("sh", "-c", e.Path+" "+strings.Join(args, " "))
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = ShellQuote(arg)
 		}
+		return ShellCommand(context.Background(), ShellQuote(e.Path)+" "+strings.Join(quoted, " "))
 	} else if runtime.GOOS == "darwin" && e.isGUIApp() {
 		// Use 'open -a' for GUI applications on macOS
 		openArgs := []string{"-a", e.Path}
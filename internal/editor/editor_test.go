@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestGetEditorName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"vim", "vim"},
+		{"/usr/local/bin/nvim", "nvim"},
+		{`C:\Program Files\Sublime Text\subl`, "subl"},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests,
+			struct{ path, want string }{`C:\Users\me\AppData\Local\Programs\Microsoft VS Code\bin\Code.CMD`, "code"},
+			struct{ path, want string }{"notepad.exe", "notepad"},
+		)
+	}
+	for _, tt := range tests {
+		if got := getEditorName(tt.path); got != tt.want {
+			t.Errorf("getEditorName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCommand_LineArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"vim", []string{"+12", "dir/my file.go"}},
+		{"hx", []string{"dir/my file.go:12"}},
+		{"code", []string{"--goto", "dir/my file.go:12"}},
+		{"notepad++", []string{"-n12", "dir/my file.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Editor{Name: tt.name, Path: tt.name}
+			if runtime.GOOS == "darwin" && e.isGUIApp() {
+				t.Skip("GUI apps are launched through open on macOS")
+			}
+			cmd := e.BuildCommand("dir/my file.go", 12)
+			if got := cmd.Args[1:]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMultiCommand(t *testing.T) {
+	locations := []Location{{Path: "main.go", Line: 3}, {Path: "dir/my file|x.go", Line: 12}}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"vim", []string{"+3", "main.go", "-c", `tabedit +12 dir/my\ file\|x.go | tabfirst`}},
+		{"code", []string{"--goto", "main.go:3", "dir/my file|x.go:12"}},
+		{"hx", []string{"main.go:3", "dir/my file|x.go:12"}},
+		{"emacs", []string{"+3", "main.go", "+12", "dir/my file|x.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Editor{Name: tt.name, Path: tt.name}
+			if runtime.GOOS == "darwin" && e.isGUIApp() {
+				t.Skip("GUI apps are launched through open on macOS")
+			}
+			cmd := e.BuildMultiCommand(locations)
+			if got := cmd.Args[1:]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVimEscape(t *testing.T) {
+	tests := map[string]string{
+		"main.go":     "main.go",
+		"-flag.go":    `\-flag.go`,
+		"a b%#.go":    `a\ b\%\#.go`,
+		"it's [x].go": `it\'s\ \[x].go`,
+		"c+d/+e.go":   "c+d/+e.go",
+	}
+	for name, want := range tests {
+		if got := vimEscape(name); got != want {
+			t.Errorf("vimEscape(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package editor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// ShellCommand runs line with sh, killed when ctx is done
+func ShellCommand(ctx context.Context, line string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// ShellQuote quotes arg for the shell ShellCommand runs, sh here, leaving
+// plain words such as flags unquoted
+func ShellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, needsQuote) == -1 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func needsQuote(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("+-_./:=@%,", r))
+}
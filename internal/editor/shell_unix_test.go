@@ -0,0 +1,18 @@
+//go:build !windows
+
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildCommand_ShellQuotesArgs(t *testing.T) {
+	e := &Editor{Name: "vim", Path: "vim", UsesShell: true}
+	cmd := e.BuildCommand("it's a file.go", 3)
+
+	want := []string{"sh", "-c", `vim +3 'it'\''s a file.go'`}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args = %q, want %q", cmd.Args, want)
+	}
+}
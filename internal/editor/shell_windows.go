@@ -0,0 +1,30 @@
+//go:build windows
+
+package editor
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// ShellCommand runs line with cmd.exe, killed when ctx is done. The command
+// line is passed through verbatim: Go's default argument escaping targets
+// the C runtime's rules, which cmd.exe doesn't follow.
+func ShellCommand(ctx context.Context, line string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `/d /s /c "` + line + `"`}
+	return cmd
+}
+
+// ShellQuote quotes arg for the shell ShellCommand runs, cmd.exe here.
+// Double quotes protect spaces and & | < > ^ ( ); % can't be escaped on
+// cmd's command line, so a path with a defined %VAR% in it would still
+// expand.
+func ShellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^()") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package editor
+
+import "testing"
+
+func TestBuildCommand_ShellQuotesArgs(t *testing.T) {
+	e := &Editor{Name: "vim", Path: "vim", UsesShell: true}
+	cmd := e.BuildCommand("it's a file.go", 3)
+
+	want := `/d /s /c "vim +3 "it's a file.go""`
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CmdLine != want {
+		t.Errorf("command line = %+v, want %q", cmd.SysProcAttr, want)
+	}
+}
@@ -0,0 +1,199 @@
+// Package explain describes a regular expression in plain words, one line per
+// part of its syntax tree, to help work out why a pattern matches nothing.
+package explain
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// indent is the indentation of each nesting level
+const indent = "  "
+
+// namedClasses names the character classes behind common escapes
+var namedClasses = map[string]string{
+	`[0-9]`:         `digit (\d)`,
+	`[^0-9]`:        `non-digit (\D)`,
+	`[0-9A-Z_a-z]`:  `word character (\w)`,
+	`[^0-9A-Z_a-z]`: `non-word character (\W)`,
+	`[\t\n\f\r ]`:   `whitespace (\s)`,
+	`[^\t\n\f\r ]`:  `non-whitespace (\S)`,
+	`[A-Za-z]`:      `letter`,
+}
+
+// Regex explains pattern, parsed with Perl syntax as ripgrep does. Notes
+// about parts that can never match in a line-by-line search follow the
+// breakdown.
+func Regex(pattern string) ([]string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	e := &explainer{}
+	if re.Op == syntax.OpConcat {
+		e.checkAnchors(re.Sub)
+		for _, sub := range re.Sub {
+			e.node(sub, 0)
+		}
+	} else {
+		e.node(re, 0)
+	}
+	if len(e.notes) > 0 {
+		e.lines = append(e.lines, "")
+		e.lines = append(e.lines, e.notes...)
+	}
+	return e.lines, nil
+}
+
+type explainer struct {
+	lines []string
+	notes []string
+}
+
+func (e *explainer) add(depth int, format string, args ...any) {
+	e.lines = append(e.lines, strings.Repeat(indent, depth)+fmt.Sprintf(format, args...))
+}
+
+func (e *explainer) note(msg string) {
+	for _, n := range e.notes {
+		if n == msg {
+			return
+		}
+	}
+	e.notes = append(e.notes, msg)
+}
+
+func (e *explainer) node(re *syntax.Regexp, depth int) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		e.add(depth, "nothing (can never match)")
+		e.note("Note: part of the pattern can never match")
+	case syntax.OpEmptyMatch:
+		e.add(depth, "empty string")
+	case syntax.OpLiteral:
+		text := string(re.Rune)
+		desc := "literal " + strconv.Quote(text)
+		if re.Flags&syntax.FoldCase != 0 {
+			desc += ", any case"
+		}
+		e.add(depth, "%s", desc)
+		if strings.ContainsRune(text, '\n') {
+			e.note("Note: a newline never matches, since ripgrep searches line by line")
+		}
+	case syntax.OpCharClass:
+		e.add(depth, "%s", classDescription(re))
+	case syntax.OpAnyCharNotNL:
+		e.add(depth, "any character except newline (.)")
+	case syntax.OpAnyChar:
+		e.add(depth, "any character, including newline")
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		// Each line is searched on its own, so the start of the text is the
+		// start of the line
+		e.add(depth, "start of line (^)")
+	case syntax.OpEndLine, syntax.OpEndText:
+		e.add(depth, "end of line ($)")
+	case syntax.OpWordBoundary:
+		e.add(depth, `word boundary (\b)`)
+	case syntax.OpNoWordBoundary:
+		e.add(depth, `not a word boundary (\B)`)
+	case syntax.OpCapture:
+		if re.Name != "" {
+			e.add(depth, "group %d %q:", re.Cap, re.Name)
+		} else {
+			e.add(depth, "group %d:", re.Cap)
+		}
+		e.children(re.Sub, depth+1)
+	case syntax.OpStar:
+		e.add(depth, "zero or more times%s:", greed(re))
+		e.children(re.Sub, depth+1)
+	case syntax.OpPlus:
+		e.add(depth, "one or more times%s:", greed(re))
+		e.children(re.Sub, depth+1)
+	case syntax.OpQuest:
+		e.add(depth, "optionally%s:", greed(re))
+		e.children(re.Sub, depth+1)
+	case syntax.OpRepeat:
+		switch {
+		case re.Max == -1:
+			e.add(depth, "at least %d times%s:", re.Min, greed(re))
+		case re.Min == re.Max:
+			e.add(depth, "exactly %d times:", re.Min)
+		default:
+			e.add(depth, "between %d and %d times%s:", re.Min, re.Max, greed(re))
+		}
+		e.children(re.Sub, depth+1)
+	case syntax.OpConcat:
+		e.checkAnchors(re.Sub)
+		e.add(depth, "sequence:")
+		e.children(re.Sub, depth+1)
+	case syntax.OpAlternate:
+		e.add(depth, "one of %d alternatives:", len(re.Sub))
+		for i, sub := range re.Sub {
+			e.add(depth+1, "%d.", i+1)
+			e.node(sub, depth+2)
+		}
+	default:
+		e.add(depth, "%s", re.String())
+	}
+}
+
+// checkAnchors notes line anchors in a sequence that text must cross, such
+// as the ^ in "a^b"
+func (e *explainer) checkAnchors(subs []*syntax.Regexp) {
+	for i, sub := range subs {
+		switch sub.Op {
+		case syntax.OpBeginLine, syntax.OpBeginText:
+			if i > 0 && consumes(subs[i-1]) {
+				e.note("Note: ^ after other text can never match within a line")
+			}
+		case syntax.OpEndLine, syntax.OpEndText:
+			if i < len(subs)-1 && consumes(subs[i+1]) {
+				e.note("Note: $ before other text can never match within a line")
+			}
+		}
+	}
+}
+
+// consumes reports whether re always matches at least one character
+func consumes(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral, syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpPlus, syntax.OpCapture:
+		return consumes(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min > 0 && consumes(re.Sub[0])
+	}
+	return false
+}
+
+// children explains subs, flattening a lone sequence into its parts
+func (e *explainer) children(subs []*syntax.Regexp, depth int) {
+	if len(subs) == 1 && subs[0].Op == syntax.OpConcat {
+		subs = subs[0].Sub
+	}
+	for _, sub := range subs {
+		e.node(sub, depth)
+	}
+}
+
+func greed(re *syntax.Regexp) string {
+	if re.Flags&syntax.NonGreedy != 0 {
+		return ", as few as possible"
+	}
+	return ""
+}
+
+// classDescription names well-known classes and lists the others
+func classDescription(re *syntax.Regexp) string {
+	s := re.String()
+	if name, ok := namedClasses[s]; ok {
+		return name
+	}
+	if strings.HasPrefix(s, "[^") {
+		return "any character not in " + s
+	}
+	return "one character from " + s
+}
@@ -0,0 +1,80 @@
+package explain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`^func (\w+)\(`, []string{
+			"start of line (^)",
+			`literal "func "`,
+			"group 1:",
+			"  one or more times:",
+			`    word character (\w)`,
+			`literal "("`,
+		}},
+		{`(?P<year>\d{4})-\d{1,2}?`, []string{
+			`group 1 "year":`,
+			"  exactly 4 times:",
+			`    digit (\d)`,
+			`literal "-"`,
+			"between 1 and 2 times, as few as possible:",
+			`  digit (\d)`,
+		}},
+		{`(?i:todo)|[^a-z]*?$`, []string{
+			"one of 2 alternatives:",
+			"  1.",
+			`    literal "TODO", any case`,
+			"  2.",
+			"    sequence:",
+			"      zero or more times, as few as possible:",
+			"        any character not in [^a-z]",
+			"      end of line ($)",
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Regex(tt.pattern)
+		if err != nil {
+			t.Errorf("Regex(%q): %v", tt.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Regex(%q) =\n%s\nwant\n%s", tt.pattern, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestRegex_NotesUnmatchableParts(t *testing.T) {
+	tests := []struct {
+		pattern string
+		note    string
+	}{
+		{`foo\nbar`, "newline never matches"},
+		{`foo^bar`, "^ after other text"},
+		{`foo$bar`, "$ before other text"},
+	}
+	for _, tt := range tests {
+		got, err := Regex(tt.pattern)
+		if err != nil {
+			t.Fatalf("Regex(%q): %v", tt.pattern, err)
+		}
+		if !strings.Contains(strings.Join(got, "\n"), tt.note) {
+			t.Errorf("Regex(%q) has no note about %q:\n%s", tt.pattern, tt.note, strings.Join(got, "\n"))
+		}
+	}
+	if got, _ := Regex(`^foo$`); strings.Contains(strings.Join(got, "\n"), "Note") {
+		t.Errorf("Regex(^foo$) has a note:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRegex_InvalidPattern(t *testing.T) {
+	if _, err := Regex(`foo(`); err == nil || !strings.Contains(err.Error(), "missing closing )") {
+		t.Errorf("err = %v, want a parse error", err)
+	}
+}
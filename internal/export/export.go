@@ -0,0 +1,74 @@
+// Package export writes search results in formats other tools consume.
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// Format identifies an export format
+type Format string
+
+const (
+	FormatSARIF    Format = "sarif"
+	FormatQuickfix Format = "quickfix"
+	FormatJSON     Format = "json"
+)
+
+// Formats lists every supported format, in the order shown in help text
+var Formats = []Format{FormatSARIF, FormatQuickfix, FormatJSON}
+
+// Set is a result set to export
+type Set struct {
+	Pattern string
+	Matches []search.Match
+	Notes   map[int]string // Notes attached to matches, by index in Matches
+}
+
+// ParseFormat validates a user-supplied format name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(name, string(f)) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown output format %q (want one of: %s)", name, strings.Join(names, ", "))
+}
+
+// Write encodes set to w in the given format
+func Write(w io.Writer, format Format, set Set) error {
+	switch format {
+	case FormatSARIF:
+		return WriteSARIF(w, set)
+	case FormatQuickfix:
+		return WriteQuickfix(w, set)
+	case FormatJSON:
+		return WriteJSON(w, set)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// WriteFile encodes set to the file at path, replacing it if it exists
+func WriteFile(path string, format Format, set Set) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if err := Write(file, format, set); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", path, err)
+	}
+	return nil
+}
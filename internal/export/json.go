@@ -0,0 +1,57 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// JSONFile is the file the export-json action writes to
+const JSONFile = "irg-results.jsonl"
+
+// jsonMatch is one line of the JSON lines export. Columns are 1-based byte
+// offsets into the file's line, as in the quickfix export; End is exclusive.
+type jsonMatch struct {
+	Path       string         `json:"path"`
+	Line       int            `json:"line"`
+	EndLine    int            `json:"end_line,omitempty"`
+	Column     int            `json:"column"`
+	Text       string         `json:"text"`
+	Submatches []jsonSubmatch `json:"submatches"`
+	Note       string         `json:"note,omitempty"`
+}
+
+type jsonSubmatch struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// WriteJSON writes one JSON object per match and line, for tools like jq.
+// A match spanning several lines has them all in text, and end_line set.
+func WriteJSON(w io.Writer, set Set) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for i, match := range set.Matches {
+		out := jsonMatch{
+			Path:       match.Path,
+			Line:       match.LineNumber,
+			Column:     match.Column(),
+			Text:       strings.TrimRight(match.LineText, "\n\r"),
+			Submatches: make([]jsonSubmatch, 0, len(match.Submatches)),
+			Note:       set.Notes[i],
+		}
+		if match.EndLine > match.LineNumber {
+			out.EndLine = match.EndLine
+		}
+		for _, sm := range match.LineSubmatches() {
+			out.Submatches = append(out.Submatches, jsonSubmatch{Text: sm.Match, Start: sm.Start + 1, End: sm.End + 1})
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestWriteJSON(t *testing.T) {
+	set := Set{
+		Matches: []search.Match{
+			{
+				Path:       "main.go",
+				LineNumber: 10,
+				LineText:   "\tfmt.Println(\"<hi>\")\n",
+				Submatches: []search.Submatch{{Match: "Println", Start: 5, End: 12}},
+			},
+			{
+				// An excerpt of a long line, and a multiline match
+				Path:       "min.js",
+				LineNumber: 1,
+				EndLine:    2,
+				LineText:   "a(\nb)\n",
+				LineOffset: 100,
+				Submatches: []search.Submatch{{Match: "a(\nb)", Start: 0, End: 5}},
+			},
+			{Path: "notes.txt", LineNumber: 3, LineText: "no submatch data"},
+		},
+		Notes: map[int]string{2: "check"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := `{"path":"main.go","line":10,"column":6,"text":"\tfmt.Println(\"<hi>\")","submatches":[{"text":"Println","start":6,"end":13}]}` + "\n" +
+		`{"path":"min.js","line":1,"end_line":2,"column":101,"text":"a(\nb)","submatches":[{"text":"a(\nb)","start":101,"end":106}]}` + "\n" +
+		`{"path":"notes.txt","line":3,"column":1,"text":"no submatch data","submatches":[],"note":"check"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
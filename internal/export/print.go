@@ -0,0 +1,73 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// ANSI colors of rg's default output
+const (
+	colorPath  = "\x1b[35m"
+	colorLine  = "\x1b[32m"
+	colorMatch = "\x1b[1;31m"
+	colorNote  = "\x1b[33m"
+	colorReset = "\x1b[0m"
+)
+
+// Print writes set for people to read, grouped by file like rg's output:
+// each file's path, then its matches as line:text. At most limit matches
+// are written, followed by a count of the rest; 0 writes them all. With
+// color, paths, line numbers and matches are colored.
+func Print(w io.Writer, set Set, limit int, color bool) error {
+	paint := func(code, s string) string {
+		if !color || s == "" {
+			return s
+		}
+		return code + s + colorReset
+	}
+
+	bw := bufio.NewWriter(w)
+	path := ""
+	for i, match := range set.Matches {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(bw, "\n… %d more not shown\n", len(set.Matches)-limit)
+			break
+		}
+		if i == 0 || match.Path != path {
+			if i > 0 {
+				bw.WriteString("\n")
+			}
+			path = match.Path
+			fmt.Fprintln(bw, paint(colorPath, path))
+		}
+		text := highlight(strings.TrimRight(match.LineText, "\r\n"), match.Submatches, func(s string) string {
+			return paint(colorMatch, s)
+		})
+		if note := set.Notes[i]; note != "" {
+			text = paint(colorNote, "["+note+"]") + " " + text
+		}
+		fmt.Fprintf(bw, "%s:%s\n", paint(colorLine, fmt.Sprint(match.LineNumber)), text)
+	}
+	return bw.Flush()
+}
+
+// highlight applies mark to the submatches of line, skipping any that are
+// out of range or overlap an earlier one
+func highlight(line string, submatches []search.Submatch, mark func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, sm := range submatches {
+		if sm.Start < last || sm.End > len(line) || sm.Start >= sm.End {
+			continue
+		}
+		b.WriteString(line[last:sm.Start])
+		b.WriteString(mark(line[sm.Start:sm.End]))
+		last = sm.End
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
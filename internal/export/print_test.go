@@ -0,0 +1,38 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestPrint(t *testing.T) {
+	set := Set{
+		Matches: []search.Match{
+			{Path: "a.go", LineNumber: 3, LineText: "func Foo() {\n", Submatches: []search.Submatch{{Match: "Foo", Start: 5, End: 8}}},
+			{Path: "a.go", LineNumber: 9, LineText: "Foo()\n"},
+			{Path: "b.go", LineNumber: 1, LineText: "// Foo\n"},
+			{Path: "c.go", LineNumber: 2, LineText: "Foo\n"},
+		},
+		Notes: map[int]string{2: "check"},
+	}
+
+	var buf bytes.Buffer
+	if err := Print(&buf, set, 3, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "a.go\n3:func Foo() {\n9:Foo()\n\nb.go\n1:[check] // Foo\n\n… 1 more not shown\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := Print(&buf, Set{Matches: set.Matches[:1]}, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	want = "\x1b[35ma.go\x1b[0m\n\x1b[32m3\x1b[0m:func \x1b[1;31mFoo\x1b[0m() {\n"
+	if buf.String() != want {
+		t.Errorf("colored output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,32 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// QuickfixFile is the default quickfix file name. It matches Vim's default
+// 'errorfile', so `:cfile` with no argument loads it.
+const QuickfixFile = "errors.err"
+
+// WriteQuickfix writes one `path:line:col: text` entry per match, the format
+// understood by Vim's default 'errorformat' (`vim -q`, `:cfile`, `:cgetfile`).
+// A match with a note has it in brackets before the text.
+func WriteQuickfix(w io.Writer, set Set) error {
+	bw := bufio.NewWriter(w)
+	for i, match := range set.Matches {
+		// Vim columns are 1-based byte offsets, like ripgrep's
+		column := match.Column()
+		// Each entry takes one line, so a multiline match shows its first
+		text := strings.TrimRight(match.Head().LineText, "\n\r")
+		if note := set.Notes[i]; note != "" {
+			text = "[" + note + "] " + text
+		}
+		if _, err := fmt.Fprintf(bw, "%s:%d:%d: %s\n", match.Path, match.LineNumber, column, text); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestWriteQuickfix(t *testing.T) {
+	set := Set{
+		Matches: []search.Match{
+			{
+				Path:       "main.go",
+				LineNumber: 10,
+				LineText:   "\tfmt.Println(\"hi\")\n",
+				Submatches: []search.Submatch{{Match: "Println", Start: 5, End: 12}},
+			},
+			{
+				Path:       "internal/ui/model.go",
+				LineNumber: 3,
+				LineText:   "no submatch data",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatQuickfix, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := "main.go:10:6: \tfmt.Println(\"hi\")\n" +
+		"internal/ui/model.go:3:1: no submatch data\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteQuickfix_Notes(t *testing.T) {
+	set := Set{
+		Matches: []search.Match{
+			{Path: "a.go", LineNumber: 1, LineText: "one\n"},
+			{Path: "b.go", LineNumber: 2, LineText: "two\n"},
+		},
+		Notes: map[int]string{1: "fix after lunch"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatQuickfix, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := "a.go:1:1: one\n" +
+		"b.go:2:1: [fix after lunch] two\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteQuickfix_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteQuickfix(&buf, Set{}); err != nil {
+		t.Fatalf("WriteQuickfix: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestWriteQuickfix_MultilineShowsFirstLine(t *testing.T) {
+	set := Set{Matches: []search.Match{{
+		Path:       "a.go",
+		LineNumber: 4,
+		EndLine:    5,
+		LineText:   "if x {\n}\n",
+		Submatches: []search.Submatch{{Match: "{\n}", Start: 5, End: 8}},
+	}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatQuickfix, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if want := "a.go:4:6: if x {\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sarifRuleID  = "irg/match"
+	toolURI      = "https://github.com/William9923/irg"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int          `json:"startLine"`
+	StartColumn int          `json:"startColumn,omitempty"`
+	EndLine     int          `json:"endLine,omitempty"`
+	EndColumn   int          `json:"endColumn,omitempty"`
+	Snippet     sarifMessage `json:"snippet"`
+}
+
+// WriteSARIF writes set as a SARIF 2.1.0 log with one result per match,
+// suitable for code-scanning dashboards and CI annotation tools
+func WriteSARIF(w io.Writer, set Set) error {
+	description := "Search match"
+	if set.Pattern != "" {
+		description = "Match for pattern: " + set.Pattern
+	}
+
+	results := make([]sarifResult, 0, len(set.Matches))
+	for i, match := range set.Matches {
+		lineText := strings.TrimRight(match.LineText, "\n\r")
+		region := sarifRegion{
+			StartLine: match.LineNumber,
+			Snippet:   sarifMessage{Text: lineText},
+		}
+
+		message := description
+		if len(match.Submatches) > 0 {
+			sm := match.Submatches[0]
+			// Columns count characters from the start of the line, which
+			// an excerpt of a long line has lost
+			if match.LineOffset == 0 {
+				region.StartColumn = sarifColumn(lineText, sm.Start)
+				region.EndColumn = sarifColumn(lineText, sm.End)
+				// A multiline match ends on a later line of the snippet
+				if end := min(sm.End, len(lineText)); strings.Contains(lineText[:end], "\n") {
+					lastLine := strings.LastIndex(lineText[:end], "\n") + 1
+					region.EndLine = match.LineNumber + strings.Count(lineText[:end], "\n")
+					region.EndColumn = sarifColumn(lineText[lastLine:], end-lastLine)
+				}
+			}
+			message = "Matched " + strconv.Quote(sm.Match)
+		}
+
+		result := sarifResult{
+			RuleID:  sarifRuleID,
+			Level:   "note",
+			Message: sarifMessage{Text: message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(match.Path)},
+					Region:           region,
+				},
+			}},
+		}
+		if note := set.Notes[i]; note != "" {
+			result.Properties = map[string]any{"note": note}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "irg",
+				InformationURI: toolURI,
+				Rules: []sarifRule{{
+					ID:               sarifRuleID,
+					ShortDescription: sarifMessage{Text: description},
+				}},
+			}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// sarifColumn converts a byte offset into a 1-based code point column
+func sarifColumn(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return utf8.RuneCountInString(line[:offset]) + 1
+}
+
+// sarifURI returns a relative, forward-slash URI for path
+func sarifURI(path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(path), "./")
+}
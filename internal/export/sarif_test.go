@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	set := Set{
+		Pattern: "Deprecated",
+		Matches: []search.Match{
+			{
+				Path:       "./internal/api/client.go",
+				LineNumber: 12,
+				LineText:   "\tclient.DeprecatedCall()\n",
+				Submatches: []search.Submatch{{Match: "Deprecated", Start: 8, End: 18}},
+			},
+			{
+				Path:       "docs/ünïcode.md",
+				LineNumber: 3,
+				LineText:   "héllo Deprecated",
+				Submatches: []search.Submatch{{Match: "Deprecated", Start: 7, End: 17}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSARIF, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log header: version=%q runs=%d", log.Version, len(log.Runs))
+	}
+
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	loc := results[0].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "internal/api/client.go" {
+		t.Errorf("URI = %q, want relative path without ./", loc.ArtifactLocation.URI)
+	}
+	if loc.Region.StartLine != 12 || loc.Region.StartColumn != 9 || loc.Region.EndColumn != 19 {
+		t.Errorf("region = %+v, want line 12 columns 9-19", loc.Region)
+	}
+	if loc.Region.Snippet.Text != "\tclient.DeprecatedCall()" {
+		t.Errorf("snippet = %q", loc.Region.Snippet.Text)
+	}
+
+	// Byte offsets are converted to code point columns: "héllo " is 7 bytes, 6 runes
+	if col := results[1].Locations[0].PhysicalLocation.Region.StartColumn; col != 7 {
+		t.Errorf("StartColumn = %d, want 7", col)
+	}
+}
+
+func TestWriteSARIF_NoteProperty(t *testing.T) {
+	set := Set{
+		Matches: []search.Match{{Path: "a.go", LineNumber: 1}, {Path: "b.go", LineNumber: 2}},
+		Notes:   map[int]string{0: "audit: unsafe cast"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSARIF, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	results := log.Runs[0].Results
+	if got := results[0].Properties["note"]; got != "audit: unsafe cast" {
+		t.Errorf("note property = %v", got)
+	}
+	if results[1].Properties != nil {
+		t.Errorf("match without a note has properties %v", results[1].Properties)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"sarif", "sarif", FormatSARIF, false},
+		{"uppercase", "SARIF", FormatSARIF, false},
+		{"unknown", "xml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSARIF_MultilineRegion(t *testing.T) {
+	set := Set{Matches: []search.Match{{
+		Path:       "a.go",
+		LineNumber: 4,
+		EndLine:    5,
+		LineText:   "if x {\n\t}\n",
+		Submatches: []search.Submatch{{Match: "{\n\t}", Start: 5, End: 9}},
+	}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSARIF, set); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	region := log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region
+	if region.StartLine != 4 || region.StartColumn != 6 || region.EndLine != 5 || region.EndColumn != 3 {
+		t.Errorf("region = %+v, want 4:6 to 5:3", region)
+	}
+}
@@ -157,7 +157,8 @@ func (h *Highlighter) IsEnabled() bool {
 // SetEnabled enables or disables syntax highlighting
 func (h *Highlighter) SetEnabled(enabled bool) {
 	h.enabled = enabled
-	if enabled && h.formatter == nil {
+	if enabled {
+		// The style may have changed while highlighting was off
 		h.initialize()
 	}
 	if !enabled {
@@ -179,6 +180,11 @@ func (h *Highlighter) SetStyle(style string) {
 	}
 }
 
+// Styles returns the names of the available styles, sorted
+func Styles() []string {
+	return styles.Names()
+}
+
 // GetStyle returns the current style name
 func (h *Highlighter) GetStyle() string {
 	return h.style
@@ -0,0 +1,110 @@
+// Package history keeps the searches run in past sessions, their patterns
+// with the path and types they were scoped to, so they can be recalled from
+// the pattern input. Unlike stats, the history holds patterns, so it stays
+// in the user's data directory and is never shared.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/William9923/irg/internal/store"
+)
+
+// maxEntries bounds the history; past it the oldest searches are dropped
+const maxEntries = 1000
+
+// Entry is one search in the history
+type Entry struct {
+	Pattern string   `json:"pattern"`
+	Path    string   `json:"path,omitempty"` // "" for the current directory
+	Types   []string `json:"types,omitempty"`
+}
+
+// Equal reports whether e and other are the same search
+func (e Entry) Equal(other Entry) bool {
+	return e.Pattern == other.Pattern && e.Path == other.Path && slices.Equal(e.Types, other.Types)
+}
+
+// Path returns the history file location: $IRG_HISTORY, else
+// $XDG_DATA_HOME/irg/history, else ~/.local/share/irg/history
+func Path() (string, error) {
+	if path := os.Getenv("IRG_HISTORY"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "irg", "history"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "irg", "history"), nil
+}
+
+// Read returns the searches in the history file at path, oldest first. A
+// missing file yields none.
+func Read(path string) ([]Entry, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return parse(path, raw)
+}
+
+// parse reads one JSON entry per line, skipping blank lines
+func parse(path string, raw []byte) ([]Entry, error) {
+	var entries []Entry
+	for i, line := range bytes.Split(raw, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("read history %s:%d: %w", path, i+1, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Add appends entry to the history file at path, keeping what other irg
+// instances added. An earlier run of the same search is dropped, so each
+// search appears once, at its latest use.
+func Add(path string, entry Entry) error {
+	err := store.Update(path, 0o600, func(raw []byte) ([]byte, error) {
+		entries, err := parse(path, raw)
+		if err != nil {
+			return nil, err
+		}
+		entries = slices.DeleteFunc(entries, entry.Equal)
+		entries = append(entries, entry)
+		if len(entries) > maxEntries {
+			entries = entries[len(entries)-maxEntries:]
+		}
+
+		var out bytes.Buffer
+		enc := json.NewEncoder(&out)
+		enc.SetEscapeHTML(false)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return nil, err
+			}
+		}
+		return out.Bytes(), nil
+	})
+	if err != nil {
+		return fmt.Errorf("save history: %w", err)
+	}
+	return nil
+}
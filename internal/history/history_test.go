@@ -0,0 +1,72 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead_MissingIsEmpty(t *testing.T) {
+	entries, err := Read(filepath.Join(t.TempDir(), "history"))
+	if err != nil || entries != nil {
+		t.Errorf("Read = %v, %v; want no entries", entries, err)
+	}
+}
+
+func TestAdd_MovesRepeatsToTheEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history")
+	adds := []Entry{
+		{Pattern: "func main"},
+		{Pattern: "TODO", Path: "internal", Types: []string{"go"}},
+		{Pattern: `"quoted" <tag>`},
+		{Pattern: "TODO", Path: "internal", Types: []string{"go", "md"}},
+		{Pattern: "func main"},
+	}
+	for _, e := range adds {
+		if err := Add(path, e); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{adds[1], adds[2], adds[3], adds[4]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("history = %+v, want %+v", got, want)
+	}
+	raw, _ := os.ReadFile(path)
+	if !strings.Contains(string(raw), `"pattern":"\"quoted\" <tag>"`) {
+		t.Errorf("file = %s, want one unescaped JSON line per search", raw)
+	}
+}
+
+func TestAdd_KeepsTheNewest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	for i := range maxEntries + 5 {
+		if err := Add(path, Entry{Pattern: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != maxEntries || got[0].Pattern != "5" || got[len(got)-1].Pattern != fmt.Sprint(maxEntries+4) {
+		t.Errorf("kept %d entries from %q to %q, want the newest %d", len(got), got[0].Pattern, got[len(got)-1].Pattern, maxEntries)
+	}
+}
+
+func TestRead_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("{\"pattern\":\"ok\"}\n\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil || !strings.Contains(err.Error(), ":3:") {
+		t.Errorf("err = %v, want the bad line reported", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package hooks runs user-configured shell commands on lifecycle events
+// such as opening a match or finishing a search.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/William9923/irg/internal/config"
+	"github.com/William9923/irg/internal/editor"
+)
+
+// hookTimeout bounds how long a hook may run before it is killed
+const hookTimeout = 10 * time.Second
+
+// Event names a point in irg's lifecycle that a hook can run on
+type Event string
+
+const (
+	EventOpen              Event = "on-open"
+	EventSearchComplete    Event = "on-search-complete"
+	EventExitWithSelection Event = "on-exit-with-selection"
+)
+
+// Env describes the match or search a hook runs for. It is passed to the
+// hook as IRG_* environment variables.
+type Env struct {
+	Pattern    string
+	SearchPath string
+	Path       string
+	Line       int
+	Text       string
+	MatchCount int
+}
+
+func (e Env) vars(event Event) []string {
+	vars := []string{
+		"IRG_EVENT=" + string(event),
+		"IRG_PATTERN=" + e.Pattern,
+		"IRG_SEARCH_PATH=" + e.SearchPath,
+		"IRG_MATCH_COUNT=" + strconv.Itoa(e.MatchCount),
+	}
+	if e.Path != "" {
+		vars = append(vars,
+			"IRG_PATH="+e.Path,
+			"IRG_LINE="+strconv.Itoa(e.Line),
+			"IRG_TEXT="+strings.TrimRight(e.Text, "\r\n"),
+		)
+	}
+	return vars
+}
+
+// Runner runs the configured hooks. A nil *Runner runs nothing.
+type Runner struct {
+	commands map[Event]string
+}
+
+// New creates a runner for the hooks in cfg, or nil if none are set
+func New(cfg config.Hooks) *Runner {
+	commands := map[Event]string{
+		EventOpen:              cfg.OnOpen,
+		EventSearchComplete:    cfg.OnSearchComplete,
+		EventExitWithSelection: cfg.OnExitWithSelection,
+	}
+	for event, command := range commands {
+		if strings.TrimSpace(command) == "" {
+			delete(commands, event)
+		}
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	return &Runner{commands: commands}
+}
+
+// Has reports whether a hook is configured for event
+func (r *Runner) Has(event Event) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.commands[event]
+	return ok
+}
+
+// Run runs the hook for event, if any, and waits for it to finish. The
+// hook's stdout goes to out, which may be nil to discard it.
+func (r *Runner) Run(event Event, env Env, out io.Writer) error {
+	if !r.Has(event) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := r.command(ctx, event, env)
+	cmd.Stdout = out
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s hook: %w: %s", event, err, msg)
+		}
+		return fmt.Errorf("%s hook: %w", event, err)
+	}
+	return nil
+}
+
+// command runs the hook for event in the shell, with env in its environment
+func (r *Runner) command(ctx context.Context, event Event, env Env) *exec.Cmd {
+	cmd := editor.ShellCommand(ctx, r.commands[event])
+	cmd.Env = append(os.Environ(), env.vars(event)...)
+	return cmd
+}
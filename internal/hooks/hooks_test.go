@@ -0,0 +1,48 @@
+package hooks
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/William9923/irg/internal/config"
+)
+
+func TestNew_NoHooksIsNil(t *testing.T) {
+	r := New(config.Hooks{OnOpen: "  "})
+	if r != nil {
+		t.Fatal("expected nil runner when no hooks are set")
+	}
+	if err := r.Run(EventOpen, Env{}, nil); err != nil {
+		t.Errorf("Run on nil runner: %v", err)
+	}
+}
+
+func TestRunner_PassesEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	r := New(config.Hooks{OnOpen: `echo "$IRG_EVENT $IRG_PATH:$IRG_LINE $IRG_TEXT"`})
+
+	var out bytes.Buffer
+	err := r.Run(EventOpen, Env{Path: "main.go", Line: 12, Text: "func main() {\n"}, &out)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "on-open main.go:12 func main() {" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRunner_ReportsFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	r := New(config.Hooks{OnSearchComplete: "echo broken >&2; exit 3"})
+
+	err := r.Run(EventSearchComplete, Env{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("err = %v, want failure with stderr", err)
+	}
+}
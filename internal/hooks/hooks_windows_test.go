@@ -0,0 +1,20 @@
+//go:build windows
+
+package hooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/William9923/irg/internal/config"
+)
+
+func TestRunner_PassesCommandLineVerbatim(t *testing.T) {
+	r := New(config.Hooks{OnOpen: `"C:\Program Files\notify.exe" "%IRG_PATH%"`})
+	cmd := r.command(context.Background(), EventOpen, Env{Path: "main.go"})
+
+	want := `/d /s /c ""C:\Program Files\notify.exe" "%IRG_PATH%""`
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CmdLine != want {
+		t.Errorf("command line = %+v, want %q", cmd.SysProcAttr, want)
+	}
+}
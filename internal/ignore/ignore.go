@@ -0,0 +1,214 @@
+// Package ignore matches paths against .gitignore-style rules, as used by
+// the path index to skip the files ripgrep would skip.
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Files lists the per-directory ignore files honored, in the order ripgrep
+// applies them (later files take precedence)
+var Files = []string{".gitignore", ".ignore", ".rgignore"}
+
+// rule is one parsed ignore pattern
+type rule struct {
+	base    string // Directory of the ignore file, relative to the root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	// anchored rules match the path below base; others match the name alone
+	anchored bool
+}
+
+// Matcher holds the rules in effect for one directory. The zero value
+// ignores nothing.
+type Matcher struct {
+	rules []rule
+}
+
+// New returns the matcher for the root directory: its ignore files plus the
+// repository's .git/info/exclude, if root is a work tree's top level
+func New(root string) *Matcher {
+	m := &Matcher{}
+	if rules, err := parseFile(filepath.Join(root, ".git", "info", "exclude"), ""); err == nil {
+		m.rules = rules
+	}
+	return m.ForDir(root, "")
+}
+
+// ForDir returns the matcher for dir, a directory at rel below the root:
+// m's rules plus those in dir's ignore files. m itself is unchanged, so
+// sibling directories don't see each other's rules.
+func (m *Matcher) ForDir(dir, rel string) *Matcher {
+	var added []rule
+	for _, name := range Files {
+		rules, err := parseFile(filepath.Join(dir, name), rel)
+		if err != nil {
+			continue
+		}
+		added = append(added, rules...)
+	}
+	if len(added) == 0 {
+		return m
+	}
+	var inherited []rule
+	if m != nil {
+		inherited = m.rules
+	}
+	// Copy so appends never share a backing array with the parent
+	rules := make([]rule, 0, len(inherited)+len(added))
+	rules = append(append(rules, inherited...), added...)
+	return &Matcher{rules: rules}
+}
+
+// WithPatterns returns a matcher with m's rules plus patterns, given as lines
+// of an ignore file in the directory at rel below the root
+func (m *Matcher) WithPatterns(rel string, patterns []string) *Matcher {
+	var inherited []rule
+	if m != nil {
+		inherited = m.rules
+	}
+	rules := append([]rule(nil), inherited...)
+	for _, line := range patterns {
+		if r, ok := parseLine(line, rel); ok {
+			rules = append(rules, r)
+		}
+	}
+	return &Matcher{rules: rules}
+}
+
+// Match reports whether rel, a slash-separated path below the root, is
+// ignored. The last matching rule wins, so negated rules re-include paths.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		r := m.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		subject, ok := r.subject(rel)
+		if ok && r.re.MatchString(subject) {
+			return !r.negate
+		}
+	}
+	return false
+}
+
+// subject returns the part of rel the rule's pattern applies to, or false if
+// rel isn't below the rule's directory
+func (r rule) subject(rel string) (string, bool) {
+	if r.base != "" {
+		var ok bool
+		if rel, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+			return "", false
+		}
+	}
+	if r.anchored {
+		return rel, true
+	}
+	return rel[strings.LastIndex(rel, "/")+1:], true
+}
+
+func parseFile(path, base string) ([]rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []rule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseLine(scanner.Text(), base); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// parseLine parses one line of an ignore file, reporting false for blank
+// lines, comments and patterns that can't be compiled
+func parseLine(line, base string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// globToRegexp translates a gitignore glob: * and ? stay within one path
+// element, ** spans elements, and [...] is a character class
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
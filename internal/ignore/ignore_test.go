@@ -0,0 +1,95 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	m := (&Matcher{}).WithPatterns("", []string{
+		"# build output",
+		"*.log",
+		"!keep.log",
+		"/dist",
+		"build/",
+		"docs/**/*.png",
+		"**/tmp",
+		`\#literal`,
+		"",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"dist", true, true},
+		{"src/dist", true, false}, // Anchored to the root
+		{"build", true, true},
+		{"build", false, false}, // Directories only
+		{"src/build", true, true},
+		{"docs/a/b/c.png", false, true},
+		{"docs/c.png", false, true},
+		{"img/c.png", false, false},
+		{"a/b/tmp", true, true},
+		{"#literal", false, true},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_NestedFilesAreScoped(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, data string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".gitignore", "*.tmp\n")
+	write("web/.gitignore", "/generated\n!important.tmp\n")
+	write(".git/info/exclude", "scratch\n")
+
+	rootMatcher := New(root)
+	web := rootMatcher.ForDir(filepath.Join(root, "web"), "web")
+	api := rootMatcher.ForDir(filepath.Join(root, "api"), "api")
+
+	tests := []struct {
+		name string
+		m    *Matcher
+		path string
+		want bool
+	}{
+		{"root rule applies below", web, "web/x.tmp", true},
+		{"nested negation", web, "web/important.tmp", false},
+		{"nested anchored rule", web, "web/generated", true},
+		{"sibling doesn't see nested rules", api, "api/generated", false},
+		{"info/exclude", rootMatcher, "scratch", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Match(tt.path, false); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_NilIgnoresNothing(t *testing.T) {
+	var m *Matcher
+	if m.Match("anything", false) {
+		t.Error("nil matcher ignored a path")
+	}
+}
@@ -0,0 +1,124 @@
+// Package lint runs an external linter on a file and reads the diagnostics
+// it prints in the common path:line:column: message format, which go vet,
+// ruff, shellcheck -f gcc, eslint -f unix and most others can produce.
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/William9923/irg/internal/classify"
+	"github.com/William9923/irg/internal/editor"
+)
+
+// Diagnostic is one problem a linter reported
+type Diagnostic struct {
+	Line    int
+	Column  int // 0 when the linter gave none
+	Message string
+}
+
+// Linter is a linter command template for the files matching Globs, run
+// with sh -c (cmd /C on Windows). The file is substituted for {file},
+// quoted. Globs follow the class globs.
+type Linter struct {
+	Globs   []string
+	Command string
+}
+
+// For returns the first of linters with a glob matching path, or nil
+func For(linters []Linter, path string) *Linter {
+	p := strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for i, l := range linters {
+		for _, glob := range l.Globs {
+			if classify.Match(glob, p) {
+				return &linters[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Run lints path and returns its diagnostics, ordered by line. Linters
+// exit with an error when they find problems, so a failure only counts when
+// the output has no diagnostics in it.
+func (l Linter) Run(ctx context.Context, path string) ([]Diagnostic, error) {
+	cmd := l.command(ctx, path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	// Some linters, like go vet, report on stderr
+	output = append(output, stderr.Bytes()...)
+	diagnostics := Parse(output, path)
+	if err == nil || len(diagnostics) > 0 {
+		return diagnostics, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case !errors.As(err, &exitErr):
+		return nil, fmt.Errorf("%s: %w", l.Command, err)
+	case diagnosticLine.Match(output):
+		// Problems in other files only
+		return nil, nil
+	case firstLine(stderr.String()) != "":
+		return nil, fmt.Errorf("%s: %s", l.Command, firstLine(stderr.String()))
+	}
+	return nil, nil
+}
+
+var diagnosticLine = regexp.MustCompile(`(?m)^(.+?):(\d+):(?:(\d+):)?\s*(.*)$`)
+
+// Parse reads the diagnostics for path from a linter's output, skipping
+// those for other files, which linters checking a whole package report too
+func Parse(output []byte, path string) []Diagnostic {
+	var diagnostics []Diagnostic
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		m := diagnosticLine.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if m == nil || !sameFile(m[1], path) {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		diagnostics = append(diagnostics, Diagnostic{Line: line, Column: column, Message: strings.TrimSpace(m[4])})
+	}
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int { return a.Line - b.Line })
+	return diagnostics
+}
+
+// sameFile reports whether the linter's name for a file refers to path
+func sameFile(name, path string) bool {
+	if filepath.Clean(name) == filepath.Clean(path) {
+		return true
+	}
+	a, err1 := filepath.Abs(name)
+	b, err2 := filepath.Abs(path)
+	return err1 == nil && err2 == nil && a == b
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// command runs the linter's command in the shell, with path for {file}
+func (l Linter) command(ctx context.Context, path string) *exec.Cmd {
+	return editor.ShellCommand(ctx, strings.ReplaceAll(l.Command, "{file}", editor.ShellQuote(path)))
+}
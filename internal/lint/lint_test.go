@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	output := strings.Join([]string{
+		"# example.com/pkg",
+		"./main.go:12:2: unreachable code",
+		"other.go:3:1: not this file",
+		"main.go:4: exported function Run should have comment",
+		"main.go:2:10: [E501] line too long\r",
+		"Found 3 errors.",
+	}, "\n")
+	got := Parse([]byte(output), "main.go")
+	want := []Diagnostic{
+		{Line: 2, Column: 10, Message: "[E501] line too long"},
+		{Line: 4, Message: "exported function Run should have comment"},
+		{Line: 12, Column: 2, Message: "unreachable code"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+
+	abs, err := filepath.Abs("main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Parse([]byte(abs+":7:1: shadowed\n"), "main.go"); len(got) != 1 || got[0].Line != 7 {
+		t.Errorf("Parse of an absolute path = %+v, want its diagnostic", got)
+	}
+}
+
+func TestFor(t *testing.T) {
+	linters := []Linter{
+		{Globs: []string{"*.py", "*.pyi"}, Command: "ruff check {file}"},
+		{Globs: []string{"scripts/*"}, Command: "shellcheck -f gcc {file}"},
+	}
+	for path, want := range map[string]string{
+		"app/models.py":     "ruff check {file}",
+		"./scripts/release": "shellcheck -f gcc {file}",
+		"main.go":           "",
+	} {
+		got := ""
+		if l := For(linters, path); l != nil {
+			got = l.Command
+		}
+		if got != want {
+			t.Errorf("For(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands use sh")
+	}
+	// Linters exit with an error when they find problems
+	l := Linter{Command: `printf '%s:3:5: x declared and not used\nother.go:1:1: elsewhere\n' {file}; exit 1`}
+	got, err := l.Run(context.Background(), "it's.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Diagnostic{{Line: 3, Column: 5, Message: "x declared and not used"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Run = %+v, want %+v", got, want)
+	}
+
+	if got, err := (Linter{Command: "echo 'other.go:1:1: elsewhere' >&2; exit 1"}).Run(context.Background(), "main.go"); err != nil || got != nil {
+		t.Errorf("Run = %+v, %v; want problems in other files ignored", got, err)
+	}
+	if _, err := (Linter{Command: "no-such-linter-irg {file}"}).Run(context.Background(), "main.go"); err == nil || !strings.Contains(err.Error(), "no-such-linter-irg") {
+		t.Errorf("err = %v, want the missing linter reported", err)
+	}
+}
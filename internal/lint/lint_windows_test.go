@@ -0,0 +1,18 @@
+//go:build windows
+
+package lint
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLinter_QuotesFileForCmd(t *testing.T) {
+	l := Linter{Command: `ruff check --output-format concise {file}`}
+	cmd := l.command(context.Background(), `My Docs\x.py`)
+
+	want := `/d /s /c "ruff check --output-format concise "My Docs\x.py""`
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CmdLine != want {
+		t.Errorf("command line = %+v, want %q", cmd.SysProcAttr, want)
+	}
+}
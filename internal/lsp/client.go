@@ -0,0 +1,335 @@
+// Package lsp is a small Language Server Protocol client used to ask servers
+// such as gopls for the references and definition of a symbol.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shutdownTimeout bounds how long Close waits for a server to exit cleanly
+const shutdownTimeout = 2 * time.Second
+
+// servers maps file extensions to the language server command that handles them
+var servers = map[string]server{
+	".go": {languageID: "go", command: []string{"gopls"}},
+}
+
+type server struct {
+	languageID string
+	command    []string
+}
+
+// ErrUnsupported is returned for files no language server is configured for
+var ErrUnsupported = errors.New("no language server for this file type")
+
+// Location is a position in a file, with lines and columns 1-based and
+// columns in bytes, matching ripgrep's results
+type Location struct {
+	Path      string
+	Line      int
+	Column    int
+	EndColumn int    // Exclusive; equal to Column when the range spans lines
+	Text      string // The full text of Line
+}
+
+// Client talks to a single running language server
+type Client struct {
+	cmd  *exec.Cmd
+	conn *conn
+	root string
+
+	mu     sync.Mutex
+	opened map[string]bool
+}
+
+// Start launches command with root as the workspace and performs the
+// initialize handshake
+func Start(ctx context.Context, command []string, root string) (*Client, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve root: %w", err)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", command[0], err)
+	}
+
+	c := newClient(stdout, stdin, root)
+	c.cmd = cmd
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func newClient(r io.Reader, w io.Writer, root string) *Client {
+	return &Client{conn: newConn(r, w), root: root, opened: make(map[string]bool)}
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"rootUri":   pathToURI(c.root),
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"references": map[string]any{},
+				"definition": map[string]any{"linkSupport": false},
+			},
+		},
+		"workspaceFolders": []map[string]string{
+			{"uri": pathToURI(c.root), "name": filepath.Base(c.root)},
+		},
+	}
+	if err := c.callContext(ctx, "initialize", params, nil); err != nil {
+		return err
+	}
+	return c.conn.notify("initialized", struct{}{})
+}
+
+// References returns every reference to the symbol at path:line:column,
+// including its declaration. line and column are 1-based; column is a byte
+// offset into lineText.
+func (c *Client) References(ctx context.Context, path string, line, column int, lineText string) ([]Location, error) {
+	params, err := c.positionParams(path, line, column, lineText)
+	if err != nil {
+		return nil, err
+	}
+	params["context"] = map[string]bool{"includeDeclaration": true}
+
+	var raw []json.RawMessage
+	if err := c.callContext(ctx, "textDocument/references", params, &raw); err != nil {
+		return nil, err
+	}
+	return c.decodeLocations(raw)
+}
+
+// Definition returns the definition of the symbol at path:line:column
+func (c *Client) Definition(ctx context.Context, path string, line, column int, lineText string) ([]Location, error) {
+	params, err := c.positionParams(path, line, column, lineText)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err := c.callContext(ctx, "textDocument/definition", params, &raw); err != nil {
+		return nil, err
+	}
+	// The result is a Location, a Location[], or a LocationLink[]
+	var list []json.RawMessage
+	if len(raw) > 0 && raw[0] == '{' {
+		list = []json.RawMessage{raw}
+	} else if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("decode definition: %w", err)
+		}
+	}
+	return c.decodeLocations(list)
+}
+
+// Close shuts the server down, killing it if it doesn't exit in time
+func (c *Client) Close() error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := c.conn.call("shutdown", nil, nil); err == nil {
+			c.conn.notify("exit", nil)
+		}
+		if c.cmd != nil {
+			c.cmd.Wait()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		if c.cmd != nil && c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+	}
+	return nil
+}
+
+// callContext is call with cancellation; a cancelled request is abandoned
+// rather than sent $/cancelRequest, which servers treat as optional anyway
+func (c *Client) callContext(ctx context.Context, method string, params, result any) error {
+	errc := make(chan error, 1)
+	go func() { errc <- c.conn.call(method, params, result) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// positionParams builds TextDocumentPositionParams, opening the document
+// with the server first if needed
+func (c *Client) positionParams(path string, line, column int, lineText string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", path, err)
+	}
+	if err := c.open(abs); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"textDocument": map[string]string{"uri": pathToURI(abs)},
+		"position": map[string]int{
+			"line":      line - 1,
+			"character": byteToUTF16(lineText, column-1),
+		},
+	}, nil
+}
+
+// open sends textDocument/didOpen once per file
+func (c *Client) open(abs string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.opened[abs] {
+		return nil
+	}
+
+	srv, ok := servers[strings.ToLower(filepath.Ext(abs))]
+	if !ok {
+		return ErrUnsupported
+	}
+	text, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", abs, err)
+	}
+	err = c.conn.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{
+			"uri":        pathToURI(abs),
+			"languageId": srv.languageID,
+			"version":    1,
+			"text":       string(text),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.opened[abs] = true
+	return nil
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+
+	// LocationLink fields
+	TargetURI            string   `json:"targetUri"`
+	TargetSelectionRange lspRange `json:"targetSelectionRange"`
+}
+
+// decodeLocations converts LSP locations to 1-based byte positions relative
+// to the current directory
+func (c *Client) decodeLocations(raw []json.RawMessage) ([]Location, error) {
+	cwd, _ := os.Getwd()
+	lines := make(map[string][]string)
+
+	locations := make([]Location, 0, len(raw))
+	for _, r := range raw {
+		var l lspLocation
+		if err := json.Unmarshal(r, &l); err != nil {
+			return nil, fmt.Errorf("decode location: %w", err)
+		}
+		uri, rng := l.URI, l.Range
+		if l.TargetURI != "" {
+			uri, rng = l.TargetURI, l.TargetSelectionRange
+		}
+
+		abs, err := uriToPath(uri)
+		if err != nil {
+			continue
+		}
+		fileLines, ok := lines[abs]
+		if !ok {
+			if data, err := os.ReadFile(abs); err == nil {
+				fileLines = strings.Split(string(data), "\n")
+			}
+			lines[abs] = fileLines
+		}
+
+		var lineText string
+		if rng.Start.Line < len(fileLines) {
+			lineText = strings.TrimSuffix(fileLines[rng.Start.Line], "\r")
+		}
+		loc := Location{
+			Path:   relativeTo(cwd, abs),
+			Line:   rng.Start.Line + 1,
+			Column: utf16ToByte(lineText, rng.Start.Character) + 1,
+			Text:   lineText,
+		}
+		loc.EndColumn = loc.Column
+		if rng.End.Line == rng.Start.Line {
+			loc.EndColumn = utf16ToByte(lineText, rng.End.Character) + 1
+		}
+		locations = append(locations, loc)
+	}
+	return locations, nil
+}
+
+func relativeTo(base, path string) string {
+	if base != "" {
+		if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("parse uri: %w", err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported uri scheme %q", u.Scheme)
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path), nil
+}
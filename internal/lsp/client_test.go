@@ -0,0 +1,119 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestByteUTF16Conversion(t *testing.T) {
+	tests := []struct {
+		line      string
+		byteOff   int
+		utf16Off  int
+		roundTrip bool
+	}{
+		{"hello", 3, 3, true},
+		{"héllo", 3, 2, true},   // é is 2 bytes, 1 code unit
+		{"a😀b", 5, 3, true},     // 😀 is 4 bytes, 2 code units
+		{"short", 99, 5, false}, // Clamped to the line length
+	}
+	for _, tt := range tests {
+		if got := byteToUTF16(tt.line, tt.byteOff); got != tt.utf16Off {
+			t.Errorf("byteToUTF16(%q, %d) = %d, want %d", tt.line, tt.byteOff, got, tt.utf16Off)
+		}
+		if tt.roundTrip {
+			if got := utf16ToByte(tt.line, tt.utf16Off); got != tt.byteOff {
+				t.Errorf("utf16ToByte(%q, %d) = %d, want %d", tt.line, tt.utf16Off, got, tt.byteOff)
+			}
+		}
+	}
+}
+
+// fakeServer answers initialize and references requests with canned results
+func fakeServer(t *testing.T, r io.Reader, w io.Writer, refs []lspLocation) {
+	t.Helper()
+	br := bufio.NewReader(r)
+	tp := textproto.NewReader(br)
+	out := &conn{w: w}
+	for {
+		msg, err := readMessage(tp, br)
+		if err != nil {
+			return
+		}
+		if msg.ID == nil {
+			continue
+		}
+		var result any
+		switch msg.Method {
+		case "initialize":
+			result = map[string]any{"capabilities": map[string]any{}}
+		case "textDocument/references":
+			result = refs
+		}
+		out.write(struct {
+			JSONRPC string `json:"jsonrpc"`
+			ID      *int64 `json:"id"`
+			Result  any    `json:"result"`
+		}{"2.0", msg.ID, result})
+	}
+}
+
+func TestClient_References(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc héllo() {}\n\nvar _ = héllo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	defer clientW.Close()
+	defer serverW.Close()
+
+	uri := pathToURI(path)
+	go fakeServer(t, serverR, serverW, []lspLocation{
+		{URI: uri, Range: lspRange{Start: lspPosition{2, 5}, End: lspPosition{2, 10}}},
+		{URI: uri, Range: lspRange{Start: lspPosition{4, 8}, End: lspPosition{4, 13}}},
+	})
+
+	c := newClient(clientR, clientW, dir)
+	if err := c.initialize(context.Background()); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	locs, err := c.References(context.Background(), path, 3, 6, "func héllo() {}")
+	if err != nil {
+		t.Fatalf("References: %v", err)
+	}
+	if len(locs) != 2 {
+		t.Fatalf("got %d locations, want 2", len(locs))
+	}
+	// "héllo" is 5 UTF-16 units but 6 bytes
+	want := Location{Path: path, Line: 5, Column: 9, EndColumn: 15, Text: "var _ = héllo"}
+	got := locs[1]
+	got.Path, _ = filepath.Abs(got.Path)
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReadMessage_Framing(t *testing.T) {
+	body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 7, "result": "ok"})
+	input := "Content-Length: " + strconv.Itoa(len(body)) + "\r\nContent-Type: application/vscode-jsonrpc\r\n\r\n" + string(body)
+	br := bufio.NewReader(strings.NewReader(input))
+	msg, err := readMessage(textproto.NewReader(br), br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID == nil || *msg.ID != 7 || string(msg.Result) != `"ok"` {
+		t.Errorf("got id=%v result=%s", msg.ID, msg.Result)
+	}
+}
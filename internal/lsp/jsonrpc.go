@@ -0,0 +1,175 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// conn is a minimal JSON-RPC 2.0 connection using the LSP base protocol's
+// Content-Length framing
+type conn struct {
+	w   io.Writer
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan response
+	closed  error
+}
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	c := &conn{w: w, pending: make(map[int64]chan response)}
+	go c.readLoop(bufio.NewReader(r))
+	return c
+}
+
+// call sends a request and waits for its response
+func (c *conn) call(method string, params, result any) error {
+	c.mu.Lock()
+	if c.closed != nil {
+		c.mu.Unlock()
+		return c.closed
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan response, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(request{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	resp, ok := <-ch
+	if !ok {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.closed
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %w", method, resp.Error)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// notify sends a notification, which has no response
+func (c *conn) notify(method string, params any) error {
+	return c.write(request{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *conn) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	if _, err := c.w.Write(body); err != nil {
+		return fmt.Errorf("write body: %w", err)
+	}
+	return nil
+}
+
+func (c *conn) readLoop(r *bufio.Reader) {
+	tp := textproto.NewReader(r)
+	var err error
+	for {
+		var msg response
+		if msg, err = readMessage(tp, r); err != nil {
+			break
+		}
+
+		// Requests from the server (progress, configuration) get an empty
+		// reply so the server doesn't block waiting on us
+		if msg.Method != "" {
+			if msg.ID != nil {
+				c.write(struct {
+					JSONRPC string `json:"jsonrpc"`
+					ID      *int64 `json:"id"`
+					Result  any    `json:"result"`
+				}{"2.0", msg.ID, nil})
+			}
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = fmt.Errorf("language server connection closed: %w", err)
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+func readMessage(tp *textproto.Reader, r io.Reader) (response, error) {
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return response{}, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil {
+		return response{}, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return response{}, err
+	}
+	var msg response
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return response{}, fmt.Errorf("decode message: %w", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,64 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Manager starts one language server per language on demand and keeps it
+// running for later requests
+type Manager struct {
+	root string
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewManager creates a manager whose servers use root as their workspace
+func NewManager(root string) *Manager {
+	return &Manager{root: root, clients: make(map[string]*Client)}
+}
+
+// ForFile returns the client for path's language, starting its server if
+// this is the first request. The second result names the server.
+func (m *Manager) ForFile(ctx context.Context, path string) (*Client, string, error) {
+	srv, ok := servers[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, "", ErrUnsupported
+	}
+	name := srv.command[0]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.clients[srv.languageID]; ok {
+		return c, name, nil
+	}
+
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, name, fmt.Errorf("%s is not installed", name)
+	}
+	c, err := Start(ctx, srv.command, m.root)
+	if err != nil {
+		return nil, name, err
+	}
+	m.clients[srv.languageID] = c
+	return c, name, nil
+}
+
+// Close shuts down every running server
+func (m *Manager) Close() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, c := range m.clients {
+		c.Close()
+		delete(m.clients, id)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package lsp
+
+// LSP positions count UTF-16 code units, while ripgrep reports byte offsets
+
+// byteToUTF16 converts a byte offset in line to a UTF-16 offset
+func byteToUTF16(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	n := 0
+	for _, r := range line[:offset] {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// utf16ToByte converts a UTF-16 offset in line to a byte offset
+func utf16ToByte(line string, offset int) int {
+	units := 0
+	for i, r := range line {
+		if units >= offset {
+			return i
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return len(line)
+}
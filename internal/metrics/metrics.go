@@ -0,0 +1,166 @@
+// Package metrics collects local, opt-in performance measurements (search
+// durations, rg spawns, preview loads, render times) and writes a summary to a
+// file on exit. Nothing is ever sent over the network.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/William9923/irg/internal/store"
+)
+
+// maxSamples bounds the samples kept per timing for percentile calculation
+const maxSamples = 10000
+
+// Collector records timings and counters. A nil *Collector is valid and
+// discards everything, so callers don't need to check whether metrics are on.
+type Collector struct {
+	mu       sync.Mutex
+	started  time.Time
+	timings  map[string]*timing
+	counters map[string]int64
+}
+
+type timing struct {
+	count   int64
+	total   time.Duration
+	min     time.Duration
+	max     time.Duration
+	samples []time.Duration
+}
+
+// New creates an enabled collector
+func New() *Collector {
+	return &Collector{
+		started:  time.Now(),
+		timings:  make(map[string]*timing),
+		counters: make(map[string]int64),
+	}
+}
+
+// Observe records a duration under name
+func (c *Collector) Observe(name string, d time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.timings[name]
+	if !ok {
+		t = &timing{min: d, max: d}
+		c.timings[name] = t
+	}
+	t.count++
+	t.total += d
+	if d < t.min {
+		t.min = d
+	}
+	if d > t.max {
+		t.max = d
+	}
+	if len(t.samples) < maxSamples {
+		t.samples = append(t.samples, d)
+	}
+}
+
+// Since records the time elapsed since start under name
+func (c *Collector) Since(name string, start time.Time) {
+	if c == nil {
+		return
+	}
+	c.Observe(name, time.Since(start))
+}
+
+// Inc increments the counter name by one
+func (c *Collector) Inc(name string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[name]++
+}
+
+// Summary is the JSON document written by WriteFile
+type Summary struct {
+	Started    time.Time                `json:"started"`
+	DurationMS float64                  `json:"duration_ms"`
+	Timings    map[string]TimingSummary `json:"timings"`
+	Counters   map[string]int64         `json:"counters"`
+}
+
+// TimingSummary aggregates the observations recorded under one name
+type TimingSummary struct {
+	Count   int64   `json:"count"`
+	TotalMS float64 `json:"total_ms"`
+	MeanMS  float64 `json:"mean_ms"`
+	MinMS   float64 `json:"min_ms"`
+	MaxMS   float64 `json:"max_ms"`
+	P50MS   float64 `json:"p50_ms"`
+	P95MS   float64 `json:"p95_ms"`
+}
+
+// Summary returns the aggregated metrics collected so far
+func (c *Collector) Summary() Summary {
+	if c == nil {
+		return Summary{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Summary{
+		Started:    c.started,
+		DurationMS: ms(time.Since(c.started)),
+		Timings:    make(map[string]TimingSummary, len(c.timings)),
+		Counters:   make(map[string]int64, len(c.counters)),
+	}
+	for name, t := range c.timings {
+		sorted := make([]time.Duration, len(t.samples))
+		copy(sorted, t.samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		s.Timings[name] = TimingSummary{
+			Count:   t.count,
+			TotalMS: ms(t.total),
+			MeanMS:  ms(t.total) / float64(t.count),
+			MinMS:   ms(t.min),
+			MaxMS:   ms(t.max),
+			P50MS:   ms(percentile(sorted, 0.50)),
+			P95MS:   ms(percentile(sorted, 0.95)),
+		}
+	}
+	for name, n := range c.counters {
+		s.Counters[name] = n
+	}
+	return s
+}
+
+// WriteFile writes the summary as indented JSON to path, replacing the file
+// atomically
+func (c *Collector) WriteFile(path string) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.Summary(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode metrics: %w", err)
+	}
+	return store.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
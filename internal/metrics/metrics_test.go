@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCollector_NilIsNoop(t *testing.T) {
+	var c *Collector
+	c.Observe("search", time.Second)
+	c.Inc("rg.spawn")
+	c.Since("render", time.Now())
+	if err := c.WriteFile(filepath.Join(t.TempDir(), "metrics.json")); err != nil {
+		t.Errorf("WriteFile on nil collector: %v", err)
+	}
+}
+
+func TestCollector_Summary(t *testing.T) {
+	c := New()
+	for i := 1; i <= 100; i++ {
+		c.Observe("search", time.Duration(i)*time.Millisecond)
+	}
+	c.Inc("rg.spawn")
+	c.Inc("rg.spawn")
+
+	s := c.Summary()
+	search := s.Timings["search"]
+	if search.Count != 100 {
+		t.Errorf("Count = %d, want 100", search.Count)
+	}
+	if search.MinMS != 1 || search.MaxMS != 100 {
+		t.Errorf("Min/Max = %v/%v, want 1/100", search.MinMS, search.MaxMS)
+	}
+	if search.MeanMS != 50.5 {
+		t.Errorf("MeanMS = %v, want 50.5", search.MeanMS)
+	}
+	if search.P95MS != 95 {
+		t.Errorf("P95MS = %v, want 95", search.P95MS)
+	}
+	if s.Counters["rg.spawn"] != 2 {
+		t.Errorf("rg.spawn = %d, want 2", s.Counters["rg.spawn"])
+	}
+}
+
+func TestCollector_WriteFile(t *testing.T) {
+	c := New()
+	c.Observe("preview.load", 3*time.Millisecond)
+
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	if err := c.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Summary
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if s.Timings["preview.load"].Count != 1 {
+		t.Errorf("preview.load count = %d, want 1", s.Timings["preview.load"].Count)
+	}
+}
@@ -0,0 +1,172 @@
+package replace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+// maxDiffCells bounds the LCS table used when a rewrite changes the number
+// of lines; larger changes are summarized instead
+const maxDiffCells = 4_000_000
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	old  int // 1-based line in the old file, for hunk headers
+	new  int
+}
+
+// Diff returns a unified diff between before and after, or "" if they are
+// equal
+func Diff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	ops, ok := diffLines(a, b)
+	if !ok {
+		return fmt.Sprintf("--- %s\n+++ %s\n@@ changed: %d -> %d lines @@\n", path, path, len(a), len(b))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", path, path)
+	for start := 0; start < len(ops); {
+		// Find the next change and the extent of its hunk
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		end := first
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// Stop once the gap to the next change exceeds twice the context
+			gap := end
+			for gap < len(ops) && ops[gap].kind == ' ' {
+				gap++
+			}
+			if gap == len(ops) || gap-end > 2*diffContext {
+				break
+			}
+			end = gap
+		}
+
+		lo := first - diffContext
+		if lo < start {
+			lo = start
+		}
+		hi := end + diffContext
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[lo:hi] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", ops[lo].old, oldCount, ops[lo].new, newCount)
+		for _, op := range ops[lo:hi] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		start = hi
+	}
+	return sb.String()
+}
+
+// diffLines computes an edit script with a longest-common-subsequence table
+// over the lines between the common prefix and suffix
+func diffLines(a, b []string) ([]diffOp, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	var ops []diffOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{' ', a[i], i + 1, i + 1})
+	}
+
+	if len(midA) == len(midB) {
+		// Rewrites usually keep lines in place; pair them up directly
+		for i := range midA {
+			line := prefix + i + 1
+			if midA[i] == midB[i] {
+				ops = append(ops, diffOp{' ', midA[i], line, line})
+				continue
+			}
+			ops = append(ops, diffOp{'-', midA[i], line, line}, diffOp{'+', midB[i], line, line})
+		}
+	} else {
+		if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+			return nil, false
+		}
+		ops = append(ops, lcsOps(midA, midB, prefix)...)
+	}
+
+	for i := 0; i < suffix; i++ {
+		ai := len(a) - suffix + i
+		bi := len(b) - suffix + i
+		ops = append(ops, diffOp{' ', a[ai], ai + 1, bi + 1})
+	}
+	return ops, true
+}
+
+func lcsOps(a, b []string, offset int) []diffOp {
+	n, m := len(a), len(b)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], offset + i + 1, offset + j + 1})
+			i++
+			j++
+		case j < m && (i == n || table[i][j+1] >= table[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j], offset + i + 1, offset + j + 1})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i], offset + i + 1, offset + j + 1})
+			i++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,145 @@
+// Package replace runs an external rewriting tool (perl, sed, comby,
+// fastmod, ...) over a set of files, with a dry run on copies to preview the
+// resulting diff.
+package replace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/William9923/irg/internal/editor"
+)
+
+// DefaultCommand rewrites every match of the pattern in place. perl is used
+// because its regex syntax is close to ripgrep's and -i behaves the same on
+// every platform, unlike sed.
+const DefaultCommand = `perl -pi -e 's/$ENV{IRG_PATTERN}/$ENV{IRG_REPLACEMENT}/g'`
+
+// commandTimeout bounds a single run of the tool
+const commandTimeout = time.Minute
+
+// Tool is a rewriting command template. The pattern and replacement are
+// passed in the IRG_PATTERN and IRG_REPLACEMENT environment variables, which
+// avoids quoting problems. The files are substituted for {files}, or
+// appended when the template has no {files} placeholder.
+type Tool struct {
+	Command string
+}
+
+// New returns a tool for command, or the default when command is empty
+func New(command string) Tool {
+	if strings.TrimSpace(command) == "" {
+		command = DefaultCommand
+	}
+	return Tool{Command: command}
+}
+
+// Preview runs the tool on temporary copies of files and returns a unified
+// diff of the changes it would make
+func (t Tool) Preview(ctx context.Context, files []string, pattern, replacement string) (string, error) {
+	dir, err := os.MkdirTemp("", "irg-replace-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Copies keep their base name, so tools that pick a language by
+	// extension still work, but live in numbered directories so paths with
+	// ".." can't escape the temp dir
+	copies := make([]string, len(files))
+	for i, f := range files {
+		copies[i] = filepath.Join(strconv.Itoa(i), filepath.Base(f))
+		if err := copyFile(f, filepath.Join(dir, copies[i])); err != nil {
+			return "", err
+		}
+	}
+	if err := t.run(ctx, dir, copies, pattern, replacement); err != nil {
+		return "", err
+	}
+
+	var diff strings.Builder
+	for i, f := range files {
+		before, err := os.ReadFile(f)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", f, err)
+		}
+		after, err := os.ReadFile(filepath.Join(dir, copies[i]))
+		if err != nil {
+			return "", fmt.Errorf("read rewritten %s: %w", f, err)
+		}
+		diff.WriteString(Diff(f, string(before), string(after)))
+	}
+	return diff.String(), nil
+}
+
+// Apply runs the tool on files in the current directory
+func (t Tool) Apply(ctx context.Context, files []string, pattern, replacement string) error {
+	return t.run(ctx, "", files, pattern, replacement)
+}
+
+func (t Tool) run(ctx context.Context, dir string, files []string, pattern, replacement string) error {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := t.command(ctx, files, pattern, replacement)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("replace command: %w: %s", err, msg)
+		}
+		return fmt.Errorf("replace command: %w", err)
+	}
+	return nil
+}
+
+// command runs the tool's command in the shell on files, given as
+// {files} or after the command
+func (t Tool) command(ctx context.Context, files []string, pattern, replacement string) *exec.Cmd {
+	quoted := make([]string, len(files))
+	for i, f := range files {
+		quoted[i] = editor.ShellQuote(f)
+	}
+	fileArgs := strings.Join(quoted, " ")
+
+	command := t.Command
+	if strings.Contains(command, "{files}") {
+		command = strings.ReplaceAll(command, "{files}", fileArgs)
+	} else {
+		command += " " + fileArgs
+	}
+
+	cmd := editor.ShellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), "IRG_PATTERN="+pattern, "IRG_REPLACEMENT="+replacement)
+	return cmd
+}
+
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(dst), err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copy %s: %w", src, err)
+	}
+	return out.Close()
+}
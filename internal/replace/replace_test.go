@@ -0,0 +1,110 @@
+package replace
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"changed line",
+			"1\n2\n3\n4\n5\n6\n7\n",
+			"1\n2\n3\nfour\n5\n6\n7\n",
+			"--- f\n+++ f\n@@ -2,5 +2,5 @@\n 2\n 3\n-4\n+four\n 5\n 6\n",
+		},
+		{
+			"inserted line",
+			"a\nb\nc\n",
+			"a\nb\nnew\nc\n",
+			"--- f\n+++ f\n@@ -1,4 +1,5 @@\n a\n b\n+new\n c\n \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff("f", tt.before, tt.after); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_PreviewLeavesFilesAlone(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	if _, err := exec.LookPath("perl"); err != nil {
+		t.Skip("perl not installed")
+	}
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.MkdirAll("pkg", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	original := "foo := 1\nbar := foo\n"
+	if err := os.WriteFile(filepath.Join("pkg", "a.go"), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := New("")
+	diff, err := tool.Preview(context.Background(), []string{"pkg/a.go"}, "foo", "baz")
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if !strings.Contains(diff, "+baz := 1") || !strings.Contains(diff, "-bar := foo") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if data, _ := os.ReadFile("pkg/a.go"); string(data) != original {
+		t.Error("Preview modified the original file")
+	}
+
+	if err := tool.Apply(context.Background(), []string{"pkg/a.go"}, "foo", "baz"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if data, _ := os.ReadFile("pkg/a.go"); string(data) != "baz := 1\nbar := baz\n" {
+		t.Errorf("after Apply: %q", data)
+	}
+}
+
+func TestTool_FilesPlaceholder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.WriteFile("it's.txt", []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := New(`for f in {files}; do printf '%s\n' "$IRG_REPLACEMENT" > "$f"; done`)
+	if err := tool.Apply(context.Background(), []string{"it's.txt"}, "x", "y"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if data, _ := os.ReadFile("it's.txt"); string(data) != "y\n" {
+		t.Errorf("got %q", data)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package replace
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTool_QuotesFilesForCmd(t *testing.T) {
+	tool := New(`fix.exe {files}`)
+	cmd := tool.command(context.Background(), []string{`My Docs\a.txt`, "b.txt"}, "x", "y")
+
+	want := `/d /s /c "fix.exe "My Docs\a.txt" b.txt"`
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CmdLine != want {
+		t.Errorf("command line = %+v, want %q", cmd.SysProcAttr, want)
+	}
+}
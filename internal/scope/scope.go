@@ -0,0 +1,164 @@
+// Package scope finds the function, method or type definition enclosing a
+// line of source code, using per-language patterns and indentation rather
+// than a parser.
+package scope
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Lookback is the number of lines above a match searched for its enclosing
+// definition
+const Lookback = 2000
+
+// definitions holds each language's definition patterns. The first group of
+// a pattern that matched is the text shown for the definition.
+var definitions = map[string][]*regexp.Regexp{
+	"go": {
+		regexp.MustCompile(`^\s*(func\s+(?:\([^)]*\)\s*)?\w+)`),
+		regexp.MustCompile(`^\s*(type\s+\w+)\s+(?:struct|interface)\b`),
+	},
+	"python": {
+		regexp.MustCompile(`^\s*((?:async\s+)?def\s+\w+|class\s+\w+)`),
+	},
+	"javascript": {
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?(function\*?\s*\w+|class\s+\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?((?:const|let|var)\s+\w+)\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|\w+\s*=>)`),
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|async|get|set|override|readonly)\s+)*(\w+)\s*\([^)]*\)\s*(?::\s*[^{]+)?\{\s*$`),
+	},
+	"rust": {
+		regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|const|unsafe|extern\s+"\w+")\s+)*(fn\s+\w+|struct\s+\w+|enum\s+\w+|trait\s+\w+|mod\s+\w+)`),
+		regexp.MustCompile(`^\s*(?:unsafe\s+)?(impl\b[^{]*?)\s*(?:\{|where\b|$)`),
+	},
+	"java": {
+		regexp.MustCompile(`^\s*(?:[\w@]+\s+)*?((?:class|interface|enum|record|object|struct|namespace)\s+\w+)`),
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|abstract|synchronized|override|async|virtual|open|suspend)\s+)*(?:fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(\w+)|[\w<>\[\],.?]+\s+(\w+))\s*\([^;]*$`),
+	},
+	"c": {
+		regexp.MustCompile(`^((?:struct|class|namespace)\s+\w+)`),
+		regexp.MustCompile(`^(?:[\w*&:<>,]+\s+)+\**([\w:~]+)\s*\([^;]*$`),
+	},
+	"ruby": {
+		regexp.MustCompile(`^\s*(def\s+[\w.?!=]+|class\s+[\w:]+|module\s+[\w:]+)`),
+	},
+	"php": {
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*(function\s+\w+|class\s+\w+|interface\s+\w+|trait\s+\w+)`),
+	},
+	"shell": {
+		regexp.MustCompile(`^\s*(?:function\s+)?(\w[\w-]*)\s*\(\)`),
+		regexp.MustCompile(`^\s*function\s+(\w[\w-]*)`),
+	},
+}
+
+// keywords start statements the method patterns would otherwise take for
+// definitions, as a name or a return type
+var keywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "function": true, "else": true, "do": true, "try": true,
+	"new": true, "throw": true, "sizeof": true, "using": true, "synchronized": true,
+	"foreach": true, "when": true, "lock": true, "fixed": true, "await": true,
+	"yield": true, "case": true, "delete": true, "typeof": true, "echo": true,
+}
+
+var extensions = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".pyi":   "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".cjs":   "javascript",
+	".ts":    "javascript",
+	".tsx":   "javascript",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "java",
+	".kts":   "java",
+	".cs":    "java",
+	".scala": "java",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "c",
+	".cpp":   "c",
+	".cxx":   "c",
+	".hpp":   "c",
+	".hxx":   "c",
+	".rb":    "ruby",
+	".php":   "php",
+	".sh":    "shell",
+	".bash":  "shell",
+	".zsh":   "shell",
+}
+
+// Supported reports whether definitions can be found in path's language
+func Supported(path string) bool {
+	_, ok := extensions[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Enclosing returns the definition enclosing the last of lines, which are
+// the lines of path up to and including a match, or "" if it is outside any.
+// A definition encloses the line when it is indented less than it and than
+// every line between them, ignoring lines that only open or close brackets.
+func Enclosing(path string, lines []string) string {
+	patterns := definitions[extensions[strings.ToLower(filepath.Ext(path))]]
+	if len(patterns) == 0 || len(lines) == 0 {
+		return ""
+	}
+	level, ok := indent(lines[len(lines)-1])
+	if !ok {
+		return ""
+	}
+	for i := len(lines) - 2; i >= 0 && level > 0; i-- {
+		depth, ok := indent(lines[i])
+		if !ok || depth >= level {
+			continue
+		}
+		if name := definition(patterns, lines[i]); name != "" {
+			return name
+		}
+		// The rest of a signature split over lines, or a brace on its own
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "{" || strings.HasPrefix(trimmed, ")") || strings.HasPrefix(trimmed, "]") {
+			continue
+		}
+		level = depth
+	}
+	return ""
+}
+
+// definition returns the definition line declares, or ""
+func definition(patterns []*regexp.Regexp, line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || keywords[strings.TrimRight(fields[0], "(")] {
+		return ""
+	}
+	for _, re := range patterns {
+		m := re.FindStringSubmatch(line)
+		for _, group := range m[min(len(m), 1):] {
+			if group != "" && !keywords[group] {
+				return strings.Join(strings.Fields(group), " ")
+			}
+		}
+	}
+	return ""
+}
+
+// indent returns the columns of line's leading whitespace, counting a tab
+// as four, and false for a blank line
+func indent(line string) (int, bool) {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package scope
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnclosing(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		src  string // The match is the last line
+		want string
+	}{
+		{"go method", "model.go", `
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "q" {
+			return m, tea.Quit`, "func (m *Model) Update"},
+		{"go split signature", "main.go", `
+func run(
+	ctx context.Context,
+	args []string,
+) error {
+	return nil`, "func run"},
+		{"go after a function", "main.go", `
+func run() {
+	return
+}
+
+var names = []string{
+	"match",`, ""},
+		{"go closure", "main.go", `
+func serve() {
+	handler := func(w http.ResponseWriter) {
+		w.Write(nil)`, "func serve"},
+		{"go top level", "main.go", `
+func run() {
+}
+var match = 1`, ""},
+		{"python method", "app.py", `
+class Service:
+    def __init__(self):
+        self.x = 1
+
+    async def fetch(self, url):
+        for attempt in range(3):
+            match = url`, "async def fetch"},
+		{"python class body", "app.py", `
+class Service:
+    timeout = 3`, "class Service"},
+		{"typescript method", "app.ts", `
+export class Store {
+  private load(key: string): Item {
+    if (key) {
+      return this.items[key];`, "load"},
+		{"javascript arrow", "app.js", `
+export const handler = async (event) => {
+  return match;`, "const handler"},
+		{"rust impl", "lib.rs", `
+impl<T: Clone> Stack<T> {
+    pub fn push(&mut self, item: T) {
+        self.items.push(item);`, "fn push"},
+		{"rust impl body", "lib.rs", `
+impl<T: Clone> Stack<T> {
+    const LIMIT: usize = 8;`, "impl<T: Clone> Stack<T>"},
+		{"java call continuation", "App.java", `
+public class App {
+    public static void main(String[] args) {
+        System.out.println(format(args,
+            match));`, "main"},
+		{"c allman braces", "main.c", `
+static int parse(const char *s)
+{
+    return match;`, "parse"},
+		{"ruby", "app.rb", `
+module Billing
+  def self.charge(amount)
+    match`, "def self.charge"},
+		{"unsupported", "notes.txt", `
+func main() {
+	match`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(strings.TrimPrefix(tt.src, "\n"), "\n")
+			if got := Enclosing(tt.path, lines); got != tt.want {
+				t.Errorf("Enclosing = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
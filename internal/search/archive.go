@@ -0,0 +1,195 @@
+package search
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Decoder describes how rg turns a file into the text it searches: by
+// decompressing it (--search-zip) or through a preprocessor command (--pre,
+// limited to files matching --pre-glob when set). Line numbers of matches in
+// such files refer to the decoded text, so previews and the editor must
+// decode the file the same way.
+type Decoder struct {
+	SearchZip bool
+	Pre       string
+	PreGlobs  []string
+}
+
+// decompressor is an external command that decompresses a file on stdin,
+// as rg runs it for --search-zip
+type decompressor struct {
+	ext  string
+	name string
+	args []string
+}
+
+// decompressors mirrors rg's list of --search-zip formats; gzip and bzip2
+// are decoded in process
+var decompressors = []decompressor{
+	{ext: ".gz"},
+	{ext: ".tgz"},
+	{ext: ".bz2"},
+	{ext: ".tbz2"},
+	{ext: ".xz", name: "xz", args: []string{"-d", "-c"}},
+	{ext: ".txz", name: "xz", args: []string{"-d", "-c"}},
+	{ext: ".lz4", name: "lz4", args: []string{"-d", "-c"}},
+	{ext: ".lzma", name: "xz", args: []string{"--format=lzma", "-d", "-c"}},
+	{ext: ".br", name: "brotli", args: []string{"-d", "-c"}},
+	{ext: ".zst", name: "zstd", args: []string{"-q", "-d", "-c"}},
+	{ext: ".zstd", name: "zstd", args: []string{"-q", "-d", "-c"}},
+	{ext: ".Z", name: "uncompress", args: []string{"-c"}},
+}
+
+// args returns the rg flags for d
+func (d Decoder) args() []string {
+	var args []string
+	if d.SearchZip {
+		args = append(args, "--search-zip")
+	}
+	if d.Pre != "" {
+		args = append(args, "--pre", d.Pre)
+		for _, glob := range d.PreGlobs {
+			args = append(args, "--pre-glob", glob)
+		}
+	}
+	return args
+}
+
+// Decodes reports whether rg searches the decoded text of p rather than the
+// file itself
+func (d Decoder) Decodes(p string) bool {
+	return d.preprocesses(p) || d.decompressor(p) != nil
+}
+
+// preprocesses reports whether the --pre command runs on p
+func (d Decoder) preprocesses(p string) bool {
+	if d.Pre == "" {
+		return false
+	}
+	if len(d.PreGlobs) == 0 {
+		return true
+	}
+	p = filepath.ToSlash(p)
+	for _, glob := range d.PreGlobs {
+		target := path.Base(p)
+		if strings.Contains(glob, "/") {
+			target = strings.TrimPrefix(p, "./")
+		}
+		if ok, _ := path.Match(glob, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// decompressor returns how p is decompressed with --search-zip, or nil.
+// The preprocessor wins over decompression, as in rg.
+func (d Decoder) decompressor(p string) *decompressor {
+	if !d.SearchZip || d.preprocesses(p) {
+		return nil
+	}
+	for i, dc := range decompressors {
+		if strings.HasSuffix(p, dc.ext) {
+			return &decompressors[i]
+		}
+	}
+	return nil
+}
+
+// Read returns the text rg searched for the file at p
+func (d Decoder) Read(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if d.preprocesses(p) {
+		return run(exec.Command(d.Pre, p), f, "--pre "+d.Pre)
+	}
+	dc := d.decompressor(p)
+	switch {
+	case dc == nil:
+		return io.ReadAll(f)
+	case dc.name != "":
+		return run(exec.Command(dc.name, dc.args...), f, dc.name)
+	case strings.HasSuffix(dc.ext, "gz"):
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s: %w", p, err)
+		}
+		defer zr.Close()
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s: %w", p, err)
+		}
+		return data, nil
+	default:
+		data, err := io.ReadAll(bzip2.NewReader(f))
+		if err != nil {
+			return nil, fmt.Errorf("decompress %s: %w", p, err)
+		}
+		return data, nil
+	}
+}
+
+// run runs cmd with stdin as its input and returns its output
+func run(cmd *exec.Cmd, stdin io.Reader, name string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// Extract writes the text rg searched for the file at p to a new temporary
+// file and returns its path. The name keeps the member's extension, such as
+// app.log for app.log.gz, so editors pick the right syntax. The caller
+// removes the file.
+func (d Decoder) Extract(p string) (string, error) {
+	data, err := d.Read(p)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(p)
+	if dc := d.decompressor(p); dc != nil {
+		name = strings.TrimSuffix(name, dc.ext)
+		switch dc.ext {
+		case ".tgz", ".tbz2", ".txz":
+			name += ".tar"
+		}
+	} else {
+		name += ".txt"
+	}
+
+	f, err := os.CreateTemp("", "irg-*-"+name)
+	if err != nil {
+		return "", fmt.Errorf("extract %s: %w", p, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("extract %s: %w", p, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("extract %s: %w", p, err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeGzip writes text gzip-compressed to path
+func writeGzip(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecoder_Decodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoder Decoder
+		path    string
+		want    bool
+	}{
+		{"plain file", Decoder{SearchZip: true}, "app.log", false},
+		{"gzip", Decoder{SearchZip: true}, "logs/app.log.gz", true},
+		{"zstd", Decoder{SearchZip: true}, "dump.sql.zst", true},
+		{"gzip without --search-zip", Decoder{}, "app.log.gz", false},
+		{"pre on every file", Decoder{Pre: "unzip-members"}, "src/main.go", true},
+		{"pre glob on name", Decoder{Pre: "unzip-members", PreGlobs: []string{"*.zip"}}, "dist/bundle.zip", true},
+		{"pre glob miss", Decoder{Pre: "unzip-members", PreGlobs: []string{"*.zip"}}, "src/main.go", false},
+		{"pre glob on path", Decoder{Pre: "x", PreGlobs: []string{"dist/*.tar"}}, "./dist/out.tar", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.decoder.Decodes(tt.path); got != tt.want {
+				t.Errorf("Decodes(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileCache_DecodesCompressedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	writeGzip(t, path, "one\ntwo\nthree\nfour\n")
+
+	c := NewFileCache()
+	c.SetDecoder(Decoder{SearchZip: true})
+	got, err := c.GetFileContextWithMatches(path, 3, 1, nil)
+	if err != nil {
+		t.Fatalf("GetFileContextWithMatches: %v", err)
+	}
+	if got.StartLine != 2 || strings.Join(got.Lines, ",") != "two,three,four" {
+		t.Errorf("context = %d %q, want lines 2-4 of the decompressed text", got.StartLine, got.Lines)
+	}
+}
+
+func TestDecoder_ExtractKeepsMemberName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	writeGzip(t, path, "hello\n")
+
+	tmp, err := Decoder{SearchZip: true}.Extract(path)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	defer os.Remove(tmp)
+	if !strings.HasSuffix(tmp, "-app.log") {
+		t.Errorf("extracted to %s, want a name ending in app.log", tmp)
+	}
+	if data, _ := os.ReadFile(tmp); string(data) != "hello\n" {
+		t.Errorf("extracted %q", data)
+	}
+}
+
+func TestDecoder_PreprocessorMatchesRipgrep(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the preprocessor is a shell script")
+	}
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	pre := filepath.Join(dir, "members.sh")
+	// Prefix each line with a header, shifting line numbers like an
+	// archive lister would
+	script := "#!/bin/sh\necho \"== $1\"\ncat\n"
+	if err := os.WriteFile(pre, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := filepath.Join(dir, "bundle.zip")
+	if err := os.WriteFile(data, []byte("alpha\nneedle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := Decoder{Pre: pre, PreGlobs: []string{"*.zip"}}
+	results := make(chan Match)
+	if err := NewRipgrepSearcher().Search(context.Background(), "needle", data, Options{Decoder: decoder}, results); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	var matches []Match
+	for m := range results {
+		matches = append(matches, m)
+	}
+	if len(matches) != 1 || matches[0].LineNumber != 3 {
+		t.Fatalf("matches = %+v, want needle on line 3 of the preprocessed text", matches)
+	}
+
+	c := NewFileCache()
+	c.SetDecoder(decoder)
+	got, err := c.GetFileContextWithMatches(data, matches[0].LineNumber, 0, nil)
+	if err != nil {
+		t.Fatalf("GetFileContextWithMatches: %v", err)
+	}
+	if len(got.Lines) != 1 || got.Lines[0] != "needle" {
+		t.Errorf("preview of the match = %q, want needle", got.Lines)
+	}
+}
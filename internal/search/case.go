@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// String returns the mode's flag name: smart, sensitive or insensitive
+func (c CaseSensitivity) String() string {
+	switch c {
+	case CaseSensitive:
+		return "sensitive"
+	case CaseInsensitive:
+		return "insensitive"
+	default:
+		return "smart"
+	}
+}
+
+// ParseCaseSensitivity parses a mode name as accepted by --case
+func ParseCaseSensitivity(name string) (CaseSensitivity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "smart":
+		return CaseSmart, nil
+	case "sensitive":
+		return CaseSensitive, nil
+	case "insensitive":
+		return CaseInsensitive, nil
+	}
+	return CaseSmart, fmt.Errorf("invalid case mode %q: want smart, sensitive or insensitive", name)
+}
+
+// SmartCaseSensitive reports whether rg's --smart-case makes pattern case
+// sensitive, which it does when the pattern contains an uppercase literal.
+// Escapes such as \S or \p{Lu} and group names aren't literals.
+func SmartCaseSensitive(pattern string) bool {
+	for i := 0; i < len(pattern); {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i += escapeLen(pattern[i:])
+			continue
+		case strings.HasPrefix(pattern[i:], "(?P<"), strings.HasPrefix(pattern[i:], "(?<"):
+			if end := strings.IndexByte(pattern[i:], '>'); end >= 0 {
+				i += end + 1
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		if unicode.IsUpper(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// escapeLen returns the length of the escape sequence at the start of s,
+// including its braced argument for forms like \p{Greek} and \x{41}
+func escapeLen(s string) int {
+	_, size := utf8.DecodeRuneInString(s[1:])
+	n := 1 + size
+	if strings.ContainsRune("pPx", rune(s[1])) && n < len(s) && s[n] == '{' {
+		if end := strings.IndexByte(s[n:], '}'); end >= 0 {
+			return n + end + 1
+		}
+	}
+	return n
+}
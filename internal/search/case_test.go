@@ -0,0 +1,43 @@
+package search
+
+import "testing"
+
+func TestSmartCaseSensitive(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    bool
+	}{
+		{"lowercase", "func main", false},
+		{"uppercase literal", "NewModel", true},
+		{"uppercase in class", "[A-Z]+", true},
+		{"perl class escape", `\S+\W`, false},
+		{"escaped uppercase literal", `\.Foo`, true},
+		{"unicode class", `\p{Lu}`, false},
+		{"group name", `(?P<Name>x)`, false},
+		{"short group name", `(?<Name>x)`, false},
+		{"non-ascii uppercase", "Ärger", true},
+		{"trailing backslash", `abc\`, false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SmartCaseSensitive(tt.pattern); got != tt.want {
+				t.Errorf("SmartCaseSensitive(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCaseSensitivity(t *testing.T) {
+	for _, c := range []CaseSensitivity{CaseSmart, CaseSensitive, CaseInsensitive} {
+		got, err := ParseCaseSensitivity(c.String())
+		if err != nil || got != c {
+			t.Errorf("ParseCaseSensitivity(%q) = %v, %v", c.String(), got, err)
+		}
+	}
+	if _, err := ParseCaseSensitivity("loud"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// CaseInsensitiveFS reports whether the filesystem holding path ignores the
+// case of file names, as macOS and Windows do by default. It looks the
+// nearest name with letters up with its case swapped; when no such name can
+// be checked it falls back to the platform's default.
+func CaseInsensitiveFS(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		for p := abs; filepath.Dir(p) != p; p = filepath.Dir(p) {
+			base := filepath.Base(p)
+			swapped := swapCase(base)
+			if swapped == base {
+				continue
+			}
+			st, err := os.Stat(p)
+			if err != nil {
+				continue
+			}
+			other, err := os.Stat(filepath.Join(filepath.Dir(p), swapped))
+			return err == nil && os.SameFile(st, other)
+		}
+	}
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// swapCase turns upper case letters into lower case ones and back
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// FoldPath returns the key under which a case-insensitive filesystem finds
+// path, so that two spellings of one file compare equal
+func FoldPath(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// TrueCase spells the existing part of path the way the directories on disk
+// do, for a path typed in the wrong case on a case-insensitive filesystem.
+// The rest of the path is kept as given.
+func TrueCase(path string) string {
+	clean := filepath.Clean(path)
+	volume := filepath.VolumeName(clean)
+	rest := clean[len(volume):]
+	out := volume
+	if strings.HasPrefix(rest, string(filepath.Separator)) {
+		out += string(filepath.Separator)
+		rest = rest[1:]
+	}
+	if rest == "" || rest == "." {
+		return clean
+	}
+
+	parts := strings.Split(rest, string(filepath.Separator))
+	for i, part := range parts {
+		if part == "." || part == ".." {
+			out = filepath.Join(out, part)
+			continue
+		}
+		name, ok := nameOnDisk(out, part)
+		if !ok {
+			return filepath.Join(append([]string{out}, parts[i:]...)...)
+		}
+		out = filepath.Join(out, name)
+	}
+	return out
+}
+
+// nameOnDisk finds the entry of dir matching name regardless of case,
+// preferring an exact match
+func nameOnDisk(dir, name string) (string, bool) {
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	found, ok := "", false
+	for _, entry := range entries {
+		switch {
+		case entry.Name() == name:
+			return name, true
+		case !ok && strings.EqualFold(entry.Name(), name):
+			found, ok = entry.Name(), true
+		}
+	}
+	return found, ok
+}
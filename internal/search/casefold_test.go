@@ -0,0 +1,46 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestTrueCase(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Src", "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(dir, "src", "PKG"), filepath.Join(dir, "Src", "pkg")},
+		{filepath.Join(dir, "Src", "pkg"), filepath.Join(dir, "Src", "pkg")},
+		{filepath.Join(dir, "SRC", "missing", "X"), filepath.Join(dir, "Src", "missing", "X")},
+		{".", "."},
+	}
+	for _, tt := range tests {
+		if got := TrueCase(tt.path); got != tt.want {
+			t.Errorf("TrueCase(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCaseInsensitiveFS_MatchesLookup(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Probe")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_, err := os.Stat(filepath.Join(filepath.Dir(dir), "pROBE"))
+	if got, want := CaseInsensitiveFS(dir), err == nil; got != want {
+		t.Errorf("CaseInsensitiveFS = %v, want %v (%s on %s)", got, want, dir, runtime.GOOS)
+	}
+}
+
+func TestFoldPath(t *testing.T) {
+	if FoldPath("Src/Main.go") != FoldPath("./src/main.GO") {
+		t.Error("spellings of one path fold differently")
+	}
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/William9923/irg/internal/editor"
+)
+
+// CommandLine returns the shell command that runs the search Search would
+// run for pattern under path with opts, for reproducing it outside irg.
+// Git-tracked searches list their files with git ls-files first; sharded
+// searches are shown as the single rg they split up.
+func CommandLine(pattern, path string, opts Options) string {
+	if path == "" {
+		path = "."
+	}
+	rg := "rg"
+	for _, arg := range buildArgs(pattern, opts) {
+		rg += " " + editor.ShellQuote(arg)
+	}
+
+	paths := []string{path}
+	if len(opts.Roots) > 0 {
+		paths = rootsUnder(opts.Roots, path)
+	}
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = editor.ShellQuote(p)
+	}
+	if opts.GitTracked {
+		return "git ls-files -z -- " + strings.Join(quoted, " ") + " | xargs -0 " + rg
+	}
+	return rg + " " + strings.Join(quoted, " ")
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+
+package search
+
+import "testing"
+
+func TestCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "plain",
+			pattern: "func main",
+			opts:    Options{CaseSensitivity: CaseSmart},
+			want:    "rg --json --line-number --column --max-count=1000 --smart-case -- 'func main' .",
+		},
+		{
+			name:    "types and literal",
+			pattern: "it's",
+			path:    "src",
+			opts:    Options{CaseSensitivity: CaseInsensitive, FileTypes: []string{"go"}, FixedStrings: true},
+			want:    `rg --json --line-number --column --max-count=1000 --type go --ignore-case --fixed-strings -- 'it'\''s' src`,
+		},
+		{
+			name:    "git tracked",
+			pattern: "x",
+			opts:    Options{CaseSensitivity: CaseSensitive, GitTracked: true},
+			want:    "git ls-files -z -- . | xargs -0 rg --json --line-number --column --max-count=1000 --case-sensitive -- x",
+		},
+		{
+			name:    "no ignore",
+			pattern: "x",
+			path:    "vendor",
+			opts:    Options{CaseSensitivity: CaseSmart, NoIgnore: true},
+			want:    "rg --json --line-number --column --max-count=1000 --smart-case --no-ignore -- x vendor",
+		},
+		{
+			name:    "whole word",
+			pattern: "id",
+			opts:    Options{CaseSensitivity: CaseSmart, WholeWord: true},
+			want:    "rg --json --line-number --column --max-count=1000 --smart-case --word-regexp -- id .",
+		},
+		{
+			name:    "max depth",
+			pattern: "x",
+			opts:    Options{CaseSensitivity: CaseSmart, MaxDepth: 2},
+			want:    "rg --json --line-number --column --max-count=1000 --smart-case --max-depth=2 -- x .",
+		},
+		{
+			name:    "multiline",
+			pattern: `\{\n\}`,
+			opts:    Options{CaseSensitivity: CaseSmart, Multiline: true},
+			want:    `rg --json --line-number --column --max-count=1000 --smart-case --multiline -- '\{\n\}' .`,
+		},
+		{
+			name:    "roots under path",
+			pattern: "x",
+			path:    "a",
+			opts:    Options{CaseSensitivity: CaseSensitive, Roots: []string{"a/one.go", "b/two.go", "a/my dir"}},
+			want:    "rg --json --line-number --column --max-count=1000 --case-sensitive -- x a/one.go 'a/my dir'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommandLine(tt.pattern, tt.path, tt.opts); got != tt.want {
+				t.Errorf("CommandLine = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package search
+
+import "testing"
+
+func TestCommandLine_QuotesForCmd(t *testing.T) {
+	got := CommandLine("func main", `My Docs`, Options{CaseSensitivity: CaseSmart})
+	want := `rg --json --line-number --column --max-count=1000 --smart-case -- "func main" "My Docs"`
+	if got != want {
+		t.Errorf("CommandLine = %q, want %q", got, want)
+	}
+}
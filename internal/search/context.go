@@ -0,0 +1,78 @@
+package search
+
+import "strings"
+
+// contextCollector attaches rg's context lines to the matches they surround.
+// rg reports context as separate messages in file order, so a match is held
+// back until the lines after it have arrived.
+type contextCollector struct {
+	lines   int     // Context lines wanted on each side
+	recent  []line  // Contiguous lines just before the current one
+	pending []Match // Matches still waiting for after-context
+}
+
+type line struct {
+	path   string
+	number int
+	text   string
+}
+
+// add records a match or context line from rg and returns the matches whose
+// context is now complete, in order
+func (c *contextCollector) add(m Match, isMatch bool) []Match {
+	if c.lines <= 0 {
+		if isMatch {
+			return []Match{m}
+		}
+		return nil
+	}
+
+	// Each line of a multiline match is context for the matches around it
+	texts := strings.Split(strings.TrimRight(m.LineText, "\r\n"), "\n")
+	var ready []Match
+	for i, text := range texts {
+		cur := line{path: m.Path, number: m.LineNumber + i, text: strings.TrimRight(text, "\r")}
+
+		kept := c.pending[:0]
+		for _, p := range c.pending {
+			if p.Path == cur.path && p.LastLine()+len(p.After)+1 == cur.number {
+				p.After = append(p.After, cur.text)
+			} else {
+				// A gap in rg's output: there is no more context for this match
+				ready = append(ready, p)
+				continue
+			}
+			if len(p.After) == c.lines {
+				ready = append(ready, p)
+			} else {
+				kept = append(kept, p)
+			}
+		}
+		c.pending = kept
+
+		if n := len(c.recent); n > 0 && (c.recent[n-1].path != cur.path || c.recent[n-1].number != cur.number-1) {
+			c.recent = c.recent[:0]
+		}
+		if isMatch && i == 0 {
+			for _, r := range c.recent {
+				m.Before = append(m.Before, r.text)
+			}
+		}
+		c.recent = append(c.recent, cur)
+		if len(c.recent) > c.lines {
+			c.recent = c.recent[1:]
+		}
+	}
+	if isMatch {
+		c.pending = append(c.pending, m)
+	}
+	return ready
+}
+
+// flush returns every pending match, at the end of a file or of the output
+func (c *contextCollector) flush() []Match {
+	ready := c.pending
+	c.pending = nil
+	c.recent = c.recent[:0]
+	return ready
+}
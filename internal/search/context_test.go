@@ -0,0 +1,74 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rgLine renders one line of rg --json output
+func rgLine(kind, path string, number int, text string) string {
+	return fmt.Sprintf(`{"type":%q,"data":{"path":{"text":%q},"lines":{"text":%q},"line_number":%d,"submatches":[]}}`,
+		kind, path, text+"\n", number)
+}
+
+func collect(t *testing.T, output []string, contextLines int) []Match {
+	t.Helper()
+	results := make(chan Match, 100)
+	streamMatches(context.Background(), strings.NewReader(strings.Join(output, "\n")), Options{Context: contextLines}, results)
+	close(results)
+
+	var matches []Match
+	for m := range results {
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func TestStreamMatches_AttachesContext(t *testing.T) {
+	output := []string{
+		`{"type":"begin","data":{"path":{"text":"a.go"}}}`,
+		rgLine("match", "a.go", 1, "first"),
+		rgLine("context", "a.go", 2, "two"),
+		rgLine("context", "a.go", 4, "four"),
+		rgLine("match", "a.go", 5, "second"),
+		rgLine("match", "a.go", 6, "third"),
+		rgLine("context", "a.go", 7, "seven"),
+		`{"type":"end","data":{"path":{"text":"a.go"}}}`,
+		rgLine("match", "b.go", 3, "last"),
+	}
+
+	got := collect(t, output, 1)
+	want := []struct {
+		line          int
+		before, after []string
+	}{
+		{1, nil, []string{"two"}},
+		{5, []string{"four"}, []string{"third"}},
+		{6, []string{"second"}, []string{"seven"}},
+		{3, nil, nil},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d matches, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].LineNumber != w.line || !reflect.DeepEqual(got[i].Before, w.before) || !reflect.DeepEqual(got[i].After, w.after) {
+			t.Errorf("match %d = line %d before %q after %q, want line %d before %q after %q",
+				i, got[i].LineNumber, got[i].Before, got[i].After, w.line, w.before, w.after)
+		}
+	}
+}
+
+func TestStreamMatches_NoContextIgnoresContextLines(t *testing.T) {
+	output := []string{
+		rgLine("context", "a.go", 1, "one"),
+		rgLine("match", "a.go", 2, "two"),
+	}
+
+	got := collect(t, output, 0)
+	if len(got) != 1 || got[0].Before != nil || got[0].After != nil {
+		t.Errorf("got %+v, want one match without context", got)
+	}
+}
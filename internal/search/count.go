@@ -0,0 +1,158 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// Count is the number of matches of a pattern and the files they are in
+type Count struct {
+	Matches int
+	Files   int
+}
+
+// Count counts the matches of pattern under path without collecting them,
+// calling progress with the running total after each file. Options that
+// shape the result list, such as Context and Sorted, are ignored.
+func (s *RipgrepSearcher) Count(ctx context.Context, pattern, path string, opts Options, progress func(Count)) (Count, error) {
+	var total Count
+	if pattern == "" {
+		return total, nil
+	}
+	if path == "" {
+		path = "."
+	}
+
+	paths := []string{path}
+	if opts.GitTracked || len(opts.Roots) > 0 {
+		files, err := s.listedFiles(ctx, path, opts)
+		if err != nil {
+			return total, err
+		}
+		paths = files
+	}
+
+	args := append([]string{"--count-matches", "--with-filename", "--null"}, filterArgs(opts)...)
+	args = append(args, "--", pattern)
+	for start := 0; start < len(paths); start += gitBatchSize {
+		batch := paths[start:min(start+gitBatchSize, len(paths))]
+		if err := s.countBatch(ctx, append(append([]string(nil), args...), batch...), &total, opts.Spawned, progress); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// countBatch runs one rg --count-matches and adds its per-file counts to
+// total
+func (s *RipgrepSearcher) countBatch(ctx context.Context, args []string, total *Count, spawned func(), progress func(Count)) error {
+	var stderr bytes.Buffer
+	cmd, stdout, err := s.start(ctx, args, &stderr, spawned)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// Each line is the path, a NUL and the file's match count
+		line := scanner.Bytes()
+		sep := bytes.LastIndexByte(line, 0)
+		if sep < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(string(line[sep+1:]))
+		if err != nil {
+			continue
+		}
+		total.Matches += n
+		total.Files++
+		if progress != nil {
+			progress(*total)
+		}
+	}
+
+	err = s.wait(cmd)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// rg exits 1 when nothing matched
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return fmt.Errorf("rg: %s", bytes.TrimPrefix(msg, []byte("rg: ")))
+		}
+		return fmt.Errorf("rg: %w", err)
+	}
+	return nil
+}
+
+// CountFiles counts the files a search of path with opts would look at, up
+// to limit: it stops there, so that a look at a huge tree stays quick, and
+// reports whether there were more. Unreadable directories, which rg warns
+// about, are left out of the count.
+func (s *RipgrepSearcher) CountFiles(ctx context.Context, path string, opts Options, limit int) (int, bool, error) {
+	if path == "" {
+		path = "."
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	args := append([]string{"--files"}, filterArgs(opts)...)
+	cmd := exec.CommandContext(ctx, "rg", append(args, "--", path)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = processWaitDelay
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, false, fmt.Errorf("rg: %w", err)
+	}
+	trackProcessGroup(cmd)
+	defer releaseProcessGroup(cmd)
+	if opts.Spawned != nil {
+		opts.Spawned()
+	}
+
+	n := 0
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if n++; n > limit {
+			// Enough to know; stop listing
+			cancel()
+			cmd.Wait()
+			return limit, true, nil
+		}
+	}
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return n, false, ctx.Err()
+	}
+	// rg exits 1 when there are no files, and 2 after warnings about
+	// directories it couldn't read
+	if err != nil && n == 0 {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return 0, false, nil
+		}
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return 0, false, fmt.Errorf("rg: %s", bytes.TrimPrefix(msg, []byte("rg: ")))
+		}
+		return 0, false, fmt.Errorf("rg: %w", err)
+	}
+	return n, false, nil
+}
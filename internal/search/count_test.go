@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCount_SumsMatchesAndFiles(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	root := writeFiles(t, map[string]string{
+		"a.go":     "OldAPI()\nOldAPI(); OldAPI()\n",
+		"b/c.go":   "OldAPI()\n",
+		"b/d.md":   "OldAPI\n",
+		"e f.go":   "OldAPI()\n",
+		"none.txt": "NewAPI()\n",
+	})
+
+	var updates int
+	count, err := NewRipgrepSearcher().Count(context.Background(), "OldAPI", root, Options{}, func(Count) { updates++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != (Count{Matches: 6, Files: 4}) {
+		t.Errorf("Count = %+v, want 6 matches in 4 files", count)
+	}
+	if updates != 4 {
+		t.Errorf("progress called %d times, want once per file", updates)
+	}
+
+	count, err = NewRipgrepSearcher().Count(context.Background(), "OldAPI", root, Options{FileTypes: []string{"go"}}, nil)
+	if err != nil || count != (Count{Matches: 5, Files: 3}) {
+		t.Errorf("Count(-t go) = %+v, %v; want 5 matches in 3 files", count, err)
+	}
+
+	count, err = NewRipgrepSearcher().Count(context.Background(), "Missing", root, Options{}, nil)
+	if err != nil || count != (Count{}) {
+		t.Errorf("Count(no matches) = %+v, %v; want zero", count, err)
+	}
+}
+
+func TestCount_ReportsPatternErrors(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	_, err := NewRipgrepSearcher().Count(context.Background(), "Old(", t.TempDir(), Options{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "regex") {
+		t.Errorf("err = %v, want rg's regex error", err)
+	}
+}
+
+func TestCountFiles_StopsAtLimit(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	root := writeFiles(t, map[string]string{
+		"a.go":   "",
+		"b/c.go": "",
+		"b/d.md": "",
+		"e.go":   "",
+	})
+
+	for _, tt := range []struct {
+		opts  Options
+		limit int
+		n     int
+		more  bool
+	}{
+		{Options{}, 10, 4, false},
+		{Options{FileTypes: []string{"go"}}, 10, 3, false},
+		{Options{}, 2, 2, true},
+		{Options{FileTypes: []string{"rust"}}, 10, 0, false},
+	} {
+		n, more, err := NewRipgrepSearcher().CountFiles(context.Background(), root, tt.opts, tt.limit)
+		if err != nil || n != tt.n || more != tt.more {
+			t.Errorf("CountFiles(%v, %d) = %d, %v, %v; want %d, %v", tt.opts.FileTypes, tt.limit, n, more, err, tt.n, tt.more)
+		}
+	}
+}
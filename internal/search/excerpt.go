@@ -0,0 +1,103 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineLength is the longest line, in bytes, kept whole in results;
+// longer ones, such as minified JavaScript, are cut down around their match
+const DefaultMaxLineLength = 1000
+
+// ellipsis marks where an excerpted line was cut
+const ellipsis = "…"
+
+// Column returns the 1-based byte column of the match's first submatch in
+// the full line, or 1 when it has none
+func (m Match) Column() int {
+	if len(m.Submatches) == 0 {
+		return 1
+	}
+	return m.LineOffset + m.Submatches[0].Start + 1
+}
+
+// LineSubmatches returns the submatches with offsets in the file's line
+// rather than in LineText
+func (m Match) LineSubmatches() []Submatch {
+	if m.LineOffset == 0 {
+		return m.Submatches
+	}
+	shifted := make([]Submatch, len(m.Submatches))
+	for i, sm := range m.Submatches {
+		shifted[i] = Submatch{Match: sm.Match, Start: sm.Start + m.LineOffset, End: sm.End + m.LineOffset}
+	}
+	return shifted
+}
+
+// excerpt cuts a line longer than limit bytes down to about limit bytes
+// around its first submatch, marking the cuts with an ellipsis, and shifts
+// the submatches to match. Submatches outside the excerpt are dropped. A line
+// without submatches, such as a context line, keeps its start.
+func excerpt(m *Match, limit int) {
+	body := strings.TrimRight(m.LineText, "\r\n")
+	if limit <= 0 || len(body) <= limit {
+		return
+	}
+	ending := m.LineText[len(body):]
+
+	from, to := 0, limit
+	if len(m.Submatches) > 0 {
+		start, end := m.Submatches[0].Start, m.Submatches[0].End
+		if end-start >= limit {
+			from, to = start, start+limit
+		} else {
+			// Center the match, then slide the window back inside the line
+			from = start - (limit-(end-start))/2
+			to = from + limit
+			if from < 0 {
+				from, to = 0, limit
+			}
+			if to > len(body) {
+				from, to = len(body)-limit, len(body)
+			}
+		}
+	}
+	to = min(to, len(body))
+	from = min(from, to)
+	if from <= len(ellipsis) {
+		// Too little to cut to be worth an ellipsis; this also keeps
+		// LineOffset nonzero exactly when the start of the line is cut
+		from = 0
+	}
+	for from > 0 && from < to && !utf8.RuneStart(body[from]) {
+		from++
+	}
+	for to < len(body) && !utf8.RuneStart(body[to]) {
+		to--
+	}
+
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = ellipsis
+	}
+	if to < len(body) {
+		suffix = ellipsis
+	}
+	m.LineText = prefix + body[from:to] + suffix + ending
+	// Offsets in LineText plus LineOffset are offsets in the full line
+	m.LineOffset = from - len(prefix)
+
+	var kept []Submatch
+	for _, sm := range m.Submatches {
+		if sm.End <= from || sm.Start >= to {
+			continue
+		}
+		start, end := max(sm.Start, from), min(sm.End, to)
+		kept = append(kept, Submatch{
+			Match: body[start:end],
+			Start: start - m.LineOffset,
+			End:   end - m.LineOffset,
+		})
+	}
+	m.Submatches = kept
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("a", 100) + "needle" + strings.Repeat("b", 100)
+	tests := []struct {
+		name      string
+		line      string
+		start     int
+		limit     int
+		want      string
+		wantStart int
+	}{
+		{"short line kept", "x needle y\n", 2, 20, "x needle y\n", 2},
+		{"centered", long + "\n", 100, 16, "…aaaaaneedlebbbbb…\n", 8},
+		{"match near start", long, 100, 200, strings.Repeat("a", 100) + "needle" + strings.Repeat("b", 97) + "…", 100},
+		{"match near end", "aaaaaaaaaaaaaaaaaaaaneedle", 20, 10, "…aaaaneedle", 7},
+		{"multibyte cut", strings.Repeat("é", 20) + "needle", 40, 11, "…ééneedle", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Match{LineText: tt.line, Submatches: []Submatch{{Match: "needle", Start: tt.start, End: tt.start + 6}}}
+			excerpt(&m, tt.limit)
+			if m.LineText != tt.want {
+				t.Errorf("LineText = %q, want %q", m.LineText, tt.want)
+			}
+			if len(m.Submatches) != 1 || m.Submatches[0].Start != tt.wantStart {
+				t.Fatalf("submatches = %+v, want one at %d", m.Submatches, tt.wantStart)
+			}
+			sm := m.Submatches[0]
+			if got := m.LineText[sm.Start:sm.End]; got != "needle" {
+				t.Errorf("submatch covers %q, want needle", got)
+			}
+			if got := m.Column(); got != tt.start+1 {
+				t.Errorf("Column = %d, want %d in the full line", got, tt.start+1)
+			}
+			if got := m.LineSubmatches()[0].Start; got != tt.start {
+				t.Errorf("LineSubmatches start = %d, want %d", got, tt.start)
+			}
+		})
+	}
+}
+
+func TestExcerpt_DropsSubmatchesOutside(t *testing.T) {
+	line := "needle" + strings.Repeat("x", 100) + "needle"
+	m := Match{LineText: line, Submatches: []Submatch{{"needle", 0, 6}, {"needle", 106, 112}}}
+	excerpt(&m, 20)
+	if len(m.Submatches) != 1 || m.Submatches[0].Start != 0 {
+		t.Errorf("submatches = %+v, want only the first", m.Submatches)
+	}
+}
+
+func TestStreamMatches_ExcerptsLongLines(t *testing.T) {
+	text := strings.Repeat("x", 5000) + "needle"
+	output := []string{
+		rgLine("context", "min.js", 1, strings.Repeat("y", 5000)),
+		`{"type":"match","data":{"path":{"text":"min.js"},"lines":{"text":"` + text + `\n"},"line_number":2,"submatches":[{"match":{"text":"needle"},"start":5000,"end":5006}]}}`,
+	}
+	results := make(chan Match, 10)
+	streamMatches(context.Background(), strings.NewReader(strings.Join(output, "\n")), Options{Context: 1, MaxLineLength: 100}, results)
+	close(results)
+
+	match := <-results
+	if len(match.LineText) > 110 || !strings.HasSuffix(match.LineText, "needle\n") {
+		t.Errorf("LineText = %q, want an excerpt ending with the match", match.LineText)
+	}
+	if match.Column() != 5001 {
+		t.Errorf("Column = %d, want 5001", match.Column())
+	}
+	if len(match.Before) != 1 || len(match.Before[0]) > 110 {
+		t.Errorf("context line not cut down: %d bytes", len(match.Before[0]))
+	}
+}
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// excludeArgs returns rg globs leaving dirs out of the search. rg matches
+// globs against paths as it prints them, which only lines up for
+// directories below the current one; the others are filtered from rg's
+// output by excluded instead.
+func excludeArgs(dirs []string) []string {
+	var args []string
+	for _, dir := range dirs {
+		clean := filepath.ToSlash(filepath.Clean(dir))
+		if filepath.IsAbs(dir) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			continue
+		}
+		args = append(args, "--glob", "!"+clean+"/**")
+	}
+	return args
+}
+
+// excluded reports whether path lies in one of dirs
+func excluded(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if within(filepath.Clean(path), filepath.Clean(dir)) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeGlobArgs returns rg globs leaving out the files and directories
+// matching globs at any depth
+func excludeGlobArgs(globs []string) []string {
+	var args []string
+	for _, glob := range globs {
+		args = append(args, "--glob", "!"+glob)
+	}
+	return args
+}
+
+// excludedByGlob reports whether p, relative to the search path, is left out
+// by one of globs as rg would leave it out: a glob without a slash matches
+// any name along p, and one with a slash p or a directory above it. rg
+// doesn't apply globs to the paths it is given, such as shards and listed
+// files, so they are checked with this instead.
+func excludedByGlob(p string, globs []string) bool {
+	if len(globs) == 0 {
+		return false
+	}
+	slashed := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(p)), "./")
+	names := strings.Split(slashed, "/")
+	for _, glob := range globs {
+		if strings.Contains(glob, "/") {
+			anchored := strings.TrimPrefix(glob, "/")
+			for i := range names {
+				if ok, _ := path.Match(anchored, strings.Join(names[:i+1], "/")); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for _, name := range names {
+			if ok, _ := path.Match(glob, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// relativeTo returns p relative to root, or p itself when it is outside
+// root, so that the directories above the search path don't match
+// exclusion globs
+func relativeTo(root, p string) string {
+	absRoot, err1 := filepath.Abs(root)
+	absPath, err2 := filepath.Abs(p)
+	if err1 != nil || err2 != nil {
+		return p
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return rel
+}
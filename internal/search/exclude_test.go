@@ -0,0 +1,110 @@
+package search
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExcludeArgs(t *testing.T) {
+	got := excludeArgs([]string{"vendor/", "src/gen", "../other", filepath.Join(string(filepath.Separator), "abs")})
+	want := []string{"--glob", "!vendor/**", "--glob", "!src/gen/**"}
+	if !slices.Equal(got, want) {
+		t.Errorf("excludeArgs = %q, want %q", got, want)
+	}
+}
+
+func TestSearch_ExcludeDirs(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"app/main.go", "vendor/lib/lib.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An absolute directory isn't passed to rg as a glob, so this checks
+	// the output filter
+	results := make(chan Match, 10)
+	opts := Options{ExcludeDirs: []string{filepath.Join(dir, "vendor")}}
+	if err := NewRipgrepSearcher().Search(context.Background(), "needle", dir, opts, results); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for match := range results {
+		got = append(got, filepath.Base(match.Path))
+	}
+	if !slices.Equal(got, []string{"main.go"}) {
+		t.Errorf("matches in %v, want only main.go", got)
+	}
+}
+
+func TestSearch_ExcludeGlobs(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	// The temporary directory is likely under /tmp, which the tmp glob
+	// mustn't take for an excluded directory
+	dir := t.TempDir()
+	for _, name := range []string{"app/main.go", "app/tmp/scratch.go", "node_modules/lib/lib.go", "main.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, opts := range []Options{
+		{},
+		{Shards: 2},
+		{Roots: []string{filepath.Join(dir, "app/main.go"), filepath.Join(dir, "node_modules/lib/lib.go"), filepath.Join(dir, "main.go")}},
+	} {
+		opts.ExcludeGlobs = []string{"node_modules", "tmp"}
+		results := make(chan Match, 10)
+		if err := NewRipgrepSearcher().Search(context.Background(), "needle", dir, opts, results); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for match := range results {
+			rel, _ := filepath.Rel(dir, match.Path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, []string{"app/main.go", "main.go"}) {
+			t.Errorf("shards %d, roots %d: matches in %v, want app/main.go and main.go", opts.Shards, len(opts.Roots), got)
+		}
+	}
+}
+
+func TestExcludedByGlob(t *testing.T) {
+	globs := []string{"node_modules", "*.min.js", "docs/gen"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"node_modules", true},
+		{"web/node_modules/react/index.js", true},
+		{"./dist/app.min.js", true},
+		{"docs/gen", true},
+		{"docs/gen/api.md", true},
+		{"src/docs/gen", false},
+		{"src/app.js", false},
+		{filepath.Join("web", "node_modules_old", "a.js"), false},
+	}
+	for _, tt := range tests {
+		if got := excludedByGlob(tt.path, globs); got != tt.want {
+			t.Errorf("excludedByGlob(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	fileCacheEntries    = 16              // Files kept in the preview cache
+	lineIndexEntries    = 8               // Line-offset indexes kept for large files
+	maxCachedFileSize   = 4 * 1024 * 1024 // Larger files are read via a line-offset index
+	maxScannerLineBytes = 1024 * 1024     // Matches the ripgrep stdout buffer
+)
+
+// FileCache keeps the lines of recently previewed files in memory so moving
+// between matches in the same file doesn't re-read it from the beginning.
+// Files too large to hold in memory get a sparse line-offset index instead.
+// Entries are validated against the file's size and modification time.
+type FileCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedFile
+	order   []string // Least recently used first
+	limit   int
+
+	indexes    map[string]*lineIndex
+	indexOrder []string // Least recently used first
+
+	decoder Decoder // How rg decodes compressed or preprocessed files
+}
+
+type cachedFile struct {
+	lines   []string
+	size    int64
+	modTime time.Time
+}
+
+// NewFileCache creates a cache holding up to the default number of files
+func NewFileCache() *FileCache {
+	return &FileCache{
+		entries: make(map[string]*cachedFile),
+		limit:   fileCacheEntries,
+		indexes: make(map[string]*lineIndex),
+	}
+}
+
+// SetDecoder reads the files d decodes as rg searched them, so the lines of
+// a match in a compressed or preprocessed file line up
+func (c *FileCache) SetDecoder(d Decoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decoder = d
+	clear(c.entries)
+	c.order = nil
+}
+
+// GetFileContextWithMatches returns the lines around lineNum, served from the
+// cache when the file hasn't changed since it was last read
+func (c *FileCache) GetFileContextWithMatches(path string, lineNum, contextLines int, submatches []Submatch) (*FileContext, error) {
+	return c.GetFileContextSpan(path, lineNum, lineNum, contextLines, submatches)
+}
+
+// GetFileContextSpan returns lines lineNum through lastLine, those of a
+// match spanning several lines, with contextLines more on either side
+func (c *FileCache) GetFileContextSpan(path string, lineNum, lastLine, contextLines int, submatches []Submatch) (*FileContext, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	startLine := lineNum - contextLines
+	if startLine < 1 {
+		startLine = 1
+	}
+	endLine := max(lastLine, lineNum) + contextLines
+	region, err := c.readRange(path, info, startLine, endLine)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileContext{
+		Lines:      region,
+		StartLine:  startLine,
+		MatchLine:  lineNum,
+		Submatches: submatches,
+	}, nil
+}
+
+// GetLines returns lines startLine through endLine of path, fewer if the
+// file ends first
+func (c *FileCache) GetLines(path string, startLine, endLine int) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.readRange(path, info, max(startLine, 1), endLine)
+}
+
+// readRange reads lines startLine through endLine of path, from the cache or
+// through a line-offset index for files too large to hold
+func (c *FileCache) readRange(path string, info os.FileInfo, startLine, endLine int) ([]string, error) {
+	c.mu.Lock()
+	decoder := c.decoder
+	c.mu.Unlock()
+
+	// Decoded files are held whole: the index covers the file on disk
+	if info.Size() > maxCachedFileSize && !decoder.Decodes(path) {
+		idx, err := c.index(path, info)
+		if err != nil {
+			return nil, err
+		}
+		return idx.readLines(path, startLine, endLine)
+	}
+	lines, err := c.lines(path, info, decoder)
+	if err != nil {
+		return nil, err
+	}
+	if endLine > len(lines) {
+		endLine = len(lines)
+	}
+	if startLine > endLine {
+		return nil, nil
+	}
+	return lines[startLine-1 : endLine], nil
+}
+
+// Invalidate drops a file from the cache
+func (c *FileCache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(path)
+	delete(c.indexes, path)
+	c.indexOrder = removeKey(c.indexOrder, path)
+}
+
+func (c *FileCache) index(path string, info os.FileInfo) (*lineIndex, error) {
+	c.mu.Lock()
+	if idx, ok := c.indexes[path]; ok {
+		if idx.valid(info) {
+			c.indexOrder = append(removeKey(c.indexOrder, path), path)
+			c.mu.Unlock()
+			return idx, nil
+		}
+		delete(c.indexes, path)
+		c.indexOrder = removeKey(c.indexOrder, path)
+	}
+	c.mu.Unlock()
+
+	idx, err := buildLineIndex(path, info)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.indexOrder) >= lineIndexEntries {
+		delete(c.indexes, c.indexOrder[0])
+		c.indexOrder = c.indexOrder[1:]
+	}
+	c.indexes[path] = idx
+	c.indexOrder = append(c.indexOrder, path)
+	return idx, nil
+}
+
+func (c *FileCache) lines(path string, info os.FileInfo, decoder Decoder) ([]string, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[path]; ok {
+		if entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+			c.touch(path)
+			c.mu.Unlock()
+			return entry.lines, nil
+		}
+		c.remove(path)
+	}
+	c.mu.Unlock()
+
+	var lines []string
+	var err error
+	if decoder.Decodes(path) {
+		lines, err = readDecodedLines(path, decoder)
+	} else {
+		lines, err = readLines(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.order) >= c.limit {
+		c.remove(c.order[0])
+	}
+	c.entries[path] = &cachedFile{
+		lines:   lines,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+	c.order = append(c.order, path)
+	return lines, nil
+}
+
+// touch marks path as most recently used; callers must hold c.mu
+func (c *FileCache) touch(path string) {
+	c.order = append(removeKey(c.order, path), path)
+}
+
+// remove drops path from the cache; callers must hold c.mu
+func (c *FileCache) remove(path string) {
+	if _, ok := c.entries[path]; !ok {
+		return
+	}
+	delete(c.entries, path)
+	c.order = removeKey(c.order, path)
+}
+
+// removeKey returns keys without key, preserving order
+func removeKey(keys []string, key string) []string {
+	for i, k := range keys {
+		if k == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+	return keys
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return scanLines(file, path)
+}
+
+// readDecodedLines reads the lines of path as decoder presents them to rg
+func readDecodedLines(path string, decoder Decoder) ([]string, error) {
+	data, err := decoder.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return scanLines(bytes.NewReader(data), path)
+}
+
+func scanLines(r io.Reader, path string) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxScannerLineBytes)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return lines, nil
+}
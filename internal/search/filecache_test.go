@@ -0,0 +1,111 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLines(t *testing.T, path string, n int, prefix string) {
+	t.Helper()
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "%s %d\n", prefix, i)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileCache_MatchesUncachedContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	writeLines(t, path, 30, "line")
+
+	c := NewFileCache()
+	for _, lineNum := range []int{1, 3, 15, 28, 30} {
+		got, err := c.GetFileContextWithMatches(path, lineNum, 5, nil)
+		if err != nil {
+			t.Fatalf("cached context: %v", err)
+		}
+		want, err := GetFileContextWithMatches(path, lineNum, 5, nil)
+		if err != nil {
+			t.Fatalf("uncached context: %v", err)
+		}
+		if got.StartLine != want.StartLine || strings.Join(got.Lines, "\n") != strings.Join(want.Lines, "\n") {
+			t.Errorf("line %d: cached context %v differs from %v", lineNum, got.Lines, want.Lines)
+		}
+	}
+}
+
+func TestFileCache_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	writeLines(t, path, 10, "old")
+
+	c := NewFileCache()
+	if _, err := c.GetFileContextWithMatches(path, 5, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	writeLines(t, path, 10, "new")
+	// Ensure the modification time differs even on coarse filesystems
+	future := time.Now().Add(2 * time.Second)
+	os.Chtimes(path, future, future)
+
+	got, err := c.GetFileContextWithMatches(path, 5, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Lines) != 1 || got.Lines[0] != "new 5" {
+		t.Errorf("got %v, want [new 5]", got.Lines)
+	}
+}
+
+func TestFileCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	c := NewFileCache()
+	c.limit = 2
+
+	paths := make([]string, 3)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("f%d.txt", i))
+		writeLines(t, paths[i], 3, "x")
+	}
+
+	c.GetFileContextWithMatches(paths[0], 1, 1, nil)
+	c.GetFileContextWithMatches(paths[1], 1, 1, nil)
+	c.GetFileContextWithMatches(paths[0], 1, 1, nil) // paths[0] is now most recent
+	c.GetFileContextWithMatches(paths[2], 1, 1, nil)
+
+	if _, ok := c.entries[paths[1]]; ok {
+		t.Error("expected least recently used file to be evicted")
+	}
+	if _, ok := c.entries[paths[0]]; !ok {
+		t.Error("expected recently used file to stay cached")
+	}
+}
+
+func TestFileCache_GetLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	writeLines(t, path, 10, "line")
+
+	c := NewFileCache()
+	for _, tt := range []struct {
+		start, end int
+		want       string
+	}{
+		{-5, 2, "line 1,line 2"},
+		{9, 20, "line 9,line 10"},
+		{11, 12, ""},
+	} {
+		got, err := c.GetLines(path, tt.start, tt.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("GetLines(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
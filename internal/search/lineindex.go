@@ -0,0 +1,127 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// lineIndexStride is the number of lines between indexed offsets. A sparse
+// index keeps memory small (a 500MB log with 10M lines needs ~80KB) while
+// bounding the forward scan to a few thousand lines.
+const lineIndexStride = 1024
+
+// lineIndex records the byte offset of every lineIndexStride-th line of a
+// file so context around deep matches can be read without scanning from line 1
+type lineIndex struct {
+	offsets []int64 // offsets[k] is the byte offset of line k*lineIndexStride+1
+	lines   int
+	size    int64
+	modTime time.Time
+}
+
+// buildLineIndex scans path once, recording sparse line offsets
+func buildLineIndex(path string, info os.FileInfo) (*lineIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	idx := &lineIndex{
+		offsets: []int64{0},
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+
+	buf := make([]byte, 256*1024)
+	var offset int64
+	lastByte := byte('\n')
+	for {
+		n, err := file.Read(buf)
+		chunk := buf[:n]
+		for len(chunk) > 0 {
+			i := bytes.IndexByte(chunk, '\n')
+			if i < 0 {
+				offset += int64(len(chunk))
+				break
+			}
+			offset += int64(i + 1)
+			chunk = chunk[i+1:]
+			idx.lines++
+			if idx.lines%lineIndexStride == 0 {
+				idx.offsets = append(idx.offsets, offset)
+			}
+		}
+		if n > 0 {
+			lastByte = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("index %s: %w", path, err)
+		}
+	}
+
+	// A final line without a trailing newline still counts
+	if lastByte != '\n' {
+		idx.lines++
+	}
+	return idx, nil
+}
+
+// valid reports whether the index still describes the file
+func (idx *lineIndex) valid(info os.FileInfo) bool {
+	return idx.size == info.Size() && idx.modTime.Equal(info.ModTime())
+}
+
+// readLines returns lines [startLine, endLine] (1-based, inclusive)
+func (idx *lineIndex) readLines(path string, startLine, endLine int) ([]string, error) {
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine > idx.lines {
+		endLine = idx.lines
+	}
+	if startLine > endLine {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	block := (startLine - 1) / lineIndexStride
+	if block >= len(idx.offsets) {
+		block = len(idx.offsets) - 1
+	}
+	if _, err := file.Seek(idx.offsets[block], io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek %s: %w", path, err)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxScannerLineBytes)
+
+	lines := make([]string, 0, endLine-startLine+1)
+	currentLine := block * lineIndexStride
+	for scanner.Scan() {
+		currentLine++
+		if currentLine < startLine {
+			continue
+		}
+		if currentLine > endLine {
+			break
+		}
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return lines, nil
+}
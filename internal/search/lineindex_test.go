@@ -0,0 +1,75 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLineIndex_ReadLinesAcrossStrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.log")
+	writeLines(t, path, 3*lineIndexStride+10, "entry")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := buildLineIndex(path, info)
+	if err != nil {
+		t.Fatalf("buildLineIndex: %v", err)
+	}
+	if idx.lines != 3*lineIndexStride+10 {
+		t.Fatalf("lines = %d, want %d", idx.lines, 3*lineIndexStride+10)
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+	}{
+		{"first lines", 1, 3},
+		{"stride boundary", lineIndexStride - 1, lineIndexStride + 1},
+		{"deep block", 2*lineIndexStride + 500, 2*lineIndexStride + 510},
+		{"last line", 3*lineIndexStride + 10, 3*lineIndexStride + 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := idx.readLines(path, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("readLines: %v", err)
+			}
+			end := tt.end
+			if end > idx.lines {
+				end = idx.lines
+			}
+			if len(lines) != end-tt.start+1 {
+				t.Fatalf("got %d lines, want %d", len(lines), end-tt.start+1)
+			}
+			for i, line := range lines {
+				want := fmt.Sprintf("entry %d", tt.start+i)
+				if line != want {
+					t.Errorf("line %d = %q, want %q", tt.start+i, line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLineIndex_NoTrailingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	idx, err := buildLineIndex(path, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.lines != 3 {
+		t.Errorf("lines = %d, want 3", idx.lines)
+	}
+	lines, err := idx.readLines(path, 3, 3)
+	if err != nil || len(lines) != 1 || lines[0] != "three" {
+		t.Errorf("readLines(3, 3) = %v, %v", lines, err)
+	}
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+	"sync"
+)
+
+// MockSearcher is a deterministic Searcher for tests: every search streams
+// Matches in order, or fails with Err without starting, closing results
+// either way
+type MockSearcher struct {
+	Matches []Match
+	Err     error
+
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+// MockCall records the arguments of one Search call
+type MockCall struct {
+	Pattern string
+	Path    string
+	Opts    Options
+}
+
+// NewMockSearcher returns a MockSearcher that finds matches
+func NewMockSearcher(matches ...Match) *MockSearcher {
+	return &MockSearcher{Matches: matches}
+}
+
+func (s *MockSearcher) Search(ctx context.Context, pattern, path string, opts Options, results chan<- Match) error {
+	s.mu.Lock()
+	s.calls = append(s.calls, MockCall{Pattern: pattern, Path: path, Opts: opts})
+	s.mu.Unlock()
+	if s.Err != nil {
+		close(results)
+		return s.Err
+	}
+	go func() {
+		defer close(results)
+		for _, match := range s.Matches {
+			select {
+			case results <- match:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Cancel does nothing; cancelling the context passed to Search stops it
+func (s *MockSearcher) Cancel() {}
+
+// Calls returns the Search calls made so far, oldest first
+func (s *MockSearcher) Calls() []MockCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]MockCall(nil), s.calls...)
+}
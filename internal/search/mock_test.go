@@ -0,0 +1,45 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMockSearcher_StreamsMatchesAndRecordsCalls(t *testing.T) {
+	s := NewMockSearcher(Match{Path: "a.go", LineNumber: 1}, Match{Path: "b.go", LineNumber: 2})
+	results := make(chan Match)
+	if err := s.Search(context.Background(), "needle", "src", Options{FixedStrings: true}, results); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for match := range results {
+		paths = append(paths, match.Path)
+	}
+	if len(paths) != 2 || paths[0] != "a.go" || paths[1] != "b.go" {
+		t.Errorf("streamed %v, want a.go then b.go", paths)
+	}
+
+	calls := s.Calls()
+	if len(calls) != 1 || calls[0].Pattern != "needle" || calls[0].Path != "src" || !calls[0].Opts.FixedStrings {
+		t.Errorf("calls = %+v, want the one search", calls)
+	}
+}
+
+func TestMockSearcher_Err(t *testing.T) {
+	want := errors.New("rg: not found")
+	s := &MockSearcher{Err: want}
+	results := make(chan Match)
+	if err := s.Search(context.Background(), "needle", ".", Options{}, results); !errors.Is(err, want) {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+	select {
+	case _, ok := <-results:
+		if ok {
+			t.Error("got a match from a failed search")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("results wasn't closed after the search failed")
+	}
+}
@@ -0,0 +1,51 @@
+package search
+
+import "strings"
+
+// LastLine returns the number of the match's last line, its only one unless
+// it spans several
+func (m Match) LastLine() int {
+	return max(m.LineNumber, m.EndLine)
+}
+
+// SubmatchesByLine splits the submatches between the lines of the match,
+// with offsets in the file's line of each. A submatch crossing a line ending
+// is cut at it; a line it doesn't touch gets none.
+func (m Match) SubmatchesByLine() [][]Submatch {
+	if m.EndLine <= m.LineNumber {
+		return [][]Submatch{m.LineSubmatches()}
+	}
+	lines := strings.SplitAfter(strings.TrimRight(m.LineText, "\r\n"), "\n")
+	spans := make([][]Submatch, len(lines))
+	start := 0 // Offset of the line in LineText
+	for i, line := range lines {
+		end := start + len(strings.TrimRight(line, "\r\n"))
+		for _, sm := range m.Submatches {
+			from, to := max(sm.Start, start), min(sm.End, end)
+			if from >= to {
+				continue
+			}
+			spans[i] = append(spans[i], Submatch{
+				Match: m.LineText[from:to],
+				Start: from - start + m.LineOffset,
+				End:   to - start + m.LineOffset,
+			})
+		}
+		start += len(line)
+	}
+	return spans
+}
+
+// Head returns the match cut down to its first line, for showing a match
+// spanning several lines in one row. EndLine is kept so the row can say how
+// many lines follow.
+func (m Match) Head() Match {
+	if m.EndLine <= m.LineNumber {
+		return m
+	}
+	spans := m.SubmatchesByLine()
+	first, _, _ := strings.Cut(m.LineText, "\n")
+	m.LineText = first + "\n"
+	m.Submatches = spans[0]
+	return m
+}
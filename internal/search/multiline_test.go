@@ -0,0 +1,70 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSubmatchesByLine(t *testing.T) {
+	m := Match{
+		LineNumber: 3,
+		EndLine:    5,
+		LineText:   "if x {\n\n}\n",
+		Submatches: []Submatch{{Match: "{\n\n}", Start: 5, End: 9}},
+	}
+	want := [][]Submatch{
+		{{Match: "{", Start: 5, End: 6}},
+		nil,
+		{{Match: "}", Start: 0, End: 1}},
+	}
+	if got := m.SubmatchesByLine(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SubmatchesByLine() = %+v, want %+v", got, want)
+	}
+
+	head := m.Head()
+	if head.LineText != "if x {\n" || !reflect.DeepEqual(head.Submatches, want[0]) || head.LastLine() != 5 {
+		t.Errorf("Head() = %+v, want the first line ending at line 5", head)
+	}
+}
+
+func TestSubmatchesByLine_SingleLine(t *testing.T) {
+	m := Match{LineNumber: 2, LineText: "…abc\n", LineOffset: 10, Submatches: []Submatch{{Match: "b", Start: 4, End: 5}}}
+	want := [][]Submatch{{{Match: "b", Start: 14, End: 15}}}
+	if got := m.SubmatchesByLine(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SubmatchesByLine() = %+v, want %+v", got, want)
+	}
+	if m.LastLine() != 2 || !reflect.DeepEqual(m.Head(), m) {
+		t.Errorf("a single-line match should be its own head, ending at its line")
+	}
+}
+
+func TestStreamMatches_MultilineContext(t *testing.T) {
+	output := []string{
+		rgLine("context", "a.go", 1, "one"),
+		rgLine("match", "a.go", 2, "two\nthree"),
+		rgLine("context", "a.go", 4, "four"),
+		rgLine("match", "a.go", 5, "five"),
+	}
+	results := make(chan Match, 10)
+	streamMatches(context.Background(), strings.NewReader(strings.Join(output, "\n")), Options{Context: 1, MaxLineLength: 4}, results)
+	close(results)
+
+	var got []Match
+	for m := range results {
+		got = append(got, m)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d matches, want 2", len(got))
+	}
+	if got[0].EndLine != 3 || got[0].LineText != "two\nthree\n" {
+		t.Errorf("multiline match = lines %d-%d %q, want lines 2-3 kept whole", got[0].LineNumber, got[0].EndLine, got[0].LineText)
+	}
+	if !reflect.DeepEqual(got[0].Before, []string{"one"}) || !reflect.DeepEqual(got[0].After, []string{"four"}) {
+		t.Errorf("multiline match context = %q / %q, want one / four", got[0].Before, got[0].After)
+	}
+	if !reflect.DeepEqual(got[1].Before, []string{"four"}) {
+		t.Errorf("next match before = %q, want four", got[1].Before)
+	}
+}
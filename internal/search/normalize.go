@@ -0,0 +1,115 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizePattern rewrites pattern to match text in either Unicode
+// normalization form, so é typed as one character also finds e followed by
+// a combining acute accent, and the other way round. Characters inside
+// brackets, such as [é], are left as they are, since a class can't hold the
+// decomposed sequence. A literal pattern that needs rewriting becomes a
+// regex, reported by fixed turning false.
+func normalizePattern(pattern string, literal bool) (rewritten string, fixed bool) {
+	composed := norm.NFC.String(pattern)
+	if composed == norm.NFD.String(pattern) {
+		return pattern, literal
+	}
+
+	var sb strings.Builder
+	class := false // Inside brackets
+	for i := 0; i < len(composed); {
+		r, size := utf8.DecodeRuneInString(composed[i:])
+		char := composed[i : i+size]
+		switch {
+		case literal:
+			sb.WriteString(eitherForm(char, regexp.QuoteMeta))
+		case r == '\\' && i+size < len(composed):
+			// Copy the escaped character with its backslash
+			_, next := utf8.DecodeRuneInString(composed[i+size:])
+			sb.WriteString(composed[i : i+size+next])
+			size += next
+		case class:
+			sb.WriteString(char)
+			if r == ']' {
+				class = false
+			}
+		case r == '[':
+			class = true
+			sb.WriteString(char)
+			// A ] first in the class, as in []a] or [^]a], is literal
+			if rest := composed[i+size:]; strings.HasPrefix(rest, "^]") {
+				sb.WriteString("^]")
+				size += 2
+			} else if strings.HasPrefix(rest, "]") {
+				sb.WriteString("]")
+				size++
+			}
+		default:
+			sb.WriteString(eitherForm(char, func(s string) string { return s }))
+		}
+		i += size
+	}
+	return sb.String(), false
+}
+
+// eitherForm returns the regex matching char composed or decomposed, with
+// quote applied to each
+func eitherForm(char string, quote func(string) string) string {
+	decomposed := norm.NFD.String(char)
+	if decomposed == char {
+		return quote(char)
+	}
+	return "(?:" + quote(char) + "|" + quote(decomposed) + ")"
+}
+
+// NormalizeLine returns text in Unicode normalization form C, with
+// submatches moved to the same text in it. A submatch starting or ending
+// inside a character that composes, such as an e followed by an accent,
+// takes in the whole character.
+func NormalizeLine(text string, submatches []Submatch) (string, []Submatch) {
+	if norm.NFC.IsNormalString(text) {
+		return text, submatches
+	}
+
+	// Offsets in text of the boundaries between the characters NFC
+	// composes, and the same boundaries in the normalized text
+	var sb strings.Builder
+	from, to := []int{0}, []int{0}
+	for i := 0; i < len(text); {
+		n := norm.NFC.NextBoundaryInString(text[i:], true)
+		if n <= 0 {
+			n = len(text) - i
+		}
+		sb.WriteString(norm.NFC.String(text[i : i+n]))
+		i += n
+		from = append(from, i)
+		to = append(to, sb.Len())
+	}
+	normalized := sb.String()
+
+	moved := make([]Submatch, len(submatches))
+	for k, sm := range submatches {
+		start, end := 0, len(normalized)
+		// Round the start down and the end up to a boundary
+		for j := len(from) - 1; j >= 0; j-- {
+			if from[j] <= sm.Start {
+				start = to[j]
+				break
+			}
+		}
+		for j := range from {
+			if from[j] >= sm.End {
+				end = to[j]
+				break
+			}
+		}
+		start = min(start, end)
+		moved[k] = Submatch{Match: normalized[start:end], Start: start, End: end}
+	}
+	return normalized, moved
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// é composed as one character, and decomposed into e and an accent
+const (
+	eComposed   = "\u00e9"
+	eDecomposed = "e\u0301"
+)
+
+func TestNormalizePattern(t *testing.T) {
+	either := "(?:" + eComposed + "|" + eDecomposed + ")"
+	tests := []struct {
+		pattern, want  string
+		literal, fixed bool
+	}{
+		{pattern: "plain", want: "plain"},
+		{pattern: "plain.go", literal: true, want: "plain.go", fixed: true},
+		{pattern: "caf" + eComposed + "+", want: "caf" + either + "+"},
+		{pattern: "caf" + eDecomposed, want: "caf" + either},
+		{pattern: "caf" + eComposed + ".", literal: true, want: "caf" + either + `\.`},
+		{pattern: "[" + eComposed + "]x|" + eComposed, want: "[" + eComposed + "]x|" + either},
+		{pattern: "[]" + eComposed + "]" + eComposed, want: "[]" + eComposed + "]" + either},
+		{pattern: `\` + eComposed, want: `\` + eComposed},
+	}
+	for _, tt := range tests {
+		got, fixed := normalizePattern(tt.pattern, tt.literal)
+		if got != tt.want || fixed != tt.fixed {
+			t.Errorf("normalizePattern(%q, %v) = %q, %v; want %q, %v", tt.pattern, tt.literal, got, fixed, tt.want, tt.fixed)
+		}
+	}
+}
+
+func TestNormalizeLine(t *testing.T) {
+	// Matches on "caf", on the e of the decomposed é, and on "lait" after it
+	text := "caf" + eDecomposed + " au lait"
+	submatches := []Submatch{
+		{Match: "caf", Start: 0, End: 3},
+		{Match: "e", Start: 3, End: 4},
+		{Match: "lait", Start: 10, End: 14},
+	}
+	got, moved := NormalizeLine(text, submatches)
+	want := []Submatch{
+		{Match: "caf", Start: 0, End: 3},
+		{Match: eComposed, Start: 3, End: 5},
+		{Match: "lait", Start: 9, End: 13},
+	}
+	if got != "caf"+eComposed+" au lait" || !reflect.DeepEqual(moved, want) {
+		t.Errorf("NormalizeLine = %q, %+v; want the line composed with %+v", got, moved, want)
+	}
+
+	composed := "caf" + eComposed
+	if got, moved := NormalizeLine(composed, submatches[:1]); got != composed || !reflect.DeepEqual(moved, submatches[:1]) {
+		t.Errorf("a composed line changed: %q, %+v", got, moved)
+	}
+}
+
+func TestSearch_NormalizeMatchesEitherForm(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{"composed.txt": "café\n", "decomposed.txt": "café\n"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, pattern := range []string{"café", "café"} {
+		for _, literal := range []bool{false, true} {
+			results := make(chan Match, 10)
+			opts := Options{Normalize: true, FixedStrings: literal, Sorted: true}
+			if err := NewRipgrepSearcher().Search(context.Background(), pattern, dir, opts, results); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for match := range results {
+				got = append(got, filepath.Base(match.Path))
+			}
+			if !slices.Equal(got, []string{"composed.txt", "decomposed.txt"}) {
+				t.Errorf("%q (literal %v) found %v, want both spellings", pattern, literal, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package search
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group so it can be killed
+// together with any children it spawns
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// trackProcessGroup does nothing: the process group setProcessGroup starts
+// cmd in already holds the preprocessors rg runs for --pre and --search-zip
+func trackProcessGroup(cmd *exec.Cmd) {}
+
+// releaseProcessGroup does nothing; see trackProcessGroup
+func releaseProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills every process in cmd's process group
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	// A negative pid signals the whole group led by the process
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+// pauseProcessGroup stops every process in cmd's process group until
+// resumeProcessGroup continues them
+func pauseProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGSTOP)
+}
+
+// resumeProcessGroup continues the processes pauseProcessGroup stopped
+func resumeProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGCONT)
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package search
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSearcher_PauseHoldsResults(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("needle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewRipgrepSearcher()
+	s.Pause()
+	results := make(chan Match, 10)
+	if err := s.Search(context.Background(), "needle", dir, Options{}, results); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case match := <-results:
+		t.Fatalf("got %s:%d while paused", match.Path, match.LineNumber)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	s.Resume()
+	select {
+	case match, ok := <-results:
+		if !ok {
+			t.Fatal("search ended without its match")
+		}
+		if filepath.Base(match.Path) != "a.txt" {
+			t.Errorf("match in %s, want a.txt", match.Path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no match after Resume")
+	}
+}
@@ -0,0 +1,99 @@
+//go:build windows
+
+package search
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	jobsMu sync.Mutex
+	jobs   = make(map[*exec.Cmd]windows.Handle) // Job object of each running rg
+)
+
+// setProcessGroup starts cmd in a new process group so console signals
+// aimed at irg don't reach it directly
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// trackProcessGroup puts the started cmd in a job object, which the
+// preprocessors rg runs for --pre and --search-zip join as they start, so
+// that killProcessGroup stops them too. A child started before cmd joins
+// the job, in the moment after it starts, is left running; rg starts
+// preprocessors only once it is searching files. Without a job object, when
+// one can't be created, only cmd itself is killed.
+func trackProcessGroup(cmd *exec.Cmd) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			// Closing the last handle kills whatever is left in the job
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return
+	}
+	err = windows.AssignProcessToJobObject(job, process)
+	windows.CloseHandle(process)
+	if err != nil {
+		windows.CloseHandle(job)
+		return
+	}
+	jobsMu.Lock()
+	jobs[cmd] = job
+	jobsMu.Unlock()
+}
+
+// releaseProcessGroup closes the job object of cmd once it has exited,
+// killing any preprocessor still running in it
+func releaseProcessGroup(cmd *exec.Cmd) {
+	jobsMu.Lock()
+	job, ok := jobs[cmd]
+	delete(jobs, cmd)
+	jobsMu.Unlock()
+	if ok {
+		windows.CloseHandle(job)
+	}
+}
+
+// killProcessGroup kills cmd's process together with the preprocessors in
+// its job object, or only the process when it has none
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	jobsMu.Lock()
+	job, ok := jobs[cmd]
+	jobsMu.Unlock()
+	if ok && windows.TerminateJobObject(job, 1) == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// pauseProcessGroup does nothing: Windows has no job control, so irg is
+// never suspended with a search running
+func pauseProcessGroup(cmd *exec.Cmd) error {
+	return nil
+}
+
+// resumeProcessGroup does nothing; see pauseProcessGroup
+func resumeProcessGroup(cmd *exec.Cmd) error {
+	return nil
+}
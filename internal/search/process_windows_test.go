@@ -0,0 +1,62 @@
+//go:build windows
+
+package search
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// jobAccounting is JOBOBJECT_BASIC_ACCOUNTING_INFORMATION, which
+// golang.org/x/sys/windows doesn't define
+type jobAccounting struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+func TestKillProcessGroup_KillsChildren(t *testing.T) {
+	// cmd.exe stands in for rg running a preprocessor
+	cmd := exec.Command("cmd.exe", "/c", "ping -n 30 127.0.0.1 >NUL")
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	trackProcessGroup(cmd)
+	defer releaseProcessGroup(cmd)
+	jobsMu.Lock()
+	job, ok := jobs[cmd]
+	jobsMu.Unlock()
+	if !ok {
+		t.Fatal("the process wasn't put in a job object")
+	}
+
+	time.Sleep(300 * time.Millisecond) // Let ping start
+	if err := killProcessGroup(cmd); err != nil {
+		t.Fatalf("killProcessGroup: %v", err)
+	}
+	cmd.Wait()
+
+	var info jobAccounting
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		if err := windows.QueryInformationJobObject(job, windows.JobObjectBasicAccountingInformation,
+			uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil); err != nil {
+			t.Fatalf("QueryInformationJobObject: %v", err)
+		}
+		if info.ActiveProcesses == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d processes still running in the job", info.ActiveProcesses)
+		}
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 )
 
 type CaseSensitivity int
@@ -22,6 +26,22 @@ type Match struct {
 	LineNumber int
 	LineText   string
 	Submatches []Submatch
+
+	// LineOffset is added to byte offsets in LineText, such as those of
+	// the submatches, to get offsets in the file's line. It's nonzero only
+	// when a line longer than Options.MaxLineLength was cut down around its
+	// match.
+	LineOffset int `json:",omitempty"`
+
+	// EndLine is the number of the last line of a match spanning several
+	// lines, found with Options.Multiline; LineText then holds all of
+	// them. It's 0 for a match on a single line.
+	EndLine int `json:",omitempty"`
+
+	// Before and After hold the context lines around the match when
+	// Options.Context is set, without line endings
+	Before []string `json:",omitempty"`
+	After  []string `json:",omitempty"`
 }
 
 type Submatch struct {
@@ -52,38 +72,216 @@ type MatchData struct {
 	} `json:"submatches"`
 }
 
-type Searcher struct {
-	cmd    *exec.Cmd
-	cancel context.CancelFunc
+// processWaitDelay bounds how long Wait blocks on rg's pipes after the process
+// group has been killed
+const processWaitDelay = time.Second
+
+// gitBatchSize bounds the number of file arguments passed to one rg process
+// when searching git-tracked files, keeping well under ARG_MAX
+const gitBatchSize = 1000
+
+// Options controls how a search is run
+type Options struct {
+	CaseSensitivity CaseSensitivity
+	FileTypes       []string
+	FileTypesNot    []string
+
+	// GitTracked restricts the search to files tracked by git, excluding
+	// untracked scratch files and build output even when not gitignored
+	GitTracked bool
+
+	// NoIgnore searches files that .gitignore, .ignore and similar files
+	// exclude, such as vendored dependencies. Hidden files stay skipped.
+	NoIgnore bool
+
+	// Roots replaces the search path with a list of files and directories,
+	// such as one read with --paths-from. Only roots inside the path are
+	// searched.
+	Roots []string
+
+	// Context is the number of lines of context to collect before and after
+	// each match
+	Context int
+
+	// CustomTypes defines extra file types by name, usable in FileTypes and
+	// FileTypesNot like rg's built-in ones
+	CustomTypes map[string][]string
+
+	// Shards splits a search of a directory across its top-level entries,
+	// running up to this many rg processes at once; 0 runs a single rg.
+	// Progress, if set, counts the shards as they finish.
+	Shards   int
+	Progress *ShardProgress
+
+	// Spawned, if set, is called each time an rg process is started, such
+	// as once per shard or per batch of listed files
+	Spawned func()
+
+	// FixedStrings searches for the pattern as a literal string rather than
+	// a regex
+	FixedStrings bool
+
+	// Sorted makes repeated searches return matches in the same order: rg
+	// sorts by path, which makes it single-threaded, and shards are merged
+	// in order
+	Sorted bool
+
+	// Decoder searches compressed files or runs a preprocessor on files
+	// before they are searched
+	Decoder Decoder
+
+	// MaxLineLength cuts matched and context lines longer than this many
+	// bytes down to an excerpt around the match; 0 keeps lines whole
+	MaxLineLength int
+
+	// ExcludeDirs leaves these directories out of the search
+	ExcludeDirs []string
+
+	// ExcludeGlobs leaves out files and directories matching these globs
+	// at any depth, such as "node_modules" or "*.min.js", like rg's
+	// --glob '!GLOB'
+	ExcludeGlobs []string
+
+	// MaxDepth stops descending into directories this many levels below
+	// the search path, like rg --max-depth; 0 searches at any depth. As with
+	// rg, depth counts from each of the Roots, while GitTracked files are
+	// kept to the same depth below the search path.
+	MaxDepth int
+
+	// WholeWord only matches the pattern where it is surrounded by word
+	// boundaries, so a search for id skips identifier and valid
+	WholeWord bool
+
+	// Multiline lets the pattern match across line endings, such as
+	// `\{\n\s*\}`; a match then covers every line it touches
+	Multiline bool
+
+	// Normalize matches the pattern against text in either Unicode
+	// normalization form, so é typed as one character also finds an e
+	// followed by a combining accent
+	Normalize bool
+}
+
+// RipgrepSearcher searches local files by running rg
+type RipgrepSearcher struct {
+	mu      sync.Mutex // Guards cmd and running, which sharded searches start concurrently
+	cmd     *exec.Cmd
+	running map[*exec.Cmd]bool // rg processes that haven't exited yet
+	paused  bool               // Pause was called without Resume
+	cancel  context.CancelFunc
+
+	typeGlobsOnce sync.Once
+	typeGlobs     map[string][]string
+	typeGlobsErr  error
 }
 
-func NewSearcher() *Searcher {
-	return &Searcher{}
+func NewRipgrepSearcher() *RipgrepSearcher {
+	return &RipgrepSearcher{}
 }
 
-func (s *Searcher) Search(ctx context.Context, pattern, path string, caseSensitivity CaseSensitivity, fileTypes []string, fileTypesNot []string, results chan<- Match) error {
+func (s *RipgrepSearcher) Search(ctx context.Context, pattern, path string, opts Options, results chan<- Match) error {
 	if pattern == "" {
 		close(results)
 		return nil
 	}
+	if path == "" {
+		path = "."
+	}
+
+	args := buildArgs(pattern, opts)
+
+	if opts.GitTracked || len(opts.Roots) > 0 {
+		files, err := s.listedFiles(ctx, path, opts)
+		if err != nil {
+			close(results)
+			return err
+		}
+		go func() {
+			defer close(results)
+			for start := 0; start < len(files); start += gitBatchSize {
+				end := start + gitBatchSize
+				if end > len(files) {
+					end = len(files)
+				}
+				batchArgs := append(append([]string(nil), args...), files[start:end]...)
+				if err := s.run(ctx, batchArgs, opts, results); err != nil || ctx.Err() != nil {
+					return
+				}
+			}
+		}()
+		return nil
+	}
+
+	if opts.Shards > 0 {
+		shards, err := listShards(path, opts.NoIgnore, opts.ExcludeGlobs)
+		if err != nil {
+			close(results)
+			return err
+		}
+		if shards != nil {
+			if opts.MaxDepth > 0 {
+				args = shardDepthArgs(args, opts.MaxDepth)
+			}
+			go s.runShards(ctx, args, shards, opts.Shards, opts, results)
+			return nil
+		}
+	}
 
+	cmd, stdout, err := s.start(ctx, append(args, path), nil, opts.Spawned)
+	if err != nil {
+		close(results)
+		return err
+	}
+
+	go func() {
+		defer close(results)
+		streamMatches(ctx, stdout, opts, results)
+		// Wait only after stdout is drained; it closes the pipe
+		s.wait(cmd)
+	}()
+
+	return nil
+}
+
+// buildArgs returns the rg arguments for pattern, up to and including the
+// pattern itself; search paths are appended by the caller
+func buildArgs(pattern string, opts Options) []string {
+	if opts.Normalize {
+		pattern, opts.FixedStrings = normalizePattern(pattern, opts.FixedStrings)
+	}
 	args := []string{
 		"--json",
 		"--line-number",
 		"--column",
 		"--max-count=1000",
 	}
+	if opts.Context > 0 {
+		args = append(args, fmt.Sprintf("--context=%d", opts.Context))
+	}
+	if opts.Sorted {
+		args = append(args, "--sort=path")
+	}
 
+	args = append(args, filterArgs(opts)...)
+	args = append(args, "--")
+	args = append(args, pattern)
+	return args
+}
+
+// filterArgs returns the rg arguments for opts' file types, case mode and
+// pattern syntax
+func filterArgs(opts Options) []string {
 	// Add file types
-	for _, t := range fileTypes {
+	args := TypeAddArgs(opts.CustomTypes)
+	for _, t := range opts.FileTypes {
 		args = append(args, "--type", t)
 	}
-	for _, t := range fileTypesNot {
+	for _, t := range opts.FileTypesNot {
 		args = append(args, "--type-not", t)
 	}
 
 	// Add case sensitivity flag based on mode
-	switch caseSensitivity {
+	switch opts.CaseSensitivity {
 	case CaseSmart:
 		args = append(args, "--smart-case")
 	case CaseSensitive:
@@ -92,92 +290,264 @@ func (s *Searcher) Search(ctx context.Context, pattern, path string, caseSensiti
 		args = append(args, "--ignore-case")
 	}
 
-	args = append(args, "--")
-	args = append(args, pattern)
-
-	if path != "" {
-		args = append(args, path)
-	} else {
-		args = append(args, ".")
+	if opts.FixedStrings {
+		args = append(args, "--fixed-strings")
+	}
+	if opts.WholeWord {
+		args = append(args, "--word-regexp")
+	}
+	if opts.MaxDepth > 0 {
+		args = append(args, fmt.Sprintf("--max-depth=%d", opts.MaxDepth))
+	}
+	if opts.NoIgnore {
+		args = append(args, "--no-ignore")
 	}
+	if opts.Multiline {
+		args = append(args, "--multiline")
+	}
+	args = append(args, excludeArgs(opts.ExcludeDirs)...)
+	args = append(args, excludeGlobArgs(opts.ExcludeGlobs)...)
+	return append(args, opts.Decoder.args()...)
+}
 
-	s.cmd = exec.CommandContext(ctx, "rg", args...)
+// start launches rg with args and returns its stdout. rg's stderr goes to
+// stderr, or is discarded when it is nil; spawned, if set, is called once rg
+// is running.
+func (s *RipgrepSearcher) start(ctx context.Context, args []string, stderr io.Writer, spawned func()) (*exec.Cmd, io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, "rg", args...)
+	cmd.Stderr = stderr
+	// Canceling the context kills rg's whole process group rather than just the
+	// direct child, and WaitDelay force-closes the pipes if anything lingers
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = processWaitDelay
+	s.mu.Lock()
+	s.cmd = cmd
+	s.mu.Unlock()
 
-	stdout, err := s.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		close(results)
-		return err
+		return nil, nil, err
+	}
+	// Starting under the lock keeps Pause from missing a process that is
+	// just starting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	trackProcessGroup(cmd)
+	if spawned != nil {
+		spawned()
+	}
+	if s.running == nil {
+		s.running = make(map[*exec.Cmd]bool)
+	}
+	s.running[cmd] = true
+	if s.paused {
+		pauseProcessGroup(cmd)
+	}
+	return cmd, stdout, nil
+}
+
+// wait waits for cmd, started by start, to exit
+func (s *RipgrepSearcher) wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	releaseProcessGroup(cmd)
+	s.mu.Lock()
+	delete(s.running, cmd)
+	s.mu.Unlock()
+	return err
+}
+
+// Pause stops the running rg processes, and any started later, until
+// Resume. irg pauses its search while it is suspended with Ctrl+Z: rg runs
+// in a process group of its own, so it isn't stopped along with irg.
+func (s *RipgrepSearcher) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	for cmd := range s.running {
+		pauseProcessGroup(cmd)
 	}
+}
 
-	if err := s.cmd.Start(); err != nil {
-		close(results)
+// Resume continues the rg processes stopped by Pause
+func (s *RipgrepSearcher) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	for cmd := range s.running {
+		resumeProcessGroup(cmd)
+	}
+}
+
+// run starts rg with args and streams its matches until it exits
+func (s *RipgrepSearcher) run(ctx context.Context, args []string, opts Options, results chan<- Match) error {
+	cmd, stdout, err := s.start(ctx, args, nil, opts.Spawned)
+	if err != nil {
 		return err
 	}
+	streamMatches(ctx, stdout, opts, results)
+	return s.wait(cmd)
+}
 
-	go func() {
-		defer close(results)
-		scanner := bufio.NewScanner(stdout)
+// streamMatches parses rg's JSON output and sends each match to results,
+// with opts.Context lines of context attached when rg was asked for them and
+// long lines cut down to opts.MaxLineLength
+func streamMatches(ctx context.Context, stdout io.Reader, opts Options, results chan<- Match) {
+	scanner := bufio.NewScanner(stdout)
 
-		// Buffer size 1MB for long lines (ripgrep can return very long matches)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
+	// Buffer size 1MB for long lines (ripgrep can return very long matches)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
 
-		for scanner.Scan() {
+	collector := &contextCollector{lines: opts.Context}
+	send := func(matches []Match) bool {
+		for _, match := range matches {
 			select {
+			case results <- match:
 			case <-ctx.Done():
-				return
-			default:
+				return false
 			}
+		}
+		return true
+	}
 
-			var msg RipgrepMessage
-			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
-				continue
-			}
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
 
-			if msg.Type != "match" {
-				continue
-			}
+		var msg RipgrepMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
 
-			var matchData MatchData
-			if err := json.Unmarshal(msg.Data, &matchData); err != nil {
-				continue
+		switch msg.Type {
+		case "match", "context":
+		case "end":
+			if !send(collector.flush()) {
+				return
 			}
+			continue
+		default:
+			continue
+		}
 
-			match := Match{
-				Path:       matchData.Path.Text,
-				LineNumber: matchData.LineNumber,
-				LineText:   matchData.Lines.Text,
-			}
+		var matchData MatchData
+		if err := json.Unmarshal(msg.Data, &matchData); err != nil {
+			continue
+		}
+		if excluded(matchData.Path.Text, opts.ExcludeDirs) {
+			continue
+		}
 
-			for _, sm := range matchData.Submatches {
-				match.Submatches = append(match.Submatches, Submatch{
-					Match: sm.Match.Text,
-					Start: sm.Start,
-					End:   sm.End,
-				})
-			}
+		match := Match{
+			Path:       matchData.Path.Text,
+			LineNumber: matchData.LineNumber,
+			LineText:   matchData.Lines.Text,
+		}
 
-			select {
-			case results <- match:
-			case <-ctx.Done():
-				return
-			}
+		for _, sm := range matchData.Submatches {
+			match.Submatches = append(match.Submatches, Submatch{
+				Match: sm.Match.Text,
+				Start: sm.Start,
+				End:   sm.End,
+			})
+		}
+		if n := strings.Count(strings.TrimRight(match.LineText, "\r\n"), "\n"); n > 0 {
+			// Lines of a multiline match are kept whole
+			match.EndLine = match.LineNumber + n
+		} else {
+			excerpt(&match, opts.MaxLineLength)
 		}
-	}()
 
-	go func() {
-		s.cmd.Wait()
-	}()
+		if !send(collector.add(match, msg.Type == "match")) {
+			return
+		}
+	}
+	send(collector.flush())
+}
 
-	return nil
+// listedFiles returns the paths to hand rg explicitly: the git-tracked files
+// under path, the roots under path, or the tracked files under those roots.
+// rg ignores --type filters for explicitly named files, so type filters are
+// applied here instead.
+func (s *RipgrepSearcher) listedFiles(ctx context.Context, path string, opts Options) ([]string, error) {
+	files := []string{path}
+	if len(opts.Roots) > 0 {
+		files = rootsUnder(opts.Roots, path)
+		if len(files) == 0 {
+			return nil, nil
+		}
+	}
+	if opts.GitTracked {
+		var tracked []string
+		for start := 0; start < len(files); start += gitBatchSize {
+			batch, err := GitTrackedFiles(ctx, files[start:min(start+gitBatchSize, len(files))]...)
+			if err != nil {
+				return nil, err
+			}
+			tracked = append(tracked, batch...)
+		}
+		files = tracked
+	}
+	if len(opts.ExcludeDirs) > 0 || len(opts.ExcludeGlobs) > 0 {
+		// rg doesn't apply its globs to files named on its command line
+		var kept []string
+		for _, f := range files {
+			if !excluded(f, opts.ExcludeDirs) && !excludedByGlob(relativeTo(path, f), opts.ExcludeGlobs) {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+	if opts.GitTracked && opts.MaxDepth > 0 {
+		// rg searches named files at any depth
+		files = withinDepth(files, path, opts.MaxDepth)
+	}
+	if len(opts.FileTypes) == 0 && len(opts.FileTypesNot) == 0 {
+		return files, nil
+	}
+
+	s.typeGlobsOnce.Do(func() {
+		s.typeGlobs, s.typeGlobsErr = LoadTypeGlobs(opts.CustomTypes)
+	})
+	if s.typeGlobsErr != nil {
+		return nil, s.typeGlobsErr
+	}
+	if opts.GitTracked {
+		return filterByTypes(files, opts.FileTypes, opts.FileTypesNot, s.typeGlobs), nil
+	}
+	// Listed directories are walked by rg, which applies the filters itself
+	var kept []string
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && info.IsDir() {
+			kept = append(kept, f)
+		} else if len(filterByTypes([]string{f}, opts.FileTypes, opts.FileTypesNot, s.typeGlobs)) > 0 {
+			kept = append(kept, f)
+		}
+	}
+	return kept, nil
 }
 
-func (s *Searcher) Cancel() {
+func (s *RipgrepSearcher) Cancel() {
 	if s.cancel != nil {
 		s.cancel()
 	}
-	if s.cmd != nil && s.cmd.Process != nil {
-		s.cmd.Process.Kill()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cmd != nil {
+		killProcessGroup(s.cmd)
+	}
+	// A sharded search runs several rg processes at once
+	for cmd := range s.running {
+		killProcessGroup(cmd)
 	}
 }
 
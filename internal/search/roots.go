@@ -0,0 +1,133 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadRoots reads newline-separated search paths, as printed by fd, git
+// ls-files or a build system query. Blank lines and repeats are dropped.
+func ReadRoots(r io.Reader) ([]string, error) {
+	seen := make(map[string]bool)
+	var roots []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		root := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(root) == "" || seen[root] {
+			continue
+		}
+		seen[root] = true
+		roots = append(roots, root)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading paths: %w", err)
+	}
+	return roots, nil
+}
+
+// ReadRootsFile reads search paths from name with ReadRoots, or from stdin
+// when name is "-"
+func ReadRootsFile(name string) ([]string, error) {
+	if name == "-" {
+		return ReadRoots(os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRoots(f)
+}
+
+// ResolveRoots makes roots read with ReadRoots usable from the working
+// directory. Relative roots are taken relative to base, the directory the
+// list was printed in, such as where fd or make ran; "" is the working
+// directory. Each root is then spelled relative to the working directory
+// when it lies inside it, and absolute otherwise, so results look alike
+// however the list spelled them and open from where irg runs.
+func ResolveRoots(roots []string, base string) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		base = wd
+	}
+	base, err = filepath.Abs(base)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var resolved []string
+	for _, root := range roots {
+		abs := root
+		if !filepath.IsAbs(root) {
+			abs = filepath.Join(base, root)
+		}
+		abs = filepath.Clean(abs)
+		shown := abs
+		if within(abs, wd) {
+			shown, _ = filepath.Rel(wd, abs)
+		}
+		if !seen[shown] {
+			seen[shown] = true
+			resolved = append(resolved, shown)
+		}
+	}
+	return resolved, nil
+}
+
+// rootsUnder narrows roots to path: roots inside path are kept, and path
+// itself replaces any root that contains it
+func rootsUnder(roots []string, path string) []string {
+	if path == "" || filepath.Clean(path) == "." {
+		return roots
+	}
+	base := filepath.Clean(path)
+	var kept []string
+	for _, root := range roots {
+		clean := filepath.Clean(root)
+		switch {
+		case within(clean, base):
+			kept = append(kept, root)
+		case within(base, clean):
+			return []string{path}
+		}
+	}
+	return kept
+}
+
+// withinDepth keeps the files at most maxDepth levels below dir, counting
+// as rg --max-depth does: a file directly in dir is at depth 1. A file whose
+// depth can't be told, such as an absolute root below a relative dir, is
+// kept.
+func withinDepth(files []string, dir string, maxDepth int) []string {
+	var kept []string
+	for _, f := range files {
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			kept = append(kept, f)
+			continue
+		}
+		depth := 0
+		if rel != "." {
+			depth = strings.Count(rel, string(filepath.Separator)) + 1
+		}
+		if depth <= maxDepth {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// within reports whether path is dir or lies below it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
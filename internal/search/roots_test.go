@@ -0,0 +1,143 @@
+package search
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestReadRoots_SkipsBlanksAndRepeats(t *testing.T) {
+	roots, err := ReadRoots(strings.NewReader("cmd\r\n\ninternal/ui\n  \ncmd\nREADME.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"cmd", "internal/ui", "README.md"}
+	if !reflect.DeepEqual(roots, want) {
+		t.Errorf("ReadRoots = %q, want %q", roots, want)
+	}
+}
+
+func TestRootsUnder(t *testing.T) {
+	roots := []string{"cmd/main.go", "internal", "internal/ui/model.go", "docs"}
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"current directory keeps all", ".", roots},
+		{"empty path keeps all", "", roots},
+		{"keeps roots inside path", "cmd", []string{"cmd/main.go"}},
+		{"root containing path searches path", "internal/ui", []string{"internal/ui"}},
+		{"no overlap", "scripts", nil},
+		{"sibling prefix is not inside", "doc", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rootsUnder(roots, filepath.FromSlash(tt.path))
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rootsUnder(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinDepth(t *testing.T) {
+	files := []string{"top.go", "a/one.go", "a/b/two.go"}
+	for i := range files {
+		files[i] = filepath.FromSlash(files[i])
+	}
+	if got, want := withinDepth(files, ".", 2), files[:2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("withinDepth(., 2) = %q, want %q", got, want)
+	}
+	if got, want := withinDepth(files[1:], "a", 1), files[1:2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("withinDepth(a, 1) = %q, want %q", got, want)
+	}
+}
+
+func TestResolveRoots(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := t.TempDir()
+	tests := []struct {
+		name  string
+		roots []string
+		base  string
+		want  []string
+	}{
+		{"working directory", []string{"./roots.go", "a/../ui"}, "", []string{"roots.go", "ui"}},
+		{"relative base", []string{"search/roots.go"}, "..", []string{"roots.go"}},
+		{"absolute inside is relative", []string{filepath.Join(wd, "roots.go")}, other, []string{"roots.go"}},
+		{"outside stays absolute", []string{"x.go"}, other, []string{filepath.Join(other, "x.go")}},
+		{"repeats dropped", []string{"roots.go", "./roots.go"}, "", []string{"roots.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots := make([]string, len(tt.roots))
+			for i, root := range tt.roots {
+				roots[i] = filepath.FromSlash(root)
+			}
+			got, err := ResolveRoots(roots, tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			for i := range tt.want {
+				tt.want[i] = filepath.ToSlash(tt.want[i])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveRoots(%q, %q) = %q, want %q", tt.roots, tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch_OnlyListedRoots(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	root := writeFiles(t, map[string]string{
+		"a/one.go":  "needle\n",
+		"a/two.md":  "needle\n",
+		"b/skip.go": "needle\n",
+		"c/d/x.go":  "needle\n",
+	})
+
+	search := func(opts Options) []string {
+		results := make(chan Match)
+		if err := NewRipgrepSearcher().Search(context.Background(), "needle", root, opts, results); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for m := range results {
+			rel, _ := filepath.Rel(root, m.Path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	roots := []string{filepath.Join(root, "a", "one.go"), filepath.Join(root, "a", "two.md"), filepath.Join(root, "c")}
+	if got, want := search(Options{Roots: roots}), []string{"a/one.go", "a/two.md", "c/d/x.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("roots search = %q, want %q", got, want)
+	}
+	// Type filters apply to listed files as well as to listed directories
+	if got, want := search(Options{Roots: roots, FileTypes: []string{"go"}}), []string{"a/one.go", "c/d/x.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("roots search with -t go = %q, want %q", got, want)
+	}
+	// As with rg, depth counts from each listed path
+	if got, want := search(Options{Roots: roots, MaxDepth: 1}), []string{"a/one.go", "a/two.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("roots search with --max-depth 1 = %q, want %q", got, want)
+	}
+}
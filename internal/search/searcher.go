@@ -0,0 +1,18 @@
+package search
+
+import "context"
+
+// Searcher runs searches, streaming matches into results until the search
+// ends or ctx is cancelled. Search returns once the search has started;
+// results is closed when it ends.
+type Searcher interface {
+	Search(ctx context.Context, pattern, path string, opts Options, results chan<- Match) error
+	// Cancel stops the running search
+	Cancel()
+}
+
+var (
+	_ Searcher = (*RipgrepSearcher)(nil)
+	_ Searcher = (*SourcegraphSearcher)(nil)
+	_ Searcher = (*MockSearcher)(nil)
+)
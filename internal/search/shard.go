@@ -0,0 +1,159 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/William9923/irg/internal/ignore"
+)
+
+// ShardProgress counts the shards of a sharded search as they finish. It is
+// safe to read while the search runs.
+type ShardProgress struct {
+	total atomic.Int32
+	done  atomic.Int32
+}
+
+// Counts returns the finished and total shards, or zeros for a nil progress
+func (p *ShardProgress) Counts() (done, total int) {
+	if p == nil {
+		return 0, 0
+	}
+	return int(p.done.Load()), int(p.total.Load())
+}
+
+// listShards splits a search of root into one shard per top-level directory,
+// plus shards of the top-level files. Hidden entries, ignored ones unless
+// noIgnore is set and those matching the exclude globs are left out, as rg
+// would skip them; naming them explicitly would search them. It returns nil if root is not a directory.
+func listShards(root string, noIgnore bool, exclude []string) ([][]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	ignored := ignore.New(root)
+	prefix := root
+	if !strings.HasSuffix(prefix, "/") && !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+
+	var shards [][]string
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !noIgnore && ignored.Match(name, entry.IsDir()) || excludedByGlob(name, exclude) {
+			continue
+		}
+		// Keep rg's "./" prefix so paths look the same as an unsharded search
+		if entry.IsDir() {
+			shards = append(shards, []string{prefix + name})
+		} else {
+			files = append(files, prefix+name)
+		}
+	}
+	for start := 0; start < len(files); start += gitBatchSize {
+		shards = append(shards, files[start:min(start+gitBatchSize, len(files))])
+	}
+	return shards, nil
+}
+
+// shardDepthArgs returns args with the --max-depth for searching the shards
+// of a directory: they are its entries, a level below it, and rg counts
+// depth from each path it is given. rg honors the last --max-depth, so it is
+// added just before the pattern.
+func shardDepthArgs(args []string, maxDepth int) []string {
+	end := slices.Index(args, "--")
+	if end < 0 {
+		end = len(args)
+	}
+	out := append(slices.Clone(args[:end]), fmt.Sprintf("--max-depth=%d", maxDepth-1))
+	return append(out, args[end:]...)
+}
+
+// runShards searches each shard with its own rg process, at most workers at
+// a time, merging their matches into results. A sorted search forwards the
+// shards in order, so the merged stream is the same on every run.
+func (s *RipgrepSearcher) runShards(ctx context.Context, args []string, shards [][]string, workers int, opts Options, results chan<- Match) {
+	defer close(results)
+	if opts.Progress != nil {
+		opts.Progress.total.Store(int32(len(shards)))
+	}
+
+	var outputs []chan Match
+	if opts.Sorted {
+		outputs = make([]chan Match, len(shards))
+		for i := range outputs {
+			outputs[i] = make(chan Match, 100)
+		}
+	}
+
+	queue := make(chan int)
+	go func() {
+		defer close(queue)
+		for i := range shards {
+			select {
+			case queue <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(shards)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				out := results
+				if outputs != nil {
+					out = outputs[i]
+				}
+				shardArgs := append(append([]string(nil), args...), shards[i]...)
+				s.run(ctx, shardArgs, opts, out)
+				if outputs != nil {
+					close(outputs[i])
+				}
+				if opts.Progress != nil {
+					opts.Progress.done.Add(1)
+				}
+			}
+		}()
+	}
+
+	// Shards are dispatched in order, so the one being forwarded always has
+	// a worker and later ones only wait while their buffers are full
+forward:
+	for _, out := range outputs {
+		for {
+			select {
+			case match, ok := <-out:
+				if !ok {
+					continue forward
+				}
+				select {
+				case results <- match:
+				case <-ctx.Done():
+					break forward
+				}
+			case <-ctx.Done():
+				break forward
+			}
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,198 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestListShards_SkipsHiddenAndIgnored(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		".gitignore":    "build/\n",
+		".cache/x":      "",
+		"build/out.go":  "",
+		"cmd/main.go":   "",
+		"internal/a.go": "",
+		"README.md":     "",
+		"go.mod":        "",
+	})
+
+	shards, err := listShards(root, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, shard := range shards {
+		for _, p := range shard {
+			rel, _ := filepath.Rel(root, p)
+			got = append(got, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(got)
+	want := []string{"README.md", "cmd", "go.mod", "internal"}
+	if len(got) != len(want) {
+		t.Fatalf("shards cover %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("shards cover %v, want %v", got, want)
+		}
+	}
+	if len(shards) != 3 {
+		t.Errorf("got %d shards, want 2 directories and 1 of files", len(shards))
+	}
+}
+
+func TestListShards_NoIgnoreKeepsIgnored(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		".gitignore":   "build/\n",
+		".cache/x":     "",
+		"build/out.go": "",
+	})
+
+	shards, err := listShards(root, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shards) != 1 || filepath.Base(shards[0][0]) != "build" {
+		t.Errorf("shards = %v, want only the ignored build directory", shards)
+	}
+}
+
+func TestListShards_FileRootIsNotSharded(t *testing.T) {
+	root := writeFiles(t, map[string]string{"a.go": ""})
+	shards, err := listShards(filepath.Join(root, "a.go"), false, nil)
+	if err != nil || shards != nil {
+		t.Errorf("listShards(file) = %v, %v; want nil, nil", shards, err)
+	}
+}
+
+func TestSearch_ShardedMatchesSingleProcess(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	root := writeFiles(t, map[string]string{
+		"a/one.go":   "needle\n",
+		"b/two.go":   "needle\nneedle\n",
+		"c/d/три.go": "needle\n",
+		"top.go":     "needle\n",
+	})
+
+	search := func(opts Options) []string {
+		results := make(chan Match)
+		if err := NewRipgrepSearcher().Search(context.Background(), "needle", root, opts, results); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for m := range results {
+			got = append(got, fmt.Sprintf("%s:%d", m.Path, m.LineNumber))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	progress := &ShardProgress{}
+	var shardSpawns, singleSpawns atomic.Int32
+	sharded := search(Options{Shards: 2, Progress: progress, Spawned: func() { shardSpawns.Add(1) }})
+	single := search(Options{Spawned: func() { singleSpawns.Add(1) }})
+	if len(sharded) != 5 || len(sharded) != len(single) {
+		t.Fatalf("sharded = %v, single = %v", sharded, single)
+	}
+	for i := range single {
+		if sharded[i] != single[i] {
+			t.Errorf("sharded = %v, single = %v", sharded, single)
+			break
+		}
+	}
+	if done, total := progress.Counts(); done != 4 || total != 4 {
+		t.Errorf("progress = %d/%d, want 4/4", done, total)
+	}
+	if shardSpawns.Load() != 4 || singleSpawns.Load() != 1 {
+		t.Errorf("rg started %d times sharded and %d single, want 4 and 1", shardSpawns.Load(), singleSpawns.Load())
+	}
+}
+
+func TestSearch_ShardedKeepsMaxDepth(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	root := writeFiles(t, map[string]string{
+		"top.go":       "needle\n",
+		"a/one.go":     "needle\n",
+		"a/b/two.go":   "needle\n",
+		"a/b/c/six.go": "needle\n",
+	})
+
+	for depth, want := range map[int]int{1: 1, 2: 2, 3: 3} {
+		var counts []int
+		for _, shards := range []int{0, 2} {
+			results := make(chan Match)
+			if err := NewRipgrepSearcher().Search(context.Background(), "needle", root, Options{Shards: shards, MaxDepth: depth}, results); err != nil {
+				t.Fatal(err)
+			}
+			n := 0
+			for range results {
+				n++
+			}
+			counts = append(counts, n)
+		}
+		if counts[0] != want || counts[1] != want {
+			t.Errorf("--max-depth %d: single = %d, sharded = %d matches; want %d", depth, counts[0], counts[1], want)
+		}
+	}
+}
+
+func TestSearch_SortedIsRepeatable(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	files := map[string]string{}
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("d%02d/f%02d.go", i%5, i)] = "needle\nneedle\n"
+	}
+	root := writeFiles(t, files)
+
+	search := func(opts Options) []string {
+		results := make(chan Match)
+		if err := NewRipgrepSearcher().Search(context.Background(), "needle", root, opts, results); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for m := range results {
+			got = append(got, fmt.Sprintf("%s:%d", m.Path, m.LineNumber))
+		}
+		return got
+	}
+
+	single := search(Options{Sorted: true})
+	if !sort.StringsAreSorted(single) {
+		t.Errorf("sorted search out of order: %v", single)
+	}
+	for run := 0; run < 3; run++ {
+		sharded := search(Options{Sorted: true, Shards: 3})
+		if fmt.Sprint(sharded) != fmt.Sprint(single) {
+			t.Fatalf("sharded run %d = %v, want %v", run, sharded, single)
+		}
+	}
+}
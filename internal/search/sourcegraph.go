@@ -0,0 +1,383 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// sourcegraphResultLimit caps the matches requested from Sourcegraph per query
+const sourcegraphResultLimit = 2000
+
+// sourcegraphBlobCacheSize bounds the remote files kept for previews
+const sourcegraphBlobCacheSize = 16
+
+const sourcegraphSearchQuery = `query IrgSearch($query: String!) {
+  search(query: $query, version: V3, patternType: regexp) {
+    results {
+      results {
+        __typename
+        ... on FileMatch {
+          repository { name }
+          file { path }
+          commit { oid }
+          lineMatches { preview lineNumber offsetAndLengths }
+        }
+      }
+    }
+  }
+}`
+
+const sourcegraphBlobQuery = `query IrgBlob($repo: String!, $rev: String!, $path: String!) {
+  repository(name: $repo) {
+    commit(rev: $rev) {
+      blob(path: $path) { content }
+    }
+  }
+}`
+
+// SourcegraphSearcher runs queries against a Sourcegraph instance's GraphQL
+// API. Result paths are "repo/path/to/file".
+type SourcegraphSearcher struct {
+	endpoint string
+	token    string
+	client   *http.Client
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	files  map[string]remoteFile // Result path -> location on the code host
+	blobs  map[string][]string
+	order  []string // Blob cache keys, least recently used first
+}
+
+type remoteFile struct {
+	repo string
+	rev  string
+	path string
+}
+
+// NewSourcegraphSearcher creates a searcher for the instance at endpoint,
+// e.g. https://sourcegraph.example.com. token may be empty for public
+// instances.
+func NewSourcegraphSearcher(endpoint, token string) *SourcegraphSearcher {
+	return &SourcegraphSearcher{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: 60 * time.Second},
+		files:    make(map[string]remoteFile),
+		blobs:    make(map[string][]string),
+	}
+}
+
+// NewSourcegraphSearcherFromEnv reads SRC_ENDPOINT and SRC_ACCESS_TOKEN, the
+// same variables the src CLI uses
+func NewSourcegraphSearcherFromEnv() (*SourcegraphSearcher, error) {
+	endpoint := os.Getenv("SRC_ENDPOINT")
+	if endpoint == "" {
+		return nil, errors.New("SRC_ENDPOINT is not set")
+	}
+	return NewSourcegraphSearcher(endpoint, os.Getenv("SRC_ACCESS_TOKEN")), nil
+}
+
+// Search sends pattern to Sourcegraph and streams the matches to results,
+// closing it when done. path, if set, is added as a repo: filter. Unlike
+// ripgrep, the request completes before Search returns, so request errors
+// are returned directly.
+func (s *SourcegraphSearcher) Search(ctx context.Context, pattern, path string, opts Options, results chan<- Match) error {
+	if pattern == "" {
+		close(results)
+		return nil
+	}
+
+	query, err := buildSourcegraphQuery(pattern, path, opts)
+	if err != nil {
+		close(results)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancel = cancel
+	s.files = make(map[string]remoteFile)
+	s.mu.Unlock()
+
+	var resp struct {
+		Search struct {
+			Results struct {
+				Results []sourcegraphFileMatch `json:"results"`
+			} `json:"results"`
+		} `json:"search"`
+	}
+	if err := s.graphql(ctx, sourcegraphSearchQuery, map[string]any{"query": query}, &resp); err != nil {
+		cancel()
+		close(results)
+		return err
+	}
+
+	go func() {
+		defer close(results)
+		defer cancel()
+
+		for _, fm := range resp.Search.Results.Results {
+			if fm.Typename != "FileMatch" {
+				continue
+			}
+			resultPath := fm.Repository.Name + "/" + fm.File.Path
+			s.mu.Lock()
+			s.files[resultPath] = remoteFile{repo: fm.Repository.Name, rev: fm.Commit.OID, path: fm.File.Path}
+			s.mu.Unlock()
+
+			for _, lm := range fm.LineMatches {
+				select {
+				case results <- lm.toMatch(resultPath):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Cancel stops the in-flight query
+func (s *SourcegraphSearcher) Cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// FileContext fetches the lines around lineNum of a file from the last
+// search's results
+func (s *SourcegraphSearcher) FileContext(ctx context.Context, path string, lineNum, contextLines int, submatches []Submatch) (*FileContext, error) {
+	lines, err := s.blob(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	startLine := lineNum - contextLines
+	if startLine < 1 {
+		startLine = 1
+	}
+	endLine := lineNum + contextLines
+	if endLine > len(lines) {
+		endLine = len(lines)
+	}
+	if startLine > endLine {
+		return &FileContext{StartLine: startLine, MatchLine: lineNum, Submatches: submatches}, nil
+	}
+	return &FileContext{
+		Lines:      lines[startLine-1 : endLine],
+		StartLine:  startLine,
+		MatchLine:  lineNum,
+		Submatches: submatches,
+	}, nil
+}
+
+func (s *SourcegraphSearcher) blob(ctx context.Context, path string) ([]string, error) {
+	s.mu.Lock()
+	file, ok := s.files[path]
+	if lines, cached := s.blobs[path]; cached {
+		s.order = append(removeKey(s.order, path), path)
+		s.mu.Unlock()
+		return lines, nil
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%s is not part of the current results", path)
+	}
+
+	rev := file.rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	var resp struct {
+		Repository *struct {
+			Commit *struct {
+				Blob *struct {
+					Content string `json:"content"`
+				} `json:"blob"`
+			} `json:"commit"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"repo": file.repo, "rev": rev, "path": file.path}
+	if err := s.graphql(ctx, sourcegraphBlobQuery, vars, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Repository == nil || resp.Repository.Commit == nil || resp.Repository.Commit.Blob == nil {
+		return nil, fmt.Errorf("%s not found on Sourcegraph", path)
+	}
+	lines := strings.Split(resp.Repository.Commit.Blob.Content, "\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[path] = lines
+	s.order = append(removeKey(s.order, path), path)
+	for len(s.order) > sourcegraphBlobCacheSize {
+		delete(s.blobs, s.order[0])
+		s.order = s.order[1:]
+	}
+	return lines, nil
+}
+
+// graphql posts a query and decodes its data into out
+func (s *SourcegraphSearcher) graphql(ctx context.Context, query string, variables map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("encode query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/.api/graphql", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sourcegraph request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sourcegraph: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("decode sourcegraph response: %w", err)
+	}
+	if len(envelope.Errors) > 0 {
+		return fmt.Errorf("sourcegraph: %s", envelope.Errors[0].Message)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("decode sourcegraph data: %w", err)
+	}
+	return nil
+}
+
+type sourcegraphFileMatch struct {
+	Typename   string `json:"__typename"`
+	Repository struct {
+		Name string `json:"name"`
+	} `json:"repository"`
+	File struct {
+		Path string `json:"path"`
+	} `json:"file"`
+	Commit struct {
+		OID string `json:"oid"`
+	} `json:"commit"`
+	LineMatches []sourcegraphLineMatch `json:"lineMatches"`
+}
+
+type sourcegraphLineMatch struct {
+	Preview          string   `json:"preview"`
+	LineNumber       int      `json:"lineNumber"` // 0-based
+	OffsetAndLengths [][2]int `json:"offsetAndLengths"`
+}
+
+// toMatch converts a line match, whose offsets count characters, to a
+// Match with ripgrep's 1-based lines and byte offsets
+func (lm sourcegraphLineMatch) toMatch(path string) Match {
+	m := Match{Path: path, LineNumber: lm.LineNumber + 1, LineText: lm.Preview}
+	for _, ol := range lm.OffsetAndLengths {
+		start := runeOffsetToByte(lm.Preview, ol[0])
+		end := runeOffsetToByte(lm.Preview, ol[0]+ol[1])
+		m.Submatches = append(m.Submatches, Submatch{Match: lm.Preview[start:end], Start: start, End: end})
+	}
+	return m
+}
+
+func runeOffsetToByte(s string, runes int) int {
+	offset := 0
+	for i := 0; i < runes && offset < len(s); i++ {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// buildSourcegraphQuery translates irg's search settings into Sourcegraph
+// query syntax. ripgrep type names are passed through as lang: filters,
+// which covers the common languages. The pattern goes in a quoted content:
+// filter, so spaces or words like "repo:" in it aren't read as query syntax.
+func buildSourcegraphQuery(pattern, path string, opts Options) (string, error) {
+	if opts.Multiline {
+		return "", errors.New("multiline searches aren't supported with Sourcegraph")
+	}
+	parts := []string{fmt.Sprintf("count:%d", sourcegraphResultLimit)}
+
+	switch opts.CaseSensitivity {
+	case CaseSensitive:
+		parts = append(parts, "case:yes")
+	case CaseSmart:
+		if strings.ToLower(pattern) != pattern {
+			parts = append(parts, "case:yes")
+		}
+	}
+
+	if path != "" && path != "." {
+		parts = append(parts, "repo:"+sourcegraphQuoteIfNeeded(path))
+	}
+	switch len(opts.FileTypes) {
+	case 0:
+	case 1:
+		parts = append(parts, "lang:"+opts.FileTypes[0])
+	default:
+		// Repeated lang: filters are ANDed, so group them with or
+		langs := make([]string, len(opts.FileTypes))
+		for i, t := range opts.FileTypes {
+			langs[i] = "lang:" + t
+		}
+		parts = append(parts, "("+strings.Join(langs, " or ")+")")
+	}
+	for _, t := range opts.FileTypesNot {
+		parts = append(parts, "-lang:"+t)
+	}
+
+	// Sourcegraph has no whole-word option, so the pattern is wrapped in
+	// word boundaries as a regexp, the literal one quoted first
+	switch {
+	case opts.WholeWord && opts.FixedStrings:
+		pattern = `\b` + regexp.QuoteMeta(pattern) + `\b`
+	case opts.WholeWord:
+		pattern = `\b(?:` + pattern + `)\b`
+	case opts.FixedStrings:
+		parts = append(parts, "patternType:literal")
+	}
+	return strings.Join(append(parts, "content:"+sourcegraphQuote(pattern)), " "), nil
+}
+
+// sourcegraphQuote quotes s as a Sourcegraph query value, escaping the
+// backslashes and double quotes in it
+func sourcegraphQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// sourcegraphQuoteIfNeeded quotes a filter value only when it holds
+// characters that would end it or start other query syntax
+func sourcegraphQuoteIfNeeded(s string) string {
+	if strings.ContainsAny(s, " \t\"'()") {
+		return sourcegraphQuote(s)
+	}
+	return s
+}
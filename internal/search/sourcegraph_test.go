@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildSourcegraphQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		opts    Options
+		want    string
+	}{
+		{"smart lowercase", "foo", ".", Options{CaseSensitivity: CaseSmart}, `count:2000 content:"foo"`},
+		{"smart uppercase", "Foo", "", Options{CaseSensitivity: CaseSmart}, `count:2000 case:yes content:"Foo"`},
+		{"repo filter", "foo", "github.com/org/repo", Options{CaseSensitivity: CaseInsensitive}, `count:2000 repo:github.com/org/repo content:"foo"`},
+		{"types", "foo", ".", Options{FileTypes: []string{"go", "rust"}, FileTypesNot: []string{"markdown"}},
+			`count:2000 (lang:go or lang:rust) -lang:markdown content:"foo"`},
+		{"query syntax in pattern", `repo:x "a\d b`, ".", Options{}, `count:2000 content:"repo:x \"a\\d b"`},
+		{"literal", "a.b(", ".", Options{FixedStrings: true}, `count:2000 patternType:literal content:"a.b("`},
+		{"whole word", "foo|bar", ".", Options{WholeWord: true}, `count:2000 content:"\\b(?:foo|bar)\\b"`},
+		{"literal whole word", "a.b", ".", Options{WholeWord: true, FixedStrings: true}, `count:2000 content:"\\ba\\.b\\b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildSourcegraphQuery(tt.pattern, tt.path, tt.opts)
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := buildSourcegraphQuery("foo", ".", Options{Multiline: true}); err == nil {
+		t.Error("a multiline query built without an error")
+	}
+}
+
+func TestSourcegraphSearcher_SearchAndPreview(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.api/graphql" || r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if strings.Contains(req.Query, "IrgBlob") {
+			w.Write([]byte(`{"data":{"repository":{"commit":{"blob":{"content":"package main\n\n// héllo world\nfunc main() {}\n"}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"search":{"results":{"results":[
+			{"__typename":"Repository"},
+			{"__typename":"FileMatch","repository":{"name":"github.com/org/repo"},"file":{"path":"main.go"},"commit":{"oid":"abc"},
+			 "lineMatches":[{"preview":"// héllo world","lineNumber":2,"offsetAndLengths":[[9,5]]}]}
+		]}}}}`))
+	}))
+	defer srv.Close()
+
+	s := NewSourcegraphSearcher(srv.URL+"/", "secret")
+	results := make(chan Match, 10)
+	if err := s.Search(context.Background(), "world", ".", Options{}, results); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	var matches []Match
+	for m := range results {
+		matches = append(matches, m)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	m := matches[0]
+	if m.Path != "github.com/org/repo/main.go" || m.LineNumber != 3 {
+		t.Errorf("got %s:%d, want github.com/org/repo/main.go:3", m.Path, m.LineNumber)
+	}
+	// "é" is one character but two bytes
+	if len(m.Submatches) != 1 || m.Submatches[0].Start != 10 || m.Submatches[0].Match != "world" {
+		t.Errorf("submatches = %+v, want world at byte 10", m.Submatches)
+	}
+
+	ctx, err := s.FileContext(context.Background(), m.Path, m.LineNumber, 1, m.Submatches)
+	if err != nil {
+		t.Fatalf("FileContext: %v", err)
+	}
+	if ctx.StartLine != 2 || len(ctx.Lines) != 3 || ctx.Lines[1] != "// héllo world" {
+		t.Errorf("context = %+v", ctx)
+	}
+}
+
+func TestSourcegraphSearcher_ReturnsRequestErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"invalid query"}]}`))
+	}))
+	defer srv.Close()
+
+	s := NewSourcegraphSearcher(srv.URL, "")
+	results := make(chan Match, 1)
+	err := s.Search(context.Background(), "(", ".", Options{}, results)
+	if err == nil || !strings.Contains(err.Error(), "invalid query") {
+		t.Fatalf("err = %v, want invalid query", err)
+	}
+	if _, open := <-results; open {
+		t.Error("results channel not closed on error")
+	}
+}
@@ -0,0 +1,245 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+const (
+	defaultMemoryLimit = 2000 // Matches kept in memory before spilling
+	defaultPageSize    = 500  // Matches per spilled page
+	pageCacheSize      = 4    // Spilled pages kept decoded in memory
+)
+
+// ResultStore holds search results, keeping the most recent matches in memory
+// and spilling older pages to a temporary file once the memory limit is hit.
+// Callers page through it by index without knowing where a match lives.
+type ResultStore struct {
+	memoryLimit int
+	pageSize    int
+
+	mem     []Match // Matches [spilled, Len()) kept in memory
+	spilled int     // Number of matches written to disk (multiple of pageSize)
+
+	file  *os.File
+	pages []spilledPage
+	size  int64
+
+	cache     map[int][]Match
+	cacheKeys []int // Oldest first, for eviction
+}
+
+type spilledPage struct {
+	offset int64
+	length int
+}
+
+// NewResultStore creates a store with the default memory limit and page size
+func NewResultStore() *ResultStore {
+	return NewResultStoreWithLimits(defaultMemoryLimit, defaultPageSize)
+}
+
+// NewResultStoreWithLimits creates a store that spills pages of pageSize matches
+// once more than memoryLimit matches are held in memory
+func NewResultStoreWithLimits(memoryLimit, pageSize int) *ResultStore {
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	if memoryLimit < pageSize {
+		memoryLimit = pageSize
+	}
+	return &ResultStore{
+		memoryLimit: memoryLimit,
+		pageSize:    pageSize,
+		cache:       make(map[int][]Match),
+	}
+}
+
+// Len returns the total number of stored matches
+func (s *ResultStore) Len() int {
+	return s.spilled + len(s.mem)
+}
+
+// Append adds matches to the end of the store, spilling to disk as needed
+func (s *ResultStore) Append(matches ...Match) error {
+	s.mem = append(s.mem, matches...)
+	for len(s.mem) > s.memoryLimit {
+		if err := s.spillPage(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the match at index i
+func (s *ResultStore) Get(i int) (Match, error) {
+	if i < 0 || i >= s.Len() {
+		return Match{}, fmt.Errorf("result index %d out of range [0, %d)", i, s.Len())
+	}
+	if i >= s.spilled {
+		return s.mem[i-s.spilled], nil
+	}
+
+	page, err := s.loadPage(i / s.pageSize)
+	if err != nil {
+		return Match{}, err
+	}
+	return page[i%s.pageSize], nil
+}
+
+// Slice returns matches in [start, end), clamped to the stored range
+func (s *ResultStore) Slice(start, end int) ([]Match, error) {
+	if start < 0 {
+		start = 0
+	}
+	if end > s.Len() {
+		end = s.Len()
+	}
+	if start >= end {
+		return nil, nil
+	}
+
+	matches := make([]Match, 0, end-start)
+	for i := start; i < end; i++ {
+		match, err := s.Get(i)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// Truncate drops every match at index n and beyond. If the page holding n
+// can't be read back from the spill file, the store is left unchanged.
+func (s *ResultStore) Truncate(n int) error {
+	if n >= s.Len() {
+		return nil
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n >= s.spilled {
+		s.mem = s.mem[:n-s.spilled]
+		return nil
+	}
+
+	// Pull the page containing n back into memory and drop later pages
+	pageIdx := n / s.pageSize
+	page, err := s.loadPage(pageIdx)
+	if err != nil {
+		return err
+	}
+	keep := min(n%s.pageSize, len(page))
+	s.mem = append([]Match(nil), page[:keep]...)
+	s.spilled = pageIdx * s.pageSize
+	s.pages = s.pages[:pageIdx]
+	if pageIdx > 0 {
+		last := s.pages[pageIdx-1]
+		s.size = last.offset + int64(last.length)
+	} else {
+		s.size = 0
+	}
+	s.resetCache()
+	return nil
+}
+
+// Snapshot returns a copy of the store as it is now, for reading on another
+// goroutine while the store stays in use. The copy shares the spill file and
+// needs no Close; once the store is reset or closed its reads may fail or
+// return later matches, so callers discard them then.
+func (s *ResultStore) Snapshot() *ResultStore {
+	return &ResultStore{
+		memoryLimit: s.memoryLimit,
+		pageSize:    s.pageSize,
+		mem:         slices.Clone(s.mem),
+		spilled:     s.spilled,
+		file:        s.file,
+		pages:       slices.Clone(s.pages),
+		size:        s.size,
+		cache:       make(map[int][]Match),
+	}
+}
+
+// Reset clears all matches while keeping the spill file for reuse
+func (s *ResultStore) Reset() {
+	s.mem = s.mem[:0]
+	s.spilled = 0
+	s.pages = nil
+	s.size = 0
+	s.resetCache()
+}
+
+// Close removes the spill file, if one was created
+func (s *ResultStore) Close() error {
+	s.Reset()
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	s.file.Close()
+	s.file = nil
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *ResultStore) spillPage() error {
+	if s.file == nil {
+		file, err := os.CreateTemp("", "irg-results-*.json")
+		if err != nil {
+			return fmt.Errorf("create spill file: %w", err)
+		}
+		s.file = file
+	}
+
+	data, err := json.Marshal(s.mem[:s.pageSize])
+	if err != nil {
+		return fmt.Errorf("encode result page: %w", err)
+	}
+	if _, err := s.file.WriteAt(data, s.size); err != nil {
+		return fmt.Errorf("write %s: %w", s.file.Name(), err)
+	}
+
+	s.pages = append(s.pages, spilledPage{offset: s.size, length: len(data)})
+	s.size += int64(len(data))
+	s.spilled += s.pageSize
+
+	// Copy the remainder so the spilled matches can be garbage collected
+	s.mem = append([]Match(nil), s.mem[s.pageSize:]...)
+	return nil
+}
+
+func (s *ResultStore) loadPage(idx int) ([]Match, error) {
+	if page, ok := s.cache[idx]; ok {
+		return page, nil
+	}
+
+	info := s.pages[idx]
+	data := make([]byte, info.length)
+	if _, err := s.file.ReadAt(data, info.offset); err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.file.Name(), err)
+	}
+
+	var page []Match
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("decode result page %d: %w", idx, err)
+	}
+
+	if len(s.cacheKeys) >= pageCacheSize {
+		oldest := s.cacheKeys[0]
+		s.cacheKeys = s.cacheKeys[1:]
+		delete(s.cache, oldest)
+	}
+	s.cache[idx] = page
+	s.cacheKeys = append(s.cacheKeys, idx)
+	return page, nil
+}
+
+func (s *ResultStore) resetCache() {
+	s.cache = make(map[int][]Match)
+	s.cacheKeys = nil
+}
@@ -0,0 +1,177 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func makeMatches(start, n int) []Match {
+	matches := make([]Match, n)
+	for i := range matches {
+		matches[i] = Match{
+			Path:       fmt.Sprintf("file%d.go", start+i),
+			LineNumber: start + i,
+			LineText:   fmt.Sprintf("line %d", start+i),
+			Submatches: []Submatch{{Match: "line", Start: 0, End: 4}},
+		}
+	}
+	return matches
+}
+
+func TestResultStore_InMemory(t *testing.T) {
+	s := NewResultStoreWithLimits(100, 10)
+	defer s.Close()
+
+	if err := s.Append(makeMatches(0, 50)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if s.Len() != 50 {
+		t.Fatalf("Len() = %d, want 50", s.Len())
+	}
+	if s.file != nil {
+		t.Error("expected no spill file below the memory limit")
+	}
+
+	m, err := s.Get(42)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if m.LineNumber != 42 {
+		t.Errorf("Get(42).LineNumber = %d, want 42", m.LineNumber)
+	}
+}
+
+func TestResultStore_SpillsAndPages(t *testing.T) {
+	s := NewResultStoreWithLimits(20, 10)
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := s.Append(makeMatches(i*10, 10)...); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	if s.Len() != 100 {
+		t.Fatalf("Len() = %d, want 100", s.Len())
+	}
+	if len(s.mem) > 20 {
+		t.Errorf("in-memory matches = %d, want <= 20", len(s.mem))
+	}
+	if s.file == nil {
+		t.Fatal("expected a spill file above the memory limit")
+	}
+
+	for _, i := range []int{0, 9, 10, 55, 79, 80, 99} {
+		m, err := s.Get(i)
+		if err != nil {
+			t.Fatalf("Get(%d): %v", i, err)
+		}
+		if m.LineNumber != i || len(m.Submatches) != 1 {
+			t.Errorf("Get(%d) = %+v", i, m)
+		}
+	}
+
+	window, err := s.Slice(5, 15)
+	if err != nil {
+		t.Fatalf("Slice: %v", err)
+	}
+	if len(window) != 10 || window[0].LineNumber != 5 || window[9].LineNumber != 14 {
+		t.Errorf("Slice(5, 15) returned unexpected window: %d items", len(window))
+	}
+}
+
+func TestResultStore_Truncate(t *testing.T) {
+	s := NewResultStoreWithLimits(20, 10)
+	defer s.Close()
+
+	s.Append(makeMatches(0, 100)...)
+	if err := s.Truncate(35); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+
+	if s.Len() != 35 {
+		t.Fatalf("Len() = %d, want 35", s.Len())
+	}
+	m, err := s.Get(34)
+	if err != nil || m.LineNumber != 34 {
+		t.Errorf("Get(34) = %+v, %v", m, err)
+	}
+
+	s.Append(makeMatches(35, 50)...)
+	m, err = s.Get(84)
+	if err != nil || m.LineNumber != 84 {
+		t.Errorf("Get(84) after re-append = %+v, %v", m, err)
+	}
+}
+
+func TestResultStore_TruncateKeepsResultsOnReadError(t *testing.T) {
+	s := NewResultStoreWithLimits(20, 10)
+	defer s.Close()
+
+	s.Append(makeMatches(0, 100)...)
+	// Lose the spilled pages, as a full or removed temp dir would
+	if err := s.file.Truncate(0); err != nil {
+		t.Fatal(err)
+	}
+	s.resetCache()
+
+	if err := s.Truncate(35); err == nil {
+		t.Fatal("Truncate with an unreadable page returned no error")
+	}
+	if s.Len() != 100 {
+		t.Errorf("Len() = %d after a failed Truncate, want 100", s.Len())
+	}
+}
+
+func TestResultStore_SnapshotReadsOnAnotherGoroutine(t *testing.T) {
+	s := NewResultStoreWithLimits(10, 5)
+	defer s.Close()
+	if err := s.Append(makeMatches(0, 23)...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	snapshot := s.Snapshot()
+	done := make(chan []Match)
+	go func() {
+		matches, err := snapshot.Slice(0, snapshot.Len())
+		if err != nil {
+			t.Errorf("Slice: %v", err)
+		}
+		done <- matches
+	}()
+	// The store's own page cache is in use meanwhile
+	for i := 0; i < s.Len(); i++ {
+		if _, err := s.Get(i); err != nil {
+			t.Fatalf("Get(%d): %v", i, err)
+		}
+	}
+
+	matches := <-done
+	if len(matches) != 23 || matches[0].LineNumber != 0 || matches[22].LineNumber != 22 {
+		t.Errorf("snapshot read %d matches, want 0 to 22", len(matches))
+	}
+}
+
+func TestResultStore_CloseRemovesSpillFile(t *testing.T) {
+	s := NewResultStoreWithLimits(10, 5)
+	s.Append(makeMatches(0, 30)...)
+
+	name := s.file.Name()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spill file %s still exists after Close", name)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Len() after Close = %d, want 0", s.Len())
+	}
+}
+
+func TestResultStore_GetOutOfRange(t *testing.T) {
+	s := NewResultStore()
+	if _, err := s.Get(0); err == nil {
+		t.Error("expected error for Get on empty store")
+	}
+}
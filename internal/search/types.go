@@ -0,0 +1,98 @@
+package search
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadTypeGlobs returns the file globs of every ripgrep type, keyed by type
+// name, including the custom types passed in
+func LoadTypeGlobs(custom map[string][]string) (map[string][]string, error) {
+	args := append(TypeAddArgs(custom), "--type-list")
+	output, err := exec.Command("rg", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("rg --type-list: %w", err)
+	}
+	return parseTypeList(string(output)), nil
+}
+
+// TypeAddArgs returns the --type-add flags that define custom types for rg,
+// in a stable order. A custom type with a built-in name adds to its globs.
+func TypeAddArgs(custom map[string][]string) []string {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		for _, glob := range custom[name] {
+			args = append(args, "--type-add", name+":"+glob)
+		}
+	}
+	return args
+}
+
+// parseTypeList parses `rg --type-list` output ("go: *.go" per line)
+func parseTypeList(output string) map[string][]string {
+	globs := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		name, list, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		for _, g := range strings.Split(list, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				globs[name] = append(globs[name], g)
+			}
+		}
+	}
+	return globs
+}
+
+// MatchesType reports whether path's file name matches any glob of typeName
+func MatchesType(path, typeName string, globs map[string][]string) bool {
+	base := filepath.Base(path)
+	for _, g := range globs[typeName] {
+		if ok, _ := filepath.Match(g, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByTypes applies --type/--type-not semantics to an explicit file list
+func filterByTypes(files, include, exclude []string, globs map[string][]string) []string {
+	var kept []string
+	for _, f := range files {
+		if len(include) > 0 {
+			matched := false
+			for _, t := range include {
+				if MatchesType(f, t, globs) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		excluded := false
+		for _, t := range exclude {
+			if MatchesType(f, t, globs) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
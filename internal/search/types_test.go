@@ -0,0 +1,77 @@
+package search
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+const sampleTypeList = `c: *.[chH], *.[chH].in, *.cats
+go: *.go
+make: *.mak, *.mk, GNUmakefile, Makefile, makefile
+`
+
+func TestParseTypeList(t *testing.T) {
+	globs := parseTypeList(sampleTypeList)
+	want := []string{"*.mak", "*.mk", "GNUmakefile", "Makefile", "makefile"}
+	if !reflect.DeepEqual(globs["make"], want) {
+		t.Errorf("make globs = %v, want %v", globs["make"], want)
+	}
+	if !reflect.DeepEqual(globs["go"], []string{"*.go"}) {
+		t.Errorf("go globs = %v", globs["go"])
+	}
+}
+
+func TestFilterByTypes(t *testing.T) {
+	globs := parseTypeList(sampleTypeList)
+	files := []string{"main.go", "internal/ui/model.go", "Makefile", "src/util.c", "README.md"}
+
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"no filters", nil, nil, files},
+		{"include go", []string{"go"}, nil, []string{"main.go", "internal/ui/model.go"}},
+		{"include go and make", []string{"go", "make"}, nil, []string{"main.go", "internal/ui/model.go", "Makefile"}},
+		{"exclude go", nil, []string{"go"}, []string{"Makefile", "src/util.c", "README.md"}},
+		{"unknown type", []string{"nope"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterByTypes(files, tt.include, tt.exclude, globs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeAddArgs_SortedByName(t *testing.T) {
+	got := TypeAddArgs(map[string][]string{
+		"web":   {"*.ts", "*.css"},
+		"proto": {"*.proto"},
+	})
+	want := []string{"--type-add", "proto:*.proto", "--type-add", "web:*.ts", "--type-add", "web:*.css"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TypeAddArgs = %v, want %v", got, want)
+	}
+}
+
+func TestLoadTypeGlobs_IncludesCustomTypes(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+
+	globs, err := LoadTypeGlobs(map[string][]string{"web": {"*.ts", "*.css"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rg lists globs sorted
+	if !reflect.DeepEqual(globs["web"], []string{"*.css", "*.ts"}) {
+		t.Errorf("web globs = %v", globs["web"])
+	}
+	if len(globs["go"]) == 0 {
+		t.Error("built-in types missing")
+	}
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// This file implements the subset of MessagePack needed for msgpack-RPC.
+// Values decode to nil, bool, int64, uint64, float64, string, []byte, []any
+// and map[string]any; extension types (such as Neovim's buffer handles)
+// decode to their raw payload.
+
+// maxMsgpackLen bounds the length of one decoded string, array or map
+const maxMsgpackLen = 64 * 1024 * 1024
+
+type msgpackDecoder struct {
+	r *bufio.Reader
+}
+
+func newMsgpackDecoder(r io.Reader) *msgpackDecoder {
+	return &msgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// decode reads one value
+func (d *msgpackDecoder) decode() (any, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return d.decodeString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readLen(b - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return d.readBytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readLen(b - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(n)
+	case 0xca:
+		v, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (b - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+		return v, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		v, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from size bytes
+		shift := 64 - 8*size
+		return int64(v<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readLen(b - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.readLen(b - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.readLen(b - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type byte 0x%02x", b)
+}
+
+// readLen reads a length prefix of 1, 2 or 4 bytes, selected by sizeClass 0-2
+func (d *msgpackDecoder) readLen(sizeClass byte) (int, error) {
+	v, err := d.readUint(1 << sizeClass)
+	if err != nil {
+		return 0, err
+	}
+	if v > maxMsgpackLen {
+		return 0, fmt.Errorf("msgpack: length %d too large", v)
+	}
+	return int(v), nil
+}
+
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *msgpackDecoder) readBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (d *msgpackDecoder) decodeString(n int) (string, error) {
+	buf, err := d.readBytes(n)
+	return string(buf), err
+}
+
+func (d *msgpackDecoder) decodeExt(n int) ([]byte, error) {
+	if _, err := d.r.ReadByte(); err != nil { // Extension type
+		return nil, err
+	}
+	return d.readBytes(n)
+}
+
+func (d *msgpackDecoder) decodeArray(n int) ([]any, error) {
+	out := make([]any, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (map[string]any, error) {
+	out := make(map[string]any, min(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		switch k := k.(type) {
+		case string:
+			out[k] = v
+		case []byte:
+			out[string(k)] = v
+		default:
+			out[fmt.Sprint(k)] = v
+		}
+	}
+	return out, nil
+}
+
+// appendMsgpack appends the encoding of v to buf. Supported types are those
+// produced by decode, int, and []string; map keys are sorted so output is
+// deterministic.
+func appendMsgpack(buf []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case int:
+		return appendInt(buf, int64(v)), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return appendInt(buf, int64(v)), nil
+		}
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(v)), nil
+	case string:
+		buf = appendHeader(buf, len(v), 0xa0, 31, 0xd9)
+		return append(buf, v...), nil
+	case []byte:
+		buf = appendHeader(buf, len(v), 0, 0, 0xc4)
+		return append(buf, v...), nil
+	case []string:
+		buf = appendHeader(buf, len(v), 0x90, 15, 0)
+		for _, s := range v {
+			buf, _ = appendMsgpack(buf, s)
+		}
+		return buf, nil
+	case []any:
+		buf = appendHeader(buf, len(v), 0x90, 15, 0)
+		var err error
+		for _, e := range v {
+			if buf, err = appendMsgpack(buf, e); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = appendHeader(buf, len(v), 0x80, 15, 0)
+		var err error
+		for _, k := range keys {
+			buf, _ = appendMsgpack(buf, k)
+			if buf, err = appendMsgpack(buf, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("msgpack: cannot encode %T", v)
+}
+
+func appendInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0 && v <= 0x7f:
+		return append(buf, byte(v))
+	case v < 0 && v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+}
+
+// appendHeader writes a length header: the fix form (fixBase|n) when n fits in
+// fixMax, otherwise the 8-, 16- or 32-bit form starting at code8. Types
+// without an 8-bit form (arrays and maps) pass code8 = 0 and use the codes
+// after their fix range.
+func appendHeader(buf []byte, n int, fixBase byte, fixMax int, code8 byte) []byte {
+	if fixBase != 0 && n <= fixMax {
+		return append(buf, fixBase|byte(n))
+	}
+	if code8 == 0 {
+		// Arrays use 0xdc/0xdd and maps 0xde/0xdf
+		code16 := byte(0xdc)
+		if fixBase == 0x80 {
+			code16 = 0xde
+		}
+		if n <= math.MaxUint16 {
+			return binary.BigEndian.AppendUint16(append(buf, code16), uint16(n))
+		}
+		return binary.BigEndian.AppendUint32(append(buf, code16+1), uint32(n))
+	}
+	switch {
+	case n <= math.MaxUint8:
+		return append(buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, code8+1), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, code8+2), uint32(n))
+}
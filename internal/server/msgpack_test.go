@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"nil", nil, nil},
+		{"bool", true, true},
+		{"fixint", int64(5), int64(5)},
+		{"negative fixint", int64(-3), int64(-3)},
+		{"int32", int64(-70000), int64(-70000)},
+		{"int64", int64(1) << 40, int64(1) << 40},
+		{"int", 300, int64(300)},
+		{"float", 1.5, 1.5},
+		{"fixstr", "abc", "abc"},
+		{"str8", strings.Repeat("x", 40), strings.Repeat("x", 40)},
+		{"str16", strings.Repeat("y", 300), strings.Repeat("y", 300)},
+		{"bin", []byte{1, 2}, []byte{1, 2}},
+		{"strings", []string{"a", "b"}, []any{"a", "b"}},
+		{"array16", make([]any, 20), make([]any, 20)},
+		{"map", map[string]any{"a": int64(1), "b": []any{"x"}}, map[string]any{"a": int64(1), "b": []any{"x"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := appendMsgpack(nil, tt.in)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			got, err := newMsgpackDecoder(bytes.NewReader(buf)).decode()
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpack_DecodeForeignEncodings(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want any
+	}{
+		{"uint8", []byte{0xcc, 0xff}, int64(255)},
+		{"uint16", []byte{0xcd, 0x01, 0x00}, int64(256)},
+		{"uint64 max", []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, uint64(1<<64 - 1)},
+		{"int8", []byte{0xd0, 0x80}, int64(-128)},
+		{"int16", []byte{0xd1, 0xff, 0x00}, int64(-256)},
+		{"float32", []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, 1.5},
+		// Neovim sends buffer handles as ext type 0
+		{"fixext1", []byte{0xd4, 0x00, 0x07}, []byte{0x07}},
+		{"map with int key", []byte{0x81, 0x01, 0xa1, 'a'}, map[string]any{"1": "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newMsgpackDecoder(bytes.NewReader(tt.in)).decode()
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpack_DecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"truncated string", []byte{0xa3, 'a'}},
+		{"unused type byte", []byte{0xc1}},
+		{"huge array", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newMsgpackDecoder(bytes.NewReader(tt.in)).decode(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// msgpack-RPC message types
+const (
+	rpcRequest      = 0
+	rpcResponse     = 1
+	rpcNotification = 2
+)
+
+// SearchResult is the msgpack-RPC result of a search, which is returned whole
+// rather than streamed
+type SearchResult struct {
+	Matches   []Match `json:"matches"`
+	Count     int     `json:"count"`
+	Cancelled bool    `json:"cancelled,omitempty"`
+}
+
+// serveMsgpack reads msgpack-RPC messages until the connection fails. Params
+// are the method's arguments; the first one is the method's params object, so
+// Neovim calls look like rpcrequest(chan, "search", {pattern = "TODO"}).
+func (s *Server) serveMsgpack(ctx context.Context, conn io.ReadWriter, c *connState, wg *sync.WaitGroup) {
+	c.write = func(v any) error {
+		buf, err := appendMsgpack(nil, v)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(buf)
+		return err
+	}
+
+	dec := newMsgpackDecoder(conn)
+	for {
+		v, err := dec.decode()
+		if err != nil {
+			return
+		}
+		msg, ok := v.([]any)
+		if !ok || len(msg) < 3 {
+			continue
+		}
+
+		var id int64
+		var method any
+		var args any
+		switch msgType, _ := msg[0].(int64); {
+		case msgType == rpcRequest && len(msg) == 4:
+			id, _ = msg[1].(int64)
+			method, args = msg[2], msg[3]
+		case msgType == rpcNotification:
+			id = -1
+			method, args = msg[1], msg[2]
+		default:
+			// Responses are never expected; the server makes no requests
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.handleMsgpack(ctx, c, id, method, args)
+			if id < 0 {
+				return
+			}
+			var rpcErr any
+			if err != nil {
+				rpcErr = err.Error()
+				result = nil
+			}
+			if err := c.send([]any{int64(rpcResponse), id, rpcErr, result}); err != nil {
+				c.send([]any{int64(rpcResponse), id, err.Error(), nil})
+			}
+		}()
+	}
+}
+
+func (s *Server) handleMsgpack(ctx context.Context, c *connState, id int64, method, args any) (any, error) {
+	name, ok := method.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid method %v", method)
+	}
+	var params json.RawMessage
+	if list, ok := args.([]any); ok && len(list) > 0 && list[0] != nil {
+		raw, err := json.Marshal(list[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+		params = raw
+	}
+
+	var matches []Match
+	result, err := s.dispatch(ctx, c, id, name, params, func(m Match) error {
+		matches = append(matches, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if done, ok := result.(*Done); ok {
+		if matches == nil {
+			matches = []Match{}
+		}
+		result = SearchResult{Matches: matches, Count: done.Count, Cancelled: done.Cancelled}
+	}
+	return plainValue(result)
+}
+
+// plainValue converts v to the maps, slices and scalars appendMsgpack
+// encodes, using v's JSON field names
+func plainValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return fromJSONNumbers(out), nil
+}
+
+// fromJSONNumbers replaces json.Number values with int64 or float64
+func fromJSONNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = fromJSONNumbers(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = fromJSONNumbers(v[k])
+		}
+	}
+	return v
+}
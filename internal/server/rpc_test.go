@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// dialMsgpack starts a msgpack-RPC server on one end of a pipe and returns the
+// other end with a decoder for it
+func dialMsgpack(t *testing.T) (net.Conn, *msgpackDecoder) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		New(NewService(), ProtocolMsgpack).ServeConn(ctx, serverConn)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		clientConn.Close()
+		<-done
+	})
+	clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+	return clientConn, newMsgpackDecoder(clientConn)
+}
+
+// call sends a request and returns its error and result
+func call(t *testing.T, conn net.Conn, dec *msgpackDecoder, id int64, method string, params map[string]any) (any, any) {
+	t.Helper()
+	buf, err := appendMsgpack(nil, []any{int64(rpcRequest), id, method, []any{params}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		t.Fatalf("writing request: %v", err)
+	}
+	v, err := dec.decode()
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	msg, ok := v.([]any)
+	if !ok || len(msg) != 4 || msg[0] != int64(rpcResponse) || msg[1] != id {
+		t.Fatalf("response = %#v, want [1, %d, error, result]", v, id)
+	}
+	return msg[2], msg[3]
+}
+
+func TestServeMsgpack_Search(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("// TODO: one\n// TODO: two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, dec := dialMsgpack(t)
+	rpcErr, result := call(t, conn, dec, 3, "search", map[string]any{"pattern": "TODO", "path": dir})
+	if rpcErr != nil {
+		t.Fatalf("error: %v", rpcErr)
+	}
+
+	res, _ := result.(map[string]any)
+	if res["count"] != int64(2) {
+		t.Errorf("count = %#v, want 2", res["count"])
+	}
+	matches, _ := res["matches"].([]any)
+	if len(matches) != 2 {
+		t.Fatalf("matches = %#v", res["matches"])
+	}
+	first, _ := matches[0].(map[string]any)
+	if first["line"] != int64(1) || first["text"] != "// TODO: one" {
+		t.Errorf("first match = %#v", first)
+	}
+}
+
+func TestServeMsgpack_Errors(t *testing.T) {
+	conn, dec := dialMsgpack(t)
+
+	rpcErr, result := call(t, conn, dec, 1, "search", map[string]any{})
+	if rpcErr != "pattern is required" || result != nil {
+		t.Errorf("got error %#v, result %#v", rpcErr, result)
+	}
+
+	rpcErr, _ = call(t, conn, dec, 2, "nope", nil)
+	if rpcErr != `unknown method "nope"` {
+		t.Errorf("got error %#v", rpcErr)
+	}
+}
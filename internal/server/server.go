@@ -0,0 +1,276 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxRequestSize bounds one request line
+const maxRequestSize = 1024 * 1024
+
+// Request is one line sent by a client. ID is chosen by the client and
+// echoed in every response to the request.
+type Request struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is one line sent to a client. A search produces a Match response
+// per result followed by a Done response; other methods produce a single
+// Result. Failures produce an Error instead.
+type Response struct {
+	ID     int64  `json:"id"`
+	Match  *Match `json:"match,omitempty"`
+	Done   *Done  `json:"done,omitempty"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Done ends the responses to a search
+type Done struct {
+	Count     int  `json:"count"`
+	Cancelled bool `json:"cancelled,omitempty"`
+}
+
+// CancelParams are the parameters of the "cancel" method
+type CancelParams struct {
+	ID int64 `json:"id"`
+}
+
+// DefaultSocketPath returns the socket used when none is given:
+// $XDG_RUNTIME_DIR/irg.sock, or a per-user file in the temp directory
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "irg.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("irg-%d.sock", os.Getuid()))
+}
+
+// Listen listens on a Unix socket at path, replacing a stale socket file left
+// by a server that exited without cleaning up
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a server is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Only the owner may connect; searches can read anything the user can
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Protocol selects the wire format of a server
+type Protocol int
+
+const (
+	// ProtocolJSON is newline-delimited JSON, with search results streamed
+	ProtocolJSON Protocol = iota
+	// ProtocolMsgpack is msgpack-RPC, as spoken by Neovim; each request gets a
+	// single response
+	ProtocolMsgpack
+)
+
+// Server answers requests in one protocol
+type Server struct {
+	service  *Service
+	protocol Protocol
+}
+
+// New creates a server backed by service
+func New(service *Service, protocol Protocol) *Server {
+	return &Server{service: service, protocol: protocol}
+}
+
+// Stdio joins stdin and stdout into a connection, for clients that start the
+// server as a child process
+func Stdio() io.ReadWriteCloser {
+	return stdioConn{Reader: os.Stdin, WriteCloser: os.Stdout}
+}
+
+type stdioConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// Serve accepts connections on ln until ctx is cancelled or ln fails
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.ServeConn(ctx, conn)
+		}()
+	}
+}
+
+// ServeConn answers requests on one connection until the client disconnects
+// or ctx is cancelled. Requests run concurrently, so responses may arrive out
+// of order and, for JSON, interleave.
+func (s *Server) ServeConn(ctx context.Context, conn io.ReadWriteCloser) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	// Requests still running when the client stops sending are answered
+	// before the connection closes
+	c := &connState{searches: make(map[int64]context.CancelFunc)}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if s.protocol == ProtocolMsgpack {
+		s.serveMsgpack(ctx, conn, c, &wg)
+	} else {
+		s.serveJSON(ctx, conn, c, &wg)
+	}
+}
+
+// connState is the per-connection state shared by request goroutines
+type connState struct {
+	mu       sync.Mutex
+	write    func(v any) error
+	searches map[int64]context.CancelFunc
+}
+
+func (c *connState) send(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(v)
+}
+
+func (s *Server) serveJSON(ctx context.Context, conn io.ReadWriter, c *connState, wg *sync.WaitGroup) {
+	c.write = json.NewEncoder(conn).Encode
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			c.send(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handleJSON(ctx, c, req)
+		}()
+	}
+}
+
+func (s *Server) handleJSON(ctx context.Context, c *connState, req Request) {
+	result, err := s.dispatch(ctx, c, req.ID, req.Method, req.Params, func(m Match) error {
+		return c.send(Response{ID: req.ID, Match: &m})
+	})
+	switch {
+	case err != nil:
+		c.send(Response{ID: req.ID, Error: err.Error()})
+	case req.Method == "search":
+		c.send(Response{ID: req.ID, Done: result.(*Done)})
+	default:
+		c.send(Response{ID: req.ID, Result: result})
+	}
+}
+
+// dispatch runs one request and returns its result. Search matches are passed
+// to emit as they arrive, and the search's result is its *Done.
+func (s *Server) dispatch(ctx context.Context, c *connState, id int64, method string, params json.RawMessage, emit func(Match) error) (any, error) {
+	switch method {
+	case "search":
+		return s.search(ctx, c, id, params, emit)
+	case "preview":
+		var p PreviewParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.service.Preview(p)
+	case "types":
+		return s.service.Types()
+	case "cancel":
+		var p CancelParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		cancel, ok := c.searches[p.ID]
+		c.mu.Unlock()
+		if ok {
+			cancel()
+		}
+		return ok, nil
+	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+
+func (s *Server) search(ctx context.Context, c *connState, id int64, params json.RawMessage, emit func(Match) error) (*Done, error) {
+	var p SearchParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.mu.Lock()
+	if _, ok := c.searches[id]; ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("request id %d is already in use", id)
+	}
+	c.searches[id] = cancel
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.searches, id)
+		c.mu.Unlock()
+	}()
+
+	count, err := s.service.Search(ctx, p, emit)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+	return &Done{Count: count, Cancelled: err != nil}, nil
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
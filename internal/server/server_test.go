@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dial starts a server on one end of a pipe and returns a client for the other
+func dial(t *testing.T) (net.Conn, *bufio.Scanner) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		New(NewService(), ProtocolJSON).ServeConn(ctx, serverConn)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		clientConn.Close()
+		<-done
+	})
+	clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+	return clientConn, bufio.NewScanner(clientConn)
+}
+
+func sendRequest(t *testing.T, conn net.Conn, line string) {
+	t.Helper()
+	if _, err := conn.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("writing request: %v", err)
+	}
+}
+
+func readResponse(t *testing.T, sc *bufio.Scanner) Response {
+	t.Helper()
+	if !sc.Scan() {
+		t.Fatalf("no response: %v", sc.Err())
+	}
+	var resp Response
+	if err := json.Unmarshal(sc.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding %q: %v", sc.Text(), err)
+	}
+	return resp
+}
+
+func TestServeConn_Search(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	content := "package main\n\nfunc main() {}\nfunc helper() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(SearchParams{Pattern: "func", Path: dir, Limit: 1})
+	conn, sc := dial(t)
+	sendRequest(t, conn, `{"id":7,"method":"search","params":`+string(params)+`}`)
+
+	resp := readResponse(t, sc)
+	if resp.ID != 7 || resp.Match == nil {
+		t.Fatalf("first response = %+v, want a match", resp)
+	}
+	if resp.Match.Line != 3 || resp.Match.Text != "func main() {}" {
+		t.Errorf("match = %+v", resp.Match)
+	}
+	if sm := resp.Match.Submatches; len(sm) != 1 || sm[0].Start != 1 || sm[0].End != 5 {
+		t.Errorf("submatches = %+v, want 1-based [1,5)", sm)
+	}
+
+	resp = readResponse(t, sc)
+	if resp.Done == nil || resp.Done.Count != 1 || resp.Done.Cancelled {
+		t.Errorf("second response = %+v, want done with count 1", resp)
+	}
+}
+
+func TestServeConn_Preview(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(PreviewParams{Path: path, Line: 2, Context: 1})
+	conn, sc := dial(t)
+	sendRequest(t, conn, `{"id":1,"method":"preview","params":`+string(params)+`}`)
+
+	resp := readResponse(t, sc)
+	if resp.Error != "" {
+		t.Fatalf("error: %s", resp.Error)
+	}
+	result, _ := resp.Result.(map[string]any)
+	if result["start_line"] != float64(1) {
+		t.Errorf("start_line = %v, want 1", result["start_line"])
+	}
+	lines, _ := result["lines"].([]any)
+	if len(lines) != 3 || lines[1] != "two" {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestServeConn_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		wantID  int64
+		wantErr string
+	}{
+		{"malformed", `{"id":`, 0, "invalid request"},
+		{"unknown method", `{"id":2,"method":"nope"}`, 2, `unknown method "nope"`},
+		{"missing pattern", `{"id":3,"method":"search","params":{}}`, 3, "pattern is required"},
+		{"bad case", `{"id":4,"method":"search","params":{"pattern":"x","case":"loud"}}`, 4, "invalid case"},
+		{"bad params", `{"id":5,"method":"preview","params":[1]}`, 5, "invalid params"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, sc := dial(t)
+			sendRequest(t, conn, tt.request)
+
+			resp := readResponse(t, sc)
+			if resp.ID != tt.wantID || !strings.Contains(resp.Error, tt.wantErr) {
+				t.Errorf("response = %+v, want id %d and error containing %q", resp, tt.wantID, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListen_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "irg.sock")
+
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Error("expected an error while a server is listening")
+	}
+
+	// Leave the socket file behind, as a crashed server would
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	ln, err = Listen(path)
+	if err != nil {
+		t.Fatalf("Listen over stale socket: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,159 @@
+// Package server exposes irg's search, preview and type listing to other
+// programs, such as editor plugins, without the TUI.
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// defaultLimit caps the matches returned by one search unless the request
+// asks for a different limit
+const defaultLimit = 10000
+
+// defaultPreviewContext is the number of lines shown around a previewed line
+const defaultPreviewContext = 5
+
+// SearchParams are the parameters of the "search" method
+type SearchParams struct {
+	Pattern    string   `json:"pattern"`
+	Path       string   `json:"path,omitempty"`
+	Case       string   `json:"case,omitempty"` // smart (default), sensitive, insensitive
+	Types      []string `json:"types,omitempty"`
+	TypesNot   []string `json:"types_not,omitempty"`
+	GitTracked bool     `json:"git_tracked,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+}
+
+// PreviewParams are the parameters of the "preview" method
+type PreviewParams struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Context int    `json:"context,omitempty"`
+}
+
+// Match is a search result as sent to clients. Columns are 1-based byte
+// offsets; End is exclusive.
+type Match struct {
+	Path       string     `json:"path"`
+	Line       int        `json:"line"`
+	Text       string     `json:"text"`
+	Submatches []Submatch `json:"submatches"`
+}
+
+// Submatch is one matched range within a Match
+type Submatch struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Preview is the result of the "preview" method
+type Preview struct {
+	Path      string   `json:"path"`
+	StartLine int      `json:"start_line"`
+	Lines     []string `json:"lines"`
+}
+
+// Service runs requests; it holds no per-client state and is safe for
+// concurrent use
+type Service struct {
+	cache *search.FileCache
+}
+
+// NewService creates a service
+func NewService() *Service {
+	return &Service{cache: search.NewFileCache()}
+}
+
+// Search runs a search and calls emit for each match until the search ends,
+// the limit is hit, or ctx is cancelled. It returns the number of matches
+// emitted.
+func (s *Service) Search(ctx context.Context, p SearchParams, emit func(Match) error) (int, error) {
+	if p.Pattern == "" {
+		return 0, fmt.Errorf("pattern is required")
+	}
+	caseSensitivity, err := parseCase(p.Case)
+	if err != nil {
+		return 0, err
+	}
+	limit := p.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := search.Options{
+		CaseSensitivity: caseSensitivity,
+		FileTypes:       p.Types,
+		FileTypesNot:    p.TypesNot,
+		GitTracked:      p.GitTracked,
+	}
+	results := make(chan search.Match, 100)
+	if err := search.NewRipgrepSearcher().Search(searchCtx, p.Pattern, p.Path, opts, results); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for m := range results {
+		if count >= limit {
+			// Stop rg, then drain until the channel closes
+			cancel()
+			continue
+		}
+		if err := emit(toMatch(m)); err != nil {
+			cancel()
+			return count, err
+		}
+		count++
+	}
+	// Hitting the limit cancels only searchCtx, so this reports just the
+	// caller's cancellation
+	return count, ctx.Err()
+}
+
+// Preview returns the lines around p.Line
+func (s *Service) Preview(p PreviewParams) (Preview, error) {
+	if p.Path == "" || p.Line < 1 {
+		return Preview{}, fmt.Errorf("path and a line >= 1 are required")
+	}
+	contextLines := p.Context
+	if contextLines <= 0 {
+		contextLines = defaultPreviewContext
+	}
+	fc, err := s.cache.GetFileContextWithMatches(p.Path, p.Line, contextLines, nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	return Preview{Path: p.Path, StartLine: fc.StartLine, Lines: fc.Lines}, nil
+}
+
+// Types lists the file types ripgrep knows about
+func (s *Service) Types() ([]string, error) {
+	return search.LoadRipgrepTypes()
+}
+
+func parseCase(mode string) (search.CaseSensitivity, error) {
+	if mode == "" {
+		return search.CaseSmart, nil
+	}
+	return search.ParseCaseSensitivity(mode)
+}
+
+func toMatch(m search.Match) Match {
+	out := Match{
+		Path:       m.Path,
+		Line:       m.LineNumber,
+		Text:       strings.TrimRight(m.LineText, "\r\n"),
+		Submatches: make([]Submatch, len(m.Submatches)),
+	}
+	for i, sm := range m.Submatches {
+		out.Submatches[i] = Submatch{Text: sm.Match, Start: sm.Start + 1, End: sm.End + 1}
+	}
+	return out
+}
@@ -0,0 +1,13 @@
+# irg key binding for bash: Ctrl+G inserts the selected path:line
+# Extra flags come from $IRG_WIDGET_OPTS, e.g. IRG_WIDGET_OPTS=--tmux-popup
+# Add to ~/.bashrc:  eval "$(irg --shell-init bash)"
+
+__irg_widget() {
+  local selected
+  selected="$(irg --select $IRG_WIDGET_OPTS)" || return
+  selected="$(printf '%q' "$selected")"
+  READLINE_LINE="${READLINE_LINE:0:$READLINE_POINT}${selected}${READLINE_LINE:$READLINE_POINT}"
+  READLINE_POINT=$((READLINE_POINT + ${#selected}))
+}
+bind -m emacs-standard -x '"\C-g": __irg_widget'
+bind -m vi-insert -x '"\C-g": __irg_widget'
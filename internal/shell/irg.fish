@@ -0,0 +1,15 @@
+# irg key binding for fish: Ctrl+G inserts the selected path:line
+# Extra flags come from $IRG_WIDGET_OPTS, e.g. IRG_WIDGET_OPTS=--tmux-popup
+# Add to ~/.config/fish/config.fish:  irg --shell-init fish | source
+
+function irg-widget
+    set -l selected (irg --select (string split -n " " -- "$IRG_WIDGET_OPTS"))
+    if test -n "$selected"
+        commandline -it -- (string escape -- $selected)
+    end
+    commandline -f repaint
+end
+bind \cg irg-widget
+if bind -M insert >/dev/null 2>&1
+    bind -M insert \cg irg-widget
+end
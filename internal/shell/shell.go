@@ -0,0 +1,34 @@
+// Package shell provides the key binding scripts printed by
+// `irg --shell-init`, which insert the selected match into the command line.
+package shell
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed irg.zsh
+var zshInit string
+
+//go:embed irg.bash
+var bashInit string
+
+//go:embed irg.fish
+var fishInit string
+
+// Shells lists the supported shells
+var Shells = []string{"bash", "fish", "zsh"}
+
+// Init returns the key binding script for shell
+func Init(shell string) (string, error) {
+	switch strings.ToLower(shell) {
+	case "zsh":
+		return zshInit, nil
+	case "bash":
+		return bashInit, nil
+	case "fish":
+		return fishInit, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (want one of: %s)", shell, strings.Join(Shells, ", "))
+}
@@ -0,0 +1,24 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	for _, sh := range Shells {
+		t.Run(sh, func(t *testing.T) {
+			script, err := Init(strings.ToUpper(sh))
+			if err != nil {
+				t.Fatalf("Init: %v", err)
+			}
+			if !strings.Contains(script, "irg --select") {
+				t.Errorf("%s script doesn't run irg --select", sh)
+			}
+		})
+	}
+
+	if _, err := Init("tcsh"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
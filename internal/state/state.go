@@ -0,0 +1,150 @@
+// Package state persists session state, such as the last chosen case mode,
+// per project between runs. Unlike config, it is written by irg itself.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/William9923/irg/internal/store"
+)
+
+// Project is the state kept for one project. Every field is optional.
+type Project struct {
+	// CaseMode is the case mode last chosen with the toggle-case action
+	CaseMode string `json:"case_mode,omitempty"`
+}
+
+// file is the on-disk format, keyed by project root
+type file struct {
+	Projects map[string]Project `json:"projects"`
+}
+
+// Store reads and writes the state file. A nil *Store reads as empty and
+// discards writes.
+type Store struct {
+	path string
+
+	mu   sync.Mutex
+	data file
+}
+
+// Path returns the state file location: $IRG_STATE, else
+// $XDG_STATE_HOME/irg/state.json, else ~/.local/state/irg/state.json
+func Path() (string, error) {
+	if path := os.Getenv("IRG_STATE"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "irg", "state.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "irg", "state.json"), nil
+}
+
+// Open reads the state at Path. A missing file yields an empty store.
+func Open() (*Store, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return OpenFile(path)
+}
+
+// OpenFile reads the state at path. A missing file yields an empty store.
+func OpenFile(path string) (*Store, error) {
+	s := &Store{path: path, data: file{Projects: map[string]Project{}}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("read state %s: %w", path, err)
+	}
+	if s.data.Projects == nil {
+		s.data.Projects = map[string]Project{}
+	}
+	return s, nil
+}
+
+// Project returns the state for the project rooted at root
+func (s *Store) Project(root string) Project {
+	if s == nil {
+		return Project{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Projects[root]
+}
+
+// UpdateProject applies update to the project's state and saves the file.
+// The update is applied to the file as it is on disk, so changes saved by
+// other irg instances since Open are kept.
+func (s *Store) UpdateProject(root string, update func(*Project)) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := store.Update(s.path, 0o644, func(raw []byte) ([]byte, error) {
+		data := file{Projects: map[string]Project{}}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &data); err != nil {
+				return nil, fmt.Errorf("read state %s: %w", s.path, err)
+			}
+			if data.Projects == nil {
+				data.Projects = map[string]Project{}
+			}
+		}
+
+		p := data.Projects[root]
+		update(&p)
+		if p == (Project{}) {
+			delete(data.Projects, root)
+		} else {
+			data.Projects[root] = p
+		}
+		s.data = data
+
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	})
+	if err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+// ProjectRoot returns the directory that identifies dir's project: the
+// nearest enclosing git work tree, or dir itself, as an absolute path
+func ProjectRoot(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return abs
+		}
+		d = parent
+	}
+}
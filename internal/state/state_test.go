@@ -0,0 +1,102 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFile_MissingIsEmpty(t *testing.T) {
+	s, err := OpenFile(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if got := s.Project("/src/irg"); got != (Project{}) {
+		t.Errorf("got %+v, want empty project", got)
+	}
+}
+
+func TestStore_UpdateProjectPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateProject("/src/irg", func(p *Project) { p.CaseMode = "sensitive" }); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if got := reopened.Project("/src/irg").CaseMode; got != "sensitive" {
+		t.Errorf("case mode = %q, want sensitive", got)
+	}
+	if got := reopened.Project("/src/other").CaseMode; got != "" {
+		t.Errorf("other project case mode = %q, want empty", got)
+	}
+}
+
+func TestStore_UpdateKeepsOtherInstancesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	first, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.UpdateProject("/src/a", func(p *Project) { p.CaseMode = "sensitive" }); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	// second was opened before first saved, but must not drop its change
+	if err := second.UpdateProject("/src/b", func(p *Project) { p.CaseMode = "insensitive" }); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if a, b := reopened.Project("/src/a").CaseMode, reopened.Project("/src/b").CaseMode; a != "sensitive" || b != "insensitive" {
+		t.Errorf("case modes = %q, %q; want sensitive, insensitive", a, b)
+	}
+}
+
+func TestStore_NilIsNoop(t *testing.T) {
+	var s *Store
+	if err := s.UpdateProject("/src/irg", func(p *Project) { p.CaseMode = "smart" }); err != nil {
+		t.Errorf("UpdateProject: %v", err)
+	}
+	if got := s.Project("/src/irg"); got != (Project{}) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestOpenFile_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(path); err == nil {
+		t.Error("expected an error for a corrupt state file")
+	}
+}
+
+func TestProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "internal", "ui")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ProjectRoot(sub); got != root {
+		t.Errorf("ProjectRoot(%q) = %q, want %q", sub, got, root)
+	}
+}
@@ -0,0 +1,194 @@
+// Package stats keeps local usage statistics: the directories searched, the
+// types searches are filtered by and how many results they find, so that
+// `irg stats` can help pick better scopes and defaults. The statistics never
+// leave the machine, and patterns aren't recorded.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/William9923/irg/internal/store"
+)
+
+// maxEntries bounds the directories and types kept; past it the least
+// searched are dropped
+const maxEntries = 500
+
+// Search is one search to count
+type Search struct {
+	Dir     string // Absolute path searched; "" when not a single directory
+	Types   []string
+	Results int
+}
+
+// Stats are the totals in the stats file
+type Stats struct {
+	Since    time.Time      `json:"since"`
+	Searches int            `json:"searches"`
+	Results  int64          `json:"results"`
+	Dirs     map[string]int `json:"dirs"`  // Searches per directory
+	Types    map[string]int `json:"types"` // Searches per type filter
+}
+
+// Average returns the mean number of results per search
+func (s Stats) Average() float64 {
+	if s.Searches == 0 {
+		return 0
+	}
+	return float64(s.Results) / float64(s.Searches)
+}
+
+// Count is a directory or type and how many searches used it
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Top returns the n most used entries of counts, most used first
+func Top(counts map[string]int, n int) []Count {
+	top := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		top = append(top, Count{Name: name, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Name < top[j].Name
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// Path returns the stats file location: $IRG_STATS, else
+// $XDG_STATE_HOME/irg/stats.json, else ~/.local/state/irg/stats.json
+func Path() (string, error) {
+	if path := os.Getenv("IRG_STATS"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "irg", "stats.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "irg", "stats.json"), nil
+}
+
+// Read returns the stats in the file at path. A missing file yields empty
+// stats.
+func Read(path string) (Stats, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Stats{}, nil
+	}
+	if err != nil {
+		return Stats{}, fmt.Errorf("read stats: %w", err)
+	}
+	return parse(path, raw)
+}
+
+func parse(path string, raw []byte) (Stats, error) {
+	var s Stats
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return Stats{}, fmt.Errorf("read stats %s: %w", path, err)
+		}
+	}
+	if s.Dirs == nil {
+		s.Dirs = map[string]int{}
+	}
+	if s.Types == nil {
+		s.Types = map[string]int{}
+	}
+	return s, nil
+}
+
+// Record adds search to the stats file at path, keeping what other irg
+// instances recorded
+func Record(path string, search Search) error {
+	err := store.Update(path, 0o644, func(raw []byte) ([]byte, error) {
+		s, err := parse(path, raw)
+		if err != nil {
+			return nil, err
+		}
+		if s.Since.IsZero() {
+			s.Since = time.Now().UTC().Truncate(time.Second)
+		}
+		s.Searches++
+		s.Results += int64(search.Results)
+		if search.Dir != "" {
+			s.Dirs[search.Dir]++
+			prune(s.Dirs)
+		}
+		for _, t := range search.Types {
+			s.Types[t]++
+		}
+		prune(s.Types)
+
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	})
+	if err != nil {
+		return fmt.Errorf("save stats: %w", err)
+	}
+	return nil
+}
+
+// Reset deletes the stats file at path
+func Reset(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reset stats: %w", err)
+	}
+	return nil
+}
+
+// prune drops the least used entries of counts beyond maxEntries
+func prune(counts map[string]int) {
+	if len(counts) <= maxEntries {
+		return
+	}
+	for _, c := range Top(counts, len(counts))[maxEntries:] {
+		delete(counts, c.Name)
+	}
+}
+
+// Print writes a report of s to w, with the n most searched directories
+// and types
+func Print(w io.Writer, s Stats, n int) error {
+	if s.Searches == 0 {
+		_, err := fmt.Fprintln(w, "No searches recorded yet")
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d searches since %s, %.1f results on average\n", s.Searches, s.Since.Local().Format("2006-01-02"), s.Average())
+	section := func(title string, counts map[string]int) {
+		top := Top(counts, n)
+		if len(top) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, c := range top {
+			fmt.Fprintf(&b, "%6d  %5.1f%%  %s\n", c.Count, 100*float64(c.Count)/float64(s.Searches), c.Name)
+		}
+	}
+	section("Top directories", s.Dirs)
+	section("Top types", s.Types)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
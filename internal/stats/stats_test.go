@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRead_MissingIsEmpty(t *testing.T) {
+	s, err := Read(filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if s.Searches != 0 || s.Average() != 0 || len(s.Dirs) != 0 {
+		t.Errorf("got %+v, want empty stats", s)
+	}
+}
+
+func TestRecord_Accumulates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "stats.json")
+	searches := []Search{
+		{Dir: "/src/irg", Types: []string{"go"}, Results: 10},
+		{Dir: "/src/irg", Types: []string{"go", "md"}, Results: 20},
+		{Dir: "/src/web", Results: 0},
+		{Results: 6}, // --paths-from: no single directory
+	}
+	for _, s := range searches {
+		if err := Record(path, s); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	s, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Searches != 4 || s.Average() != 9 {
+		t.Errorf("searches = %d, average = %.1f; want 4, 9", s.Searches, s.Average())
+	}
+	if s.Since.IsZero() {
+		t.Error("start of the stats not recorded")
+	}
+	wantDirs := []Count{{"/src/irg", 2}, {"/src/web", 1}}
+	if got := Top(s.Dirs, 10); !reflect.DeepEqual(got, wantDirs) {
+		t.Errorf("top dirs = %v, want %v", got, wantDirs)
+	}
+	if got := Top(s.Types, 1); !reflect.DeepEqual(got, []Count{{"go", 2}}) {
+		t.Errorf("top type = %v, want go", got)
+	}
+
+	if err := Reset(path); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := Read(path); s.Searches != 0 {
+		t.Errorf("%d searches after a reset", s.Searches)
+	}
+}
+
+func TestPrune_KeepsMostUsed(t *testing.T) {
+	counts := map[string]int{"busy": 5}
+	for i := 0; i < maxEntries; i++ {
+		counts[fmt.Sprintf("dir%03d", i)] = 1
+	}
+	prune(counts)
+	if len(counts) != maxEntries || counts["busy"] != 5 {
+		t.Errorf("kept %d entries, busy = %d; want %d with busy kept", len(counts), counts["busy"], maxEntries)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	s := Stats{
+		Since:    time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Searches: 4,
+		Results:  50,
+		Dirs:     map[string]int{"/src/irg": 3, "/src/web": 1},
+		Types:    map[string]int{"go": 2},
+	}
+	var out strings.Builder
+	if err := Print(&out, s, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"4 searches since 2026-10-01, 12.5 results on average", "3   75.0%  /src/irg", "2   50.0%  go"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "/src/web") {
+		t.Errorf("report lists more than the top directory:\n%s", out.String())
+	}
+}
@@ -0,0 +1,125 @@
+// Package store writes the files irg persists, such as the config saved from
+// the settings screen and session state, so that a crash or a second irg
+// instance never leaves one half-written or loses an update.
+//
+// Each write goes to a temporary file in the same directory, which is
+// synced and then renamed over the target, so readers see either the old
+// or the new contents. Writers hold an exclusive lock on a sidecar
+// PATH.lock file while they read, change and replace the file.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout bounds how long a write waits for another instance's lock
+const lockTimeout = 5 * time.Second
+
+// lockRetry is how often a held lock is tried again
+const lockRetry = 10 * time.Millisecond
+
+// WriteFile atomically replaces the file at path with data, creating it and
+// its directory when they don't exist
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return Update(path, perm, func([]byte) ([]byte, error) {
+		return data, nil
+	})
+}
+
+// Update replaces the file at path with the result of change, which gets
+// the current contents, or nil when the file doesn't exist. No other
+// Update of the same path runs in between, in this or another process.
+// When change fails the file is left untouched. A symlink, as dotfile
+// managers create, is followed so the link itself stays in place, and an
+// existing file keeps its permissions.
+func Update(path string, perm fs.FileMode, change func(old []byte) ([]byte, error)) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+
+	unlock, err := lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	data, err := change(old)
+	if err != nil {
+		return err
+	}
+	if err := replace(path, data, perm); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// replace writes data to a temporary file next to path and renames it over
+// path
+func replace(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Clean up after any failure; after the rename this finds nothing
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lock takes the exclusive lock on the file at path, waiting up to
+// lockTimeout for another holder to release it
+func lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%s is locked by another irg", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
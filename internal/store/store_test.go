@@ -0,0 +1,109 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWriteFile_CreatesDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "irg", "state.json")
+	if err := WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "{}\n" {
+		t.Errorf("read %q, %v", got, err)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "state.json" && e.Name() != "state.json.lock" {
+			t.Errorf("stray file %s", e.Name())
+		}
+	}
+}
+
+func TestUpdate_FailedChangeKeepsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	errBad := errors.New("bad")
+	err := Update(path, 0o644, func(old []byte) ([]byte, error) {
+		if string(old) != "old" {
+			t.Errorf("change got %q, want old", old)
+		}
+		return nil, errBad
+	})
+	if !errors.Is(err, errBad) {
+		t.Fatalf("Update = %v, want %v", err, errBad)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "old" {
+		t.Errorf("file is %q after a failed change", got)
+	}
+}
+
+func TestUpdate_ConcurrentWritersKeepEveryChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	const writers = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, 0o644, func(old []byte) ([]byte, error) {
+				n, _ := strconv.Atoi(string(old))
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, _ := os.ReadFile(path); string(got) != strconv.Itoa(writers) {
+		t.Errorf("counter = %q, want %d", got, writers)
+	}
+}
+
+func TestUpdate_FollowsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config.toml")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(link, []byte("new"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link replaced: %v, %v", info, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("target mode = %v, %v; want 0600 kept", info, err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Errorf("target = %q, want new", got)
+	}
+}
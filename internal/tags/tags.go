@@ -0,0 +1,281 @@
+// Package tags looks up symbol definitions in ctags-format tags files, or by
+// running universal-ctags when no tags file exists.
+package tags
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tagsFileNames are the file names searched for, in order of preference
+var tagsFileNames = []string{"tags", ".tags", "gotags"}
+
+// ErrNoTags is returned when neither a tags file nor ctags is available
+var ErrNoTags = errors.New("no tags file found and ctags is not installed")
+
+// ErrNotFound is returned when a symbol has no definitions
+var ErrNotFound = errors.New("not found in tags")
+
+// Tag is a single symbol definition
+type Tag struct {
+	Name string
+	Path string // Relative to the current directory when possible
+	Line int    // 1-based; 0 if only a search pattern is known
+	Kind string
+
+	pattern string
+}
+
+// Index resolves symbols to their definitions
+type Index struct {
+	root string // Directory tags paths are relative to
+
+	mu     sync.Mutex
+	loaded bool
+	tags   map[string][]Tag
+	err    error
+}
+
+// NewIndex creates an index for the tags file nearest to dir, walking up
+// towards the filesystem root. The file is read lazily on first lookup.
+func NewIndex(dir string) *Index {
+	return &Index{root: dir}
+}
+
+// Lookup returns every definition of symbol, or ErrNotFound
+func (idx *Index) Lookup(ctx context.Context, symbol string) ([]Tag, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.loaded {
+		idx.tags, idx.err = idx.load(ctx)
+		idx.loaded = idx.err == nil
+	}
+	if idx.err != nil {
+		return nil, idx.err
+	}
+
+	defs := idx.tags[symbol]
+	if len(defs) == 0 {
+		return nil, ErrNotFound
+	}
+	resolved := make([]Tag, 0, len(defs))
+	for _, t := range defs {
+		if t.Line == 0 && t.pattern != "" {
+			t.Line = findPattern(t.Path, t.pattern)
+		}
+		resolved = append(resolved, t)
+	}
+	return resolved, nil
+}
+
+// Reload forces the tags to be re-read on the next lookup
+func (idx *Index) Reload() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.loaded = false
+	idx.tags = nil
+	idx.err = nil
+}
+
+func (idx *Index) load(ctx context.Context) (map[string][]Tag, error) {
+	if path, ok := findTagsFile(idx.root); ok {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", path, err)
+		}
+		defer file.Close()
+		return parse(file, filepath.Dir(path))
+	}
+
+	ctags, err := exec.LookPath("ctags")
+	if err != nil {
+		return nil, ErrNoTags
+	}
+	// Only universal-ctags supports writing to stdout with line numbers
+	cmd := exec.CommandContext(ctx, ctags, "-R", "--fields=+n", "-f", "-", ".")
+	cmd.Dir = idx.root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ctags: %w", err)
+	}
+	return parse(bytes.NewReader(output), idx.root)
+}
+
+// findTagsFile walks up from dir looking for a tags file
+func findTagsFile(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		for _, name := range tagsFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// parse reads ctags lines of the form `name<TAB>file<TAB>address;"<TAB>fields`
+func parse(r io.Reader, baseDir string) (map[string][]Tag, error) {
+	cwd, _ := os.Getwd()
+	tags := make(map[string][]Tag)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "!_TAG_") || line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+
+		t := Tag{Name: fields[0], Path: relativePath(baseDir, fields[1], cwd)}
+
+		address := fields[2]
+		extra := fields[3:]
+		if i := strings.Index(address, ";\""); i >= 0 {
+			address = address[:i]
+		} else if len(fields) > 3 {
+			// A search pattern may contain tabs; re-join up to the terminator
+			rest := strings.Join(fields[2:], "\t")
+			if i := strings.Index(rest, ";\""); i >= 0 {
+				address = rest[:i]
+				extra = strings.Split(strings.TrimPrefix(rest[i+2:], "\t"), "\t")
+			}
+		}
+
+		if n, err := strconv.Atoi(address); err == nil {
+			t.Line = n
+		} else {
+			t.pattern = address
+		}
+
+		for _, f := range extra {
+			key, value, ok := strings.Cut(f, ":")
+			switch {
+			case !ok && len(f) == 1:
+				t.Kind = f
+			case key == "line":
+				if n, err := strconv.Atoi(value); err == nil {
+					t.Line = n
+				}
+			case key == "kind":
+				t.Kind = value
+			}
+		}
+
+		tags[t.Name] = append(tags[t.Name], t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read tags: %w", err)
+	}
+	return tags, nil
+}
+
+// relativePath resolves a tags file entry against the tags file's directory
+// and makes it relative to cwd so it matches ripgrep's result paths
+func relativePath(baseDir, file, cwd string) string {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(baseDir, file)
+	}
+	if cwd != "" {
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return file
+}
+
+// findPattern returns the line number matching a ctags search pattern such
+// as /^func main() {$/, or 0 if it can't be found
+func findPattern(path, pattern string) int {
+	if len(pattern) < 2 {
+		return 0
+	}
+	delim := pattern[0]
+	if delim != '/' && delim != '?' {
+		return 0
+	}
+	pattern = strings.TrimSuffix(pattern[1:], string(delim))
+
+	anchoredStart := strings.HasPrefix(pattern, "^")
+	anchoredEnd := strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, "\\$")
+	pattern = strings.TrimPrefix(pattern, "^")
+	if anchoredEnd {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+	pattern = strings.NewReplacer("\\/", "/", "\\?", "?", "\\\\", "\\", "\\$", "$").Replace(pattern)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		switch {
+		case anchoredStart && anchoredEnd:
+			if line == pattern {
+				return lineNum
+			}
+		case anchoredStart:
+			if strings.HasPrefix(line, pattern) {
+				return lineNum
+			}
+		default:
+			if strings.Contains(line, pattern) {
+				return lineNum
+			}
+		}
+	}
+	return 0
+}
+
+// SymbolAt returns the identifier surrounding byte offset pos in line
+func SymbolAt(line string, pos int) string {
+	if pos < 0 || pos > len(line) {
+		return ""
+	}
+	start := pos
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+	end := pos
+	for end < len(line) && isIdentByte(line[end]) {
+		end++
+	}
+	return line[start:end]
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' ||
+		(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') ||
+		b >= 0x80
+}
@@ -0,0 +1,100 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse_Formats(t *testing.T) {
+	input := strings.Join([]string{
+		"!_TAG_FILE_FORMAT\t2\t/extended format/",
+		"NewModel\tinternal/ui/model.go\t/^func NewModel() Model {$/;\"\tf\tline:146",
+		"Searcher\tinternal/search/ripgrep.go\t42;\"\tt",
+		"helper\tutil.go\t/^func helper() {$/;\"\tkind:function",
+	}, "\n")
+
+	got, err := parse(strings.NewReader(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		wantPath string
+		wantLine int
+		wantKind string
+	}{
+		{"NewModel", "internal/ui/model.go", 146, "f"},
+		{"Searcher", "internal/search/ripgrep.go", 42, "t"},
+		{"helper", "util.go", 0, "function"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs := got[tt.name]
+			if len(defs) != 1 {
+				t.Fatalf("got %d definitions, want 1", len(defs))
+			}
+			d := defs[0]
+			if filepath.ToSlash(d.Path) != tt.wantPath || d.Line != tt.wantLine || d.Kind != tt.wantKind {
+				t.Errorf("got %+v, want path=%s line=%d kind=%s", d, tt.wantPath, tt.wantLine, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestIndex_LookupResolvesPattern(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\n// helper does things\nfunc helper() {\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tagsFile := "helper\tmain.go\t/^func helper() {$/;\"\tf\n"
+	if err := os.WriteFile(filepath.Join(dir, "tags"), []byte(tagsFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "pkg")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tags file is found by walking up from a subdirectory
+	idx := NewIndex(sub)
+	defs, err := idx.Lookup(context.Background(), "helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 1 || defs[0].Line != 4 {
+		t.Fatalf("got %+v, want one definition at line 4", defs)
+	}
+	if filepath.Base(defs[0].Path) != "main.go" {
+		t.Errorf("Path = %s, want main.go", defs[0].Path)
+	}
+
+	if _, err := idx.Lookup(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing symbol error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSymbolAt(t *testing.T) {
+	tests := []struct {
+		line string
+		pos  int
+		want string
+	}{
+		{"m := NewModel()", 5, "NewModel"},
+		{"m := NewModel()", 9, "NewModel"},
+		{"foo.bar_baz(x)", 4, "bar_baz"},
+		{"a + b", 2, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := SymbolAt(tt.line, tt.pos); got != tt.want {
+			t.Errorf("SymbolAt(%q, %d) = %q, want %q", tt.line, tt.pos, got, tt.want)
+		}
+	}
+}
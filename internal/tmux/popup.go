@@ -0,0 +1,96 @@
+// Package tmux runs irg inside a tmux popup and hands the selection back to
+// the calling pane.
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/William9923/irg/internal/editor"
+)
+
+// DefaultSize is the popup size used when none is given
+const DefaultSize = "80%"
+
+// InTmux reports whether irg is running inside a tmux session
+func InTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// ParseSize splits a size such as "80%" or "120x40" or "90%x60%" into
+// width and height
+func ParseSize(size string) (width, height string, err error) {
+	if size == "" {
+		size = DefaultSize
+	}
+	width, height, found := strings.Cut(size, "x")
+	if !found {
+		height = width
+	}
+	for _, v := range []string{width, height} {
+		if !validDimension(v) {
+			return "", "", fmt.Errorf("invalid popup size %q: want N, N%%, or WxH", size)
+		}
+	}
+	return width, height, nil
+}
+
+func validDimension(v string) bool {
+	v = strings.TrimSuffix(v, "%")
+	if v == "" {
+		return false
+	}
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// PopupArgs returns the tmux arguments that run command (already quoted for
+// sh) in a popup of the given size, starting in dir
+func PopupArgs(command, dir, width, height string) []string {
+	return []string{"display-popup", "-E", "-d", dir, "-w", width, "-h", height, command}
+}
+
+// Popup runs executable with args in a tmux popup and returns what it wrote
+// to stdout. The popup's terminal shows the UI; stdout is redirected to a
+// temp file since tmux doesn't pass it back.
+func Popup(executable string, args []string, size string) (string, error) {
+	width, height, err := ParseSize(size)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("get working directory: %w", err)
+	}
+
+	out, err := os.CreateTemp("", "irg-popup-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	quoted := []string{editor.ShellQuote(executable)}
+	for _, a := range args {
+		quoted = append(quoted, editor.ShellQuote(a))
+	}
+	command := strings.Join(quoted, " ") + " > " + editor.ShellQuote(out.Name())
+
+	cmd := exec.Command("tmux", PopupArgs(command, dir, width, height)...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tmux display-popup (tmux 3.2+ required): %w", err)
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		return "", fmt.Errorf("read popup output: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,40 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size       string
+		wantWidth  string
+		wantHeight string
+		wantErr    bool
+	}{
+		{"", "80%", "80%", false},
+		{"60%", "60%", "60%", false},
+		{"120x40", "120", "40", false},
+		{"90%x50%", "90%", "50%", false},
+		{"big", "", "", true},
+		{"80%x", "", "", true},
+	}
+	for _, tt := range tests {
+		w, h, err := ParseSize(tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) err = %v, wantErr %v", tt.size, err, tt.wantErr)
+			continue
+		}
+		if w != tt.wantWidth || h != tt.wantHeight {
+			t.Errorf("ParseSize(%q) = %s, %s; want %s, %s", tt.size, w, h, tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
+
+func TestPopupArgs(t *testing.T) {
+	got := PopupArgs("'irg' '--select' > '/tmp/out'", "/src", "80%", "60%")
+	want := []string{"display-popup", "-E", "-d", "/src", "-w", "80%", "-h", "60%", "'irg' '--select' > '/tmp/out'"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,279 @@
+package todos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/editor"
+	"github.com/William9923/irg/internal/search"
+)
+
+// collectedMsg carries the items of a finished scan
+type collectedMsg struct {
+	run   int
+	items []Item
+	err   error
+}
+
+type editorFinishedMsg struct{ err error }
+
+// scanMsg starts a scan
+type scanMsg struct{}
+
+// row is a line of the list: a group, or one of its items when it's
+// expanded
+type row struct {
+	group int
+	item  int // -1 for the group itself
+}
+
+// Model is the Bubble Tea model of the TODO dashboard: the groups with
+// their counts, each expandable to its items, which open in the editor
+type Model struct {
+	path          string
+	tags          []string
+	opts          search.Options
+	editorCommand string // "" uses $EDITOR
+
+	groups   []Group
+	total    int
+	expanded map[[2]string]bool // Expanded groups by tag and owner, kept across scans
+	cursor   int
+
+	run      int // Items from older scans are dropped
+	cancel   context.CancelFunc
+	scanning bool
+	err      error
+	width    int
+	height   int
+}
+
+// New returns a dashboard of the comments under path tagged with any of
+// tags, opening items in editorCommand, or the editor from the environment
+// when it's ""
+func New(path string, tags []string, opts search.Options, editorCommand string) Model {
+	if path == "" {
+		path = "."
+	}
+	return Model{
+		path:          path,
+		tags:          tags,
+		opts:          opts,
+		editorCommand: editorCommand,
+		expanded:      make(map[[2]string]bool),
+		width:         80,
+		height:        24,
+	}
+}
+
+// Groups returns the groups of the last scan
+func (m Model) Groups() []Group {
+	return m.groups
+}
+
+// Init starts the first scan through Update, where the run can be recorded
+func (m Model) Init() tea.Cmd {
+	return func() tea.Msg { return scanMsg{} }
+}
+
+// rescan collects the items again, keeping the expanded groups
+func (m *Model) rescan() tea.Cmd {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.run++
+	m.scanning = true
+	run, path, tags, opts := m.run, m.path, m.tags, m.opts
+	return func() tea.Msg {
+		items, err := Collect(ctx, search.NewRipgrepSearcher(), path, tags, opts)
+		return collectedMsg{run: run, items: items, err: err}
+	}
+}
+
+// rows lists the groups and the items of the expanded ones
+func (m Model) rows() []row {
+	var rows []row
+	for i, g := range m.groups {
+		rows = append(rows, row{group: i, item: -1})
+		if m.expanded[[2]string{g.Tag, g.Owner}] {
+			for j := range g.Items {
+				rows = append(rows, row{group: i, item: j})
+			}
+		}
+	}
+	return rows
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.updateKey(msg)
+
+	case scanMsg:
+		return m, m.rescan()
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case collectedMsg:
+		if msg.run != m.run {
+			return m, nil
+		}
+		m.scanning = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.groups = Groups(msg.items, m.tags)
+			m.total = len(msg.items)
+		}
+		m.cursor = min(m.cursor, max(len(m.rows())-1, 0))
+
+	case editorFinishedMsg:
+		m.err = msg.err
+	}
+	return m, nil
+}
+
+func (m Model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := m.rows()
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		if m.cancel != nil {
+			m.cancel()
+		}
+		return m, tea.Quit
+	case "r":
+		return m, m.rescan()
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(rows)-1, 0))
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = max(len(rows)-1, 0)
+	case "left", "h":
+		if m.cursor < len(rows) {
+			r := rows[m.cursor]
+			g := m.groups[r.group]
+			delete(m.expanded, [2]string{g.Tag, g.Owner})
+			// Stay on the group the item was in
+			m.cursor -= r.item + 1
+		}
+	case "right", "l":
+		if m.cursor < len(rows) && rows[m.cursor].item < 0 {
+			g := m.groups[rows[m.cursor].group]
+			m.expanded[[2]string{g.Tag, g.Owner}] = true
+		}
+	case "enter", " ":
+		if m.cursor >= len(rows) {
+			break
+		}
+		r := rows[m.cursor]
+		g := m.groups[r.group]
+		if r.item >= 0 {
+			return m, m.open(g.Items[r.item])
+		}
+		key := [2]string{g.Tag, g.Owner}
+		m.expanded[key] = !m.expanded[key]
+		if !m.expanded[key] {
+			delete(m.expanded, key)
+		}
+	}
+	return m, nil
+}
+
+// open opens item in the editor at its line
+func (m *Model) open(item Item) tea.Cmd {
+	ed, err := editor.GetEditor()
+	if m.editorCommand != "" {
+		ed, err = editor.FromCommand(m.editorCommand)
+	}
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	return tea.ExecProcess(ed.BuildCommand(item.Match.Path, item.Match.LineNumber), func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
+
+func (m Model) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+	tagStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	selectedStyle := lipgloss.NewStyle().Reverse(true)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("irg todos: %d in %s", m.total, m.path)))
+	var counts []string
+	for _, tag := range m.tags {
+		n := 0
+		for _, g := range m.groups {
+			if g.Tag == tag {
+				n += len(g.Items)
+			}
+		}
+		counts = append(counts, fmt.Sprintf("%s %d", tag, n))
+	}
+	sb.WriteString(dimStyle.Render(" (" + strings.Join(counts, ", ") + ")"))
+	sb.WriteString("\n\n")
+
+	tagWidth, ownerWidth := 0, len("(unowned)")
+	for _, g := range m.groups {
+		tagWidth = max(tagWidth, ansi.StringWidth(g.Tag))
+		ownerWidth = max(ownerWidth, ansi.StringWidth(g.Owner))
+	}
+	ownerWidth = min(ownerWidth, 30)
+
+	// Scroll the list so the cursor stays in view
+	rows := m.rows()
+	visible := max(m.height-4, 1)
+	first := max(m.cursor-visible+1, 0)
+	for i := first; i < len(rows) && i < first+visible; i++ {
+		r := rows[i]
+		g := m.groups[r.group]
+		var line string
+		if r.item < 0 {
+			marker := "▸"
+			if m.expanded[[2]string{g.Tag, g.Owner}] {
+				marker = "▾"
+			}
+			owner := ansi.Truncate(ownerName(g.Owner), ownerWidth, "…")
+			line = fmt.Sprintf("%s %s %-*s %5d", marker, tagStyle.Render(fmt.Sprintf("%-*s", tagWidth, g.Tag)), ownerWidth, owner, len(g.Items))
+		} else {
+			item := g.Items[r.item]
+			location := dimStyle.Render(fmt.Sprintf("%s:%d", item.Match.Path, item.Match.LineNumber))
+			line = "    " + location + " " + item.Text
+		}
+		line = ansi.Truncate(line, m.width, "…")
+		if i == m.cursor {
+			line = selectedStyle.Render(ansi.Strip(line))
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	if len(rows) == 0 && !m.scanning && m.err == nil {
+		sb.WriteString(dimStyle.Render("No " + strings.Join(m.tags, ", ") + " comments found"))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	if m.err != nil {
+		sb.WriteString(errStyle.Render("Error: " + m.err.Error()))
+		sb.WriteString("\n")
+	}
+	status := ""
+	if m.scanning {
+		status = "Scanning... | "
+	}
+	sb.WriteString(dimStyle.Render(status + "↑/↓ move | enter expand/open | r (rescan) | q (quit)"))
+	return sb.String()
+}
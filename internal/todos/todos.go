@@ -0,0 +1,166 @@
+// Package todos collects the TODO, FIXME and similar comments of a tree and
+// groups them by tag and by the owner named in TODO(name), for `irg todos`.
+package todos
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// DefaultTags are the tags collected when none are configured
+var DefaultTags = []string{"TODO", "FIXME", "HACK"}
+
+// Item is one tagged comment
+type Item struct {
+	Tag   string
+	Owner string // From TODO(name); "" when none is named
+	Text  string // What follows the tag and owner
+	Match search.Match
+}
+
+// Group is the items of one tag and owner
+type Group struct {
+	Tag   string
+	Owner string
+	Items []Item
+}
+
+// Pattern returns the regex rg searches for: any of tags as a whole word
+func Pattern(tags []string) string {
+	quoted := make([]string, len(tags))
+	for i, tag := range tags {
+		quoted[i] = regexp.QuoteMeta(tag)
+	}
+	return `\b(?:` + strings.Join(quoted, "|") + `)\b`
+}
+
+// parser returns the regex splitting a line into the tag, the owner and
+// the text after them
+func parser(tags []string) *regexp.Regexp {
+	return regexp.MustCompile(`\b(` + strings.TrimPrefix(Pattern(tags), `\b(?:`) + `(?:\(([^)]*)\))?:?\s*(.*)`)
+}
+
+// Parse reads the first tagged comment in m's line, reporting false when
+// there is none
+func Parse(m search.Match, tags []string) (Item, bool) {
+	return parse(parser(tags), m)
+}
+
+func parse(re *regexp.Regexp, m search.Match) (Item, bool) {
+	sub := re.FindStringSubmatch(strings.TrimRight(m.LineText, "\r\n"))
+	if sub == nil {
+		return Item{}, false
+	}
+	text := strings.TrimSpace(sub[3])
+	// Block comment closers aren't part of the text
+	for _, closer := range []string{"*/", "-->", "#}", "%>"} {
+		text = strings.TrimSpace(strings.TrimSuffix(text, closer))
+	}
+	return Item{
+		Tag:   sub[1],
+		Owner: strings.TrimPrefix(strings.TrimSpace(sub[2]), "@"),
+		Text:  text,
+		Match: m,
+	}, true
+}
+
+// Collect searches path for comments tagged with any of tags and returns
+// them ordered by path and line. The tags are matched case-sensitively, so
+// words like "todo" in prose are left out.
+func Collect(ctx context.Context, s search.Searcher, path string, tags []string, opts search.Options) ([]Item, error) {
+	opts.CaseSensitivity = search.CaseSensitive
+	opts.FixedStrings = false
+	opts.WholeWord = false
+	opts.Multiline = false
+	opts.Context = 0
+	results := make(chan search.Match, 256)
+	if err := s.Search(ctx, Pattern(tags), path, opts, results); err != nil {
+		return nil, err
+	}
+	re := parser(tags)
+	var items []Item
+	for m := range results {
+		if item, ok := parse(re, m); ok {
+			items = append(items, item)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(items, func(a, b Item) int {
+		return cmp.Or(strings.Compare(a.Match.Path, b.Match.Path), a.Match.LineNumber-b.Match.LineNumber)
+	})
+	return items, nil
+}
+
+// Groups groups items by tag, in the order of tags, then by owner, named
+// owners alphabetically and the unowned last. Items keep their order.
+func Groups(items []Item, tags []string) []Group {
+	index := make(map[[2]string]int)
+	var groups []Group
+	for _, item := range items {
+		key := [2]string{item.Tag, item.Owner}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Tag: item.Tag, Owner: item.Owner})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		if c := slices.Index(tags, a.Tag) - slices.Index(tags, b.Tag); c != 0 {
+			return c
+		}
+		if (a.Owner == "") != (b.Owner == "") {
+			if a.Owner == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Owner, b.Owner)
+	})
+	return groups
+}
+
+// Print writes groups as a Markdown report, a section per tag and a list
+// per owner, for pasting into an issue or a wiki page
+func Print(w io.Writer, groups []Group) error {
+	var sb strings.Builder
+	for i, g := range groups {
+		if i == 0 || groups[i-1].Tag != g.Tag {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			n := 0
+			for _, other := range groups[i:] {
+				if other.Tag == g.Tag {
+					n += len(other.Items)
+				}
+			}
+			fmt.Fprintf(&sb, "## %s (%d)\n", g.Tag, n)
+		}
+		fmt.Fprintf(&sb, "\n### %s (%d)\n\n", ownerName(g.Owner), len(g.Items))
+		for _, item := range g.Items {
+			line := fmt.Sprintf("- %s:%d %s", item.Match.Path, item.Match.LineNumber, item.Text)
+			sb.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ownerName is how an owner is shown, naming the unowned items
+func ownerName(owner string) string {
+	if owner == "" {
+		return "(unowned)"
+	}
+	return owner
+}
@@ -0,0 +1,155 @@
+package todos
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line             string
+		tag, owner, text string
+		ok               bool
+	}{
+		{"\t// TODO(alice): handle resize\n", "TODO", "alice", "handle resize", true},
+		{"# FIXME: flaky on CI", "FIXME", "", "flaky on CI", true},
+		{"/* HACK(@bob) work around the driver */", "HACK", "bob", "work around the driver", true},
+		{"<!-- TODO -->", "TODO", "", "", true},
+		{"var TODOS = 3", "", "", "", false},
+	}
+	for _, tt := range tests {
+		item, ok := Parse(search.Match{LineText: tt.line}, DefaultTags)
+		if ok != tt.ok || item.Tag != tt.tag || item.Owner != tt.owner || item.Text != tt.text {
+			t.Errorf("Parse(%q) = %q, %q, %q, %v; want %q, %q, %q, %v", tt.line, item.Tag, item.Owner, item.Text, ok, tt.tag, tt.owner, tt.text, tt.ok)
+		}
+	}
+}
+
+func testItems() []Item {
+	item := func(tag, owner, path string, line int) Item {
+		return Item{Tag: tag, Owner: owner, Text: "fix " + path, Match: search.Match{Path: path, LineNumber: line}}
+	}
+	return []Item{
+		item("HACK", "", "a.go", 1),
+		item("TODO", "", "a.go", 2),
+		item("TODO", "zoe", "b.go", 3),
+		item("TODO", "alice", "c.go", 4),
+		item("TODO", "zoe", "d.go", 5),
+	}
+}
+
+func TestGroups_OrdersByTagThenOwner(t *testing.T) {
+	var got []string
+	for _, g := range Groups(testItems(), DefaultTags) {
+		got = append(got, g.Tag+"/"+ownerName(g.Owner)+"/"+string(rune('0'+len(g.Items))))
+	}
+	want := []string{"TODO/alice/1", "TODO/zoe/2", "TODO/(unowned)/1", "HACK/(unowned)/1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Groups = %v, want %v", got, want)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var out bytes.Buffer
+	if err := Print(&out, Groups(testItems(), DefaultTags)); err != nil {
+		t.Fatal(err)
+	}
+	want := `## TODO (4)
+
+### alice (1)
+
+- c.go:4 fix c.go
+
+### zoe (2)
+
+- b.go:3 fix b.go
+- d.go:5 fix d.go
+
+### (unowned) (1)
+
+- a.go:2 fix a.go
+
+## HACK (1)
+
+### (unowned) (1)
+
+- a.go:1 fix a.go
+`
+	if out.String() != want {
+		t.Errorf("Print =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("rg not installed")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.go": "package b\n\n// TODO(alice): drop the fallback\nfunc f() {} // FIXME\n",
+		"a.py": "# todo in prose isn't a tag\n# HACK: pin the version\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, err := Collect(context.Background(), search.NewRipgrepSearcher(), dir, DefaultTags, search.Options{CaseSensitivity: search.CaseInsensitive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, filepath.Base(item.Match.Path)+":"+item.Tag+":"+item.Owner)
+	}
+	if want := "a.py:HACK: b.go:TODO:alice b.go:FIXME:"; strings.Join(got, " ") != want {
+		t.Errorf("Collect = %v, want %s", got, want)
+	}
+}
+
+func TestModel_ExpandsGroups(t *testing.T) {
+	m := New(".", DefaultTags, search.Options{}, "")
+	m.run = 1
+	updated, _ := m.Update(collectedMsg{run: 1, items: testItems()})
+	m = updated.(Model)
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"irg todos: 5 in .", "TODO 4, FIXME 0, HACK 1", "alice", "(unowned)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "c.go:4") {
+		t.Error("items shown before their group was expanded")
+	}
+
+	// Enter on a group shows its items; h from an item folds it again
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeyEnter}, {Type: tea.KeyDown}} {
+		updated, _ = m.Update(key)
+		m = updated.(Model)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "b.go:3 fix b.go") || !strings.Contains(view, "d.go:5 fix d.go") {
+		t.Errorf("expanded view missing zoe's items:\n%s", view)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}})
+	m = updated.(Model)
+	if m.cursor != 1 || strings.Contains(ansi.Strip(m.View()), "b.go:3") {
+		t.Errorf("cursor = %d after h, want back on the folded group", m.cursor)
+	}
+
+	// Results from a replaced scan are dropped
+	updated, _ = m.Update(collectedMsg{run: 0})
+	if got := len(updated.(Model).Groups()); got != 4 {
+		t.Errorf("stale scan left %d groups, want 4", got)
+	}
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// announceSnippetWidth bounds the matched text in an announcement
+const announceSnippetWidth = 200
+
+// announcer writes a line describing the selected result to a side channel,
+// such as a file or a descriptor a screen reader tool reads, each time the
+// selection changes
+type announcer struct {
+	w    io.Writer
+	last string // Selection last announced, to skip repeats
+}
+
+// SetAnnouncer writes the selected result to w on every selection change,
+// one line each: "Result 3 of 120, main.go line 42: func main() {"
+func (m *Model) SetAnnouncer(w io.Writer) {
+	m.announcer = &announcer{w: w}
+}
+
+// announceSelection tells the announcer about the current selection, unless
+// it was the last one announced. A write error stops the announcements
+// rather than the UI.
+func (m *Model) announceSelection() {
+	a := m.announcer
+	if a == nil || a.w == nil {
+		return
+	}
+	var key, text string
+	if match, ok := m.selectedMatch(); ok {
+		key = fmt.Sprintf("%s\x00%d\x00%s\x00%d", m.lastPattern, m.list.selected, match.Path, match.LineNumber)
+		snippet := strings.Join(strings.Fields(match.Head().LineText), " ")
+		text = fmt.Sprintf("Result %d of %d, %s line %d: %s", m.list.selected+1, m.results.Len(),
+			displayPath(match.Path), match.LineNumber, ansi.Truncate(snippet, announceSnippetWidth, "…"))
+	} else if !m.searching && m.lastPattern != "" {
+		key = m.lastPattern
+		text = "No results for " + m.lastPattern
+	}
+	if key == "" || key == a.last {
+		return
+	}
+	a.last = key
+	if _, err := io.WriteString(a.w, text+"\n"); err != nil {
+		a.w = nil
+		m.status.err = fmt.Sprintf("Announcements stopped: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAnnounce_SelectionChanges(t *testing.T) {
+	m := newTestModel(t)
+	var out bytes.Buffer
+	m.SetAnnouncer(&out)
+	m.lastPattern = "match"
+
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 3)})
+	m = updated.(Model)
+	// More results for the same selection aren't announced again
+	updated, _ = m.Update(searchResultMsg{matches: testMatches(3, 2), done: true})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+
+	want := "Result 1 of 3, file0.go line 1: match number 0\n" +
+		"Result 2 of 5, file1.go line 2: match number 1\n"
+	if out.String() != want {
+		t.Errorf("announced:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestAnnounce_NoResults(t *testing.T) {
+	m := newTestModel(t)
+	var out bytes.Buffer
+	m.SetAnnouncer(&out)
+	m.lastPattern = "nothing"
+
+	updated, _ := m.Update(searchResultMsg{done: true})
+	m = updated.(Model)
+	if got := out.String(); got != "No results for nothing\n" {
+		t.Errorf("announced %q", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("reader went away") }
+
+func TestAnnounce_WriteErrorStopsAnnouncing(t *testing.T) {
+	m := newTestModel(t)
+	m.SetAnnouncer(failingWriter{})
+	m.lastPattern = "match"
+
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 3), done: true})
+	m = updated.(Model)
+	if !strings.Contains(m.status.err, "reader went away") {
+		t.Errorf("error = %q, want the write error", m.status.err)
+	}
+	if m.announcer.w != nil {
+		t.Error("announcer still writing after an error")
+	}
+}
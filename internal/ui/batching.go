@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultBatchInterval = 50 * time.Millisecond
+
+	// Bounds of the tuned batches, widened to take in the configured ones
+	minBatchSize     = 10
+	maxBatchSize     = 2000
+	minBatchInterval = 16 * time.Millisecond // About a frame
+	maxBatchInterval = 500 * time.Millisecond
+)
+
+// resultBatching decides when the matches of a running search are shown:
+// every size matches or every interval, whichever comes first. Each batch
+// costs a redraw, so with tuning on both grow while the screen lags behind
+// the batches, as on a slow terminal, and shrink while it keeps up, showing
+// matches sooner. It's shared with the commands reading the batches.
+type resultBatching struct {
+	mu       sync.Mutex
+	size     int
+	interval time.Duration
+	tune     bool
+
+	minSize, maxSize         int
+	minInterval, maxInterval time.Duration
+}
+
+// newResultBatching starts batches of size matches or interval, with 0
+// keeping the defaults
+func newResultBatching(size int, interval time.Duration, tune bool) *resultBatching {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultBatchInterval
+	}
+	return &resultBatching{
+		size:        size,
+		interval:    interval,
+		tune:        tune,
+		minSize:     min(size, minBatchSize),
+		maxSize:     max(size, maxBatchSize),
+		minInterval: min(interval, minBatchInterval),
+		maxInterval: max(interval, maxBatchInterval),
+	}
+}
+
+// SetResultBatching shows the matches of a running search every size
+// matches or interval, with 0 keeping the defaults of 100 and 50ms. With
+// tune they're a starting point, adjusted to how fast the screen keeps up.
+func (m *Model) SetResultBatching(size int, interval time.Duration, tune bool) {
+	m.batching = newResultBatching(size, interval, tune)
+}
+
+// limits returns the current batch size and interval
+func (b *resultBatching) limits() (int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size, b.interval
+}
+
+// observe tunes the batches to a batch that took lag from being flushed to
+// the next one being read, the time it spent waiting for and going through
+// the UI. Over an interval means the screen falls behind; under a quarter of
+// one, that it has time to spare.
+func (b *resultBatching) observe(lag time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.tune {
+		return
+	}
+	switch {
+	case lag > b.interval:
+		b.size = min(b.size*2, b.maxSize)
+		b.interval = min(b.interval*2, b.maxInterval)
+	case lag < b.interval/4:
+		b.size = max(b.size*3/4, b.minSize)
+		b.interval = max(b.interval*3/4, b.minInterval)
+	}
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestResultBatching_TunesToLag(t *testing.T) {
+	b := newResultBatching(0, 0, true)
+	b.observe(time.Second)
+	if size, interval := b.limits(); size != 200 || interval != 100*time.Millisecond {
+		t.Errorf("after a slow batch: %d, %v; want batches doubled", size, interval)
+	}
+	for range 20 {
+		b.observe(time.Second)
+	}
+	if size, interval := b.limits(); size != maxBatchSize || interval != maxBatchInterval {
+		t.Errorf("on a slow screen: %d, %v; want the largest batches", size, interval)
+	}
+	for range 50 {
+		b.observe(0)
+	}
+	if size, interval := b.limits(); size != minBatchSize || interval != minBatchInterval {
+		t.Errorf("on a fast screen: %d, %v; want the smallest batches", size, interval)
+	}
+
+	// A lag within the interval leaves the batches be
+	b = newResultBatching(0, 0, true)
+	b.observe(30 * time.Millisecond)
+	if size, interval := b.limits(); size != defaultBatchSize || interval != defaultBatchInterval {
+		t.Errorf("after a batch in time: %d, %v; want the defaults", size, interval)
+	}
+
+	b = newResultBatching(5000, 2*time.Second, false)
+	b.observe(time.Minute)
+	b.observe(0)
+	if size, interval := b.limits(); size != 5000 || interval != 2*time.Second {
+		t.Errorf("untuned: %d, %v; want the configured batches", size, interval)
+	}
+}
+
+func TestReadResultBatch_FlushesAtBatchSize(t *testing.T) {
+	results := make(chan search.Match, 30)
+	for _, match := range testMatches(0, 30) {
+		results <- match
+	}
+	close(results)
+
+	batching := newResultBatching(12, time.Hour, false)
+	var sizes []int
+	msg := readResultBatch(context.Background(), results, nil, batching)
+	for {
+		sizes = append(sizes, len(msg.matches))
+		if msg.done {
+			break
+		}
+		msg = msg.next().(searchResultMsg)
+	}
+	if len(sizes) != 3 || sizes[0] != 12 || sizes[1] != 12 || sizes[2] != 6 {
+		t.Errorf("batches of %v, want 12, 12 and the last 6", sizes)
+	}
+}
@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/config"
+)
+
+// Bookmark is a named directory the path input can switch to
+type Bookmark struct {
+	Name string
+	Path string
+}
+
+type bookmarksSavedMsg struct {
+	err error
+}
+
+// SetBookmarks offers bookmarks in the bookmark picker, ordered by name
+func (m *Model) SetBookmarks(bookmarks []Bookmark) {
+	m.bookmarks = slices.Clone(bookmarks)
+	slices.SortFunc(m.bookmarks, func(a, b Bookmark) int { return strings.Compare(a.Name, b.Name) })
+}
+
+// openBookmarks shows the bookmark picker, with the bookmark of the current
+// path selected if there is one
+func (m *Model) openBookmarks() {
+	m.bookmarksVisible = true
+	m.bookmarkIndex = 0
+	current := m.currentDir()
+	for i, b := range m.bookmarks {
+		if b.Path == current {
+			m.bookmarkIndex = i
+		}
+	}
+}
+
+// currentDir returns the path input as an absolute path
+func (m *Model) currentDir() string {
+	path := m.searchPath()
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// updateBookmarks handles key presses while the bookmark picker is shown:
+// Enter switches the path input to the selected bookmark, a bookmarks the
+// current path and d removes the selected bookmark
+func (m Model) updateBookmarks(msg tea.KeyMsg, a action) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "a":
+		return m, m.addBookmark()
+	case "d":
+		return m, m.removeBookmark()
+	}
+	switch a {
+	case actionUp:
+		if len(m.bookmarks) > 0 {
+			m.bookmarkIndex = (m.bookmarkIndex + len(m.bookmarks) - 1) % len(m.bookmarks)
+		}
+	case actionDown:
+		if len(m.bookmarks) > 0 {
+			m.bookmarkIndex = (m.bookmarkIndex + 1) % len(m.bookmarks)
+		}
+	case actionOpenEditor:
+		m.bookmarksVisible = false
+		if m.bookmarkIndex < len(m.bookmarks) {
+			return m, m.switchToBookmark(m.bookmarks[m.bookmarkIndex])
+		}
+	case actionClose, actionBookmarks, actionQuit:
+		m.bookmarksVisible = false
+	}
+	return m, nil
+}
+
+// switchToBookmark puts b's directory in the path input and searches it
+func (m *Model) switchToBookmark(b Bookmark) tea.Cmd {
+	m.inputs.path.SetValue(b.Path)
+	m.inputs.path.CursorEnd()
+	m.pathDropdown.hide()
+	m.lastPath = b.Path
+	m.status.message = "Searching " + b.Name
+	pattern := m.inputs.pattern.Value()
+	if pattern == "" {
+		return nil
+	}
+	return m.executeSearch(pattern, b.Path)
+}
+
+// addBookmark bookmarks the current path under its directory name, made
+// unique with a number when another bookmark has it
+func (m *Model) addBookmark() tea.Cmd {
+	dir := m.currentDir()
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		m.status.err = "Only directories can be bookmarked"
+		return nil
+	}
+	for i, b := range m.bookmarks {
+		if b.Path == dir {
+			m.bookmarkIndex = i
+			return nil
+		}
+	}
+	base := filepath.Base(dir)
+	name := base
+	for n := 2; slices.ContainsFunc(m.bookmarks, func(b Bookmark) bool { return b.Name == name }); n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	m.SetBookmarks(append(m.bookmarks, Bookmark{Name: name, Path: dir}))
+	m.bookmarkIndex = slices.IndexFunc(m.bookmarks, func(b Bookmark) bool { return b.Name == name })
+	return m.saveBookmark(name, dir)
+}
+
+// removeBookmark drops the selected bookmark
+func (m *Model) removeBookmark() tea.Cmd {
+	if m.bookmarkIndex >= len(m.bookmarks) {
+		return nil
+	}
+	name := m.bookmarks[m.bookmarkIndex].Name
+	m.bookmarks = slices.Delete(m.bookmarks, m.bookmarkIndex, m.bookmarkIndex+1)
+	m.bookmarkIndex = min(m.bookmarkIndex, max(len(m.bookmarks)-1, 0))
+	return m.saveBookmark(name, nil)
+}
+
+// saveBookmark writes the bookmark name to the config file, or removes it
+// there when path is nil. Without a config file bookmarks last the session.
+func (m *Model) saveBookmark(name string, path any) tea.Cmd {
+	if m.configPath == "" {
+		return nil
+	}
+	file := m.configPath
+	return func() tea.Msg {
+		return bookmarksSavedMsg{err: config.Save(file, []config.Setting{{Section: "bookmarks", Key: name, Value: path}})}
+	}
+}
+
+// renderBookmarks renders the bookmark picker, at most width cells wide and
+// height rows tall
+func (m *Model) renderBookmarks(width, height int) string {
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Bookmarks"), ""}
+	if len(m.bookmarks) == 0 {
+		lines = append(lines, hintStyle.Render("  No bookmarks yet"))
+	}
+	nameWidth := 0
+	for _, b := range m.bookmarks {
+		nameWidth = max(nameWidth, ansi.StringWidth(b.Name))
+	}
+	for i, b := range m.bookmarks {
+		label := b.Name + strings.Repeat(" ", nameWidth-ansi.StringWidth(b.Name)) + "  " + b.Path
+		if i == m.bookmarkIndex {
+			lines = append(lines, selectedStyle.Render("> "+label))
+		} else {
+			lines = append(lines, "  "+label)
+		}
+	}
+	lines = append(lines, "", hintStyle.Render("↑/↓ select  Enter search there  a bookmark "+displayPath(m.currentDir())+"  d remove  Esc close"))
+
+	// Border and padding take two rows and four columns
+	textWidth := max(width-4, 10)
+	if maxLines := max(height-2, 1); len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, textWidth, "…")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/config"
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestBookmarks_SwitchesPathAndSearches(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher()
+	m.searcher = searcher
+	m.SetBookmarks([]Bookmark{{Name: "notes", Path: "/home/me/notes"}, {Name: "dotfiles", Path: "/home/me/.dotfiles"}})
+	m.inputs.pattern.SetValue("alias")
+	m.lastPattern = "alias"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}, Alt: true})
+	m = updated.(Model)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "> dotfiles  /home/me/.dotfiles") {
+		t.Fatalf("picker doesn't list the bookmarks by name:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runSearch(t, updated.(Model), cmd)
+	if m.bookmarksVisible || m.inputs.path.Value() != "/home/me/notes" {
+		t.Errorf("path = %q, picker open = %v; want notes chosen", m.inputs.path.Value(), m.bookmarksVisible)
+	}
+	calls := searcher.Calls()
+	if len(calls) != 1 || calls[0].Path != "/home/me/notes" || calls[0].Pattern != "alias" {
+		t.Errorf("calls = %+v, want the pattern searched in notes", calls)
+	}
+}
+
+func TestBookmarks_AddAndRemoveAreSaved(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	m := newTestModel(t)
+	m.SetConfigFile(configFile)
+	m.inputs.path.SetValue(dir)
+	m.openBookmarks()
+
+	save := func(key rune) {
+		t.Helper()
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+		m = updated.(Model)
+		if cmd == nil {
+			t.Fatalf("%c saved nothing", key)
+		}
+		updated, _ = m.Update(cmd())
+		m = updated.(Model)
+		if m.status.err != "" {
+			t.Fatal(m.status.err)
+		}
+	}
+
+	save('a')
+	cfg, err := config.LoadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{filepath.Base(dir): dir}
+	if !reflect.DeepEqual(cfg.Bookmarks, want) {
+		t.Errorf("saved bookmarks = %v, want %v", cfg.Bookmarks, want)
+	}
+
+	save('d')
+	if cfg, err = config.LoadFile(configFile); err != nil || len(cfg.Bookmarks) != 0 || len(m.bookmarks) != 0 {
+		t.Errorf("bookmarks = %v in the file, %v in the picker, %v; want none", cfg.Bookmarks, m.bookmarks, err)
+	}
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"path/filepath"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// caseFolding keeps one spelling of each result path when the searched
+// filesystem ignores case, so that listing the same tree twice in different
+// cases, say through --paths-from, doesn't show every match twice
+type caseFolding struct {
+	enabled bool              // The current search's filesystem ignores case
+	probed  map[string]bool   // Whether each search path's filesystem ignores case
+	seen    map[string]string // First spelling of each folded result path
+}
+
+func newCaseFolding() caseFolding {
+	return caseFolding{probed: make(map[string]bool), seen: make(map[string]string)}
+}
+
+// start prepares for a search of path and returns the path to search:
+// on a case-insensitive filesystem it's spelled as on disk, so results,
+// the preview and the editor all use the real names
+func (c *caseFolding) start(path string, remote bool) string {
+	clear(c.seen)
+	c.enabled = false
+	if remote {
+		return path
+	}
+	insensitive, ok := c.probed[path]
+	if !ok {
+		insensitive = search.CaseInsensitiveFS(path)
+		c.probed[path] = insensitive
+	}
+	c.enabled = insensitive
+	if insensitive {
+		if spelled := search.TrueCase(path); spelled != filepath.Clean(path) {
+			return spelled
+		}
+	}
+	return path
+}
+
+// dedupe drops matches in files already seen under another spelling
+func (c *caseFolding) dedupe(matches []search.Match) []search.Match {
+	if !c.enabled {
+		return matches
+	}
+	kept := matches[:0:0]
+	for _, match := range matches {
+		key := search.FoldPath(match.Path)
+		if first, ok := c.seen[key]; ok && first != match.Path {
+			continue
+		}
+		c.seen[key] = match.Path
+		kept = append(kept, match)
+	}
+	return kept
+}
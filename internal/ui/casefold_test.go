@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestCaseFolding_DropsOtherSpellings(t *testing.T) {
+	m := newTestModel(t)
+	m.caseFold.probed["."] = true
+	m.executeSearch("needle", ".")
+
+	matches := []search.Match{
+		{Path: "Src/main.go", LineNumber: 1},
+		{Path: "src/main.go", LineNumber: 1},
+		{Path: "Src/main.go", LineNumber: 2},
+		{Path: "src/util.go", LineNumber: 3},
+	}
+	updated, _ := m.Update(searchResultMsg{matches: matches, ctx: m.searchCtx})
+	m = updated.(Model)
+
+	if m.results.Len() != 3 {
+		t.Fatalf("kept %d results, want 3", m.results.Len())
+	}
+	for i := 0; i < m.results.Len(); i++ {
+		if match, _ := m.results.Get(i); match.Path == "src/main.go" {
+			t.Errorf("result %d uses the second spelling of Src/main.go", i)
+		}
+	}
+}
+
+func TestCaseFolding_KeepsSpellingsOnCaseSensitiveFS(t *testing.T) {
+	c := newCaseFolding()
+	c.probed["."] = false
+	c.start(".", false)
+	matches := []search.Match{{Path: "Makefile"}, {Path: "makefile"}}
+	if got := c.dedupe(matches); len(got) != 2 {
+		t.Errorf("kept %d results, want both files", len(got))
+	}
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/classify"
+	"github.com/William9923/irg/internal/search"
+)
+
+// hiddenClasses returns the names of the classes the class filter hides:
+// none, one class at a time, then all of them
+func (m *Model) hiddenClasses() []string {
+	classes := m.classifier.Classes()
+	switch {
+	case m.classFilter == 0 || len(classes) == 0:
+		return nil
+	case m.classFilter <= len(classes):
+		return []string{classes[m.classFilter-1].Name}
+	}
+	names := make([]string, len(classes))
+	for i, class := range classes {
+		names[i] = class.Name
+	}
+	return names
+}
+
+// cycleClassFilter steps the class filter and searches again
+func (m *Model) cycleClassFilter() tea.Cmd {
+	classes := m.classifier.Classes()
+	if len(classes) == 0 {
+		m.status.message = "No result classes configured"
+		return nil
+	}
+	// With one class, hiding it and hiding all are the same step
+	steps := len(classes) + 2
+	if len(classes) == 1 {
+		steps = 2
+	}
+	m.classFilter = (m.classFilter + 1) % steps
+	if hidden := m.hiddenClasses(); len(hidden) > 0 {
+		m.status.message = "Hiding " + strings.Join(hidden, ", ") + " results"
+	} else {
+		m.status.message = "Showing all results"
+	}
+	if m.lastPattern == "" {
+		return nil
+	}
+	return m.executeSearch(m.inputs.pattern.Value(), m.inputs.path.Value())
+}
+
+// visibleMatches drops the matches in classes hidden by the class filter
+func (m *Model) visibleMatches(matches []search.Match) []search.Match {
+	hidden := m.hiddenClasses()
+	if len(hidden) == 0 {
+		return matches
+	}
+	kept := matches[:0:0]
+	for _, match := range matches {
+		class := m.classifier.Classify(match.Path)
+		if class == nil || !slices.Contains(hidden, class.Name) {
+			kept = append(kept, match)
+		}
+	}
+	return kept
+}
+
+// styleClass dims or tags a rendered result line by its class
+func styleClass(line string, class *classify.Class) string {
+	if class == nil {
+		return line
+	}
+	if class.Style == classify.StyleTag {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Render("["+class.Name+"]") + " " + line
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(ansi.Strip(line))
+}
+
+// classTagWidth is the width styleClass adds to a line
+func classTagWidth(class *classify.Class) int {
+	if class == nil || class.Style != classify.StyleTag {
+		return 0
+	}
+	return ansi.StringWidth(class.Name) + 3
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/classify"
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestClassFilter_CyclesHiddenClasses(t *testing.T) {
+	m := newTestModel(t)
+	m.SetClassifier(classify.New([]classify.Class{
+		{Name: "generated", Globs: []string{"*.pb.go"}, Style: classify.StyleTag},
+		{Name: "test", Globs: []string{"*_test.go"}, Style: classify.StyleDim},
+	}))
+	matches := []search.Match{
+		{Path: "api.pb.go", LineNumber: 1, LineText: "needle"},
+		{Path: "model_test.go", LineNumber: 2, LineText: "needle"},
+		{Path: "model.go", LineNumber: 3, LineText: "needle"},
+	}
+
+	var got [][]string
+	for i := 0; i < 4; i++ {
+		var paths []string
+		for _, match := range m.visibleMatches(matches) {
+			paths = append(paths, match.Path)
+		}
+		got = append(got, paths)
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}, Alt: true})
+		m = updated.(Model)
+	}
+	want := [][]string{
+		{"api.pb.go", "model_test.go", "model.go"},
+		{"model_test.go", "model.go"},
+		{"api.pb.go", "model.go"},
+		{"model.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("visible paths per filter step = %v, want %v", got, want)
+	}
+	if m.classFilter != 0 {
+		t.Errorf("classFilter = %d after a full cycle, want 0", m.classFilter)
+	}
+}
+
+func TestRenderResultLine_TagsClasses(t *testing.T) {
+	m := newTestModel(t)
+	tagged := m.renderResultLine(search.Match{Path: "api.pb.go", LineNumber: 1, LineText: "needle"}, false, false, false, diffNone)
+	if !strings.Contains(ansi.Strip(tagged), "[generated] api.pb.go:1: needle") {
+		t.Errorf("generated result not tagged: %q", ansi.Strip(tagged))
+	}
+	// Dimmed classes keep their text untagged
+	dimmed := m.renderResultLine(search.Match{Path: "a_test.go", LineNumber: 1, LineText: "needle"}, false, false, false, diffNone)
+	if !strings.HasPrefix(strings.TrimSpace(ansi.Strip(dimmed)), "a_test.go:1: needle") {
+		t.Errorf("test result rendered as %q", ansi.Strip(dimmed))
+	}
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/contrast"
+)
+
+// Colors are the configurable highlight colors, as ANSI 256-color numbers
+// or hex codes
+type Colors struct {
+	SelectedBackground     string // Selected row of the results and summary
+	Match                  string // Matched text in the results
+	PreviewMatchForeground string // Matched text on the preview's match line
+	PreviewMatchBackground string
+}
+
+// DefaultColors suit a dark terminal
+var DefaultColors = Colors{
+	SelectedBackground:     "237",
+	Match:                  "11",
+	PreviewMatchForeground: "196",
+	PreviewMatchBackground: "226",
+}
+
+// Merge returns c with the colors set in override replacing its own
+func (c Colors) Merge(override Colors) Colors {
+	if override.SelectedBackground != "" {
+		c.SelectedBackground = override.SelectedBackground
+	}
+	if override.Match != "" {
+		c.Match = override.Match
+	}
+	if override.PreviewMatchForeground != "" {
+		c.PreviewMatchForeground = override.PreviewMatchForeground
+	}
+	if override.PreviewMatchBackground != "" {
+		c.PreviewMatchBackground = override.PreviewMatchBackground
+	}
+	return c
+}
+
+// CheckContrast finds colors that would be unreadable on a terminal with
+// the given background: plain text on the selected row, matches on the
+// background and on the selected row, and the preview's line number and
+// match text on its highlight. With adjust, each such color is moved toward white or black
+// until it reads; the returned problems describe what was found.
+func (c Colors) CheckContrast(background string, adjust bool) (Colors, []string) {
+	bg, err := contrast.Parse(background)
+	if err != nil {
+		return c, []string{err.Error()}
+	}
+	// Unstyled text is light on a dark terminal and dark on a light one
+	text := contrast.White
+	if contrast.Luminance(bg) > 0.5 {
+		text = contrast.Black
+	}
+
+	var problems []string
+	check := func(fg, on *string, fgName, onName string, fixBackground bool) {
+		fgColor, err := contrast.Parse(*fg)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", fgName, err))
+			return
+		}
+		onColor, err := contrast.Parse(*on)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", onName, err))
+			return
+		}
+		ratio := contrast.Ratio(fgColor, onColor)
+		if ratio >= contrast.MinRatio {
+			return
+		}
+
+		problem := fmt.Sprintf("%s %s on %s %s has a contrast of %.1f:1", fgName, *fg, onName, *on, ratio)
+		if !adjust {
+			problems = append(problems, problem)
+			return
+		}
+		if fixBackground {
+			fixed := contrast.Adjust(onColor, fgColor, contrast.MinRatio).Hex()
+			problems = append(problems, fmt.Sprintf("%s; using %s for %s", problem, fixed, onName))
+			*on = fixed
+		} else {
+			fixed := contrast.Adjust(fgColor, onColor, contrast.MinRatio).Hex()
+			problems = append(problems, fmt.Sprintf("%s; using %s for %s", problem, fixed, fgName))
+			*fg = fixed
+		}
+	}
+
+	bgName, textName := background, text.Hex()
+	check(&textName, &c.SelectedBackground, "text", "selected-background", true)
+	check(&c.Match, &bgName, "match", "the background", false)
+	check(&c.Match, &c.SelectedBackground, "match", "selected-background", false)
+	lineNumber := "0"
+	check(&lineNumber, &c.PreviewMatchBackground, "the line number", "preview-match-background", true)
+	check(&c.PreviewMatchForeground, &c.PreviewMatchBackground, "preview-match-foreground", "preview-match-background", false)
+	return c, problems
+}
+
+// SetColors sets the highlight colors
+func (m *Model) SetColors(c Colors) {
+	m.colors = c
+	m.list.cache.invalidate()
+}
+
+func (m *Model) selectedStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Background(lipgloss.Color(m.colors.SelectedBackground)).Bold(true)
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/William9923/irg/internal/contrast"
+)
+
+func TestCheckContrast_DefaultsReadOnDark(t *testing.T) {
+	got, problems := DefaultColors.CheckContrast("#000000", true)
+	if len(problems) > 0 || got != DefaultColors {
+		t.Errorf("defaults on black: %+v, problems %q", got, problems)
+	}
+}
+
+func TestCheckContrast_AdjustsYellowOnYellow(t *testing.T) {
+	colors := DefaultColors.Merge(Colors{SelectedBackground: "#ffd700", Match: "11"})
+
+	got, problems := colors.CheckContrast("#ffffff", true)
+	if len(problems) == 0 {
+		t.Fatal("no problems reported for yellow on yellow")
+	}
+	ratio := func(fg, bg string) float64 {
+		a, _ := contrast.Parse(fg)
+		b, _ := contrast.Parse(bg)
+		return contrast.Ratio(a, b)
+	}
+	if r := ratio(got.Match, got.SelectedBackground); r < contrast.MinRatio {
+		t.Errorf("match %s on selected %s still has contrast %.1f", got.Match, got.SelectedBackground, r)
+	}
+	if r := ratio(got.Match, "#ffffff"); r < contrast.MinRatio {
+		t.Errorf("match %s on white still has contrast %.1f", got.Match, r)
+	}
+}
+
+func TestCheckContrast_WarnKeepsColors(t *testing.T) {
+	colors := DefaultColors.Merge(Colors{PreviewMatchForeground: "#ffff00", PreviewMatchBackground: "226"})
+
+	got, problems := colors.CheckContrast("#000000", false)
+	if got != colors {
+		t.Errorf("warn mode changed colors to %+v", got)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "preview-match-foreground #ffff00 on preview-match-background 226") {
+		t.Errorf("problems = %q", problems)
+	}
+}
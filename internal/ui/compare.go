@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// diffKind marks how a result in compare mode differs from the previous search
+type diffKind int
+
+const (
+	diffNone diffKind = iota
+	diffAdded
+	diffRemoved
+)
+
+// comparison is the state of compare mode, which swaps the results for the
+// matches added and removed since the previous search
+type comparison struct {
+	saved *search.ResultStore // Current results, restored when compare mode ends
+	kinds []diffKind          // Per entry of the diff results
+}
+
+// kind returns how result i differs, or diffNone outside compare mode
+func (c *comparison) kind(i int) diffKind {
+	if c == nil || i < 0 || i >= len(c.kinds) {
+		return diffNone
+	}
+	return c.kinds[i]
+}
+
+// matchKey identifies a match across runs. Line numbers aren't part of it
+// since edits shift them; the same text twice in a file counts twice.
+func matchKey(match search.Match) string {
+	return match.Path + "\x00" + strings.TrimRight(match.LineText, "\r\n")
+}
+
+// lineKey identifies a match that stayed on the same line
+func lineKey(match search.Match) string {
+	return fmt.Sprintf("%s\x00%d", matchKey(match), match.LineNumber)
+}
+
+// diffResults returns the matches in previous but not current, and those in
+// current but not previous, each in their original order
+func diffResults(previous, current []search.Match) (removed, added []search.Match) {
+	// Matches still on the same line pair up first, so that of two identical
+	// lines the one that moved or disappeared is the one reported
+	previousLeft := unpaired(previous, current, lineKey)
+	currentLeft := unpaired(current, previous, lineKey)
+	return unpaired(previousLeft, currentLeft, matchKey), unpaired(currentLeft, previousLeft, matchKey)
+}
+
+// unpaired returns the matches in a with no partner in b under key; each
+// match in b pairs with at most one in a
+func unpaired(a, b []search.Match, key func(search.Match) string) []search.Match {
+	counts := make(map[string]int, len(b))
+	for _, match := range b {
+		counts[key(match)]++
+	}
+	var out []search.Match
+	for _, match := range a {
+		k := key(match)
+		if counts[k] > 0 {
+			counts[k]--
+			continue
+		}
+		out = append(out, match)
+	}
+	return out
+}
+
+// comparedMsg carries the matches removed and added since the previous
+// search, worked out in the background
+type comparedMsg struct {
+	token   int
+	removed []search.Match
+	added   []search.Match
+	err     error
+}
+
+// toggleCompare enters compare mode, or leaves it if it's on. The results
+// may be paged out to disk, so they are diffed in the background and
+// compare mode starts once the diff arrives.
+func (m *Model) toggleCompare() tea.Cmd {
+	if m.compare != nil {
+		m.endCompare()
+		m.status.message = ""
+		m.updateResultsView()
+		m.updatePreviewView()
+		return m.loadPreview()
+	}
+
+	switch {
+	case m.searching:
+		m.status.err = "Wait for the search to finish before comparing"
+		return nil
+	case !m.resultsDone || m.previous == nil:
+		m.status.err = "No previous search to compare with"
+		return nil
+	}
+
+	m.compareToken++
+	token := m.compareToken
+	previous, current := m.previous.Snapshot(), m.results.Snapshot()
+	m.status.err = ""
+	m.status.message = fmt.Sprintf("Comparing with %q...", m.previousPattern)
+	return func() tea.Msg {
+		before, err := previous.Slice(0, previous.Len())
+		if err != nil {
+			return comparedMsg{token: token, err: err}
+		}
+		after, err := current.Slice(0, current.Len())
+		if err != nil {
+			return comparedMsg{token: token, err: err}
+		}
+		removed, added := diffResults(before, after)
+		return comparedMsg{token: token, removed: removed, added: added}
+	}
+}
+
+// cancelCompare drops a diff still being worked out, as the results it
+// compares are about to change
+func (m *Model) cancelCompare() {
+	m.compareToken++
+}
+
+// updateCompared enters compare mode with a diff, unless the results have
+// changed since it was asked for
+func (m *Model) updateCompared(msg comparedMsg) tea.Cmd {
+	if msg.token != m.compareToken || m.compare != nil {
+		return nil
+	}
+	if msg.err != nil {
+		m.status.err = msg.err.Error()
+		return nil
+	}
+	if len(msg.removed) == 0 && len(msg.added) == 0 {
+		m.status.err = ""
+		m.status.message = fmt.Sprintf("Same results as the previous search (%q)", m.previousPattern)
+		return nil
+	}
+
+	diff := search.NewResultStore()
+	c := &comparison{saved: m.results}
+	err := diff.Append(msg.removed...)
+	if err == nil {
+		err = diff.Append(msg.added...)
+	}
+	if err != nil {
+		diff.Close()
+		m.status.err = err.Error()
+		return nil
+	}
+	for range msg.removed {
+		c.kinds = append(c.kinds, diffRemoved)
+	}
+	for range msg.added {
+		c.kinds = append(c.kinds, diffAdded)
+	}
+
+	m.compare = c
+	m.results = diff
+	m.resetResultsSelection()
+	m.status.err = ""
+	m.status.message = fmt.Sprintf("vs %q: %d removed, %d added", m.previousPattern, len(msg.removed), len(msg.added))
+	m.updateResultsView()
+	m.updatePreviewView()
+	return m.loadPreview()
+}
+
+// endCompare leaves compare mode, restoring the current results
+func (m *Model) endCompare() {
+	if m.compare == nil {
+		return
+	}
+	m.results.Close()
+	m.results = m.compare.saved
+	m.compare = nil
+	m.resetResultsSelection()
+}
+
+// resetResultsSelection selects the first result after the result list was
+// swapped for another
+func (m *Model) resetResultsSelection() {
+	clear(m.marked)
+	clear(m.notes)
+	m.list.cache.invalidate()
+	m.list.selected = 0
+	m.matchCount = m.results.Len()
+	m.summary.reset(m.lastPath)
+	m.refreshSummary()
+	m.typeCounts.reset()
+	m.refreshTypeCounts()
+	m.preview.clear()
+}
+
+// rotateResults keeps the results of a finished search as the previous
+// search, before a new search reuses the other store
+func (m *Model) rotateResults(pattern string) {
+	m.cancelCompare()
+	if m.resultsDone {
+		if m.previous == nil {
+			m.previous = search.NewResultStore()
+		}
+		m.previous, m.results = m.results, m.previous
+		m.previousPattern = m.resultsPattern
+	}
+	m.resultsDone = false
+	m.resultsPattern = pattern
+}
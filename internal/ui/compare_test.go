@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestDiffResults(t *testing.T) {
+	m := func(path string, line int, text string) search.Match {
+		return search.Match{Path: path, LineNumber: line, LineText: text + "\n"}
+	}
+	tests := []struct {
+		name        string
+		previous    []search.Match
+		current     []search.Match
+		wantRemoved []search.Match
+		wantAdded   []search.Match
+	}{
+		{
+			name:     "identical",
+			previous: []search.Match{m("a.go", 1, "oldName()")},
+			current:  []search.Match{m("a.go", 1, "oldName()")},
+		},
+		{
+			name:     "shifted line is unchanged",
+			previous: []search.Match{m("a.go", 10, "oldName()")},
+			current:  []search.Match{m("a.go", 12, "oldName()")},
+		},
+		{
+			name:        "removed and added",
+			previous:    []search.Match{m("a.go", 1, "oldName()"), m("b.go", 3, "oldName(x)")},
+			current:     []search.Match{m("b.go", 3, "oldName(x)"), m("c.go", 7, "oldName(y)")},
+			wantRemoved: []search.Match{m("a.go", 1, "oldName()")},
+			wantAdded:   []search.Match{m("c.go", 7, "oldName(y)")},
+		},
+		{
+			name:        "duplicates count separately",
+			previous:    []search.Match{m("a.go", 1, "x"), m("a.go", 5, "x")},
+			current:     []search.Match{m("a.go", 5, "x")},
+			wantRemoved: []search.Match{m("a.go", 1, "x")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed, added := diffResults(tt.previous, tt.current)
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+		})
+	}
+}
+
+// finishSearch runs a search that yields matches to completion
+func finishSearch(t *testing.T, m Model, pattern string, matches []search.Match) Model {
+	t.Helper()
+	m.executeSearch(pattern, ".")
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	return updated.(Model)
+}
+
+func TestToggleCompare_ShowsDiffAndRestores(t *testing.T) {
+	m := newTestModel(t)
+	m = finishSearch(t, m, "match", testMatches(0, 3))
+	m = finishSearch(t, m, "match", testMatches(1, 3))
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}, Alt: true})
+	m = updated.(Model)
+	if m.compare != nil || cmd == nil {
+		t.Fatal("the diff wasn't left to a command")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if m.compare == nil {
+		t.Fatalf("compare mode not entered: %q", m.status.err)
+	}
+	if m.results.Len() != 2 {
+		t.Fatalf("diff has %d entries, want 2", m.results.Len())
+	}
+	first, _ := m.results.Get(0)
+	if first.Path != "file0.go" || m.compare.kind(0) != diffRemoved || m.compare.kind(1) != diffAdded {
+		t.Errorf("diff = %v then %v, first %s", m.compare.kind(0), m.compare.kind(1), first.Path)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}, Alt: true})
+	m = updated.(Model)
+	if m.compare != nil || m.results.Len() != 3 {
+		t.Errorf("after leaving compare mode: compare = %v, %d results, want 3", m.compare, m.results.Len())
+	}
+}
+
+func TestToggleCompare_NeedsFinishedSearch(t *testing.T) {
+	m := newTestModel(t)
+	m = finishSearch(t, m, "match", testMatches(0, 3))
+
+	// A second search that is still running
+	m.executeSearch("matc", ".")
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 1)})
+	m = updated.(Model)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}, Alt: true})
+	m = updated.(Model)
+	if m.compare != nil || m.status.err == "" {
+		t.Errorf("compare = %v, error %q; want an error while searching", m.compare, m.status.err)
+	}
+}
+
+func TestToggleCompare_DropsDiffOfOldResults(t *testing.T) {
+	m := newTestModel(t)
+	m = finishSearch(t, m, "match", testMatches(0, 3))
+	m = finishSearch(t, m, "match", testMatches(1, 3))
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}, Alt: true})
+	m = updated.(Model)
+	m = finishSearch(t, m, "matc", testMatches(0, 2))
+
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if m.compare != nil || m.results.Len() != 2 {
+		t.Errorf("compare = %v with %d results; want the diff of the earlier results dropped", m.compare, m.results.Len())
+	}
+}
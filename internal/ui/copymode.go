@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// previewHeaderRows are the path and separator rows above the preview's
+// lines
+const previewHeaderRows = 2
+
+// copySelection is the line selection of the preview's copy mode. The
+// terminal can't select text itself while irg captures the mouse, so lines
+// are picked with the keyboard or by dragging, and copied to the clipboard.
+type copySelection struct {
+	active   bool
+	cursor   int  // Index into the preview's lines
+	anchor   int  // Other end of the selection; -1 when only the cursor line is selected
+	dragging bool // The left mouse button is held down
+}
+
+// bounds returns the first and last selected line indices
+func (c copySelection) bounds() (int, int) {
+	if c.anchor < 0 {
+		return c.cursor, c.cursor
+	}
+	return min(c.cursor, c.anchor), max(c.cursor, c.anchor)
+}
+
+// selected reports whether line index i is selected
+func (c copySelection) selected(i int) bool {
+	if !c.active {
+		return false
+	}
+	first, last := c.bounds()
+	return i >= first && i <= last
+}
+
+// startCopyMode enters copy mode at the preview's match line
+func (m *Model) startCopyMode() {
+	if len(m.preview.lines) == 0 {
+		m.status.err = "Nothing in the preview to copy"
+		return
+	}
+	cursor := clamp(m.preview.match-m.preview.start, 0, len(m.preview.lines)-1)
+	m.preview.copy = copySelection{active: true, cursor: cursor, anchor: -1}
+	m.updatePreviewView()
+}
+
+// stopCopyMode leaves copy mode without copying
+func (m *Model) stopCopyMode() {
+	m.preview.copy = copySelection{}
+	m.preview.view.SetYOffset(0)
+	m.updatePreviewView()
+}
+
+// yankSelection copies the selected preview lines and leaves copy mode
+func (m *Model) yankSelection() tea.Cmd {
+	first, last := m.preview.copy.bounds()
+	if last >= len(m.preview.lines) {
+		m.stopCopyMode()
+		return nil
+	}
+	text := strings.Join(m.preview.lines[first:last+1], "\n")
+	m.stopCopyMode()
+	return m.copyToClipboard(text)
+}
+
+// updateCopyMode handles key presses in copy mode: the movement keys move
+// the cursor, v starts or drops a selection, y or Enter copies and Esc leaves
+func (m Model) updateCopyMode(msg tea.KeyMsg, a action) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "k":
+		a = actionUp
+	case "j":
+		a = actionDown
+	case "v", "V":
+		if m.preview.copy.anchor < 0 {
+			m.preview.copy.anchor = m.preview.copy.cursor
+		} else {
+			m.preview.copy.anchor = -1
+		}
+		m.updatePreviewView()
+		return m, nil
+	case "y":
+		return m, m.yankSelection()
+	case "q":
+		a = actionClose
+	}
+
+	switch a {
+	case actionUp, actionPageUp, actionDown, actionPageDown:
+		step := map[action]int{actionUp: -1, actionPageUp: -10, actionDown: 1, actionPageDown: 10}[a]
+		m.preview.copy.cursor = clamp(m.preview.copy.cursor+step, 0, len(m.preview.lines)-1)
+		m.updatePreviewView()
+	case actionOpenEditor:
+		return m, m.yankSelection()
+	case actionClose, actionCopyMode:
+		m.stopCopyMode()
+	case actionQuit:
+		m.stopCopyMode()
+		return m.Update(msg)
+	}
+	return m, nil
+}
+
+// previewLineAt returns the index into previewLines of the preview line at
+// screen cell x, y
+func (m *Model) previewLineAt(x, y int) (int, bool) {
+	// Each pane has a one-cell border around its contents
+	left := m.list.view.Width + 2
+	if m.summaryVisible {
+		left += m.summaryWidth() + 2
+	}
+	if x <= left || x > left+m.preview.view.Width || y < 1 || y > m.preview.view.Height {
+		return 0, false
+	}
+	row := y - 1 + m.preview.view.YOffset - previewHeaderRows
+	if row < 0 {
+		return 0, false
+	}
+	// Outside copy mode a row of carets follows the match line
+	first, _ := m.preview.submatches(m.preview.match)
+	if match := m.preview.match - m.preview.start; !m.preview.copy.active && row > match && match >= 0 && match < len(m.preview.lines) &&
+		caretRow(m.preview.lines[match], first, m.preview.xOffset, m.preview.codeWidth()) != "" {
+		row--
+	}
+	if row >= len(m.preview.lines) {
+		return 0, false
+	}
+	return row, true
+}
+
+// handleCopyMouse selects preview lines by dragging with the left button,
+// copying them when the button is released. It reports whether the event
+// was used.
+func (m *Model) handleCopyMouse(msg tea.MouseMsg) (tea.Cmd, bool) {
+	switch msg.Action {
+	case tea.MouseActionPress:
+		if msg.Button != tea.MouseButtonLeft {
+			return nil, false
+		}
+		i, ok := m.previewLineAt(msg.X, msg.Y)
+		if !ok {
+			return nil, false
+		}
+		m.preview.copy = copySelection{active: true, cursor: i, anchor: i, dragging: true}
+		m.updatePreviewView()
+		return nil, true
+	case tea.MouseActionMotion:
+		if !m.preview.copy.dragging {
+			return nil, false
+		}
+		if i, ok := m.previewLineAt(msg.X, msg.Y); ok {
+			m.preview.copy.cursor = i
+			m.updatePreviewView()
+		}
+		return nil, true
+	case tea.MouseActionRelease:
+		if !m.preview.copy.dragging {
+			return nil, false
+		}
+		return m.yankSelection(), true
+	}
+	return nil, false
+}
+
+// copyStatus describes copy mode in the status line
+func (m *Model) copyStatus() string {
+	first, last := m.preview.copy.bounds()
+	return fmt.Sprintf("Copy mode: %d line(s) | ↑/↓ move  v select  y copy  Esc leave", last-first+1)
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/clipboard"
+)
+
+// copyModeModel returns a model previewing five lines with the match on
+// the third
+func copyModeModel(t *testing.T) (Model, *clipboard.Mock) {
+	t.Helper()
+	m := newTestModel(t)
+	cb := &clipboard.Mock{}
+	m.SetClipboard(cb)
+	m.preview.path = "main.go"
+	m.preview.lines = []string{"one", "two", "three", "four", "five"}
+	m.preview.start = 10
+	m.preview.match = 12
+	m.updatePreviewView()
+	return m, cb
+}
+
+func TestCopyMode_VisualSelectionYanks(t *testing.T) {
+	m, cb := copyModeModel(t)
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune{'k'}, Alt: true},
+		{Type: tea.KeyUp},
+		{Type: tea.KeyRunes, Runes: []rune{'v'}},
+		{Type: tea.KeyRunes, Runes: []rune{'j'}},
+		{Type: tea.KeyDown},
+	}
+	for _, key := range keys {
+		updated, _ := m.Update(key)
+		m = updated.(Model)
+	}
+	if !m.preview.copy.active {
+		t.Fatal("Alt+K didn't enter copy mode")
+	}
+	if view := ansi.Strip(m.preview.view.View()); !strings.Contains(view, "> 13 four") {
+		t.Errorf("preview doesn't mark the cursor line:\n%s", view)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("y returned no command")
+	}
+	cmd()
+	if got := cb.Last(); got != "two\nthree\nfour" {
+		t.Errorf("copied %q, want lines two to four", got)
+	}
+	if m.preview.copy.active || m.inputs.pattern.Value() != "" {
+		t.Errorf("copy mode active = %v, pattern = %q; want it left without typing", m.preview.copy.active, m.inputs.pattern.Value())
+	}
+}
+
+func TestCopyMode_MouseDragYanks(t *testing.T) {
+	m, cb := copyModeModel(t)
+	x := m.list.view.Width + 5
+	// Row 0 is the border, then the path and separator rows; the match
+	// line at index 2 is followed by no carets since it has no submatches
+	for _, msg := range []tea.MouseMsg{
+		{X: x, Y: 3, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress},
+		{X: x, Y: 5, Button: tea.MouseButtonLeft, Action: tea.MouseActionMotion},
+	} {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(tea.MouseMsg{X: x, Y: 5, Action: tea.MouseActionRelease})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("releasing the button returned no command")
+	}
+	cmd()
+	if got := cb.Last(); got != "one\ntwo\nthree" {
+		t.Errorf("copied %q, want lines one to three", got)
+	}
+	if m.preview.copy.active {
+		t.Error("copy mode still active after the drag")
+	}
+}
+
+func TestCopyMode_ClickOutsidePreviewIgnored(t *testing.T) {
+	m, _ := copyModeModel(t)
+	updated, _ := m.Update(tea.MouseMsg{X: 2, Y: 3, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	m = updated.(Model)
+	if m.preview.copy.active {
+		t.Error("a click in the results pane started a selection")
+	}
+}
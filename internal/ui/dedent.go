@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// indentMarker stands in for the indentation stripped from result lines
+const indentMarker = "⇥"
+
+// commonIndent returns the length in bytes of the leading spaces and tabs
+// that all of lines share; blank lines don't count
+func commonIndent(lines ...string) int {
+	common, found := "", false
+	for _, line := range lines {
+		body := strings.TrimLeft(line, " \t")
+		if body == "" {
+			continue
+		}
+		indent := line[:len(line)-len(body)]
+		if !found {
+			common, found = indent, true
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(indent) && common[n] == indent[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return len(common)
+}
+
+// cutIndent strips the first n bytes of indentation from line, or all of it
+// from a blank line shorter than that
+func cutIndent(line string, n int) string {
+	if len(line) < n {
+		return strings.TrimLeft(line, " \t")
+	}
+	return line[n:]
+}
+
+// shiftSubmatches moves submatches n bytes to the left, clamping any that
+// start inside the stripped indentation
+func shiftSubmatches(submatches []search.Submatch, n int) []search.Submatch {
+	if n == 0 {
+		return submatches
+	}
+	shifted := make([]search.Submatch, 0, len(submatches))
+	for _, sm := range submatches {
+		if sm.End <= n {
+			continue
+		}
+		shifted = append(shifted, search.Submatch{Match: sm.Match, Start: max(sm.Start-n, 0), End: sm.End - n})
+	}
+	return shifted
+}
+
+// dedentResult strips the indentation shared by a result's line and its
+// inline context lines, so the narrow results column shows the code rather
+// than whitespace. The submatches are shifted to match, and the returned
+// marker is shown in place of the indentation when any was stripped.
+func (m *Model) dedentResult(lineText string, match search.Match) (text string, submatches []search.Submatch, before, after []string, marker string) {
+	lines := []string{lineText}
+	if m.resultRows() > 1 {
+		lines = append(append(lines, match.Before...), match.After...)
+	}
+	n := commonIndent(lines...)
+	if n == 0 {
+		return lineText, match.Submatches, match.Before, match.After, ""
+	}
+	for _, line := range match.Before {
+		before = append(before, cutIndent(line, n))
+	}
+	for _, line := range match.After {
+		after = append(after, cutIndent(line, n))
+	}
+	marker = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(indentMarker)
+	return lineText[n:], shiftSubmatches(match.Submatches, n), before, after, marker
+}
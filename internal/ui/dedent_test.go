@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestCommonIndent(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  int
+	}{
+		{"no indent", []string{"func main() {"}, 0},
+		{"single line", []string{"\t\treturn err"}, 2},
+		{"shared prefix", []string{"        x := 1", "    }", "        y := 2"}, 4},
+		{"blank lines ignored", []string{"\t\tfoo()", "", "\t"}, 2},
+		{"mixed tabs and spaces", []string{"\t  a", "\t\tb"}, 1},
+		{"all blank", []string{"   ", ""}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commonIndent(tt.lines...); got != tt.want {
+				t.Errorf("commonIndent(%q) = %d, want %d", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShiftSubmatches_ClampsIntoIndent(t *testing.T) {
+	got := shiftSubmatches([]search.Submatch{{Match: "  ", Start: 0, End: 2}, {Match: " x", Start: 3, End: 5}, {Match: "y", Start: 8, End: 9}}, 4)
+	want := []search.Submatch{{Match: " x", Start: 0, End: 1}, {Match: "y", Start: 4, End: 5}}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("submatch %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRenderResultLine_Dedents(t *testing.T) {
+	m := newTestModel(t)
+	match := search.Match{
+		Path:       "main.go",
+		LineNumber: 3,
+		LineText:   "\t\treturn needle\n",
+		Submatches: []search.Submatch{{Match: "needle", Start: 9, End: 15}},
+	}
+	line := ansi.Strip(m.renderResultLine(match, false, false, false, diffNone))
+	if !strings.Contains(line, "main.go:3: "+indentMarker+"return needle") {
+		t.Errorf("line = %q, want the indentation replaced by the marker", line)
+	}
+
+	m.inlineContext = true
+	match.Before = []string{"\tif err != nil {"}
+	match.After = []string{"\t}"}
+	rows := strings.Split(ansi.Strip(m.renderResultLine(match, false, false, false, diffNone)), "\n")
+	if !strings.Contains(rows[0], "2- if err") || !strings.Contains(rows[1], indentMarker+expandTabs("\treturn needle")) {
+		t.Errorf("rows = %q, want one shared tab stripped and the rest kept", rows)
+	}
+}
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/difftool"
+)
+
+// diffTarget is a file to compare with its HEAD version, and the line to
+// show first
+type diffTarget struct {
+	path string
+	line int
+}
+
+// diffHeadMsg carries the HEAD version of a file written for the diff tool
+type diffHeadMsg struct {
+	target diffTarget
+	old    string
+	added  bool
+	err    error
+}
+
+type diffFinishedMsg struct {
+	err error
+}
+
+// startDiff compares the files of the marked results, or the selected
+// result's file when none are marked, with their HEAD versions, one after
+// another
+func (m *Model) startDiff() tea.Cmd {
+	if m.remote {
+		m.status.err = "Remote results can't be compared; Ctrl+Y copies the path"
+		return nil
+	}
+	targets, err := m.markedFiles()
+	if err != nil {
+		m.status.err = err.Error()
+		return nil
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	m.diffQueue = targets
+	m.diffTotal = len(targets)
+	return m.nextDiff()
+}
+
+// markedFiles returns the distinct files of the marked results, each at
+// its first marked line, or the selected result
+func (m *Model) markedFiles() ([]diffTarget, error) {
+	if len(m.marked) == 0 {
+		match, ok := m.selectedMatch()
+		if !ok {
+			return nil, nil
+		}
+		return []diffTarget{{path: match.Path, line: match.LineNumber}}, nil
+	}
+
+	indices := make([]int, 0, len(m.marked))
+	for i := range m.marked {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	seen := make(map[string]bool)
+	var targets []diffTarget
+	for _, i := range indices {
+		match, err := m.results.Get(i)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[match.Path] {
+			seen[match.Path] = true
+			targets = append(targets, diffTarget{path: match.Path, line: match.LineNumber})
+		}
+	}
+	return targets, nil
+}
+
+// nextDiff starts the diff tool on the next queued file, writing its HEAD
+// version first when the command compares two files
+func (m *Model) nextDiff() tea.Cmd {
+	target := m.diffQueue[0]
+	m.diffQueue = m.diffQueue[1:]
+	if !m.diffTool.NeedsHead() {
+		return m.launchDiff(target, "", false)
+	}
+	return func() tea.Msg {
+		old, added, err := difftool.Head(context.Background(), target.path)
+		return diffHeadMsg{target: target, old: old, added: added, err: err}
+	}
+}
+
+// launchDiff runs the diff tool on target against old, the HEAD version,
+// and removes old once the tool exits
+func (m *Model) launchDiff(target diffTarget, old string, added bool) tea.Cmd {
+	m.status.err = ""
+	m.status.message = fmt.Sprintf("Diff %d/%d: %s", m.diffTotal-len(m.diffQueue), m.diffTotal, displayPath(target.path))
+	if added {
+		m.status.message += " (not in HEAD)"
+	}
+	return tea.ExecProcess(m.diffTool.Cmd(old, target.path, target.line), func(err error) tea.Msg {
+		if old != "" {
+			os.Remove(old)
+		}
+		return diffFinishedMsg{err: err}
+	})
+}
+
+// handleDiffMsg moves the diff queue along as files are compared
+func (m *Model) handleDiffMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case diffHeadMsg:
+		if msg.err != nil {
+			m.diffQueue = nil
+			m.status.err = fmt.Sprintf("Diff error: %v", msg.err)
+			return nil
+		}
+		return m.launchDiff(msg.target, msg.old, msg.added)
+
+	case diffFinishedMsg:
+		if msg.err != nil {
+			m.diffQueue = nil
+			m.status.err = fmt.Sprintf("Diff tool error: %v", msg.err)
+			return nil
+		}
+		if len(m.diffQueue) > 0 {
+			return m.nextDiff()
+		}
+		if m.diffTotal > 1 {
+			m.status.message = fmt.Sprintf("Compared %d files with HEAD", m.diffTotal)
+		}
+	}
+	return nil
+}
+
+// SetDiffCommand sets the diff tool template used by the diff-head action
+func (m *Model) SetDiffCommand(command string) {
+	m.diffTool = difftool.New(command)
+}
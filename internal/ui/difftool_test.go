@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiffTargets(t *testing.T) {
+	m := newTestModel(t)
+	matches := testMatches(0, 4)
+	matches[2].Path = "file0.go" // Second match in file0.go
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	m = updated.(Model)
+
+	m.list.selected = 1
+	got, err := m.markedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []diffTarget{{"file1.go", 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unmarked targets = %v, want the selection %v", got, want)
+	}
+
+	m.marked = map[int]bool{3: true, 2: true, 0: true}
+	got, err = m.markedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []diffTarget{{"file0.go", 1}, {"file3.go", 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marked targets = %v, want %v", got, want)
+	}
+}
+
+func TestDiff_QueueStopsOnError(t *testing.T) {
+	m := newTestModel(t)
+	m.SetDiffCommand("vimdiff {old} {new}")
+	m.diffQueue = []diffTarget{{"a.go", 1}, {"b.go", 1}}
+	m.diffTotal = 2
+
+	if cmd := m.nextDiff(); cmd == nil {
+		t.Fatal("no command to write the HEAD version")
+	}
+	if len(m.diffQueue) != 1 {
+		t.Fatalf("queue = %v, want b.go left", m.diffQueue)
+	}
+	m.handleDiffMsg(diffHeadMsg{target: diffTarget{"a.go", 1}, err: errors.New("not in a git repository")})
+	if m.diffQueue != nil || m.status.err == "" {
+		t.Errorf("queue = %v, error %q; want the queue dropped and the error shown", m.diffQueue, m.status.err)
+	}
+}
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// typeDropdownMaxHeight is how many types the types dropdown shows at once
+const typeDropdownMaxHeight = 8
+
+// dropdown is the list of suggestions opened above an input, such as the
+// types matching the one being typed. It keeps the highlighted item inside
+// its window of height rows.
+type dropdown[T any] struct {
+	items   []T
+	index   int // Highlighted item
+	visible bool
+	height  int // Items shown at once
+}
+
+func newDropdown[T any](height int) dropdown[T] {
+	return dropdown[T]{height: height}
+}
+
+// show replaces the items, opening the dropdown when there are any
+func (d *dropdown[T]) show(items []T) {
+	d.items = items
+	d.visible = len(items) > 0
+	if d.index >= len(items) {
+		d.index = 0
+	}
+}
+
+// setItems replaces the items without opening or closing the dropdown
+func (d *dropdown[T]) setItems(items []T) {
+	d.items = items
+	if d.index >= len(items) {
+		d.index = 0
+	}
+}
+
+func (d *dropdown[T]) hide() {
+	d.visible = false
+}
+
+// selected returns the highlighted item of an open dropdown
+func (d *dropdown[T]) selected() (T, bool) {
+	if !d.visible || d.index >= len(d.items) {
+		var zero T
+		return zero, false
+	}
+	return d.items[d.index], true
+}
+
+// Update moves the highlight of an open dropdown up or down, wrapping around
+// at either end. It reports whether a was used.
+func (d *dropdown[T]) Update(a action) bool {
+	if !d.visible || len(d.items) == 0 {
+		return false
+	}
+	switch a {
+	case actionUp:
+		d.index = (d.index + len(d.items) - 1) % len(d.items)
+	case actionDown:
+		d.index = (d.index + 1) % len(d.items)
+	default:
+		return false
+	}
+	return true
+}
+
+// View renders the window of items in a box width cells wide. label returns
+// the text of an item, highlighted along with its "> " marker, and a detail
+// shown after it; footer, if any, follows the position counter.
+func (d *dropdown[T]) View(width int, label func(item T) (text, detail string), footer string) string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Background(lipgloss.Color("235")).
+		Padding(0, 1).
+		Width(width)
+
+	var sb strings.Builder
+	start := 0
+	if d.index >= d.height {
+		start = d.index - d.height + 1
+	}
+	end := min(start+d.height, len(d.items))
+	for i := start; i < end; i++ {
+		text, detail := label(d.items[i])
+		if i == d.index {
+			sb.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")).Render("> " + text))
+		} else {
+			sb.WriteString("  " + text)
+		}
+		sb.WriteString(detail + "\n")
+	}
+
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	if len(d.items) > d.height {
+		sb.WriteString(hintStyle.Render(fmt.Sprintf("  [ %d/%d ]", d.index+1, len(d.items))))
+	}
+	if footer != "" {
+		sb.WriteString(hintStyle.Render(footer))
+	}
+	return style.Render(strings.TrimSuffix(sb.String(), "\n"))
+}
+
+// updateDropdowns handles the keys that move in, pick from and close the
+// open dropdown. It reports whether a was used.
+func (m *Model) updateDropdowns(a action) (tea.Cmd, bool) {
+	pathOpen := m.inputs.focused == focusPath && m.pathDropdown.visible
+	switch a {
+	case actionUp, actionDown:
+		if pathOpen {
+			return nil, m.pathDropdown.Update(a)
+		}
+		return nil, m.typeDropdown.Update(a)
+
+	case actionOpenEditor:
+		if entry, ok := m.pathDropdown.selected(); ok && pathOpen {
+			m.inputs.path.SetValue(entry.Path)
+			m.inputs.path.SetCursor(len(entry.Path))
+			m.pathDropdown.hide()
+			if pattern := m.inputs.pattern.Value(); pattern != "" {
+				return m.executeSearch(pattern, entry.Path), true
+			}
+			return nil, true
+		}
+		if typ, ok := m.typeDropdown.selected(); ok {
+			// Complete the type being typed, the last of the list
+			parts := strings.Split(m.inputs.types.Value(), ",")
+			parts[len(parts)-1] = typ
+			value := strings.Join(parts, ",")
+			m.inputs.types.SetValue(value)
+			m.inputs.types.SetCursor(len(value))
+			m.typeDropdown.hide()
+			m.fileTypes = parseTypes(value)
+			return m.executeSearch(m.inputs.pattern.Value(), m.inputs.path.Value()), true
+		}
+
+	case actionClose:
+		if m.pathDropdown.visible {
+			m.pathDropdown.hide()
+			return nil, true
+		}
+		if m.typeDropdown.visible {
+			m.typeDropdown.hide()
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// filterDropdowns refreshes the suggestions for the focused input after a
+// key press
+func (m *Model) filterDropdowns() {
+	switch m.inputs.focused {
+	case focusTypes:
+		parts := strings.Split(m.inputs.types.Value(), ",")
+		last := strings.TrimSpace(parts[len(parts)-1])
+		if last == "" {
+			m.typeDropdown.hide()
+			return
+		}
+		var types []string
+		for _, t := range m.allTypes {
+			if strings.HasPrefix(t, last) {
+				types = append(types, t)
+			}
+		}
+		m.typeDropdown.show(types)
+		m.refreshTypeCounts()
+	case focusPath:
+		// Local path suggestions don't apply to a remote backend's repo filter
+		if m.pathsLoaded && !m.remote {
+			m.pathDropdown.show(m.pathProvider.FilterPaths(m.inputs.path.Value(), m.allPaths))
+		}
+	}
+}
+
+// overlayDropdowns draws the open dropdown over view, opening upward from
+// its input at row inputTop over the bottom of the panes
+func (m *Model) overlayDropdowns(view string, inputTop int) string {
+	if m.typeDropdown.visible {
+		box := m.typeDropdown.View(m.inputs.types.Width+2, func(t string) (string, string) {
+			text := t
+			if slices.Contains(m.fileTypes, t) {
+				text += " [✓]"
+			}
+			return text, m.typeDetail(t, m.inputs.types.Width-ansi.StringWidth("> "+text))
+		}, "")
+		return m.overlayDropdown(view, box, m.inputs.left(focusTypes), inputTop)
+	}
+	if m.pathDropdown.visible {
+		footer := ""
+		if m.pathsIndexing {
+			footer = fmt.Sprintf("  indexing... %d paths", len(m.allPaths))
+		}
+		box := m.pathDropdown.View(m.inputs.path.Width+2, func(entry PathEntry) (string, string) {
+			icon := "📄"
+			if entry.IsDir {
+				icon = "📁"
+			}
+			return icon + " " + entry.Path, ""
+		}, footer)
+		return m.overlayDropdown(view, box, m.inputs.left(focusPath), inputTop)
+	}
+	return view
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestDropdown_WrapsAndScrolls(t *testing.T) {
+	d := newDropdown[string](2)
+	d.show([]string{"go", "gn", "gradle"})
+	if !d.Update(actionUp) || d.index != 2 {
+		t.Fatalf("index = %d after Up from the top, want it to wrap to 2", d.index)
+	}
+
+	view := ansi.Strip(d.View(20, func(s string) (string, string) { return s, "" }, ""))
+	if strings.Contains(view, "go") || !strings.Contains(view, "> gradle") || !strings.Contains(view, "[ 3/3 ]") {
+		t.Errorf("window doesn't follow the highlight:\n%s", view)
+	}
+
+	if !d.Update(actionDown) || d.index != 0 {
+		t.Errorf("index = %d after Down from the bottom, want 0", d.index)
+	}
+	if d.Update(actionOpenEditor) {
+		t.Error("dropdown used a key it doesn't handle")
+	}
+	d.hide()
+	if _, ok := d.selected(); ok || d.Update(actionDown) {
+		t.Error("closed dropdown still has a selection or handles keys")
+	}
+}
+
+func TestDropdown_ShowKeepsIndexInRange(t *testing.T) {
+	d := newDropdown[string](8)
+	d.show([]string{"a", "b", "c"})
+	d.index = 2
+	d.show([]string{"a"})
+	if item, ok := d.selected(); !ok || item != "a" {
+		t.Errorf("selected = %q, %v; want a", item, ok)
+	}
+	d.show(nil)
+	if d.visible {
+		t.Error("dropdown open with no items")
+	}
+}
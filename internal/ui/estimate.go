@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"regexp"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// estimateCount counts the current results whose text pattern matches, as an
+// instant guess at what the search waiting for typing to stop will find. It
+// is exact when the new pattern narrows the last one, as typing more of it
+// does. It reports false when there is nothing to go on or the pattern is
+// not a regexp Go understands, such as one half typed.
+func (m *Model) estimateCount(pattern string) (int, bool) {
+	n := m.results.Len()
+	if pattern == "" || n == 0 || m.remote {
+		return 0, false
+	}
+	expr := pattern
+	if m.wholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	switch m.caseSensitivity {
+	case search.CaseInsensitive:
+		expr = "(?i)" + expr
+	case search.CaseSmart:
+		if !search.SmartCaseSensitive(pattern) {
+			expr = "(?i)" + expr
+		}
+	}
+	if m.multiline {
+		expr = "(?s)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return 0, false
+	}
+	matches, err := m.results.Slice(0, n)
+	if err != nil {
+		return 0, false
+	}
+	count := 0
+	for _, match := range matches {
+		if re.MatchString(match.LineText) {
+			count++
+		}
+	}
+	return count, true
+}
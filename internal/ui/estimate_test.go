@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestTypeAhead_EstimatesFromLastResults(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 25)...)
+	m.inputs.pattern.SetValue("match number")
+	m.lastPattern = "match number"
+	m = runSearch(t, m, m.executeSearch("match number", "."))
+
+	// "match number 1" matches 1 and 10-19
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" 1")})
+	m = updated.(Model)
+	if status := ansi.Strip(m.statusLine()); !strings.Contains(status, "~11 matches") {
+		t.Errorf("status while typing = %q, want an estimate of 11", status)
+	}
+
+	updated, cmd := m.Update(debounceMsg{token: m.debounceToken, pattern: "match number 1", path: "."})
+	m = runSearch(t, updated.(Model), cmd)
+	if status := ansi.Strip(m.statusLine()); strings.Contains(status, "~") {
+		t.Errorf("status after the search = %q, want the estimate gone", status)
+	}
+}
+
+func TestTypeAhead_NoEstimate(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 5)...)
+	m = runSearch(t, m, m.executeSearch("match", "."))
+
+	if _, ok := m.estimateCount("match ("); ok {
+		t.Error("estimated a half-typed group")
+	}
+	if n, ok := m.estimateCount("MATCH"); !ok || n != 0 {
+		t.Errorf("smart case MATCH = %d, %v; want 0 as rg is case sensitive for it", n, ok)
+	}
+	m.caseSensitivity = search.CaseInsensitive
+	if n, ok := m.estimateCount("MATCH"); !ok || n != 5 {
+		t.Errorf("insensitive MATCH = %d, %v; want 5", n, ok)
+	}
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/explain"
+	"github.com/William9923/irg/internal/search"
+)
+
+// explainLines describes the pattern being typed for the explain overlay
+func (m *Model) explainLines() []string {
+	pattern := m.inputs.pattern.Value()
+	if pattern == "" {
+		return []string{"Type a pattern to see it explained"}
+	}
+
+	caseMode := m.getCaseSensitivityName()
+	if m.caseSensitivity == search.CaseSmart {
+		if search.SmartCaseSensitive(pattern) {
+			caseMode += " (sensitive: the pattern has uppercase)"
+		} else {
+			caseMode += " (insensitive)"
+		}
+	}
+	lines := []string{"Pattern: " + pattern, "Case: " + caseMode, ""}
+
+	parts, err := explain.Regex(pattern)
+	if err != nil {
+		return append(lines, "Invalid regex: "+err.Error())
+	}
+	return append(lines, parts...)
+}
+
+// searchCommand returns the rg command line a search for the current
+// pattern and path runs, for reproducing it outside irg
+func (m *Model) searchCommand() (string, error) {
+	if m.remote {
+		return "", errors.New("remote searches don't run rg")
+	}
+	pattern := m.inputs.pattern.Value()
+	if pattern == "" {
+		return "", errors.New("type a pattern first")
+	}
+	opts := m.searchOptions()
+	opts.FixedStrings = pattern == m.literalPattern
+	command := search.CommandLine(pattern, m.searchPath(), opts)
+	if opts.Shards > 0 {
+		command += fmt.Sprintf("  # split across up to %d rg processes", opts.Shards)
+	}
+	return command, nil
+}
+
+// copySearchCommand copies the rg command line of the current search
+func (m *Model) copySearchCommand() tea.Cmd {
+	command, err := m.searchCommand()
+	if err != nil {
+		m.status.err = "No rg command: " + err.Error()
+		return nil
+	}
+	return m.copyToClipboard(command)
+}
+
+// renderExplain renders the explain overlay, at most width cells wide and
+// height rows tall
+func (m *Model) renderExplain(width, height int) string {
+	lines := m.explainLines()
+	// Border, padding and the footer take four rows and four columns
+	textWidth := max(width-4, 10)
+	footer := "Press any key to close"
+	var commandLines []string
+	if command, err := m.searchCommand(); err == nil {
+		commandLines = append([]string{"", "Runs:"}, strings.Split(ansi.Wrap(command, textWidth, " "), "\n")...)
+		footer = "Press y to copy the command, any other key to close"
+	}
+	if maxLines := max(height-4-len(commandLines), 1); len(lines) > maxLines {
+		lines = append(lines[:maxLines-1], fmt.Sprintf("... %d more", len(lines)-maxLines+1))
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, textWidth, "…")
+	}
+	lines = append(lines, commandLines...)
+	lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(footer))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
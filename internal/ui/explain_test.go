@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/clipboard"
+)
+
+func TestExplain_OverlayShowsBreakdownAndCloses(t *testing.T) {
+	m := newTestModel(t)
+	m.inputs.pattern.SetValue(`Foo\w+$`)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}, Alt: true})
+	m = updated.(Model)
+	view := ansi.Strip(m.View())
+	for _, want := range []string{`literal "Foo"`, `word character (\w)`, "end of line ($)", "sensitive: the pattern has uppercase"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("explain overlay missing %q:\n%s", want, view)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m = updated.(Model)
+	if m.explainVisible {
+		t.Error("a key press didn't close the overlay")
+	}
+	if m.inputs.pattern.Value() != `Foo\w+$` {
+		t.Errorf("closing key reached the pattern: %q", m.inputs.pattern.Value())
+	}
+}
+
+func TestExplainLines_InvalidPattern(t *testing.T) {
+	m := newTestModel(t)
+	m.inputs.pattern.SetValue("foo(")
+	lines := m.explainLines()
+	if !strings.HasPrefix(lines[len(lines)-1], "Invalid regex:") {
+		t.Errorf("explainLines = %q, want an invalid regex line", lines)
+	}
+}
+
+func TestExplain_CopiesSearchCommand(t *testing.T) {
+	m := newTestModel(t)
+	cb := &clipboard.Mock{}
+	m.SetClipboard(cb)
+	m.inputs.pattern.SetValue("needle")
+	m.inputs.path.SetValue("src")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}, Alt: true})
+	m = updated.(Model)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "-- needle src") {
+		t.Errorf("explain overlay doesn't show the rg command:\n%s", view)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("y in the overlay returned no command")
+	}
+	cmd()
+	if got := cb.Last(); !strings.HasPrefix(got, "rg ") || !strings.HasSuffix(got, "-- needle src") {
+		t.Errorf("copied %q, want the rg command line", got)
+	}
+	if m.inputs.pattern.Value() != "needle" {
+		t.Errorf("y reached the pattern: %q", m.inputs.pattern.Value())
+	}
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// fileInfoWidth is the width of the age and size badge before each result
+const fileInfoWidth = 11
+
+// fileInfo is what the badges show of a matched file
+type fileInfo struct {
+	modTime time.Time
+	size    int64
+	ok      bool // The file could be stat'ed
+}
+
+// fileInfoFor stats path, caching the result until the next search
+func (m *Model) fileInfoFor(path string) fileInfo {
+	if info, ok := m.fileInfos[path]; ok {
+		return info
+	}
+	info := statFile(path)
+	m.fileInfos[path] = info
+	return info
+}
+
+// statFile returns the size and modification time of path
+func statFile(path string) fileInfo {
+	st, err := os.Stat(path)
+	if err != nil {
+		return fileInfo{}
+	}
+	return fileInfo{modTime: st.ModTime(), size: st.Size(), ok: true}
+}
+
+// fileInfoBadge renders the age and size of path, padded to fileInfoWidth
+func (m *Model) fileInfoBadge(path string) string {
+	info := m.fileInfoFor(path)
+	text := "   ?      "
+	if info.ok {
+		text = fmt.Sprintf("%4s %5s", formatAge(time.Since(info.modTime)), formatSize(info.size))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("243")).Render(text) + " "
+}
+
+// formatAge renders d in at most four cells, such as "45s", "2d" or "11mo"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(0, int(d.Seconds())))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/24/365))
+	}
+}
+
+// formatSize renders n bytes in at most five cells, such as "812B" or "1.4M"
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, "K"
+	for _, s := range []string{"M", "G", "T"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%s", value, suffix)
+	}
+	return fmt.Sprintf("%.0f%s", value, suffix)
+}
+
+// toggleFileInfo shows or hides the age and size badges
+func (m *Model) toggleFileInfo() {
+	if m.remote {
+		m.status.err = "File ages and sizes aren't available for remote results"
+		return
+	}
+	m.showFileInfo = !m.showFileInfo
+	m.list.cache.invalidate()
+	m.updateResultsView()
+}
+
+// toggleRecentSort lists the results of the most recently modified files
+// first, or restores the order ripgrep found them in
+func (m *Model) toggleRecentSort() {
+	if m.remote {
+		m.status.err = "Remote results can't be sorted by file age"
+		return
+	}
+	if m.compare != nil {
+		m.status.err = "Leave compare mode before sorting the results"
+		return
+	}
+	m.sortRecent = !m.sortRecent
+	switch {
+	case m.sortRecent && !m.searching:
+		m.sortByRecency()
+		m.status.message = "Newest files first"
+	case m.sortRecent:
+		m.status.message = "Newest files first once the search finishes"
+	case m.recentOrder != nil:
+		// recentOrder[k] is where result k was before sorting
+		inverse := make([]int, len(m.recentOrder))
+		for k, old := range m.recentOrder {
+			inverse[old] = k
+		}
+		m.recentOrder = nil
+		m.reorderResults(inverse)
+		m.status.message = "Results in search order"
+	}
+}
+
+// sortByRecency stably reorders the results by their file's modification
+// time, newest first; files that can't be stat'ed go last
+func (m *Model) sortByRecency() {
+	n := m.results.Len()
+	matches, err := m.results.Slice(0, n)
+	if err != nil {
+		m.status.err = err.Error()
+		return
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := m.fileInfoFor(matches[order[a]].Path), m.fileInfoFor(matches[order[b]].Path)
+		if ia.ok != ib.ok {
+			return ia.ok
+		}
+		return ia.modTime.After(ib.modTime)
+	})
+	m.recentOrder = order
+	m.reorderResults(order)
+}
+
+// reorderResults rearranges the results so that position k holds the result
+// at order[k], keeping marks, notes and the selection on their results
+func (m *Model) reorderResults(order []int) {
+	matches, err := m.results.Slice(0, m.results.Len())
+	if err != nil {
+		m.status.err = err.Error()
+		return
+	}
+	if len(matches) != len(order) {
+		return
+	}
+	reordered := make([]search.Match, len(order))
+	moved := make([]int, len(order)) // moved[old] is the result's new index
+	for k, old := range order {
+		reordered[k] = matches[old]
+		moved[old] = k
+	}
+	m.cancelCompare()
+	m.results.Reset()
+	if err := m.results.Append(reordered...); err != nil {
+		m.status.err = err.Error()
+		return
+	}
+
+	marked := make(map[int]bool, len(m.marked))
+	for i := range m.marked {
+		marked[moved[i]] = true
+	}
+	notes := make(map[int]string, len(m.notes))
+	for i, note := range m.notes {
+		notes[moved[i]] = note
+	}
+	m.marked, m.notes = marked, notes
+	if m.list.selected < len(moved) {
+		m.list.selected = moved[m.list.selected]
+	}
+	// Count again for the results tree, whose files start elsewhere now
+	m.summary.reset(m.summary.root)
+	m.refreshSummary()
+	m.list.cache.invalidate()
+	m.updateResultsView()
+	m.updatePreviewView()
+}
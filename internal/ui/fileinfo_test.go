@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{-time.Second, "0s"},
+		{45 * time.Second, "45s"},
+		{5 * time.Minute, "5m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+		{100 * 24 * time.Hour, "3mo"},
+		{800 * 24 * time.Hour, "2y"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.age); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0B"},
+		{812, "812B"},
+		{1536, "1.5K"},
+		{14 * 1024, "14K"},
+		{3 << 20, "3.0M"},
+		{512 << 30, "512G"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.size); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+// recentFiles writes files whose modification times are ages ago
+func recentFiles(t *testing.T, ages ...time.Duration) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i, age := range ages {
+		path := filepath.Join(dir, string(rune('a'+i))+".go")
+		if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		when := time.Now().Add(-age)
+		if err := os.Chtimes(path, when, when); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestToggleRecentSort_NewestFirstAndBack(t *testing.T) {
+	paths := recentFiles(t, 48*time.Hour, time.Hour, 24*time.Hour)
+	m := newTestModel(t)
+	var matches []search.Match
+	for _, path := range append(paths, filepath.Join(filepath.Dir(paths[0]), "gone.go")) {
+		matches = append(matches, search.Match{Path: path, LineNumber: 1, LineText: "needle\n"})
+	}
+	if err := m.results.Append(matches...); err != nil {
+		t.Fatal(err)
+	}
+	m.marked[0] = true
+	m.notes[0] = "oldest"
+	m.list.selected = 1
+
+	m.toggleRecentSort()
+	var got []string
+	for i := 0; i < m.results.Len(); i++ {
+		match, _ := m.results.Get(i)
+		got = append(got, filepath.Base(match.Path))
+	}
+	if want := "b.go c.go a.go gone.go"; strings.Join(got, " ") != want {
+		t.Errorf("sorted order = %v, want %s", got, want)
+	}
+	if !m.marked[2] || m.notes[2] != "oldest" || len(m.marked) != 1 {
+		t.Errorf("marks = %v, notes = %v, want them on a.go at 2", m.marked, m.notes)
+	}
+	if m.list.selected != 0 {
+		t.Errorf("selection = %d, want it to follow b.go to 0", m.list.selected)
+	}
+
+	m.toggleRecentSort()
+	for i, want := range matches {
+		if match, _ := m.results.Get(i); match.Path != want.Path {
+			t.Errorf("result %d = %s after toggling back, want %s", i, match.Path, want.Path)
+		}
+	}
+	if !m.marked[0] || m.list.selected != 1 {
+		t.Errorf("marks = %v, selection = %d, want the original ones", m.marked, m.list.selected)
+	}
+}
+
+func TestSortRecent_AppliedWhenSearchFinishes(t *testing.T) {
+	paths := recentFiles(t, 24*time.Hour, time.Minute)
+	m := newTestModel(t)
+	m.SetSortRecent(true)
+	m.searching = true
+	m.searchCtx, m.searchCancel = context.WithCancel(context.Background())
+	defer m.searchCancel()
+	var matches []search.Match
+	for _, path := range paths {
+		matches = append(matches, search.Match{Path: path, LineNumber: 1, LineText: "needle\n"})
+	}
+
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	m = updated.(Model)
+	if first, _ := m.results.Get(0); first.Path != paths[1] {
+		t.Errorf("first result = %s, want the newest file %s", first.Path, paths[1])
+	}
+}
+
+func TestRenderResultLine_FileInfoBadge(t *testing.T) {
+	paths := recentFiles(t, 3*time.Hour)
+	m := newTestModel(t)
+	m.SetFileInfo(true)
+
+	line := ansi.Strip(m.renderResultLine(search.Match{Path: paths[0], LineNumber: 1, LineText: "needle\n"}, false, false, false, diffNone))
+	if !strings.Contains(line, "  3h    7B ") {
+		t.Errorf("line = %q, want a 3h 7B badge", line)
+	}
+	missing := ansi.Strip(m.renderResultLine(search.Match{Path: "missing.go", LineNumber: 1, LineText: "needle\n"}, false, false, false, diffNone))
+	if !strings.Contains(missing, "?") {
+		t.Errorf("line = %q, want a ? badge for a file that can't be stat'ed", missing)
+	}
+}
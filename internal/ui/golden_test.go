@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// Run with -update to rewrite the golden files after an intended layout
+// change, then review the diff
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenModel returns a test model sized width by height with matches shown
+// as the results of a finished search
+func goldenModel(t *testing.T, width, height int, matches []search.Match) Model {
+	t.Helper()
+	m := newTestModel(t)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	m = updated.(Model)
+	if matches != nil {
+		m.inputs.pattern.SetValue("match")
+		m.lastPattern = "match"
+		updated, _ = m.Update(searchResultMsg{matches: matches, done: true})
+		m = updated.(Model)
+		// The only part of the view that depends on the clock
+		m.searchTime = 25 * time.Millisecond
+	}
+	return m
+}
+
+// showPreview loads lines into the preview as the context of the selected
+// match, found on line matchLine
+func showPreview(t *testing.T, m Model, path string, lines []string, matchLine int, submatches []search.Submatch) Model {
+	t.Helper()
+	m.loadPreview()
+	updated, _ := m.Update(previewLoadedMsg{
+		token:     m.preview.token,
+		path:      path,
+		lines:     lines,
+		startLine: 1,
+		matchLine: matchLine,
+		spans:     [][]search.Submatch{submatches},
+	})
+	return updated.(Model)
+}
+
+// checkGolden compares the view of m, without styling or trailing spaces,
+// with testdata/golden/name.golden
+func checkGolden(t *testing.T, name string, m Model) {
+	t.Helper()
+	rows := strings.Split(ansi.Strip(m.View()), "\n")
+	for i, row := range rows {
+		rows[i] = strings.TrimRight(row, " ")
+	}
+	got := strings.Join(rows, "\n") + "\n"
+
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -run %s -update to create it)", err, t.Name())
+	}
+	if got != string(want) {
+		t.Errorf("view differs from %s (run go test -run %s -update if the change is intended):\n%s", path, t.Name(), got)
+	}
+}
+
+func TestView_Golden(t *testing.T) {
+	previewLines := []string{
+		"package main",
+		"",
+		"func main() {",
+		"\tfmt.Println(\"match number 0\")",
+		"}",
+	}
+	previewSubmatch := []search.Submatch{{Match: "match", Start: 14, End: 19}}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) Model
+	}{
+		{
+			name:  "empty",
+			setup: func(t *testing.T) Model { return goldenModel(t, 100, 20, nil) },
+		},
+		{
+			name: "results",
+			setup: func(t *testing.T) Model {
+				m := goldenModel(t, 120, 24, testMatches(0, 8))
+				return showPreview(t, m, "file0.go", previewLines, 4, previewSubmatch)
+			},
+		},
+		{
+			name: "narrow",
+			setup: func(t *testing.T) Model {
+				m := goldenModel(t, 60, 16, testMatches(0, 20))
+				return showPreview(t, m, "file0.go", previewLines, 4, previewSubmatch)
+			},
+		},
+		{
+			name: "scrolled",
+			setup: func(t *testing.T) Model {
+				m := goldenModel(t, 100, 16, testMatches(0, 30))
+				for range 12 {
+					updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+					m = updated.(Model)
+				}
+				return m
+			},
+		},
+		{
+			name: "multiline",
+			setup: func(t *testing.T) Model {
+				match := search.Match{
+					Path:       "main.go",
+					LineNumber: 3,
+					EndLine:    5,
+					LineText:   "func main() {\n\tfmt.Println(\"match number 0\")\n}\n",
+					Submatches: []search.Submatch{{Match: "{\n\tfmt", Start: 12, End: 19}},
+				}
+				m := goldenModel(t, 100, 16, []search.Match{match})
+				m.multiline = true
+				m.loadPreview()
+				updated, _ := m.Update(previewLoadedMsg{
+					token:     m.preview.token,
+					path:      "main.go",
+					lines:     previewLines,
+					startLine: 1,
+					matchLine: 3,
+					spans:     match.SubmatchesByLine(),
+				})
+				return updated.(Model)
+			},
+		},
+		{
+			name: "summary",
+			setup: func(t *testing.T) Model {
+				m := goldenModel(t, 120, 20, testMatches(0, 6))
+				updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}, Alt: true})
+				return updated.(Model)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.setup(t)
+			m.highlighter.SetEnabled(false)
+			m.updatePreviewView()
+			checkGolden(t, tt.name, m)
+		})
+	}
+}
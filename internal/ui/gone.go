@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// goneLines fill the preview of a result whose file no longer exists
+var goneLines = []string{
+	"This file no longer exists.",
+	"",
+	"It may have been deleted, or moved by a branch switch or a rebuild.",
+	"Press r to re-run the search.",
+}
+
+// isGone reports whether err means a file no longer exists
+func isGone(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// reportGone tells that path has disappeared since the search and offers
+// to re-run it with the next key
+func (m *Model) reportGone(path string) {
+	m.gonePath = path
+	m.status.message = ""
+	m.status.err = fmt.Sprintf("%s is gone; press r to re-run the search", displayPath(path))
+}
+
+// updateGone handles the key after a file was reported gone: r re-runs the
+// search, and any other key dismisses the offer and is handled as usual
+func (m *Model) updateGone(msg tea.KeyMsg) (tea.Cmd, bool) {
+	m.gonePath = ""
+	if msg.String() != "r" {
+		return nil, false
+	}
+	m.status.err = ""
+	return m.executeSearch(m.inputs.pattern.Value(), m.inputs.path.Value()), true
+}
+
+// checkGone reports path gone when it no longer exists, before an editor
+// is started on it
+func (m *Model) checkGone(path string) bool {
+	if m.remote || m.decoder.Decodes(path) {
+		return false
+	}
+	if _, err := os.Stat(path); isGone(err) {
+		m.reportGone(path)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestPreview_FileGoneOffersRerun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deleted.go")
+	m := newTestModel(t)
+	m.inputs.pattern.SetValue("needle")
+
+	msg := m.loadPreviewAt(path, 3, nil, "")().(previewLoadedMsg)
+	if !msg.gone {
+		t.Fatalf("preview of a missing file = %v, want it reported gone", msg.lines)
+	}
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+	if !strings.Contains(m.status.err, "press r") {
+		t.Errorf("error = %q, want the re-run offer", m.status.err)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(Model)
+	if cmd == nil || !m.searching {
+		t.Error("r didn't re-run the search")
+	}
+	if m.inputs.pattern.Value() != "needle" {
+		t.Errorf("pattern = %q, want r not typed into it", m.inputs.pattern.Value())
+	}
+}
+
+func TestGone_OtherKeyDismissesOffer(t *testing.T) {
+	m := newTestModel(t)
+	m.reportGone("deleted.go")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.gonePath != "" || m.inputs.pattern.Value() != "x" {
+		t.Errorf("gone = %q, pattern = %q, want the offer dismissed and x typed", m.gonePath, m.inputs.pattern.Value())
+	}
+}
+
+func TestOpenInEditor_FileGone(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	if err := m.results.Append(search.Match{Path: path, LineNumber: 1, LineText: "needle\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if cmd := m.openInEditor(); cmd != nil {
+		t.Error("openInEditor started the editor on a deleted file")
+	}
+	if m.gonePath != path {
+		t.Errorf("gonePath = %q, want %q", m.gonePath, path)
+	}
+}
+
+func TestOpenInEditor_MarkedFileGone(t *testing.T) {
+	dir := t.TempDir()
+	kept, deleted := filepath.Join(dir, "kept.go"), filepath.Join(dir, "deleted.go")
+	m := newTestModel(t)
+	for _, path := range []string{kept, deleted} {
+		if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.results.Append(search.Match{Path: path, LineNumber: 1, LineText: "needle\n"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.marked = map[int]bool{0: true, 1: true}
+	if err := os.Remove(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	if cmd := m.openInEditor(); cmd != nil {
+		t.Error("openInEditor opened the marked files with one of them deleted")
+	}
+	if m.gonePath != deleted {
+		t.Errorf("gonePath = %q, want %q", m.gonePath, deleted)
+	}
+}
@@ -1,8 +1,12 @@
 package ui
 
 import (
-	"github.com/William9923/irg/internal/highlight"
 	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/highlight"
+	"github.com/William9923/irg/internal/search"
 )
 
 func TestMatchHighlightingWithSyntaxHighlighting(t *testing.T) {
@@ -75,3 +79,41 @@ func TestPreviewLineProcessing(t *testing.T) {
 		t.Error("Expected plain text to be unchanged")
 	}
 }
+
+func TestHighlightMatches_CombiningCharacters(t *testing.T) {
+	brackets := lipgloss.NewStyle().Transform(func(s string) string { return "[" + s + "]" })
+	// é decomposed into e and a combining acute accent
+	e := "e\u0301"
+	decomposed := "caf" + e + " au lait"
+
+	tests := []struct {
+		name       string
+		submatches []search.Submatch
+		want       string
+	}{
+		{"match ending before an accent takes it in", []search.Submatch{{Start: 3, End: 4}}, "caf[" + e + "] au lait"},
+		{"match on the accent alone stays whole", []search.Submatch{{Start: 3, End: 4}, {Start: 4, End: 6}}, "caf[" + e + "] au lait"},
+		{"matches around it are unchanged", []search.Submatch{{Start: 0, End: 3}, {Start: 10, End: 14}}, "[caf]" + e + " au [lait]"},
+	}
+	for _, tt := range tests {
+		if got := highlightMatches(decomposed, tt.submatches, brackets); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayLine_NormalizesWhenOn(t *testing.T) {
+	m := newTestModel(t)
+	line := "cafe\u0301"
+	submatches := []search.Submatch{{Start: 3, End: 4}}
+	if got, _ := m.displayLine(line, submatches); got != line {
+		t.Errorf("normalization off: got %q, want the line as found", got)
+	}
+
+	m.SetNormalize(true)
+	got, moved := m.displayLine(line, submatches)
+	brackets := lipgloss.NewStyle().Transform(func(s string) string { return "[" + s + "]" })
+	if shown := highlightMatches(got, moved, brackets); shown != "caf[\u00e9]" {
+		t.Errorf("normalization on: shown %q, want the composed é highlighted", shown)
+	}
+}
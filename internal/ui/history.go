@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/history"
+)
+
+type historySavedMsg struct {
+	err error
+}
+
+// SetHistory recalls the searches in entries, oldest first, and adds new
+// ones to the history file at path; "" keeps them for this session only
+func (m *Model) SetHistory(path string, entries []history.Entry) {
+	m.historyPath = path
+	m.history = entries
+}
+
+// recordHistory holds the search that just finished back from the history,
+// and returns a command saving the one before it, unless the new pattern
+// only extends or trims it, as typing does. A search recalled from the
+// history is saved again, as its latest use.
+func (m *Model) recordHistory() tea.Cmd {
+	if m.resultsPattern == "" {
+		return nil
+	}
+	current := history.Entry{Pattern: m.resultsPattern, Types: slices.Clone(m.fileTypes)}
+	if m.lastPath != "." {
+		current.Path = m.lastPath
+	}
+
+	previous := m.historyPending
+	m.historyPending = &current
+	if previous == nil || refinedEntry(*previous, current) {
+		return nil
+	}
+	return m.saveHistory(*previous)
+}
+
+// refinedEntry reports whether next searched the same scope as prev for a
+// pattern that starts with prev's, or that prev's starts with
+func refinedEntry(prev, next history.Entry) bool {
+	return prev.Path == next.Path && slices.Equal(prev.Types, next.Types) &&
+		(strings.HasPrefix(next.Pattern, prev.Pattern) || strings.HasPrefix(prev.Pattern, next.Pattern))
+}
+
+// saveHistory adds e to the recalled searches, and returns a command adding
+// it to the history file
+func (m *Model) saveHistory(e history.Entry) tea.Cmd {
+	m.history = append(slices.DeleteFunc(m.history, e.Equal), e)
+	if m.historyPath == "" {
+		return nil
+	}
+	path := m.historyPath
+	return func() tea.Msg {
+		return historySavedMsg{err: history.Add(path, e)}
+	}
+}
+
+// flushHistory saves the last finished search, when irg exits
+func (m *Model) flushHistory() error {
+	if m.historyPending == nil {
+		return nil
+	}
+	e := *m.historyPending
+	m.historyPending = nil
+	if m.historyPath == "" {
+		return nil
+	}
+	return history.Add(m.historyPath, e)
+}
+
+// recallEntries returns a copy of the searches that can be recalled, oldest
+// first, ending with the last one run even if it isn't saved yet
+func (m *Model) recallEntries() []history.Entry {
+	entries := slices.Clone(m.history)
+	if m.historyPending == nil {
+		return entries
+	}
+	pending := *m.historyPending
+	return append(slices.DeleteFunc(entries, pending.Equal), pending)
+}
+
+// updateHistory handles the keys that recall past searches: the history
+// actions anywhere, and Up and Down in the pattern input while there are no
+// results to move through. It reports whether a was used.
+func (m *Model) updateHistory(a action) (tea.Cmd, bool) {
+	switch a {
+	case actionHistoryPrev:
+		return m.recallHistory(1), true
+	case actionHistoryNext:
+		return m.recallHistory(-1), true
+	case actionUp, actionDown:
+		if m.inputs.focused != focusPattern || m.results.Len() > 0 {
+			return nil, false
+		}
+		if a == actionUp {
+			return m.recallHistory(1), true
+		}
+		return m.recallHistory(-1), true
+	}
+	return nil, false
+}
+
+// recallHistory steps back through the history, step searches older, and
+// runs the search found there with its path and types. Stepping forward past
+// the newest brings back the search being typed before recalling began.
+func (m *Model) recallHistory(step int) tea.Cmd {
+	if m.historyIndex == 0 {
+		// Searches run while recalling move to the end of the history, so
+		// recalling steps through it as it was when it began
+		m.historyDraft = history.Entry{
+			Pattern: m.inputs.pattern.Value(),
+			Path:    m.inputs.path.Value(),
+			Types:   parseTypes(m.inputs.types.Value()),
+		}
+		m.historyRecall = slices.DeleteFunc(m.recallEntries(), m.historyDraft.Equal)
+	}
+	i := clamp(m.historyIndex+step, 0, len(m.historyRecall))
+	if i == m.historyIndex {
+		return nil
+	}
+	m.historyIndex = i
+
+	e := m.historyDraft
+	if i > 0 {
+		e = m.historyRecall[len(m.historyRecall)-i]
+	}
+	m.inputs.pattern.SetValue(e.Pattern)
+	m.inputs.pattern.CursorEnd()
+	m.inputs.path.SetValue(e.Path)
+	m.inputs.types.SetValue(strings.Join(e.Types, ","))
+	path := m.searchPath()
+	m.lastPattern = e.Pattern
+	m.lastPath = path
+	m.lastFileTypes = e.Types
+	m.fileTypes = e.Types
+	return m.executeSearch(e.Pattern, path)
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/history"
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestHistory_RecallsWithPathAndTypes(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher()
+	m.searcher = searcher
+	m.SetHistory("", []history.Entry{
+		{Pattern: "func main"},
+		{Pattern: "TODO", Path: "internal", Types: []string{"go", "md"}},
+	})
+	m.inputs.pattern.SetValue("draft")
+
+	// Up in the pattern input recalls while there are no results
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = runSearch(t, updated.(Model), cmd)
+	if m.inputs.pattern.Value() != "TODO" || m.inputs.path.Value() != "internal" || m.inputs.types.Value() != "go,md" {
+		t.Errorf("recalled %q in %q for %q, want the newest search", m.inputs.pattern.Value(), m.inputs.path.Value(), m.inputs.types.Value())
+	}
+	calls := searcher.Calls()
+	if len(calls) != 1 || calls[0].Pattern != "TODO" || calls[0].Path != "internal" || !reflect.DeepEqual(calls[0].Opts.FileTypes, []string{"go", "md"}) {
+		t.Errorf("calls = %+v, want the recalled search run", calls)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyUp, Alt: true})
+	m = runSearch(t, updated.(Model), cmd)
+	if m.inputs.pattern.Value() != "func main" || m.inputs.path.Value() != "" || m.inputs.types.Value() != "" {
+		t.Errorf("recalled %q in %q for %q, want the oldest search", m.inputs.pattern.Value(), m.inputs.path.Value(), m.inputs.types.Value())
+	}
+	// Nothing older
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyUp, Alt: true}); cmd != nil {
+		t.Error("recalling past the oldest search ran a search")
+	}
+
+	for range 2 {
+		updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown, Alt: true})
+		m = runSearch(t, updated.(Model), cmd)
+	}
+	if m.inputs.pattern.Value() != "draft" {
+		t.Errorf("pattern = %q, want the draft back past the newest search", m.inputs.pattern.Value())
+	}
+}
+
+func TestHistory_SavesSettledSearches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 2)...)
+	m.SetHistory(path, nil)
+
+	// Typing "foo" searches f, fo and foo, which is one search to save
+	for _, pattern := range []string{"f", "fo", "foo", "bar"} {
+		m.lastPattern = pattern
+		m = runSearch(t, m, m.executeSearch(pattern, "."))
+	}
+	want := []history.Entry{{Pattern: "foo"}, {Pattern: "bar"}}
+	if got := m.recallEntries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("recalled searches = %+v, want %+v", got, want)
+	}
+
+	// The last search is saved on exit
+	if err := m.flushHistory(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := history.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("history file = %+v, want %+v", got, want[1:])
+	}
+}
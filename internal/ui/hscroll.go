@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// previewGutter is the width of the line number column in the preview
+const previewGutter = 5
+
+// hscrollStep is how many cells one horizontal scroll moves the panes
+const hscrollStep = 8
+
+// tabSpaces matches lipgloss, which renders each tab as four spaces
+const tabSpaces = "    "
+
+// expandTabs replaces tabs so cell widths can be measured before rendering
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", tabSpaces)
+}
+
+// displayColumn returns the cell column of byte offset off in the raw line
+func displayColumn(line string, off int) int {
+	if off > len(line) {
+		off = len(line)
+	}
+	if off < 0 {
+		off = 0
+	}
+	return ansi.StringWidth(expandTabs(line[:off]))
+}
+
+// cutLeft drops the first n cells of s, keeping its ANSI styling
+func cutLeft(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	var sb strings.Builder
+	var state byte
+	skipped := 0
+	for len(s) > 0 {
+		seq, width, size, newState := ansi.DecodeSequence(s, state, nil)
+		state = newState
+		switch {
+		case width == 0 || skipped >= n:
+			sb.WriteString(seq)
+		default:
+			skipped += width
+			// A wide character cut in half leaves a blank cell
+			if skipped > n {
+				sb.WriteString(strings.Repeat(" ", skipped-n))
+			}
+		}
+		s = s[size:]
+	}
+	return sb.String()
+}
+
+// clipLine returns the part of a rendered line visible width cells wide after
+// scrolling offset cells right, marking text cut off on the right
+func clipLine(s string, offset, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return ansi.Truncate(cutLeft(expandTabs(s), offset), width, "…")
+}
+
+// matchSpan returns the cell columns covered by the submatches of line
+func matchSpan(line string, submatches []search.Submatch) (start, end int, ok bool) {
+	for _, sm := range submatches {
+		if sm.Start < 0 || sm.End > len(line) || sm.Start >= sm.End {
+			continue
+		}
+		s, e := displayColumn(line, sm.Start), displayColumn(line, sm.End)
+		if !ok || s < start {
+			start = s
+		}
+		if !ok || e > end {
+			end = e
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+// matchOffset returns the horizontal scroll that brings the first submatch
+// of line into a pane width cells wide, or 0 if it is already visible
+func matchOffset(line string, submatches []search.Submatch, width int) int {
+	start, end, ok := matchSpan(line, submatches)
+	if !ok || end <= width {
+		return 0
+	}
+	// Leave some of the text before the match in view
+	return max(0, start-width/4)
+}
+
+// caretRow renders carets under each submatch of line, shifted by the
+// horizontal scroll, or "" if none are visible
+func caretRow(line string, submatches []search.Submatch, offset, width int) string {
+	cells := []rune(strings.Repeat(" ", width))
+	visible := false
+	for _, sm := range submatches {
+		if sm.Start < 0 || sm.End > len(line) || sm.Start >= sm.End {
+			continue
+		}
+		start, end := displayColumn(line, sm.Start)-offset, displayColumn(line, sm.End)-offset
+		for col := max(start, 0); col < min(end, width); col++ {
+			cells[col] = '^'
+			visible = true
+		}
+	}
+	if !visible {
+		return ""
+	}
+	caretStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("226")).Bold(true)
+	return strings.Repeat(" ", previewGutter) + caretStyle.Render(strings.TrimRight(string(cells), " "))
+}
+
+// scrollHorizontal moves the text of both panes delta cells sideways,
+// stopping once the longest visible line is in view
+func (m *Model) scrollHorizontal(delta int) {
+	widest := 0
+	offset := m.resultsOffset()
+	end := min(offset+m.visibleResults(), m.results.Len())
+	for i := offset; i < end; i++ {
+		match, err := m.results.Get(i)
+		if err != nil {
+			break
+		}
+		widest = max(widest, ansi.StringWidth(expandTabs(strings.TrimRight(match.Head().LineText, "\r\n"))))
+	}
+	resultsOffset := clamp(m.list.xOffset+delta, 0, widest-m.resultTextWidth())
+	if resultsOffset != m.list.xOffset {
+		m.list.xOffset = resultsOffset
+		m.list.cache.invalidate()
+		m.updateResultsView()
+	}
+
+	widest = 0
+	for _, line := range m.preview.lines {
+		widest = max(widest, ansi.StringWidth(expandTabs(line)))
+	}
+	previewOffset := clamp(m.preview.xOffset+delta, 0, widest-m.preview.codeWidth())
+	if previewOffset != m.preview.xOffset {
+		m.preview.xOffset = previewOffset
+		m.updatePreviewView()
+	}
+}
+
+// clamp limits v to [low, high], preferring low when the range is empty
+func clamp(v, low, high int) int {
+	return max(low, min(v, high))
+}
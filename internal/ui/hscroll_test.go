@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestCutLeft(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{"plain", "hello world", 6, "world"},
+		{"none", "hello", 0, "hello"},
+		{"past end", "hi", 5, ""},
+		{"keeps styling", "\x1b[31mred\x1b[0m text", 2, "\x1b[31md\x1b[0m text"},
+		{"halves wide rune", "日本", 1, " 本"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutLeft(tt.in, tt.n); got != tt.want {
+				t.Errorf("cutLeft(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClipLine_FitsWidth(t *testing.T) {
+	got := clipLine("\tfunc main() { return }", 2, 10)
+	if w := ansi.StringWidth(got); w != 10 {
+		t.Errorf("clipLine width = %d, want 10 (%q)", w, got)
+	}
+	if !strings.HasPrefix(got, "  func") || !strings.HasSuffix(got, "…") {
+		t.Errorf("clipLine = %q", got)
+	}
+}
+
+func TestMatchOffset(t *testing.T) {
+	line := strings.Repeat("x", 100) + "needle"
+	sm := []search.Submatch{{Match: "needle", Start: 100, End: 106}}
+
+	tests := []struct {
+		name       string
+		line       string
+		submatches []search.Submatch
+		width      int
+		want       int
+	}{
+		{"visible", line, sm, 120, 0},
+		{"beyond the pane", line, sm, 40, 90},
+		{"tabs count four cells", "\t\tneedle", []search.Submatch{{Start: 2, End: 8}}, 12, 5},
+		{"no submatches", line, nil, 40, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchOffset(tt.line, tt.submatches, tt.width); got != tt.want {
+				t.Errorf("matchOffset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaretRow(t *testing.T) {
+	line := "a := foo(foo)"
+	sm := []search.Submatch{{Start: 5, End: 8}, {Start: 9, End: 12}}
+
+	got := ansi.Strip(caretRow(line, sm, 0, 40))
+	if want := strings.Repeat(" ", previewGutter) + "     ^^^ ^^^"; got != want {
+		t.Errorf("caretRow = %q, want %q", got, want)
+	}
+
+	got = ansi.Strip(caretRow(line, sm, 7, 40))
+	if want := strings.Repeat(" ", previewGutter) + "^ ^^^"; got != want {
+		t.Errorf("scrolled caretRow = %q, want %q", got, want)
+	}
+
+	if got := caretRow(line, sm, 20, 40); got != "" {
+		t.Errorf("caretRow with matches scrolled away = %q, want empty", got)
+	}
+}
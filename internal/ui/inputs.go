@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type focusedInput int
+
+const (
+	focusPattern focusedInput = iota
+	focusPath
+	focusTypes
+)
+
+// inputBar is the row of pattern, path and types inputs above the status
+// text. Tab moves the focus between them.
+type inputBar struct {
+	pattern textinput.Model
+	path    textinput.Model
+	types   textinput.Model
+	focused focusedInput
+}
+
+func newInputBar() inputBar {
+	pattern := textinput.New()
+	pattern.Placeholder = "Search pattern..."
+	pattern.Focus()
+	pattern.CharLimit = 256
+	pattern.Width = 40
+
+	path := textinput.New()
+	path.Placeholder = "Path (default: .)"
+	path.CharLimit = 256
+	path.Width = 30
+
+	types := textinput.New()
+	types.Placeholder = "Types (e.g., go,rust)"
+	types.CharLimit = 256
+	types.Width = 30
+
+	return inputBar{pattern: pattern, path: path, types: types, focused: focusPattern}
+}
+
+// focusNext moves the focus to the next input, from the types input back to
+// the pattern
+func (b *inputBar) focusNext() {
+	switch b.focused {
+	case focusPattern:
+		b.focused = focusPath
+		b.pattern.Blur()
+		b.path.Focus()
+	case focusPath:
+		b.focused = focusTypes
+		b.path.Blur()
+		b.types.Focus()
+	default:
+		b.focused = focusPattern
+		b.types.Blur()
+		b.pattern.Focus()
+	}
+}
+
+// resize shares a window width cells wide between the inputs, giving the
+// pattern half of it
+func (b *inputBar) resize(width int) {
+	patternWidth := (width - 15) / 2
+	pathWidth := (width - 15) / 4
+	b.pattern.Width = patternWidth
+	b.path.Width = pathWidth
+	b.types.Width = (width - 15) - patternWidth - pathWidth
+}
+
+// Update passes msg to the inputs; only the focused one takes key presses
+func (b inputBar) Update(msg tea.Msg) (inputBar, tea.Cmd) {
+	var patternCmd, pathCmd, typesCmd tea.Cmd
+	b.pattern, patternCmd = b.pattern.Update(msg)
+	b.path, pathCmd = b.path.Update(msg)
+	b.types, typesCmd = b.types.Update(msg)
+	return b, tea.Batch(patternCmd, pathCmd, typesCmd)
+}
+
+// boxes renders each input in its box, the focused one highlighted
+func (b inputBar) boxes() []string {
+	activeStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1)
+	inactiveStyle := activeStyle.BorderForeground(lipgloss.Color("240"))
+
+	inputs := []textinput.Model{b.pattern, b.path, b.types}
+	boxes := make([]string, len(inputs))
+	for i, input := range inputs {
+		style := inactiveStyle
+		if focusedInput(i) == b.focused {
+			style = activeStyle
+		}
+		boxes[i] = style.Render(input.View())
+	}
+	return boxes
+}
+
+// left returns the column where the box of input f starts
+func (b inputBar) left(f focusedInput) int {
+	x := 0
+	for _, box := range b.boxes()[:f] {
+		x += lipgloss.Width(box) + 1
+	}
+	return x
+}
+
+// View renders the inputs side by side, followed by status
+func (b inputBar) View(status string) string {
+	boxes := b.boxes()
+	return lipgloss.JoinHorizontal(lipgloss.Top, boxes[0], " ", boxes[1], " ", boxes[2], "  ", status)
+}
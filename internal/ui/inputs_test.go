@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestInputBar_FocusCyclesAndLeftMatchesView(t *testing.T) {
+	b := newInputBar()
+	b.resize(120)
+	for _, want := range []focusedInput{focusPath, focusTypes, focusPattern} {
+		b.focusNext()
+		if b.focused != want || b.pattern.Focused() != (want == focusPattern) || b.types.Focused() != (want == focusTypes) {
+			t.Fatalf("focus = %d, want %d with only that input focused", b.focused, want)
+		}
+	}
+
+	boxes := b.boxes()
+	if got, want := b.left(focusTypes), lipgloss.Width(boxes[0])+1+lipgloss.Width(boxes[1])+1; got != want {
+		t.Errorf("types input starts at column %d, want %d", got, want)
+	}
+	if got := lipgloss.Width(b.View("")); got < b.left(focusTypes)+lipgloss.Width(boxes[2]) {
+		t.Errorf("input row is %d cells wide, narrower than its boxes", got)
+	}
+}
@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// maxJobs bounds the searches kept in the background, each holding its
+// results and maybe an rg process
+const maxJobs = 10
+
+// jobState is where a background search stands
+type jobState int
+
+const (
+	jobRunning jobState = iota
+	jobDone
+	jobStopped // Removed from the list while running, or cut short
+)
+
+func (s jobState) String() string {
+	switch s {
+	case jobRunning:
+		return "running"
+	case jobDone:
+		return "done"
+	default:
+		return "stopped"
+	}
+}
+
+// job is a search moved to the background with Alt+Q: it runs on, into
+// results of its own, while other searches take the results pane, and can
+// be brought back from the job list
+type job struct {
+	id       int
+	pattern  string
+	path     string
+	types    []string
+	searcher search.Searcher
+	ctx      context.Context
+	cancel   context.CancelFunc
+	progress *search.ShardProgress
+	fold     caseFolding
+	results  *search.ResultStore
+	started  time.Time
+	elapsed  time.Duration
+	state    jobState
+}
+
+// jobsKey moves the running search to the background, or else lists the
+// background jobs
+func (m *Model) jobsKey() {
+	switch {
+	case m.searching && m.resultsPattern != "" && m.searchCtx != nil:
+		if len(m.jobs) >= maxJobs {
+			m.status.err = fmt.Sprintf("%d jobs in the background already; remove one from the list (Alt+Q) first", maxJobs)
+			return
+		}
+		j := m.backgroundSearch()
+		m.status.message = fmt.Sprintf("Job %d (%s) runs in the background; Alt+Q lists the jobs", j.id, truncateStatus(j.pattern))
+	case len(m.jobs) == 0:
+		m.status.message = "No background jobs; Alt+Q during a search moves it to the background"
+	default:
+		m.jobsVisible = true
+		m.jobIndex = len(m.jobs) - 1
+	}
+}
+
+// backgroundSearch turns the running search into a job and clears the
+// results pane for the next search
+func (m *Model) backgroundSearch() *job {
+	// A search paused by its timeout runs on in the background
+	m.releaseSearch()
+	m.endCompare()
+	m.jobCount++
+	j := &job{
+		id:       m.jobCount,
+		pattern:  m.resultsPattern,
+		path:     m.resultsPath,
+		types:    m.fileTypes,
+		searcher: m.searcher,
+		ctx:      m.searchCtx,
+		cancel:   m.searchCancel,
+		progress: m.shardProgress,
+		fold:     m.caseFold,
+		results:  m.results,
+		started:  m.searchStart,
+		state:    jobRunning,
+	}
+	m.jobs = append(m.jobs, j)
+
+	// rg processes are paused and killed per searcher, so the next search
+	// gets one of its own
+	if _, ok := m.searcher.(*search.RipgrepSearcher); ok {
+		m.searcher = search.NewRipgrepSearcher()
+	}
+	// The job goes on dropping files seen under another spelling by itself
+	m.caseFold = caseFolding{probed: m.caseFold.probed, seen: make(map[string]string)}
+	m.results = search.NewResultStore()
+	m.searchCtx, m.searchCancel = nil, nil
+	m.shardProgress = nil
+	m.searching = false
+	m.resultsDone = false
+	m.resultsPattern = ""
+	m.clearResults()
+	m.updateResultsView()
+	return j
+}
+
+// clearResults drops what was kept about each result after the result list
+// was swapped for another
+func (m *Model) clearResults() {
+	m.resultStamps = nil
+	clear(m.fileInfos)
+	clear(m.lints)
+	m.recentOrder = nil
+	m.list.xOffset = 0
+	m.partial = false
+	m.gonePath = ""
+	m.resetResultsSelection()
+}
+
+// updateJobResults adds a batch of a background search to its job,
+// reporting false when the batch isn't from one
+func (m *Model) updateJobResults(msg searchResultMsg) (tea.Cmd, bool) {
+	i := slices.IndexFunc(m.jobs, func(j *job) bool { return j.ctx == msg.ctx })
+	if i < 0 {
+		return nil, false
+	}
+	j := m.jobs[i]
+	if j.results.Len() < maxResults {
+		if err := j.results.Append(m.visibleMatches(j.fold.dedupe(msg.matches))...); err != nil {
+			m.status.err = err.Error()
+		}
+		if j.results.Len() > maxResults {
+			if err := j.results.Truncate(maxResults); err != nil {
+				m.status.err = err.Error()
+			}
+		}
+	}
+	if !msg.done {
+		return msg.next, true
+	}
+	j.elapsed = time.Since(j.started)
+	j.state = jobDone
+	if j.ctx.Err() != nil {
+		j.state = jobStopped
+	}
+	if !m.searching && m.status.err == "" {
+		m.status.message = fmt.Sprintf("Job %d (%s) finished with %d matches; Alt+Q to bring it back", j.id, truncateStatus(j.pattern), j.results.Len())
+	}
+	return nil, true
+}
+
+// updateJobs handles key presses while the job list is shown
+func (m Model) updateJobs(msg tea.KeyMsg, a action) (tea.Model, tea.Cmd) {
+	switch {
+	case a == actionUp && len(m.jobs) > 0:
+		m.jobIndex = (m.jobIndex + len(m.jobs) - 1) % len(m.jobs)
+	case a == actionDown && len(m.jobs) > 0:
+		m.jobIndex = (m.jobIndex + 1) % len(m.jobs)
+	case a == actionOpenEditor && m.jobIndex < len(m.jobs):
+		m.jobsVisible = false
+		return m, m.attachJob(m.jobIndex)
+	case msg.String() == "d" && m.jobIndex < len(m.jobs):
+		m.removeJob(m.jobIndex)
+		m.jobIndex = min(m.jobIndex, max(len(m.jobs)-1, 0))
+		if len(m.jobs) == 0 {
+			m.jobsVisible = false
+		}
+	case a == actionClose || a == actionJobs || a == actionQuit:
+		m.jobsVisible = false
+	}
+	return m, nil
+}
+
+// attachJob brings job i back into the results pane, where a running one
+// goes on filling in. A search running in its place moves to the
+// background.
+func (m *Model) attachJob(i int) tea.Cmd {
+	j := m.jobs[i]
+	m.jobs = slices.Delete(m.jobs, i, i+1)
+	m.releaseSearch()
+	if m.searching && m.resultsPattern != "" && m.searchCtx != nil {
+		m.backgroundSearch()
+	} else if m.searchCancel != nil {
+		m.searchCancel()
+	}
+
+	m.endCompare()
+	m.rotateResults(j.pattern)
+	m.results.Close()
+	m.results = j.results
+	m.searcher = j.searcher
+	m.searchCtx, m.searchCancel = j.ctx, j.cancel
+	m.shardProgress = j.progress
+	m.searching = j.state == jobRunning
+	m.resultsDone = j.state == jobDone
+	m.searchStart = j.started
+	m.searchTime = j.elapsed
+	m.caseFold = j.fold
+	m.resultsPath = j.path
+
+	// Show the job's query as if it had been typed, without searching again
+	m.inputs.pattern.SetValue(j.pattern)
+	m.inputs.pattern.CursorEnd()
+	m.inputs.path.SetValue(j.path)
+	m.inputs.path.CursorEnd()
+	m.setTypes(j.types)
+	m.lastPattern, m.lastPath = j.pattern, j.path
+	m.debounceToken++
+	m.status.clear()
+
+	m.clearResults()
+	if m.resultsDone && m.sortRecent && !m.remote {
+		m.sortByRecency()
+	}
+	m.updateResultsView()
+	if m.results.Len() > 0 {
+		return m.loadPreview()
+	}
+	return nil
+}
+
+// removeJob stops job i if it's still running and lets go of its results
+func (m *Model) removeJob(i int) {
+	j := m.jobs[i]
+	if j.state == jobRunning {
+		j.cancel()
+		// Only searchers other than rg are shared with the search in front
+		if j.searcher != m.searcher {
+			j.searcher.Cancel()
+		}
+	}
+	j.results.Close()
+	m.jobs = slices.Delete(m.jobs, i, i+1)
+}
+
+// jobsIndicator counts the background jobs at the start of the status line
+func (m *Model) jobsIndicator() string {
+	if len(m.jobs) == 0 {
+		return ""
+	}
+	running := 0
+	for _, j := range m.jobs {
+		if j.state == jobRunning {
+			running++
+		}
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Render(
+		fmt.Sprintf("⚙ %d/%d jobs", running, len(m.jobs))) + " "
+}
+
+// renderJobs renders the job list, at most width cells wide and height
+// rows tall
+func (m *Model) renderJobs(width, height int) string {
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Background jobs"), ""}
+	for i, j := range m.jobs {
+		elapsed := j.elapsed
+		if j.state == jobRunning {
+			elapsed = time.Since(j.started)
+		}
+		where := j.path
+		if len(j.types) > 0 {
+			where += " [" + strings.Join(j.types, ",") + "]"
+		}
+		label := fmt.Sprintf("%d  %-7s  %6d matches  %6s  %s  in %s",
+			j.id, j.state, j.results.Len(), elapsed.Round(100*time.Millisecond), j.pattern, where)
+		if i == m.jobIndex {
+			lines = append(lines, selectedStyle.Render("> "+label))
+		} else {
+			lines = append(lines, "  "+label)
+		}
+	}
+	lines = append(lines, "", hintStyle.Render("↑/↓ select  Enter bring back  d stop and remove  Esc close"))
+
+	// Border and padding take two rows and four columns
+	textWidth := max(width-4, 10)
+	if maxLines := max(height-2, 1); len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, textWidth, "…")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
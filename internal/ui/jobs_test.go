@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+var altQ = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}, Alt: true}
+
+// startSearch runs the first batch of a search for pattern, leaving it
+// running, and returns the command reading the rest
+func startSearch(t *testing.T, m Model, pattern string) (Model, tea.Cmd) {
+	t.Helper()
+	msg, ok := m.executeSearch(pattern, ".")().(searchResultMsg)
+	if !ok || msg.done {
+		t.Fatal("the search finished in its first batch")
+	}
+	updated, _ := m.Update(msg)
+	return updated.(Model), msg.next
+}
+
+func TestJobs_BackgroundSearchFinishesAndComesBack(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 250)...)
+	m.inputs.pattern.SetValue("needle")
+	m, rest := startSearch(t, m, "needle")
+
+	updated, _ := m.Update(altQ)
+	m = updated.(Model)
+	if len(m.jobs) != 1 || m.searching || m.results.Len() != 0 {
+		t.Fatalf("%d jobs, searching %v, %d results; want the search moved to the background", len(m.jobs), m.searching, m.results.Len())
+	}
+	if status := ansi.Strip(m.statusLine()); !strings.Contains(status, "⚙ 1/1 jobs") {
+		t.Errorf("status = %q, want the running job counted", status)
+	}
+
+	// The rest of its batches go to the job, not the results in front
+	m = runSearch(t, m, rest)
+	j := m.jobs[0]
+	if j.state != jobDone || j.results.Len() != 250 || m.results.Len() != 0 {
+		t.Fatalf("job %v with %d matches, %d in front; want it done with all 250 its own", j.state, j.results.Len(), m.results.Len())
+	}
+	if !strings.Contains(m.status.message, "Job 1 (needle) finished with 250 matches") {
+		t.Errorf("status message = %q", m.status.message)
+	}
+
+	m = runSearch(t, m, m.executeSearch("other", "."))
+	updated, _ = m.Update(altQ)
+	m = updated.(Model)
+	if view := ansi.Strip(m.View()); !m.jobsVisible || !strings.Contains(view, "> 1  done") || !strings.Contains(view, "250 matches") {
+		t.Fatalf("job list not shown with the finished job:\n%s", view)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.jobsVisible || len(m.jobs) != 0 || m.resultsPattern != "needle" || m.matchCount != 250 || !m.resultsDone {
+		t.Fatalf("visible %v, %d jobs, results of %q (%d); want job 1 back in front", m.jobsVisible, len(m.jobs), m.resultsPattern, m.matchCount)
+	}
+	if m.inputs.pattern.Value() != "needle" || m.previousPattern != "other" {
+		t.Errorf("pattern %q, previous %q; want the job's query typed and the last search kept as the previous", m.inputs.pattern.Value(), m.previousPattern)
+	}
+}
+
+func TestJobs_RemovingStopsRunningJob(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 250)...)
+	m, _ = startSearch(t, m, "needle")
+	updated, _ := m.Update(altQ)
+	m = updated.(Model)
+	ctx := m.jobs[0].ctx
+
+	updated, _ = m.Update(altQ)
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m = updated.(Model)
+	if len(m.jobs) != 0 || m.jobsVisible || ctx.Err() == nil {
+		t.Errorf("%d jobs, list shown %v, canceled %v; want the job stopped and the empty list closed", len(m.jobs), m.jobsVisible, ctx.Err() != nil)
+	}
+	if updated, _ = m.Update(altQ); !strings.Contains(updated.(Model).status.message, "No background jobs") {
+		t.Error("Alt+Q without jobs or a search didn't say so")
+	}
+}
@@ -0,0 +1,288 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// action names a user-invokable operation that keys can be bound to
+type action string
+
+const (
+	actionNone              action = ""
+	actionQuit              action = "quit"
+	actionNextInput         action = "next-input"
+	actionToggleCase        action = "toggle-case"
+	actionToggleHighlight   action = "toggle-highlight"
+	actionToggleGitTracked  action = "toggle-git-tracked"
+	actionToggleNoIgnore    action = "toggle-no-ignore"
+	actionToggleMultiline   action = "toggle-multiline"
+	actionToggleWholeWord   action = "toggle-whole-word"
+	actionExportQuickfix    action = "export-quickfix"
+	actionExportJSON        action = "export-json"
+	actionUp                action = "up"
+	actionDown              action = "down"
+	actionPageUp            action = "page-up"
+	actionPageDown          action = "page-down"
+	actionOpenEditor        action = "open-editor"
+	actionClose             action = "close"
+	actionCopyPath          action = "copy-path"
+	actionCopyLine          action = "copy-line"
+	actionPreviewDefinition action = "preview-definition"
+	actionOpenDefinition    action = "open-definition"
+	actionLSPReferences     action = "lsp-references"
+	actionLSPDefinition     action = "lsp-definition"
+	actionToggleMark        action = "toggle-mark"
+	actionReplace           action = "replace"
+	actionComparePrevious   action = "compare-previous"
+	actionDiffHead          action = "diff-head"
+	actionToggleSummary     action = "toggle-summary"
+	actionRefreshPaths      action = "refresh-paths"
+	actionToggleContext     action = "toggle-context"
+	actionToggleFileInfo    action = "toggle-file-info"
+	actionSortRecent        action = "sort-recent"
+	actionScrollLeft        action = "scroll-left"
+	actionScrollRight       action = "scroll-right"
+	actionEditNote          action = "edit-note"
+	actionHideClass         action = "hide-class"
+	actionExplainPattern    action = "explain-pattern"
+	actionCopyCommand       action = "copy-command"
+	actionNarrow            action = "narrow"
+	actionCopyMode          action = "copy-mode"
+	actionRetryLiteral      action = "retry-literal"
+	actionRestorePaste      action = "restore-paste"
+	actionSettings          action = "settings"
+	actionSuspend           action = "suspend"
+	actionRecordMacro       action = "record-macro"
+	actionReplayMacro       action = "replay-macro"
+	actionHistoryPrev       action = "history-prev"
+	actionHistoryNext       action = "history-next"
+	actionBookmarks         action = "bookmarks"
+	actionProfiles          action = "profiles"
+	actionContinueSearch    action = "continue-search"
+	actionToggleMouse       action = "toggle-mouse"
+	actionJobs              action = "jobs"
+	actionToggleTree        action = "toggle-tree"
+	actionCycleTheme        action = "cycle-theme"
+
+	// actionIgnore unbinds a key so it falls through to the focused input
+	actionIgnore action = "ignore"
+)
+
+// actions lists every action that can be bound, including ones with no
+// default key
+var actions = []action{
+	actionQuit,
+	actionNextInput,
+	actionToggleCase,
+	actionToggleHighlight,
+	actionToggleGitTracked,
+	actionToggleNoIgnore,
+	actionToggleMultiline,
+	actionToggleWholeWord,
+	actionExportQuickfix,
+	actionExportJSON,
+	actionUp,
+	actionDown,
+	actionPageUp,
+	actionPageDown,
+	actionOpenEditor,
+	actionClose,
+	actionCopyPath,
+	actionCopyLine,
+	actionPreviewDefinition,
+	actionOpenDefinition,
+	actionLSPReferences,
+	actionLSPDefinition,
+	actionToggleMark,
+	actionReplace,
+	actionComparePrevious,
+	actionDiffHead,
+	actionToggleSummary,
+	actionRefreshPaths,
+	actionToggleContext,
+	actionToggleFileInfo,
+	actionSortRecent,
+	actionScrollLeft,
+	actionScrollRight,
+	actionEditNote,
+	actionHideClass,
+	actionExplainPattern,
+	actionCopyCommand,
+	actionNarrow,
+	actionCopyMode,
+	actionRetryLiteral,
+	actionRestorePaste,
+	actionSettings,
+	actionSuspend,
+	actionRecordMacro,
+	actionReplayMacro,
+	actionHistoryPrev,
+	actionHistoryNext,
+	actionBookmarks,
+	actionProfiles,
+	actionContinueSearch,
+	actionToggleMouse,
+	actionJobs,
+	actionToggleTree,
+	actionCycleTheme,
+	actionIgnore,
+}
+
+// defaultBindings maps Bubble Tea key strings to actions
+var defaultBindings = map[string]action{
+	"ctrl+c":   actionQuit,
+	"tab":      actionNextInput,
+	"ctrl+t":   actionToggleCase,
+	"ctrl+h":   actionToggleHighlight,
+	"ctrl+g":   actionToggleGitTracked,
+	"ctrl+w":   actionToggleWholeWord,
+	"alt+u":    actionToggleNoIgnore,
+	"alt+j":    actionToggleMultiline,
+	"ctrl+q":   actionExportQuickfix,
+	"up":       actionUp,
+	"ctrl+p":   actionUp,
+	"down":     actionDown,
+	"ctrl+n":   actionDown,
+	"pgup":     actionPageUp,
+	"pgdown":   actionPageDown,
+	"enter":    actionOpenEditor,
+	"esc":      actionClose,
+	"ctrl+y":   actionCopyPath,
+	"ctrl+]":   actionPreviewDefinition,
+	"alt+]":    actionOpenDefinition,
+	"alt+r":    actionLSPReferences,
+	"ctrl+@":   actionToggleMark,
+	"ctrl+r":   actionReplace,
+	"alt+c":    actionComparePrevious,
+	"alt+s":    actionToggleSummary,
+	"f5":       actionRefreshPaths,
+	"alt+x":    actionToggleContext,
+	"alt+i":    actionToggleFileInfo,
+	"alt+a":    actionSortRecent,
+	"alt+n":    actionEditNote,
+	"alt+t":    actionHideClass,
+	"alt+e":    actionExplainPattern,
+	"alt+y":    actionCopyCommand,
+	"alt+w":    actionNarrow,
+	"alt+k":    actionCopyMode,
+	"alt+l":    actionRetryLiteral,
+	"alt+v":    actionRestorePaste,
+	"alt+o":    actionSettings,
+	"ctrl+z":   actionSuspend,
+	"alt+m":    actionRecordMacro,
+	"alt+p":    actionReplayMacro,
+	"alt+up":   actionHistoryPrev,
+	"alt+down": actionHistoryNext,
+	"alt+b":    actionBookmarks,
+	"alt+g":    actionProfiles,
+	"alt+z":    actionContinueSearch,
+	"alt+h":    actionToggleMouse,
+	"alt+q":    actionJobs,
+	"f2":       actionToggleTree,
+	"f3":       actionCycleTheme,
+	"f7":       actionDiffHead,
+
+	"shift+left":  actionScrollLeft,
+	"shift+right": actionScrollRight,
+}
+
+// fzfKeyNames translates fzf key names that differ from Bubble Tea's
+var fzfKeyNames = map[string]string{
+	"btab":      "shift+tab",
+	"bspace":    "backspace",
+	"bs":        "backspace",
+	"del":       "delete",
+	"pgdn":      "pgdown",
+	"page-up":   "pgup",
+	"page-down": "pgdown",
+	"space":     " ",
+	"return":    "enter",
+	"escape":    "esc",
+}
+
+// keyMap resolves key presses to actions
+type keyMap struct {
+	bindings map[string]action
+}
+
+func newKeyMap() keyMap {
+	km := keyMap{bindings: make(map[string]action, len(defaultBindings))}
+	for key, a := range defaultBindings {
+		km.bindings[key] = a
+	}
+	return km
+}
+
+// lookup returns the action bound to key, or actionNone
+func (km keyMap) lookup(key string) action {
+	return km.bindings[key]
+}
+
+// bind applies an fzf-style binding list such as
+// "ctrl-y:copy-path,ctrl-o:open-editor". Bindings add to the defaults;
+// binding a key to "ignore" removes it.
+func (km keyMap) bind(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Split on the last colon so keys like "alt-:" stay intact
+		sep := strings.LastIndex(entry, ":")
+		if sep <= 0 || sep == len(entry)-1 {
+			return fmt.Errorf("invalid binding %q: want KEY:ACTION", entry)
+		}
+
+		key := parseKeyName(entry[:sep])
+		a := action(strings.TrimSpace(entry[sep+1:]))
+		if !isKnownAction(a) {
+			return fmt.Errorf("invalid binding %q: unknown action %q (want one of: %s)",
+				entry, a, strings.Join(actionNames(), ", "))
+		}
+
+		if a == actionIgnore {
+			delete(km.bindings, key)
+			continue
+		}
+		km.bindings[key] = a
+	}
+	return nil
+}
+
+// parseKeyName converts an fzf key name ("ctrl-y", "alt-enter") to the string
+// Bubble Tea reports for that key press ("ctrl+y", "alt+enter")
+func parseKeyName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if mapped, ok := fzfKeyNames[name]; ok {
+		return mapped
+	}
+	for _, mod := range []string{"ctrl-", "alt-", "shift-"} {
+		if strings.HasPrefix(name, mod) && len(name) > len(mod) {
+			rest := parseKeyName(name[len(mod):])
+			return strings.TrimSuffix(mod, "-") + "+" + rest
+		}
+	}
+	return name
+}
+
+func isKnownAction(a action) bool {
+	for _, known := range actions {
+		if known == a {
+			return true
+		}
+	}
+	return false
+}
+
+// actionNames lists every bindable action, sorted
+func actionNames() []string {
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = string(a)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseKeyName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ctrl", "ctrl-y", "ctrl+y"},
+		{"alt", "alt-enter", "alt+enter"},
+		{"ctrl alt", "ctrl-alt-x", "ctrl+alt+x"},
+		{"fzf alias", "pgdn", "pgdown"},
+		{"back tab", "btab", "shift+tab"},
+		{"plain", "f2", "f2"},
+		{"uppercase", "CTRL-O", "ctrl+o"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKeyName(tt.in); got != tt.want {
+				t.Errorf("parseKeyName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyMap_Bind(t *testing.T) {
+	km := newKeyMap()
+	if err := km.bind("ctrl-o:open-editor, ctrl-q:ignore"); err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+
+	if got := km.lookup("ctrl+o"); got != actionOpenEditor {
+		t.Errorf("ctrl+o = %q, want open-editor", got)
+	}
+	if got := km.lookup("enter"); got != actionOpenEditor {
+		t.Errorf("default enter binding lost: %q", got)
+	}
+	if got := km.lookup("ctrl+q"); got != actionNone {
+		t.Errorf("ctrl+q should be unbound, got %q", got)
+	}
+	if got := newKeyMap().lookup("ctrl+q"); got != actionExportQuickfix {
+		t.Errorf("binding leaked into a fresh key map: %q", got)
+	}
+}
+
+func TestKeyMap_BindErrors(t *testing.T) {
+	for _, spec := range []string{"ctrl-o", "ctrl-o:", ":up", "ctrl-o:launch-rockets"} {
+		if err := newKeyMap().bind(spec); err == nil {
+			t.Errorf("bind(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestBind_DrivesUpdate(t *testing.T) {
+	m := newTestModel(t)
+	if err := m.Bind("ctrl-j:down"); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 5)})
+	m = updated.(Model)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	m = updated.(Model)
+	if m.list.selected != 1 {
+		t.Errorf("selectedIndex = %d after bound down key, want 1", m.list.selected)
+	}
+}
+
+func TestDefaultKeys_LeaveAltDToTheInputs(t *testing.T) {
+	m := newTestModel(t)
+	m.inputs.pattern.SetValue("foo bar")
+	m.inputs.pattern.SetCursor(0)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}, Alt: true})
+	m = updated.(Model)
+	if got := m.inputs.pattern.Value(); got != " bar" {
+		t.Errorf("pattern after Alt+D = %q, want the word after the cursor deleted", got)
+	}
+	if got := m.keys.lookup("f7"); got != actionDiffHead {
+		t.Errorf("f7 = %q, want diff-head", got)
+	}
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/lint"
+)
+
+// lintTimeout bounds a linter run on one file
+const lintTimeout = 30 * time.Second
+
+// lintedMsg carries the diagnostics of a file the linter has finished with
+type lintedMsg struct {
+	path        string
+	run         int
+	diagnostics []lint.Diagnostic
+	err         error
+}
+
+// lintResult is what the linter found in a file, pending while it runs
+type lintResult struct {
+	run         int // Tells a run's result from that of an earlier one
+	pending     bool
+	diagnostics []lint.Diagnostic
+}
+
+// SetLinters lints the files of previewed results with the first of linters
+// whose globs match them, showing the problems on the match's line in the
+// preview header
+func (m *Model) SetLinters(linters []lint.Linter) {
+	m.linters = linters
+}
+
+// lintPreviewed starts the linter on the previewed file in the background,
+// unless it has been linted since the search or its last change
+func (m *Model) lintPreviewed() tea.Cmd {
+	path := m.preview.path
+	if path == "" || m.remote || path == m.gonePath || m.decoder.Decodes(path) {
+		return nil
+	}
+	if _, ok := m.lints[path]; ok {
+		return nil
+	}
+	linter := lint.For(m.linters, path)
+	if linter == nil {
+		return nil
+	}
+	if m.lints == nil {
+		m.lints = make(map[string]lintResult)
+	}
+	m.lintRuns++
+	run := m.lintRuns
+	m.lints[path] = lintResult{run: run, pending: true}
+	l := *linter
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lintTimeout)
+		defer cancel()
+		diagnostics, err := l.Run(ctx, path)
+		return lintedMsg{path: path, run: run, diagnostics: diagnostics, err: err}
+	}
+}
+
+// updateLinted keeps the diagnostics of a linted file, unless the file
+// changed while the linter ran
+func (m *Model) updateLinted(msg lintedMsg) {
+	if result, ok := m.lints[msg.path]; !ok || result.run != msg.run {
+		return
+	}
+	m.lints[msg.path] = lintResult{run: msg.run, diagnostics: msg.diagnostics}
+	if msg.err != nil {
+		m.status.err = fmt.Sprintf("Lint %s: %v", displayPath(msg.path), msg.err)
+	}
+	if msg.path == m.preview.path {
+		m.updatePreviewView()
+	}
+}
+
+// lintInfo describes the diagnostics on the lines of the previewed match
+// for the preview header
+func (m *Model) lintInfo() string {
+	last := m.preview.match + max(len(m.preview.spans), 1) - 1
+	var found []lint.Diagnostic
+	for _, d := range m.lints[m.preview.path].diagnostics {
+		if d.Line >= m.preview.match && d.Line <= last {
+			found = append(found, d)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	info := " ⚠ " + found[0].Message
+	if len(found) > 1 {
+		info += fmt.Sprintf(" (+%d more)", len(found)-1)
+	}
+	return info
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/lint"
+)
+
+func TestLint_ShowsDiagnosticsOfPreviewedMatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the linter uses sh")
+	}
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tx := 1\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	m.SetLinters([]lint.Linter{{
+		Globs:   []string{"*.go"},
+		Command: `printf '%s:4:2: declared and not used: x\n%s:4:2: ineffectual assignment\n%s:1:1: package comment\n' {file} {file} {file}; exit 1`,
+	}})
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 400, Height: 40})
+	m = updated.(Model)
+
+	updated, cmd := m.Update(m.loadPreviewAt(path, 4, nil, "")())
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("the preview started no linter")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	header := strings.SplitN(m.preview.view.View(), "\n", 2)[0]
+	if !strings.Contains(header, "⚠ declared and not used: x (+1 more)") || strings.Contains(header, "package comment") {
+		t.Errorf("header = %q, want the match line's diagnostics", header)
+	}
+
+	// Previewing the file again reuses the result
+	if _, cmd := m.Update(m.loadPreviewAt(path, 1, nil, "")()); cmd != nil {
+		t.Error("the linter ran again on an unchanged file")
+	}
+}
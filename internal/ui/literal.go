@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hasRegexMeta reports whether pattern contains characters a regex treats
+// specially, so searching it literally could find something else
+func hasRegexMeta(pattern string) bool {
+	return regexp.QuoteMeta(pattern) != pattern
+}
+
+// literalSuggested reports whether to offer retrying the last search as a
+// literal string: it finished with no matches, and its pattern, such as
+// "foo(" or "a.b[0]", has regex metacharacters
+func (m *Model) literalSuggested() bool {
+	return !m.searching && !m.remote && m.resultsDone && m.results.Len() == 0 &&
+		m.lastPattern != m.literalPattern && hasRegexMeta(m.lastPattern)
+}
+
+// retryLiteral searches the last pattern again as a fixed string. The
+// pattern stays literal until it is edited.
+func (m *Model) retryLiteral() tea.Cmd {
+	if !m.literalSuggested() {
+		return nil
+	}
+	m.literalPattern = m.lastPattern
+	return m.executeSearch(m.lastPattern, m.inputs.path.Value())
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// literalSearcher only finds matches in fixed-string searches, like rg does
+// for a pattern such as "foo(" that isn't a valid regex
+type literalSearcher struct{ fixed *[]bool }
+
+func (s literalSearcher) Search(ctx context.Context, pattern, path string, opts search.Options, results chan<- search.Match) error {
+	*s.fixed = append(*s.fixed, opts.FixedStrings)
+	var matches []search.Match
+	if opts.FixedStrings {
+		matches = testMatches(0, 2)
+	}
+	return search.NewMockSearcher(matches...).Search(ctx, pattern, path, opts, results)
+}
+
+func (s literalSearcher) Cancel() {}
+
+// runSearch feeds every batch of the search started by cmd back into m
+func runSearch(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	for cmd != nil {
+		msg, ok := cmd().(searchResultMsg)
+		if !ok {
+			t.Fatalf("search command returned %T", msg)
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+		cmd = msg.next
+	}
+	return m
+}
+
+func TestRetryLiteral_OfferedAfterEmptyRegexSearch(t *testing.T) {
+	var fixed []bool
+	m := newTestModel(t)
+	m.searcher = literalSearcher{fixed: &fixed}
+	m.inputs.pattern.SetValue("foo(")
+	m.lastPattern = "foo("
+	m = runSearch(t, m, m.executeSearch("foo(", "."))
+
+	if !m.literalSuggested() {
+		t.Fatal("no literal retry offered after an empty search for foo(")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Alt+L: retry as a literal string") {
+		t.Errorf("status doesn't offer the retry:\n%s", view)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}, Alt: true})
+	m = runSearch(t, updated.(Model), cmd)
+	if len(fixed) != 2 || fixed[0] || !fixed[1] {
+		t.Errorf("FixedStrings per search = %v, want [false true]", fixed)
+	}
+	if m.results.Len() != 2 || m.literalSuggested() {
+		t.Errorf("results = %d, suggested = %v after the literal retry", m.results.Len(), m.literalSuggested())
+	}
+
+	// Editing the pattern goes back to regex searches
+	m.executeSearch("foo(x", ".")
+	if m.literalPattern != "" {
+		t.Errorf("literalPattern = %q after a new pattern", m.literalPattern)
+	}
+}
+
+func TestHasRegexMeta(t *testing.T) {
+	for pattern, want := range map[string]bool{"foo(": true, "a.b[0]": true, "plain_word": false, "x+y": true} {
+		if got := hasRegexMeta(pattern); got != want {
+			t.Errorf("hasRegexMeta(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
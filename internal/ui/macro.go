@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// macroWait is how long a replay waits before checking again whether the
+// search a replayed key started has finished
+const macroWait = 50 * time.Millisecond
+
+// maxMacroRuns caps the replay count, so a typo can't start a replay that
+// takes minutes to stop
+const maxMacroRuns = 10000
+
+// macroReplay is a replay in progress
+type macroReplay struct {
+	run       int // Identifies the replay, so steps of a stopped one are dropped
+	keys      []tea.KeyMsg
+	index     int // Next key to replay
+	remaining int // Runs left, counting the current one
+	total     int
+}
+
+// macroStepMsg replays the next key of the macro
+type macroStepMsg struct {
+	run int
+}
+
+func newMacroInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "1"
+	ti.CharLimit = 5
+	ti.Width = 6
+	ti.Validate = func(s string) error {
+		if strings.Trim(s, "0123456789") != "" {
+			return fmt.Errorf("not a number")
+		}
+		return nil
+	}
+	return ti
+}
+
+// toggleMacroRecording starts recording keys, or stops and keeps the
+// recorded macro
+func (m *Model) toggleMacroRecording() {
+	if !m.macroRecording {
+		m.macroRecording = true
+		m.macroRecorded = nil
+		m.status.message = "Recording macro; Alt+M stops"
+		return
+	}
+	m.macroRecording = false
+	if len(m.macroRecorded) == 0 {
+		m.status.message = "Macro empty; the previous one is kept"
+		return
+	}
+	m.macroKeys = m.macroRecorded
+	m.macroRecorded = nil
+	m.status.message = fmt.Sprintf("Recorded a macro of %d keys; Alt+P replays it", len(m.macroKeys))
+}
+
+// recordMacroKey adds a key press to the macro being recorded. The keys
+// that control macros themselves are left out.
+func (m *Model) recordMacroKey(msg tea.KeyMsg, a action) {
+	if !m.macroRecording || m.macroStep {
+		return
+	}
+	if a == actionRecordMacro || a == actionReplayMacro {
+		return
+	}
+	m.macroRecorded = append(m.macroRecorded, msg)
+}
+
+// startMacroPrompt asks how many times to replay the macro
+func (m *Model) startMacroPrompt() tea.Cmd {
+	if m.macroRecording {
+		m.toggleMacroRecording()
+	}
+	if len(m.macroKeys) == 0 {
+		m.status.err = "No macro recorded; Alt+M starts recording"
+		return nil
+	}
+	m.macroPrompt = true
+	m.macroInput.SetValue("")
+	return m.macroInput.Focus()
+}
+
+// updateMacroPrompt handles key presses while the replay count is asked
+func (m Model) updateMacroPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.macroPrompt = false
+		m.macroInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.macroPrompt = false
+		m.macroInput.Blur()
+		runs := 1
+		if value := m.macroInput.Value(); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				m.status.err = "Replay count must be a positive number"
+				return m, nil
+			}
+			runs = min(n, maxMacroRuns)
+		}
+		return m, m.replayMacro(runs)
+	}
+	var cmd tea.Cmd
+	m.macroInput, cmd = m.macroInput.Update(msg)
+	return m, cmd
+}
+
+// replayMacro replays the recorded keys runs times
+func (m *Model) replayMacro(runs int) tea.Cmd {
+	m.macroRun++
+	m.macroReplay = &macroReplay{
+		run:       m.macroRun,
+		keys:      m.macroKeys,
+		remaining: runs,
+		total:     runs,
+	}
+	m.status.err = ""
+	run := m.macroRun
+	return func() tea.Msg {
+		return macroStepMsg{run: run}
+	}
+}
+
+// stepMacro replays one key. Each key is its own message, so searches,
+// previews and editors started by one key are handled before the next, and
+// a search still running holds the replay back.
+func (m Model) stepMacro(msg macroStepMsg) (tea.Model, tea.Cmd) {
+	replay := m.macroReplay
+	if replay == nil || replay.run != msg.run {
+		return m, nil
+	}
+	if m.searching {
+		return m, tea.Tick(macroWait, func(time.Time) tea.Msg { return msg })
+	}
+
+	key := replay.keys[replay.index]
+	replay.index++
+	if replay.index == len(replay.keys) {
+		replay.index = 0
+		replay.remaining--
+	}
+
+	m.macroStep = true
+	updated, cmd := m.Update(key)
+	m = updated.(Model)
+	m.macroStep = false
+
+	if m.macroReplay != replay {
+		// The key stopped the replay, e.g. by quitting
+		return m, cmd
+	}
+	if replay.remaining == 0 {
+		m.macroReplay = nil
+		m.status.message = fmt.Sprintf("Replayed macro %d times", replay.total)
+		return m, cmd
+	}
+	return m, tea.Batch(cmd, func() tea.Msg { return msg })
+}
+
+// stopMacro cancels a replay in progress
+func (m *Model) stopMacro() {
+	replay := m.macroReplay
+	m.macroReplay = nil
+	done := replay.total - replay.remaining
+	m.status.message = fmt.Sprintf("Macro stopped after %d of %d runs", done, replay.total)
+}
+
+// macroStatus is shown in the status area while the replay count is asked
+func (m *Model) macroStatus() string {
+	return fmt.Sprintf("Replay macro (%d keys) how many times? %s  (Enter replay, Esc cancel)", len(m.macroKeys), m.macroInput.View())
+}
+
+// macroIndicator marks the status line while a macro is recorded or
+// replayed
+func (m *Model) macroIndicator() string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+	switch {
+	case m.macroRecording:
+		return style.Render(fmt.Sprintf("● REC %d", len(m.macroRecorded))) + " "
+	case m.macroReplay != nil:
+		run := m.macroReplay.total - m.macroReplay.remaining + 1
+		return style.Render(fmt.Sprintf("▶ %d/%d", run, m.macroReplay.total)) + " "
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runMacro feeds the replay steps returned by cmd back into m until the
+// replay ends, dropping every other message
+func runMacro(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	pending := []tea.Cmd{cmd}
+	for steps := 0; len(pending) > 0; steps++ {
+		if steps > 1000 {
+			t.Fatal("replay didn't finish")
+		}
+		next := pending[0]
+		pending = pending[1:]
+		if next == nil {
+			continue
+		}
+		switch msg := next().(type) {
+		case tea.BatchMsg:
+			pending = append(pending, msg...)
+		case macroStepMsg:
+			updated, cmd := m.Update(msg)
+			m = updated.(Model)
+			pending = append(pending, cmd)
+		}
+	}
+	return m
+}
+
+func TestMacro_RecordAndReplay(t *testing.T) {
+	m := newTestModel(t)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 10), done: true})
+	m = updated.(Model)
+
+	press := func(msg tea.KeyMsg) tea.Cmd {
+		t.Helper()
+		updated, cmd := m.Update(msg)
+		m = updated.(Model)
+		return cmd
+	}
+	altM := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}, Alt: true}
+
+	// Record "mark, down": marking moves to the next result, so each run
+	// marks every other one. The keys also act while recording.
+	press(altM)
+	press(tea.KeyMsg{Type: tea.KeyCtrlAt})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(altM)
+	if len(m.macroKeys) != 2 {
+		t.Fatalf("recorded %d keys, want 2: %v", len(m.macroKeys), m.macroKeys)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}, Alt: true})
+	if !m.macroPrompt {
+		t.Fatal("Alt+P didn't ask for a replay count")
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	m = runMacro(t, m, press(tea.KeyMsg{Type: tea.KeyEnter}))
+
+	if m.macroReplay != nil {
+		t.Error("replay still running")
+	}
+	if m.list.selected != 8 {
+		t.Errorf("selected %d, want 8", m.list.selected)
+	}
+	want := map[int]bool{0: true, 2: true, 4: true, 6: true}
+	if !reflect.DeepEqual(m.marked, want) {
+		t.Errorf("marked %v, want %v", m.marked, want)
+	}
+}
+
+func TestMacro_KeyStopsReplay(t *testing.T) {
+	m := newTestModel(t)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 10), done: true})
+	m = updated.(Model)
+	m.macroKeys = []tea.KeyMsg{{Type: tea.KeyDown}}
+
+	cmd := m.replayMacro(5)
+	step := cmd()
+	updated, _ = m.Update(step)
+	m = updated.(Model)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = updated.(Model)
+	if m.macroReplay != nil {
+		t.Fatal("a key press didn't stop the replay")
+	}
+	if m.list.selected != 1 {
+		t.Errorf("selected %d, want 1: the stopping key must not act", m.list.selected)
+	}
+
+	// Steps still in flight from the stopped replay are dropped
+	updated, _ = m.Update(step)
+	m = updated.(Model)
+	if m.list.selected != 1 {
+		t.Errorf("selected %d after a stale step, want 1", m.list.selected)
+	}
+}
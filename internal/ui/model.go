@@ -2,14 +2,30 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"io"
+	"maps"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/William9923/irg/internal/classify"
+	"github.com/William9923/irg/internal/clipboard"
+	"github.com/William9923/irg/internal/difftool"
 	"github.com/William9923/irg/internal/editor"
+	"github.com/William9923/irg/internal/export"
 	"github.com/William9923/irg/internal/highlight"
+	"github.com/William9923/irg/internal/history"
+	"github.com/William9923/irg/internal/hooks"
+	"github.com/William9923/irg/internal/lint"
+	"github.com/William9923/irg/internal/lsp"
+	"github.com/William9923/irg/internal/metrics"
+	"github.com/William9923/irg/internal/replace"
 	"github.com/William9923/irg/internal/search"
+	"github.com/William9923/irg/internal/state"
+	"github.com/William9923/irg/internal/tags"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -19,69 +35,219 @@ import (
 const (
 	debounceDelay  = 200 * time.Millisecond
 	maxResults     = 10000
-	previewContext = 5
-)
-
-type focusedInput int
+	previewContext = 5 // Default lines of context previewed around a match
+	lspTimeout     = 30 * time.Second
 
-const (
-	focusPattern focusedInput = iota
-	focusPath
-	focusTypes
+	inlineContextLines = 1 // Context shown around each result in inline context mode
 )
 
+// contextProvider is implemented by backends whose result files aren't on
+// local disk, so the preview must fetch them through the backend
+type contextProvider interface {
+	FileContext(ctx context.Context, path string, lineNum, contextLines int, submatches []search.Submatch) (*search.FileContext, error)
+}
+
 type Model struct {
-	patternInput textinput.Model
-	pathInput    textinput.Model
-	typesInput   textinput.Model
-	resultsView  viewport.Model
-	previewView  viewport.Model
-	focused      focusedInput
-
-	searcher        *search.Searcher
-	results         []search.Match
-	selectedIndex   int
+	inputs  inputBar
+	list    resultsList
+	preview previewPane
+	status  statusBar
+	keys    keyMap
+
+	searcher        search.Searcher
+	remote          bool // Results come from a code host, not local files
+	results         *search.ResultStore
+	resultsDone     bool                // results holds a search that ran to completion
+	resultsPattern  string              // Pattern of the search in results
+	resultsPath     string              // Path of the search in results
+	resultStamps    map[string]fileInfo // Result files as the editor or a replacement found them
+	previous        *search.ResultStore // Last finished search before the current one
+	previousPattern string
+	compare         *comparison // nil unless comparing with the previous search
+	compareToken    int         // Tells the latest diff from ones for older results
 	searchCtx       context.Context
 	searchCancel    context.CancelFunc
 	caseSensitivity search.CaseSensitivity
+	gitTracked      bool
+	noIgnore        bool                  // Search files .gitignore and the like exclude
+	multiline       bool                  // Let the pattern match across lines
+	wholeWord       bool                  // Match the pattern only as a whole word
+	normalize       bool                  // Match and show text in either Unicode normalization form
+	maxDepth        int                   // Directory levels searched below the path; 0 for any
+	shards          int                   // rg processes for a sharded search, 0 for one
+	shardProgress   *search.ShardProgress // Progress of the running sharded search
+	batching        *resultBatching       // When the matches of a running search are shown
+	stableOrder     bool                  // Sort results so repeated searches match
+	maxLineLength   int                   // Longer result lines are excerpted
+	roots           []string              // Paths searched instead of the path input's tree, from --paths-from
+	repoRoot        string                // Searched when the path input is empty; "" searches the current directory
+	classifier      *classify.Classifier  // Labels results in tests, generated code, ...
+	classFilter     int                   // Step of the class filter; see hiddenClasses
+	literalPattern  string                // Pattern retried as a literal string
+	pasteNormalized string                // Pattern made by normalizing a paste
+	pasteRaw        string                // The same pattern with the paste as it was
+	inlineContext   bool                  // Show context lines around each result
+	showFileInfo    bool                  // Show the age and size of each result's file
+	fileInfos       map[string]fileInfo   // Stat'ed files of the current results
+	caseFold        caseFolding           // One spelling per file on case-insensitive filesystems
+	sortRecent      bool                  // List results of recently modified files first
+	recentOrder     []int                 // Search order of the results sorted by recency
+	decoder         search.Decoder        // How rg decodes compressed and preprocessed files
+	linters         []lint.Linter         // Linters for previewed files; see SetLinters
+	lints           map[string]lintResult // Linted files of the current results
+	lintRuns        int                   // Linter runs started, telling their results apart
+	extracted       []string              // Temporary copies of decoded files opened in the editor
+	marked          map[int]bool          // Indices of marked results
+	notes           map[int]string        // Session notes on marked results, by index
+	selectMode      bool                  // Enter accepts the selection and quits
+	accepted        bool
 
 	fileTypes     []string
 	fileTypesNot  []string
 	lastFileTypes []string
 
-	allTypes          []string // All ripgrep types loaded at startup
-	filteredTypes     []string // Currently filtered types for dropdown
-	dropdownVisible   bool     // Is dropdown open?
-	dropdownIndex     int      // Currently highlighted dropdown item
-	dropdownMaxHeight int      // Max items to show (8)
+	allTypes     []string            // All ripgrep types loaded at startup
+	typeGlobs    map[string][]string // File globs of each type
+	typeCounts   typeCounts          // Results per type, for the dropdown
+	customTypes  map[string][]string // Types defined in the config
+	typeDropdown dropdown[string]    // Types starting with the one being typed
+
+	// Per-directory summary panel
+	summary        dirSummary
+	summaryVisible bool
+	summaryIndex   int
+
+	// Results tree, shown in place of the list
+	treeVisible   bool
+	treeIndex     int
+	treeCollapsed map[string]bool // Folded directories, by path
 
 	// Path dropdown state
-	allPaths            []PathEntry
-	filteredPaths       []PathEntry
-	pathDropdownVisible bool
-	pathDropdownIndex   int
-	pathProvider        *PathProvider
-	pathsLoaded         bool
+	allPaths      []PathEntry
+	pathDropdown  dropdown[PathEntry] // Paths matching the path being typed
+	pathProvider  *PathProvider
+	pathIndex     *pathIndex
+	pathsLoaded   bool // At least one batch of paths has arrived
+	pathsIndexing bool // The walk is still running
 
 	highlighter *highlight.Highlighter
+	colors      Colors
+	clipboard   clipboard.Backend
+	tags        *tags.Index
+	lsp         *lsp.Manager // nil unless the experimental LSP mode is on
+	hooks       *hooks.Runner
+	state       *state.Store // nil when session state isn't persisted
+	project     string       // Project root that state is keyed by
+
+	statsPath    string         // Stats file; "" when usage isn't recorded
+	statsPending *settledSearch // Last finished search, not counted yet
+
+	historyPath    string          // History file; "" when searches aren't saved
+	history        []history.Entry // Searches to recall, oldest first
+	historyPending *history.Entry  // Last finished search, not saved yet
+	historyIndex   int             // How far back the recalled search is; 0 when none is
+	historyDraft   history.Entry   // Search being typed when recalling began
+	historyRecall  []history.Entry // Searches stepped through while recalling
+
+	previewCache *search.FileCache
+
+	metrics *metrics.Collector // nil unless --metrics is passed
+
+	quickfixFile string // Where Ctrl+Q writes the quickfix list
+
+	announcer *announcer // nil unless --announce is passed
+
+	replaceTool  replace.Tool
+	replaceState replaceState
+	replaceInput textinput.Model
+	replaceFiles []string
+	replaceDiff  []string
+
+	diffTool  difftool.Tool
+	diffQueue []diffTarget // Files still to compare after the current one
+	diffTotal int
+
+	explainVisible bool // The regex explanation overlay is open
+
+	// Narrowing menu, offered when a search hits the result limit
+	narrowVisible bool
+	narrowIndex   int
+	narrowOptions []narrowOption
+	excludeDirs   []string // Directories left out of searches
+	excludeTypes  []string // Types left out of searches, besides --type-not
+
+	// Bookmark picker
+	bookmarksVisible bool
+	bookmarkIndex    int
+	bookmarks        []Bookmark // Ordered by name
+
+	// Search profiles and their picker
+	profilesVisible bool
+	profileIndex    int       // 0 turns the profile off, then one per profile
+	profiles        []Profile // Ordered by name
+	profile         string    // Name of the active profile, or ""
+	profileExcludes []string  // Globs the active profile leaves out
+	profileDetected string    // Profile of the search path, when the picker opened
+
+	// Searches moved to the background and their list
+	jobsVisible bool
+	jobIndex    int
+	jobs        []*job // In the order they were moved
+	jobCount    int    // Numbers the jobs
+
+	mouse bool // The mouse is captured; off, the terminal selects text
+
+	// Settings screen
+	settingsVisible bool
+	settingsIndex   int
+	settingsEditing bool // Typing the value of a text setting
+	settingsInput   textinput.Model
+	settingsChanged map[int]bool // Settings changed since the last save, by row
+	configPath      string       // Where settings are saved; "" when unknown
+	themeToken      int          // Tells the latest theme change from earlier ones
+	editorCommand   string       // Editor from the config, used instead of $EDITOR
+
+	noteEditing bool
+	noteInput   textinput.Model
+	noteIndex   int
+
+	// Keyboard macro
+	macroRecording bool
+	macroRecorded  []tea.KeyMsg // Keys of the macro being recorded
+	macroKeys      []tea.KeyMsg // Last recorded macro
+	macroPrompt    bool         // Asking how many times to replay
+	macroInput     textinput.Model
+	macroReplay    *macroReplay // nil unless a replay is running
+	macroRun       int
+	macroStep      bool // Update is applying a replayed key
 
 	debounceToken int
+	estimate      int  // Matches of the pattern being typed among the last results
+	estimated     bool // estimate stands until the search is run
 	lastPattern   string
 	lastPath      string
 
 	width  int
 	height int
 
-	searching         bool
-	matchCount        int
-	searchTime        time.Duration
-	searchStart       time.Time
-	errorMessage      string
-	previewPath       string
-	previewLines      []string
-	previewStart      int
-	previewMatch      int
-	previewSubmatches []search.Submatch
+	searching      bool
+	matchCount     int
+	searchTime     time.Duration
+	searchStart    time.Time
+	suspendedAt    time.Time       // When Ctrl+Z suspended irg; zero while running
+	searchTimeout  time.Duration   // Searches running longer are stopped; 0 never
+	searchHeld     bool            // The search is paused by its timeout
+	heldAt         time.Time       // When the timeout paused the search
+	partial        bool            // The timeout stopped the search short
+	continuedCtx   context.Context // Search continued past its timeout
+	gonePath       string          // File reported gone; r re-runs the search
+	rootPrompt     *rootPrompt     // Search held back until it's confirmed
+	rootToken      int             // Tells the latest file count from earlier ones
+	confirmFiles   int             // Ask before searching more files than this; see SetConfirmFiles
+	confirmedRoots map[string]bool // Search roots confirmed this session
+	countedRoots   map[string]bool // Search roots counted, true when over confirmFiles
+	previewRadius  int             // Lines of context previewed around a match
+	previewRadii   []ContextRadius // Per-glob overrides of previewRadius
 
 	ctrlCPressed  bool
 	lastCtrlCTime time.Time
@@ -90,6 +256,8 @@ type Model struct {
 type searchResultMsg struct {
 	matches []search.Match
 	done    bool
+	ctx     context.Context // Search the batch belongs to
+	next    tea.Cmd         // Reads the following batch while not done
 }
 
 type debounceMsg struct {
@@ -103,62 +271,135 @@ type searchErrorMsg struct {
 }
 
 type previewLoadedMsg struct {
-	path       string
-	lines      []string
-	startLine  int
-	matchLine  int
-	submatches []search.Submatch
+	token     int
+	path      string
+	note      string
+	scope     string // Definition enclosing the match
+	lines     []string
+	startLine int
+	matchLine int
+	spans     [][]search.Submatch // Submatches of each line of the match, from matchLine on
+	gone      bool                // The file no longer exists
+}
+
+type definitionMsg struct {
+	symbol string
+	tag    tags.Tag
+	open   bool // Open in the editor instead of the preview
+	err    error
+}
+
+type lspResultsMsg struct {
+	kind    string // "references" or "definition"
+	symbol  string
+	server  string
+	matches []search.Match
+	err     error
+}
+
+type hookFinishedMsg struct {
+	err error
+}
+
+type stateSavedMsg struct {
+	err error
 }
 
 type editorFinishedMsg struct {
 	err error
 }
 
+// extractedMsg carries the decoded copy of a file to open in the editor
+type extractedMsg struct {
+	source string
+	path   string
+	line   int
+	err    error
+}
+
+type exportFinishedMsg struct {
+	path   string
+	count  int
+	marked bool // Only the marked results were written
+	err    error
+}
+
+type clipboardMsg struct {
+	text string
+	err  error
+}
+
 type pathsLoadedMsg struct {
+	index *pathIndex
 	paths []PathEntry
+	done  bool
 }
 
-func NewModel() Model {
-	patternTi := textinput.New()
-	patternTi.Placeholder = "Search pattern..."
-	patternTi.Focus()
-	patternTi.CharLimit = 256
-	patternTi.Width = 40
-
-	pathTi := textinput.New()
-	pathTi.Placeholder = "Path (default: .)"
-	pathTi.CharLimit = 256
-	pathTi.Width = 30
-
-	typesTi := textinput.New()
-	typesTi.Placeholder = "Types (e.g., go,rust)"
-	typesTi.CharLimit = 256
-	typesTi.Width = 30
+// pathIndex is a background walk feeding the path suggestions
+type pathIndex struct {
+	batches <-chan []PathEntry
+	cancel  context.CancelFunc
+	refresh bool // Started by the refresh key, so report when it ends
+}
+
+func newPathIndex(provider *PathProvider) *pathIndex {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pathIndex{batches: provider.Stream(ctx), cancel: cancel}
+}
 
+// next waits for the following batch of the walk
+func (idx *pathIndex) next() tea.Msg {
+	paths, ok := <-idx.batches
+	return pathsLoadedMsg{index: idx, paths: paths, done: !ok}
+}
+
+func NewModel() Model {
 	resultsVp := viewport.New(40, 20)
 	previewVp := viewport.New(40, 20)
 
 	pathProvider := NewPathProvider(".")
 
 	m := Model{
-		patternInput:      patternTi,
-		pathInput:         pathTi,
-		typesInput:        typesTi,
-		resultsView:       resultsVp,
-		previewView:       previewVp,
-		focused:           focusPattern,
-		searcher:          search.NewSearcher(),
-		results:           make([]search.Match, 0),
-		lastPath:          ".",
-		caseSensitivity:   search.CaseSmart,
-		highlighter:       highlight.New(true, "monokai"),
-		width:             80, // Default width for help positioning
-		height:            24, // Default height for help positioning
-		dropdownMaxHeight: 8,
-		pathProvider:      pathProvider,
-	}
-
-	m.allTypes, _ = search.LoadRipgrepTypes()
+		inputs:          newInputBar(),
+		list:            resultsList{view: resultsVp, cache: newResultsRenderCache()},
+		preview:         previewPane{view: previewVp},
+		keys:            newKeyMap(),
+		searcher:        search.NewRipgrepSearcher(),
+		results:         search.NewResultStore(),
+		lastPath:        ".",
+		caseSensitivity: search.CaseSmart,
+		highlighter:     highlight.New(true, "monokai"),
+		colors:          DefaultColors,
+		clipboard:       clipboard.Detect(),
+		tags:            tags.NewIndex("."),
+		width:           80, // Default width for help positioning
+		height:          24, // Default height for help positioning
+		typeDropdown:    newDropdown[string](typeDropdownMaxHeight),
+		pathDropdown:    newDropdown[PathEntry](pathDropdownMaxHeight),
+		pathProvider:    pathProvider,
+		previewCache:    search.NewFileCache(),
+		previewRadius:   previewContext,
+		quickfixFile:    export.QuickfixFile,
+		profiles:        builtinProfiles,
+		mouse:           true,
+		marked:          make(map[int]bool),
+		fileInfos:       make(map[string]fileInfo),
+		caseFold:        newCaseFolding(),
+		batching:        newResultBatching(0, 0, true),
+		notes:           make(map[int]string),
+		replaceTool:     replace.New(""),
+		replaceInput:    newReplaceInput(),
+		diffTool:        difftool.New(""),
+		maxLineLength:   search.DefaultMaxLineLength,
+		noteInput:       newNoteInput(),
+		macroInput:      newMacroInput(),
+		settingsInput:   newSettingsInput(),
+		settingsChanged: make(map[int]bool),
+		classifier:      classify.New(classify.Defaults),
+	}
+
+	m.typeGlobs, _ = search.LoadTypeGlobs(nil)
+	m.allTypes = sortedKeys(m.typeGlobs)
 	return m
 }
 
@@ -170,299 +411,82 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m *Model) loadPathsAsync() tea.Cmd {
+	provider := m.pathProvider
 	return func() tea.Msg {
-		paths := m.pathProvider.LoadPaths()
-		return pathsLoadedMsg{paths: paths}
+		return newPathIndex(provider).next()
 	}
 }
 
-// calculateViewportHeight returns the correct viewport height based on dropdown visibility
-// Base height calculation: windowHeight - 7 (for input row + help text + borders)
-// When dropdown is visible: subtract additional space for dropdown (11 lines for 8 items + borders)
-func (m *Model) calculateViewportHeight() int {
-	baseHeight := m.height - 7
-	if m.dropdownVisible {
-		// Dropdown takes ~11 lines: 8 items + borders + padding + counter
-		dropdownHeight := 11
-		if len(m.filteredTypes) < m.dropdownMaxHeight {
-			// If fewer items, dropdown is smaller: items + 3 for borders/padding
-			dropdownHeight = len(m.filteredTypes) + 3
-		}
-		baseHeight -= dropdownHeight
-	}
-	if m.pathDropdownVisible {
-		dropdownHeight := 11
-		if len(m.filteredPaths) < pathDropdownMaxHeight {
-			dropdownHeight = len(m.filteredPaths) + 3
-		}
-		baseHeight -= dropdownHeight
-	}
-	if baseHeight < 5 {
-		baseHeight = 5 // Minimum viable height
+// refreshPaths drops the path index and walks the tree again
+func (m *Model) refreshPaths() tea.Cmd {
+	if m.pathIndex != nil {
+		m.pathIndex.cancel()
 	}
-	return baseHeight
+	m.pathProvider.Invalidate()
+	m.allPaths = nil
+	m.pathsLoaded = false
+	m.pathsIndexing = true
+	m.pathIndex = newPathIndex(m.pathProvider)
+	m.pathIndex.refresh = true
+	m.status.message = "Re-indexing paths..."
+	return m.pathIndex.next
 }
 
-func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
-
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			now := time.Now()
-			if m.ctrlCPressed && now.Sub(m.lastCtrlCTime) < 2*time.Second {
-				return m, tea.Quit
-			}
-			m.ctrlCPressed = true
-			m.lastCtrlCTime = now
-			return m, nil
-
-		case "tab":
-			wasDropdownVisible := m.dropdownVisible
-			wasPathDropdownVisible := m.pathDropdownVisible
-			if m.focused == focusPattern {
-				m.focused = focusPath
-				m.patternInput.Blur()
-				m.pathInput.Focus()
-			} else if m.focused == focusPath {
-				m.focused = focusTypes
-				m.pathInput.Blur()
-				m.typesInput.Focus()
-			} else {
-				m.focused = focusPattern
-				m.typesInput.Blur()
-				m.patternInput.Focus()
-			}
-			m.dropdownVisible = false
-			m.pathDropdownVisible = false
-			// Update viewport heights when dropdown visibility changes
-			if wasDropdownVisible || wasPathDropdownVisible {
-				viewportHeight := m.calculateViewportHeight()
-				m.resultsView.Height = viewportHeight
-				m.previewView.Height = viewportHeight
-				m.updateResultsView()
-			}
-			return m, nil
-
-		case "ctrl+t":
-			switch m.caseSensitivity {
-			case search.CaseSmart:
-				m.caseSensitivity = search.CaseSensitive
-			case search.CaseSensitive:
-				m.caseSensitivity = search.CaseInsensitive
-			case search.CaseInsensitive:
-				m.caseSensitivity = search.CaseSmart
-			}
-			pattern := m.patternInput.Value()
-			path := m.pathInput.Value()
-			if pattern != "" {
-				cmds = append(cmds, m.executeSearch(pattern, path))
-			}
-			return m, tea.Batch(cmds...)
+// layout sizes the panes to the window, making room for the summary panel
+// when it is shown
+func (m *Model) layout() {
+	listWidth := m.width / 3
+	previewWidth := m.width - listWidth - 5
+	if m.summaryVisible {
+		previewWidth -= m.summaryWidth() + 2
+	}
 
-		case "ctrl+h":
-			m.highlighter.SetEnabled(!m.highlighter.IsEnabled())
-			m.updatePreviewView()
-			return m, nil
+	viewportHeight := m.calculateViewportHeight()
+	m.list.view.Width = listWidth
+	m.list.view.Height = viewportHeight
+	m.preview.view.Width = previewWidth
+	m.preview.view.Height = viewportHeight
 
-		case "up", "ctrl+p":
-			if m.focused == focusPath && m.pathDropdownVisible {
-				if m.pathDropdownIndex > 0 {
-					m.pathDropdownIndex--
-				} else {
-					m.pathDropdownIndex = len(m.filteredPaths) - 1
-				}
-				return m, nil
-			}
-			if m.dropdownVisible {
-				if m.dropdownIndex > 0 {
-					m.dropdownIndex--
-				} else {
-					m.dropdownIndex = len(m.filteredTypes) - 1
-				}
-				return m, nil
-			}
-			if m.selectedIndex > 0 {
-				m.selectedIndex--
-				m.updateResultsView()
-				cmds = append(cmds, m.loadPreview())
-			}
-			return m, tea.Batch(cmds...)
-
-		case "down", "ctrl+n":
-			if m.focused == focusPath && m.pathDropdownVisible {
-				if m.pathDropdownIndex < len(m.filteredPaths)-1 {
-					m.pathDropdownIndex++
-				} else {
-					m.pathDropdownIndex = 0
-				}
-				return m, nil
-			}
-			if m.dropdownVisible {
-				if m.dropdownIndex < len(m.filteredTypes)-1 {
-					m.dropdownIndex++
-				} else {
-					m.dropdownIndex = 0
-				}
-				return m, nil
-			}
-			if m.selectedIndex < len(m.results)-1 {
-				m.selectedIndex++
-				m.updateResultsView()
-				cmds = append(cmds, m.loadPreview())
-			}
-			return m, tea.Batch(cmds...)
-
-		case "enter":
-			if m.focused == focusPath && m.pathDropdownVisible && len(m.filteredPaths) > 0 {
-				selectedPath := m.filteredPaths[m.pathDropdownIndex].Path
-				m.pathInput.SetValue(selectedPath)
-				m.pathInput.SetCursor(len(selectedPath))
-				m.pathDropdownVisible = false
-				viewportHeight := m.calculateViewportHeight()
-				m.resultsView.Height = viewportHeight
-				m.previewView.Height = viewportHeight
-				m.updateResultsView()
-				pattern := m.patternInput.Value()
-				if pattern != "" {
-					return m, m.executeSearch(pattern, selectedPath)
-				}
-				return m, nil
-			}
-			if m.dropdownVisible && len(m.filteredTypes) > 0 {
-				selectedType := m.filteredTypes[m.dropdownIndex]
-				currentVal := m.typesInput.Value()
-				parts := strings.Split(currentVal, ",")
-				if len(parts) > 0 {
-					parts[len(parts)-1] = selectedType
-					newVal := strings.Join(parts, ",")
-					m.typesInput.SetValue(newVal)
-					m.typesInput.SetCursor(len(newVal))
-					m.dropdownVisible = false
-					// Update viewport heights when dropdown is closed
-					viewportHeight := m.calculateViewportHeight()
-					m.resultsView.Height = viewportHeight
-					m.previewView.Height = viewportHeight
-					m.updateResultsView()
-					m.fileTypes = parseTypes(newVal)
-					return m, m.executeSearch(m.patternInput.Value(), m.pathInput.Value())
-				}
-			}
-			if m.selectedIndex < len(m.results) && len(m.results) > 0 {
-				return m, m.openInEditor()
-			}
-			return m, nil
+	m.updateResultsView()
+	m.updatePreviewView()
+}
 
-		case "esc":
-			if m.pathDropdownVisible {
-				m.pathDropdownVisible = false
-				viewportHeight := m.calculateViewportHeight()
-				m.resultsView.Height = viewportHeight
-				m.previewView.Height = viewportHeight
-				m.updateResultsView()
-				return m, nil
-			}
-			if m.dropdownVisible {
-				m.dropdownVisible = false
-				// Update viewport heights when dropdown is closed
-				viewportHeight := m.calculateViewportHeight()
-				m.resultsView.Height = viewportHeight
-				m.previewView.Height = viewportHeight
-				m.updateResultsView()
-				return m, nil
-			}
-			if m.focused == focusTypes {
-				m.typesInput.SetValue("")
-				m.fileTypes = nil
-				return m, m.executeSearch(m.patternInput.Value(), m.pathInput.Value())
-			}
+// calculateViewportHeight returns the viewport height: the window height less
+// the input row, help text and borders. Dropdowns are drawn over the panes, so
+// opening one doesn't resize them.
+func (m *Model) calculateViewportHeight() int {
+	return max(m.height-7, 5) // Minimum viable height
+}
 
-		case "pgup":
-			m.selectedIndex -= 10
-			if m.selectedIndex < 0 {
-				m.selectedIndex = 0
-			}
-			m.updateResultsView()
-			cmds = append(cmds, m.loadPreview())
-			return m, tea.Batch(cmds...)
-
-		case "pgdown":
-			m.selectedIndex += 10
-			if m.selectedIndex >= len(m.results) {
-				m.selectedIndex = len(m.results) - 1
-			}
-			if m.selectedIndex < 0 {
-				m.selectedIndex = 0
-			}
-			m.updateResultsView()
-			cmds = append(cmds, m.loadPreview())
-			return m, tea.Batch(cmds...)
-		}
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	if next, ok := updated.(Model); ok && next.announcer != nil {
+		next.announceSelection()
+		updated = next
+	}
+	return updated, cmd
+}
 
-		// Reset Ctrl+C state on any other key press
-		if msg.String() != "ctrl+c" {
-			m.ctrlCPressed = false
-		}
+// update handles msg; Update wraps it to follow the selection
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.status.Update(msg) {
+		return m, nil
+	}
+	if cmd, handled := m.updateBackground(msg); handled {
+		return m, cmd
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.updateKey(msg)
 
 	case tea.MouseMsg:
-		// Handle mouse wheel events by updating selectedIndex instead of letting
-		// the viewport handle scrolling directly. This ensures scroll position
-		// stays synchronized with the selected item through updateResultsView().
-		switch msg.Button {
-		case tea.MouseButtonWheelUp:
-			// Scroll up by 3 lines (default mouse wheel delta)
-			m.selectedIndex -= 3
-			if m.selectedIndex < 0 {
-				m.selectedIndex = 0
-			}
-			m.updateResultsView()
-			cmds = append(cmds, m.loadPreview())
-			return m, tea.Batch(cmds...)
-
-		case tea.MouseButtonWheelDown:
-			// Scroll down by 3 lines (default mouse wheel delta)
-			m.selectedIndex += 3
-			if m.selectedIndex >= len(m.results) {
-				m.selectedIndex = len(m.results) - 1
-			}
-			if m.selectedIndex < 0 {
-				m.selectedIndex = 0
-			}
-			m.updateResultsView()
-			cmds = append(cmds, m.loadPreview())
-			return m, tea.Batch(cmds...)
-		}
-		// For other mouse events (clicks, etc.), let viewport handle them
-		var cmd tea.Cmd
-		m.resultsView, cmd = m.resultsView.Update(msg)
-		if cmd != nil {
-			cmds = append(cmds, cmd)
-		}
-		return m, tea.Batch(cmds...)
+		return m.updateMouse(msg)
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-
-		patternWidth := (msg.Width - 15) / 2
-		pathWidth := (msg.Width - 15) / 4
-		typesWidth := (msg.Width - 15) - patternWidth - pathWidth
-		m.patternInput.Width = patternWidth
-		m.pathInput.Width = pathWidth
-		m.typesInput.Width = typesWidth
-
-		listWidth := msg.Width / 3
-		previewWidth := msg.Width - listWidth - 5
-
-		viewportHeight := m.calculateViewportHeight()
-		m.resultsView.Width = listWidth
-		m.resultsView.Height = viewportHeight
-		m.previewView.Width = previewWidth
-		m.previewView.Height = viewportHeight
-
-		m.updateResultsView()
-		m.updatePreviewView()
+		m.inputs.resize(msg.Width)
+		m.layout()
 		return m, nil
 
 	case debounceMsg:
@@ -472,680 +496,1459 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case searchResultMsg:
-		m.results = append(m.results, msg.matches...)
-		m.matchCount = len(m.results)
-
-		if msg.done {
-			m.searching = false
-			m.searchTime = time.Since(m.searchStart)
-		}
+		return m, m.updateSearchResults(msg)
 
-		if len(m.results) > maxResults {
-			m.results = m.results[:maxResults]
-		}
+	case searchErrorMsg:
+		m.status.err = msg.err.Error()
+		m.searching = false
+		return m, nil
 
-		m.updateResultsView()
+	case searchTimeoutMsg:
+		m.stopSearch(msg)
+		return m, nil
 
-		if len(m.results) > 0 && m.previewPath == "" {
-			cmds = append(cmds, m.loadPreview())
-		}
+	case macroStepMsg:
+		return m.stepMacro(msg)
 
-		return m, tea.Batch(cmds...)
+	case tea.ResumeMsg:
+		m.resume()
+		return m, m.mouseMode()
+	}
 
-	case searchErrorMsg:
-		m.errorMessage = msg.err.Error()
-		m.searching = false
-		return m, nil
+	return m.updateInputs(msg)
+}
 
-	case editorFinishedMsg:
+// updateBackground handles the outcome of work done off the UI loop, such
+// as a preview or definition lookup, reporting whether msg was one
+func (m *Model) updateBackground(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case definitionMsg:
 		if msg.err != nil {
-			m.errorMessage = fmt.Sprintf("Editor error: %v", msg.err)
-		} else {
-			m.errorMessage = ""
+			m.status.err = fmt.Sprintf("Definition of %s: %v", msg.symbol, msg.err)
+			return nil, true
 		}
-		return m, nil
+		if msg.open {
+			return m.openFileInEditor(msg.tag.Path, msg.tag.Line), true
+		}
+		line := msg.tag.Line
+		if line == 0 {
+			line = 1
+		}
+		return m.loadPreviewAt(msg.tag.Path, line, nil, "definition of "+msg.symbol), true
 
-	case previewLoadedMsg:
-		if m.selectedIndex < len(m.results) && m.results[m.selectedIndex].Path == msg.path {
-			m.previewPath = msg.path
-			m.previewLines = msg.lines
-			m.previewStart = msg.startLine
-			m.previewMatch = msg.matchLine
-			m.previewSubmatches = msg.submatches
-			m.updatePreviewView()
+	case lspResultsMsg:
+		if msg.err != nil {
+			m.status.err = fmt.Sprintf("LSP %s of %s: %v", msg.kind, msg.symbol, msg.err)
+			return nil, true
 		}
-		return m, nil
+		m.showLSPResults(msg)
+		return m.loadPreview(), true
 
-	case pathsLoadedMsg:
-		m.allPaths = msg.paths
-		m.pathsLoaded = true
-		return m, nil
-	}
+	case replacePreviewMsg, replaceAppliedMsg:
+		return m.handleReplaceMsg(msg), true
 
-	var patternCmd, pathCmd, typesCmd tea.Cmd
-	m.patternInput, patternCmd = m.patternInput.Update(msg)
-	m.pathInput, pathCmd = m.pathInput.Update(msg)
-	m.typesInput, typesCmd = m.typesInput.Update(msg)
-	cmds = append(cmds, patternCmd, pathCmd, typesCmd)
-
-	currentPattern := m.patternInput.Value()
-	currentPath := m.pathInput.Value()
-	if currentPath == "" {
-		currentPath = "."
-	}
-	currentTypes := m.typesInput.Value()
-
-	if m.focused == focusTypes && msg != nil {
-		if _, ok := msg.(tea.KeyMsg); ok {
-			wasDropdownVisible := m.dropdownVisible
-			parts := strings.Split(currentTypes, ",")
-			lastPart := strings.TrimSpace(parts[len(parts)-1])
-			if lastPart != "" {
-				m.filteredTypes = nil
-				for _, t := range m.allTypes {
-					if strings.HasPrefix(t, lastPart) {
-						m.filteredTypes = append(m.filteredTypes, t)
-					}
-				}
-				m.dropdownVisible = len(m.filteredTypes) > 0
-				if m.dropdownIndex >= len(m.filteredTypes) {
-					m.dropdownIndex = 0
-				}
-			} else {
-				m.dropdownVisible = false
-			}
-			// Update viewport heights when dropdown visibility changes
-			if wasDropdownVisible != m.dropdownVisible {
-				viewportHeight := m.calculateViewportHeight()
-				m.resultsView.Height = viewportHeight
-				m.previewView.Height = viewportHeight
-				m.updateResultsView()
-			}
+	case diffHeadMsg:
+		return m.handleDiffMsg(msg), true
+
+	case diffFinishedMsg:
+		return tea.Batch(m.mouseMode(), m.handleDiffMsg(msg)), true
+
+	case themeSaveMsg:
+		return m.saveTheme(msg), true
+
+	case themeSavedMsg:
+		m.updateThemeSaved(msg)
+		return nil, true
+
+	case settingsSavedMsg:
+		if msg.err != nil {
+			m.status.err = msg.err.Error()
+			return nil, true
 		}
-	}
+		clear(m.settingsChanged)
+		m.status.err = ""
+		m.status.message = "Settings saved to " + msg.path
+		return nil, true
 
-	if m.focused == focusPath && msg != nil && m.pathsLoaded {
-		if _, ok := msg.(tea.KeyMsg); ok {
-			wasPathDropdownVisible := m.pathDropdownVisible
-			currentPath := m.pathInput.Value()
-			m.filteredPaths = m.pathProvider.FilterPaths(currentPath, m.allPaths)
-			m.pathDropdownVisible = len(m.filteredPaths) > 0
-			if m.pathDropdownIndex >= len(m.filteredPaths) {
-				m.pathDropdownIndex = 0
-			}
-			if wasPathDropdownVisible != m.pathDropdownVisible {
-				viewportHeight := m.calculateViewportHeight()
-				m.resultsView.Height = viewportHeight
-				m.previewView.Height = viewportHeight
-				m.updateResultsView()
-			}
+	case extractedMsg:
+		if msg.err != nil {
+			m.status.err = fmt.Sprintf("Editor error: %v", msg.err)
+			return nil, true
 		}
-	}
+		m.extracted = append(m.extracted, msg.path)
+		m.status.message = fmt.Sprintf("Opened a decoded copy of %s; changes won't be written back", displayPath(msg.source))
+		return m.launchEditor(msg.path, msg.line), true
 
-	newFileTypes := parseTypes(currentTypes)
-	typesChanged := false
-	if len(newFileTypes) != len(m.lastFileTypes) {
-		typesChanged = true
-	} else {
-		for i := range newFileTypes {
-			if newFileTypes[i] != m.lastFileTypes[i] {
-				typesChanged = true
-				break
-			}
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.resultStamps = nil
+			m.status.err = fmt.Sprintf("Editor error: %v", msg.err)
+			return m.mouseMode(), true
 		}
-	}
+		m.status.err = ""
+		cmd, _ := m.refreshChanged("")
+		return tea.Batch(m.mouseMode(), cmd), true
 
-	if currentPattern != m.lastPattern || currentPath != m.lastPath || typesChanged {
-		m.lastPattern = currentPattern
-		m.lastPath = currentPath
-		m.lastFileTypes = newFileTypes
-		m.fileTypes = newFileTypes
-		m.debounceToken++
-		token := m.debounceToken
+	case refreshedMsg:
+		return m.updateRefreshed(msg), true
 
-		if m.searchCancel != nil {
-			m.searchCancel()
-		}
+	case previewLoadedMsg:
+		m.updatePreviewLoaded(msg)
+		return m.lintPreviewed(), true
 
-		m.previewPath = ""
-		m.previewLines = nil
-		m.previewSubmatches = nil
-		m.updatePreviewView()
+	case lintedMsg:
+		m.updateLinted(msg)
+		return nil, true
 
-		cmds = append(cmds, tea.Tick(debounceDelay, func(t time.Time) tea.Msg {
-			return debounceMsg{token: token, pattern: currentPattern, path: currentPath}
-		}))
-	}
+	case comparedMsg:
+		return m.updateCompared(msg), true
 
-	return m, tea.Batch(cmds...)
-}
+	case rootCountedMsg:
+		return m.updateRootCounted(msg), true
 
-func (m *Model) openInEditor() tea.Cmd {
-	if m.selectedIndex >= len(m.results) {
-		return nil
+	case pathsLoadedMsg:
+		return m.updatePathsLoaded(msg), true
 	}
+	return nil, false
+}
 
-	match := m.results[m.selectedIndex]
+// updateKey handles a key press. An open overlay or prompt takes it first,
+// then the dropdowns, the results list and the bound actions; anything else
+// is typed into the focused input.
+func (m Model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.macroReplay != nil && !m.macroStep {
+		// Any key press stops a replay, and is otherwise ignored
+		m.stopMacro()
+		return m, nil
+	}
+	if m.macroPrompt {
+		return m.updateMacroPrompt(msg)
+	}
+	m.recordMacroKey(msg, m.keys.lookup(msg.String()))
+	keyAction := m.keys.lookup(msg.String())
+	if model, cmd, handled := m.updateOverlays(msg, keyAction); handled {
+		return model, cmd
+	}
+	if cmd, handled := m.updateDropdowns(keyAction); handled {
+		return m, cmd
+	}
+	if cmd, handled := m.updateHistory(keyAction); handled {
+		return m, cmd
+	}
+	if cmd, handled := m.updateResults(keyAction); handled {
+		return m, cmd
+	}
+	if cmd, handled := m.updateOptions(keyAction); handled {
+		return m, cmd
+	}
+	if cmd, handled := m.updateOpen(keyAction); handled {
+		return m, cmd
+	}
+	if cmd, handled := m.updateSelected(keyAction); handled {
+		return m, cmd
+	}
 
-	ed, err := editor.GetEditor()
-	if err != nil {
-		return func() tea.Msg {
-			return editorFinishedMsg{err: err}
+	switch keyAction {
+	case actionQuit:
+		now := time.Now()
+		if m.ctrlCPressed && now.Sub(m.lastCtrlCTime) < 2*time.Second {
+			return m, tea.Quit
 		}
-	}
+		m.ctrlCPressed = true
+		m.lastCtrlCTime = now
+		return m, nil
 
-	cmd := ed.BuildCommand(match.Path, match.LineNumber)
+	case actionNextInput:
+		m.inputs.focusNext()
+		m.typeDropdown.hide()
+		m.pathDropdown.hide()
+		return m, nil
 
-	return tea.ExecProcess(cmd, func(err error) tea.Msg {
-		return editorFinishedMsg{err: err}
-	})
-}
+	case actionContinueSearch:
+		return m, m.continueSearch()
 
-func (m *Model) loadPreview() tea.Cmd {
-	if m.selectedIndex >= len(m.results) {
-		return nil
-	}
+	case actionCopyCommand:
+		return m, m.copySearchCommand()
 
-	match := m.results[m.selectedIndex]
+	case actionRetryLiteral:
+		return m, m.retryLiteral()
 
-	return func() tea.Msg {
-		ctx, err := search.GetFileContextWithMatches(match.Path, match.LineNumber, previewContext, match.Submatches)
-		if err != nil {
-			return previewLoadedMsg{path: match.Path, lines: []string{"Error loading preview: " + err.Error()}, startLine: 1, matchLine: 1}
-		}
+	case actionRestorePaste:
+		return m, m.restorePaste()
 
-		return previewLoadedMsg{
-			path:       match.Path,
-			lines:      ctx.Lines,
-			startLine:  ctx.StartLine,
-			matchLine:  ctx.MatchLine,
-			submatches: ctx.Submatches,
-		}
-	}
-}
+	case actionSuspend:
+		return m, m.suspend()
 
-func (m *Model) executeSearch(pattern, path string) tea.Cmd {
-	// Cancel any existing search before starting a new one
-	if m.searchCancel != nil {
-		m.searchCancel()
-	}
+	case actionRecordMacro:
+		m.toggleMacroRecording()
+		return m, nil
 
-	m.results = m.results[:0]
-	m.selectedIndex = 0
-	m.matchCount = 0
-	m.searching = true
-	m.errorMessage = ""
-	m.searchStart = time.Now()
-	m.previewPath = ""
-	m.previewLines = nil
-	m.previewSubmatches = nil
+	case actionReplayMacro:
+		return m, m.startMacroPrompt()
 
-	m.searchCtx, m.searchCancel = context.WithCancel(context.Background())
+	case actionScrollLeft:
+		m.scrollHorizontal(-hscrollStep)
+		return m, nil
 
-	return func() tea.Msg {
-		results := make(chan search.Match, 100)
+	case actionScrollRight:
+		m.scrollHorizontal(hscrollStep)
+		return m, nil
 
-		err := m.searcher.Search(m.searchCtx, pattern, path, m.caseSensitivity, m.fileTypes, m.fileTypesNot, results)
-		if err != nil {
-			return searchErrorMsg{err: err}
+	case actionRefreshPaths:
+		if m.remote {
+			return m, nil
 		}
+		return m, m.refreshPaths()
 
-		// Batch results every 50ms to reduce UI redraws while maintaining responsiveness
-		var batch []search.Match
-		batchTicker := time.NewTicker(50 * time.Millisecond)
-		defer batchTicker.Stop()
-
-		for {
-			select {
-			case match, ok := <-results:
-				if !ok {
-					return searchResultMsg{matches: batch, done: true}
-				}
-				batch = append(batch, match)
-
-				if len(batch) >= 100 {
-					return searchResultMsg{matches: batch, done: false}
-				}
-
-			case <-batchTicker.C:
-				if len(batch) > 0 {
-					return searchResultMsg{matches: batch, done: false}
-				}
-
-			case <-m.searchCtx.Done():
-				return searchResultMsg{matches: batch, done: true}
-			}
+	case actionReplace:
+		return m, m.startReplace()
+
+	case actionDiffHead:
+		return m, m.startDiff()
+
+	case actionClose:
+		if m.inputs.focused == focusTypes {
+			m.inputs.types.SetValue("")
+			m.fileTypes = nil
+			return m, m.executeSearch(m.inputs.pattern.Value(), m.inputs.path.Value())
 		}
 	}
-}
 
-func (m *Model) SetFileTypes(types, typesNot []string) {
-	m.fileTypes = types
-	m.fileTypesNot = typesNot
-	if len(types) > 0 {
-		m.typesInput.SetValue(strings.Join(types, ","))
+	// Reset Ctrl+C state on any other key press
+	if keyAction != actionQuit {
+		m.ctrlCPressed = false
 	}
+	return m.updateInputs(msg)
 }
 
-func parseTypes(input string) []string {
-	if input == "" {
-		return nil
-	}
-	parts := strings.Split(input, ",")
-	var types []string
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			types = append(types, p)
+// updateOverlays hands a key press to the open overlay or prompt, if any,
+// reporting whether it took the key
+func (m *Model) updateOverlays(msg tea.KeyMsg, keyAction action) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case m.replaceState != replaceOff:
+		model, cmd := m.updateReplace(msg)
+		return model, cmd, true
+	case m.noteEditing:
+		model, cmd := m.updateNote(msg)
+		return model, cmd, true
+	case m.settingsVisible:
+		model, cmd := m.updateSettings(msg, keyAction)
+		return model, cmd, true
+	case m.explainVisible:
+		// Any key dismisses the overlay; y also copies the rg command
+		m.explainVisible = false
+		if msg.String() == "y" {
+			return *m, m.copySearchCommand(), true
 		}
+		return *m, nil, true
+	case m.narrowVisible:
+		model, cmd := m.updateNarrow(keyAction)
+		return model, cmd, true
+	case m.bookmarksVisible:
+		model, cmd := m.updateBookmarks(msg, keyAction)
+		return model, cmd, true
+	case m.profilesVisible:
+		model, cmd := m.updateProfiles(keyAction)
+		return model, cmd, true
+	case m.jobsVisible:
+		model, cmd := m.updateJobs(msg, keyAction)
+		return model, cmd, true
+	case m.preview.copy.active:
+		model, cmd := m.updateCopyMode(msg, keyAction)
+		return model, cmd, true
 	}
-	return types
-}
-
-// highlightMatches applies highlighting to matched text using submatch positions
-func highlightMatches(text string, submatches []search.Submatch, highlightStyle lipgloss.Style) string {
-	if len(submatches) == 0 {
-		return text
-	}
-
-	// Sort submatches by start position to handle overlaps correctly
-	sortedMatches := make([]search.Submatch, len(submatches))
-	copy(sortedMatches, submatches)
-
-	// Simple bubble sort since we typically have few submatches
-	for i := 0; i < len(sortedMatches); i++ {
-		for j := i + 1; j < len(sortedMatches); j++ {
-			if sortedMatches[i].Start > sortedMatches[j].Start {
-				sortedMatches[i], sortedMatches[j] = sortedMatches[j], sortedMatches[i]
-			}
+	if m.rootPrompt != nil && !m.rootPrompt.counting {
+		if cmd, handled := m.updateRootPrompt(msg); handled {
+			return *m, cmd, true
 		}
 	}
-
-	var sb strings.Builder
-	lastEnd := 0
-
-	for _, match := range sortedMatches {
-		// Handle bounds checking
-		start := match.Start
-		end := match.End
-		if start < 0 || end < 0 || start >= len(text) || end > len(text) || start >= end {
-			continue
+	if m.gonePath != "" {
+		if cmd, handled := m.updateGone(msg); handled {
+			return *m, cmd, true
 		}
-
-		// Add text before this match
-		if start > lastEnd {
-			sb.WriteString(text[lastEnd:start])
+	}
+	if m.treeVisible {
+		if model, cmd, handled := m.updateTree(msg, keyAction); handled {
+			return model, cmd, true
 		}
-
-		// Add highlighted match text
-		matchText := text[start:end]
-		sb.WriteString(highlightStyle.Render(matchText))
-
-		lastEnd = end
 	}
-
-	// Add remaining text after last match
-	if lastEnd < len(text) {
-		sb.WriteString(text[lastEnd:])
+	if m.summaryVisible {
+		if model, cmd, handled := m.updateSummary(msg, keyAction); handled {
+			return model, cmd, true
+		}
 	}
-
-	return sb.String()
+	return *m, nil, false
 }
 
-func (m *Model) updateResultsView() {
-	var sb strings.Builder
+// updateOptions toggles a search or display option, reporting whether
+// keyAction was one
+func (m *Model) updateOptions(keyAction action) (tea.Cmd, bool) {
+	switch keyAction {
+	case actionToggleCase:
+		switch m.caseSensitivity {
+		case search.CaseSmart:
+			m.caseSensitivity = search.CaseSensitive
+		case search.CaseSensitive:
+			m.caseSensitivity = search.CaseInsensitive
+		case search.CaseInsensitive:
+			m.caseSensitivity = search.CaseSmart
+		}
+		return tea.Batch(m.saveCaseMode(), m.searchAgain()), true
 
-	pathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
-	lineNumStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
-	selectedStyle := lipgloss.NewStyle().Background(lipgloss.Color("237")).Bold(true)
-	matchHighlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
-	selectedMatchHighlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
+	case actionHideClass:
+		return m.cycleClassFilter(), true
 
-	for i, match := range m.results {
-		lineText := strings.TrimRight(match.LineText, "\n\r")
-		maxTextLen := m.resultsView.Width - 20
-		if maxTextLen > 0 && len(lineText) > maxTextLen {
-			lineText = lineText[:maxTextLen-3] + "..."
-		}
+	case actionToggleGitTracked:
+		m.gitTracked = !m.gitTracked
+		return m.searchAgain(), true
 
-		var highlightedText string
-		if i == m.selectedIndex {
-			highlightedText = highlightMatches(lineText, match.Submatches, selectedMatchHighlightStyle)
-		} else {
-			highlightedText = highlightMatches(lineText, match.Submatches, matchHighlightStyle)
-		}
+	case actionToggleNoIgnore:
+		m.noIgnore = !m.noIgnore
+		return m.searchAgain(), true
 
-		line := fmt.Sprintf("%s:%s: %s",
-			pathStyle.Render(match.Path),
-			lineNumStyle.Render(fmt.Sprintf("%d", match.LineNumber)),
-			highlightedText)
+	case actionToggleMultiline:
+		m.multiline = !m.multiline
+		return m.searchAgain(), true
 
-		if i == m.selectedIndex {
-			line = selectedStyle.Render("> " + line)
-		} else {
-			line = "  " + line
+	case actionToggleWholeWord:
+		m.wholeWord = !m.wholeWord
+		return m.searchAgain(), true
+
+	case actionToggleContext:
+		m.inlineContext = !m.inlineContext
+		m.list.cache.invalidate()
+		if cmd := m.searchAgain(); cmd != nil {
+			return cmd, true
 		}
+		m.updateResultsView()
+		return nil, true
 
-		sb.WriteString(line)
-		sb.WriteString("\n")
-	}
+	case actionToggleFileInfo:
+		m.toggleFileInfo()
+		return nil, true
 
-	m.resultsView.SetContent(sb.String())
+	case actionSortRecent:
+		m.toggleRecentSort()
+		return nil, true
 
-	if m.selectedIndex >= 0 && len(m.results) > 0 {
-		targetLine := m.selectedIndex
-		centerOffset := targetLine - m.resultsView.Height/2
+	case actionToggleHighlight:
+		m.highlighter.SetEnabled(!m.highlighter.IsEnabled())
+		m.updatePreviewView()
+		return nil, true
 
-		// Calculate the maximum valid offset to prevent scrolling past content
-		// Content has len(m.results) lines, viewport shows Height lines
-		// Maximum offset is when the last line is at the bottom of the viewport
-		maxOffset := len(m.results) - m.resultsView.Height
+	case actionCycleTheme:
+		return m.cycleTheme(), true
 
-		// Clamp the offset to valid range [0, maxOffset]
-		// Similar to Telescope in Neovim: ensure last item is always visible
-		offset := centerOffset
-		if offset < 0 {
-			offset = 0
-		}
-		// If maxOffset <= 0, all content fits in viewport, so offset should be 0
-		// Otherwise, clamp to maxOffset to ensure we don't scroll past the end
-		if offset > maxOffset {
-			if maxOffset < 0 {
-				offset = 0
-			} else {
-				offset = maxOffset
-			}
-		}
+	case actionToggleMouse:
+		return m.toggleMouse(), true
 
-		m.resultsView.SetYOffset(offset)
+	case actionComparePrevious:
+		return m.toggleCompare(), true
 	}
+	return nil, false
 }
 
-func (m *Model) updatePreviewView() {
-	if len(m.previewLines) == 0 {
-		m.previewView.SetContent("No preview available")
-		return
+// updateOpen opens the overlay or mode keyAction names, reporting whether it
+// named one
+func (m *Model) updateOpen(keyAction action) (tea.Cmd, bool) {
+	switch keyAction {
+	case actionEditNote:
+		return m.startNote(), true
+	case actionExplainPattern:
+		m.explainVisible = true
+	case actionCopyMode:
+		m.startCopyMode()
+	case actionNarrow:
+		m.openNarrow()
+	case actionBookmarks:
+		m.openBookmarks()
+	case actionProfiles:
+		m.openProfiles()
+	case actionJobs:
+		m.jobsKey()
+	case actionToggleTree:
+		m.toggleTree()
+	case actionToggleSummary:
+		m.toggleSummary()
+	case actionSettings:
+		m.openSettings()
+	default:
+		return nil, false
 	}
+	return nil, true
+}
 
-	var sb strings.Builder
-	normalLineNumStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Width(4)
-	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-
-	matchLineNumStyle := lipgloss.NewStyle().Background(lipgloss.Color("226")).Foreground(lipgloss.Color("0")).Bold(true).Width(4)
-	matchTextHighlightStyle := lipgloss.NewStyle().Background(lipgloss.Color("226")).Foreground(lipgloss.Color("196")).Bold(true)
+// updateSelected runs keyAction on the results or the selected one,
+// reporting whether it acts on them
+func (m *Model) updateSelected(keyAction action) (tea.Cmd, bool) {
+	switch keyAction {
+	case actionExportQuickfix:
+		if m.results.Len() > 0 {
+			return m.exportResults(export.FormatQuickfix, m.quickfixFile), true
+		}
+		return nil, true
 
-	sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true).Render(m.previewPath))
-	sb.WriteString("\n")
-	sb.WriteString(separatorStyle.Render(strings.Repeat("─", m.previewView.Width-2)))
-	sb.WriteString("\n")
+	case actionExportJSON:
+		if m.results.Len() > 0 {
+			return m.exportResults(export.FormatJSON, export.JSONFile), true
+		}
+		return nil, true
 
-	for i, line := range m.previewLines {
-		lineNum := m.previewStart + i
+	case actionCopyPath:
+		if match, ok := m.selectedMatch(); ok {
+			return m.copyToClipboard(fmt.Sprintf("%s:%d", match.Path, match.LineNumber)), true
+		}
+		return nil, true
 
-		var processedLine string
-		if m.highlighter.IsEnabled() && m.highlighter.IsSupported(m.previewPath) {
-			processedLine = m.highlighter.Highlight(line, m.previewPath)
-		} else {
-			processedLine = line
+	case actionCopyLine:
+		if match, ok := m.selectedMatch(); ok {
+			return m.copyToClipboard(strings.TrimRight(match.LineText, "\n\r")), true
 		}
+		return nil, true
 
-		if lineNum == m.previewMatch {
-			styledLineNum := matchLineNumStyle.Render(fmt.Sprintf("%4d", lineNum))
-
-			var highlightedLine string
-			if m.highlighter.IsEnabled() && m.highlighter.IsSupported(m.previewPath) {
-				// For syntax-highlighted lines, just use a subtle background for the entire line
-				// instead of trying to highlight specific matches within colored text
-				highlightedLine = lipgloss.NewStyle().Background(lipgloss.Color("236")).Render(processedLine)
-			} else {
-				// For plain text, use the existing match highlighting
-				highlightedLine = highlightMatches(processedLine, m.previewSubmatches, matchTextHighlightStyle)
-			}
+	case actionPreviewDefinition, actionOpenDefinition:
+		return m.findDefinition(keyAction == actionOpenDefinition), true
 
-			sb.WriteString(styledLineNum + " " + highlightedLine)
-		} else {
-			normalLineNum := normalLineNumStyle.Render(fmt.Sprintf("%4d", lineNum))
-			sb.WriteString(normalLineNum + " " + processedLine)
+	case actionLSPReferences, actionLSPDefinition:
+		kind := "references"
+		if keyAction == actionLSPDefinition {
+			kind = "definition"
 		}
-		sb.WriteString("\n")
+		return m.queryLanguageServer(kind), true
 	}
-
-	m.previewView.SetContent(sb.String())
+	return nil, false
 }
 
-func (m *Model) SetCaseSensitivity(caseSensitivity search.CaseSensitivity) {
-	m.caseSensitivity = caseSensitivity
+// updateMouse scrolls the results with the wheel and selects preview lines
+// to copy by dragging
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if cmd, handled := m.handleCopyMouse(msg); handled {
+		return m, cmd
+	}
+	// Wheel events move the selection instead of letting the viewport
+	// scroll directly, so the scroll position stays synchronized with the
+	// selected item through updateResultsView()
+	if m.treeVisible && (msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown) {
+		delta := wheelStep
+		if msg.Button == tea.MouseButtonWheelUp {
+			delta = -wheelStep
+		}
+		return m, m.moveTree(m.treeRows(), delta)
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return m, m.moveSelection(-wheelStep)
+	case tea.MouseButtonWheelDown:
+		return m, m.moveSelection(wheelStep)
+	}
+	// For other mouse events (clicks, etc.), let viewport handle them
+	var cmd tea.Cmd
+	m.list.view, cmd = m.list.view.Update(msg)
+	return m, cmd
 }
 
-func (m *Model) getCaseSensitivityName() string {
-	switch m.caseSensitivity {
-	case search.CaseSmart:
-		return "Smart"
-	case search.CaseSensitive:
-		return "Sensitive"
-	case search.CaseInsensitive:
-		return "Insensitive"
-	default:
-		return "Smart"
+// updateInputs passes msg to the inputs, refreshes the suggestions of the
+// focused one and schedules a search when the query changed
+func (m Model) updateInputs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	key, isKey := msg.(tea.KeyMsg)
+	normalized := false
+	if isKey && key.Paste && m.inputs.focused == focusPattern {
+		msg, normalized = m.normalizePatternPaste(key)
 	}
-}
 
-func (m *Model) getSyntaxHighlightingStatus() string {
-	if m.highlighter.IsEnabled() {
-		return "On"
+	var cmd tea.Cmd
+	m.inputs, cmd = m.inputs.Update(msg)
+	cmds = append(cmds, cmd)
+	if normalized {
+		m.pasteNormalized = m.inputs.pattern.Value()
+	}
+	if isKey {
+		m.filterDropdowns()
 	}
-	return "Off"
-}
 
-func (m Model) View() string {
-	viewportHeight := m.calculateViewportHeight()
-	resultsStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		Width(m.width / 3).
-		Height(viewportHeight)
+	currentPattern := m.inputs.pattern.Value()
+	currentPath := m.searchPath()
+	newFileTypes := parseTypes(m.inputs.types.Value())
+	typesChanged := !slices.Equal(newFileTypes, m.lastFileTypes)
 
-	previewStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		Width(m.width - m.width/3 - 5).
-		Height(viewportHeight)
+	if currentPattern != m.lastPattern || currentPath != m.lastPath || typesChanged {
+		// The last results only hint at the new count when the pattern is
+		// all that changed
+		m.estimated = false
+		if currentPath == m.lastPath && !typesChanged {
+			m.estimate, m.estimated = m.estimateCount(currentPattern)
+		}
+		m.lastPattern = currentPattern
+		m.lastPath = currentPath
+		m.lastFileTypes = newFileTypes
+		m.fileTypes = newFileTypes
+		m.historyIndex = 0
+		m.debounceToken++
+		token := m.debounceToken
 
-	activeInputStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")).
-		Padding(0, 1)
+		m.releaseSearch()
+		if m.searchCancel != nil {
+			m.searchCancel()
+		}
 
-	inactiveInputStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		Padding(0, 1)
+		m.preview.clear()
+		m.updatePreviewView()
 
-	statusStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241"))
+		// A paste is a complete query, so it's searched without waiting
+		// for more typing
+		if isKey && key.Paste && m.inputs.focused == focusPattern {
+			cmds = append(cmds, m.executeSearch(currentPattern, currentPath))
+		} else {
+			cmds = append(cmds, tea.Tick(debounceDelay, func(t time.Time) tea.Msg {
+				return debounceMsg{token: token, pattern: currentPattern, path: currentPath}
+			}))
+		}
+	}
 
-	mainContent := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		resultsStyle.Render(m.resultsView.View()),
-		previewStyle.Render(m.previewView.View()),
+	return m, tea.Batch(cmds...)
+}
+
+// updatePathsLoaded adds a batch of the path index walk to the suggestions,
+// returning the command that waits for the next one
+func (m *Model) updatePathsLoaded(msg pathsLoadedMsg) tea.Cmd {
+	// The walk started by Init is adopted by its first batch; a refresh
+	// replaces it
+	if m.pathIndex == nil {
+		m.pathIndex = msg.index
+	}
+	if msg.index != m.pathIndex {
+		msg.index.cancel()
+		return nil
+	}
+	m.allPaths = append(m.allPaths, msg.paths...)
+	m.pathsLoaded = true
+	m.pathsIndexing = !msg.done
+	if m.pathDropdown.visible {
+		m.pathDropdown.setItems(m.pathProvider.FilterPaths(m.inputs.path.Value(), m.allPaths))
+	}
+	if msg.done {
+		msg.index.cancel()
+		if msg.index.refresh {
+			m.status.message = fmt.Sprintf("Indexed %d paths", len(m.allPaths))
+		}
+		return nil
+	}
+	return msg.index.next
+}
+
+func (m *Model) openInEditor() tea.Cmd {
+	if len(m.marked) > 0 {
+		return m.openMarkedInEditor()
+	}
+	match, ok := m.selectedMatch()
+	if !ok {
+		return nil
+	}
+	if m.remote {
+		m.status.err = "Remote results can't be opened in an editor; Ctrl+Y copies the path"
+		return nil
+	}
+	if m.checkGone(match.Path) {
+		return nil
+	}
+	return tea.Batch(
+		m.runHook(hooks.EventOpen, match),
+		m.openFileInEditor(match.Path, match.LineNumber),
 	)
+}
 
-	var patternBox, pathBox, typesBox string
-	if m.focused == focusPattern {
-		patternBox = activeInputStyle.Render(m.patternInput.View())
-		pathBox = inactiveInputStyle.Render(m.pathInput.View())
-		typesBox = inactiveInputStyle.Render(m.typesInput.View())
-	} else if m.focused == focusPath {
-		patternBox = inactiveInputStyle.Render(m.patternInput.View())
-		pathBox = activeInputStyle.Render(m.pathInput.View())
-		typesBox = inactiveInputStyle.Render(m.typesInput.View())
-	} else {
-		patternBox = inactiveInputStyle.Render(m.patternInput.View())
-		pathBox = inactiveInputStyle.Render(m.pathInput.View())
-		typesBox = activeInputStyle.Render(m.typesInput.View())
-	}
-
-	var status string
-	if m.searching {
-		status = "Searching..."
-	} else if m.errorMessage != "" {
-		status = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.errorMessage)
-	} else if m.matchCount > 0 {
-		pathInfo := m.lastPath
-		if pathInfo == "." {
-			pathInfo = "current directory"
+// openMarkedInEditor opens the files of the marked results in one editor
+// session, each at its first marked line
+func (m *Model) openMarkedInEditor() tea.Cmd {
+	if m.remote {
+		m.status.err = "Remote results can't be opened in an editor; Ctrl+Y copies the path"
+		return nil
+	}
+	targets, err := m.markedFiles()
+	if err != nil {
+		m.status.err = err.Error()
+		return nil
+	}
+	locations := make([]editor.Location, 0, len(targets))
+	for _, target := range targets {
+		if m.decoder.Decodes(target.path) {
+			m.status.err = fmt.Sprintf("%s is decoded for searching; open it on its own", displayPath(target.path))
+			return nil
+		}
+		if m.checkGone(target.path) {
+			return nil
 		}
-		typeInfo := ""
-		if len(m.fileTypes) > 0 {
-			typeInfo = fmt.Sprintf(" [📁 %s]", strings.Join(m.fileTypes, ","))
+		locations = append(locations, editor.Location{Path: target.path, Line: target.line})
+	}
+
+	ed, err := m.editor()
+	if err != nil {
+		return func() tea.Msg {
+			return editorFinishedMsg{err: err}
 		}
+	}
+	cmds := make([]tea.Cmd, 0, len(locations)+1)
+	for _, loc := range locations {
+		cmds = append(cmds, m.runHook(hooks.EventOpen, search.Match{Path: loc.Path, LineNumber: loc.Line}))
+	}
+	m.stampResults()
+	cmds = append(cmds, tea.ExecProcess(ed.BuildMultiCommand(locations), func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	}))
+	return tea.Batch(cmds...)
+}
 
-		statusParts := []string{fmt.Sprintf("%d matches in %s%s (%s)",
-			m.matchCount, pathInfo, typeInfo, m.searchTime.Round(time.Millisecond))}
+// openFileInEditor opens path at line in the editor. Files rg decoded are
+// extracted to a temporary copy first, so the line numbers match.
+func (m *Model) openFileInEditor(path string, line int) tea.Cmd {
+	if m.decoder.Decodes(path) {
+		decoder := m.decoder
+		return func() tea.Msg {
+			tmp, err := decoder.Extract(path)
+			return extractedMsg{source: path, path: tmp, line: line, err: err}
+		}
+	}
+	return m.launchEditor(path, line)
+}
 
-		if len(m.fileTypes) > 0 && m.lastPath != "" && m.lastPath != "." {
-			// Check if path looks like a specific file (has extension, not ending with /)
-			if strings.Contains(filepath.Base(m.lastPath), ".") && !strings.HasSuffix(m.lastPath, "/") {
-				// Path is likely a specific file - type filter may not apply
-				statusParts = append(statusParts, "ℹ️  Type filter overridden by file path")
-			}
+// editor returns the configured editor, or the one from the environment
+func (m *Model) editor() (*editor.Editor, error) {
+	if m.editorCommand != "" {
+		return editor.FromCommand(m.editorCommand)
+	}
+	return editor.GetEditor()
+}
+
+// launchEditor runs the editor on path at line
+func (m *Model) launchEditor(path string, line int) tea.Cmd {
+	ed, err := m.editor()
+	if err != nil {
+		return func() tea.Msg {
+			return editorFinishedMsg{err: err}
 		}
+	}
 
-		status = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(strings.Join(statusParts, " "))
-	} else if m.lastPattern != "" {
-		status = "No matches"
+	cmd := ed.BuildCommand(path, line)
+	m.stampResults()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
+
+// hookEnv describes match and the current search for a hook
+func (m *Model) hookEnv(match search.Match) hooks.Env {
+	return hooks.Env{
+		Pattern:    m.lastPattern,
+		SearchPath: m.lastPath,
+		Path:       match.Path,
+		Line:       match.LineNumber,
+		Text:       match.LineText,
+		MatchCount: m.results.Len(),
 	}
+}
 
-	inputRow := lipgloss.JoinHorizontal(lipgloss.Top, patternBox, " ", pathBox, " ", typesBox, "  ", statusStyle.Render(status))
+// runHook runs the hook for event in the background, discarding its output
+func (m *Model) runHook(event hooks.Event, match search.Match) tea.Cmd {
+	if !m.hooks.Has(event) {
+		return nil
+	}
+	runner := m.hooks
+	env := m.hookEnv(match)
+	return func() tea.Msg {
+		return hookFinishedMsg{err: runner.Run(event, env, nil)}
+	}
+}
 
-	var dropdown string
-	if m.dropdownVisible {
-		dropdownStyle := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
-			Background(lipgloss.Color("235")).
-			Padding(0, 1).
-			Width(m.typesInput.Width + 2)
-
-		var ds strings.Builder
-		start := 0
-		if m.dropdownIndex >= m.dropdownMaxHeight {
-			start = m.dropdownIndex - m.dropdownMaxHeight + 1
+// RunExitHook runs the on-exit-with-selection hook for the selected match,
+// writing its output to out. It does nothing when nothing is selected.
+func (m Model) RunExitHook(out io.Writer) error {
+	match, ok := m.selectedMatch()
+	if !ok || !m.hooks.Has(hooks.EventExitWithSelection) {
+		return nil
+	}
+	return m.hooks.Run(hooks.EventExitWithSelection, m.hookEnv(match), out)
+}
+
+// copyToClipboard copies text using the configured clipboard backend
+func (m *Model) copyToClipboard(text string) tea.Cmd {
+	cb := m.clipboard
+	return func() tea.Msg {
+		return clipboardMsg{text: text, err: cb.Copy(text)}
+	}
+}
+
+// truncateStatus shortens text for display in the status area
+func truncateStatus(text string) string {
+	const maxLen = 40
+	if len(text) > maxLen {
+		return text[:maxLen-3] + "..."
+	}
+	return text
+}
+
+// exportResults writes the marked results, or all of them when none are
+// marked, to path in the background
+func (m *Model) exportResults(format export.Format, path string) tea.Cmd {
+	set, err := m.PrintSet()
+	if err != nil {
+		return func() tea.Msg {
+			return exportFinishedMsg{path: path, err: err}
 		}
-		end := start + m.dropdownMaxHeight
-		if end > len(m.filteredTypes) {
-			end = len(m.filteredTypes)
+	}
+	marked := len(m.marked) > 0
+	return func() tea.Msg {
+		err := export.WriteFile(path, format, set)
+		return exportFinishedMsg{path: path, count: len(set.Matches), marked: marked, err: err}
+	}
+}
+
+// findDefinition looks up the symbol under the selected match's first
+// submatch in the tags file and reports it as a definitionMsg
+func (m *Model) findDefinition(open bool) tea.Cmd {
+	match, ok := m.selectedMatch()
+	if !ok {
+		return nil
+	}
+	symbol := symbolAtMatch(match)
+	if symbol == "" {
+		m.status.err = "No symbol under the selected match"
+		return nil
+	}
+
+	index := m.tags
+	return func() tea.Msg {
+		defs, err := index.Lookup(context.Background(), symbol)
+		if err != nil {
+			return definitionMsg{symbol: symbol, err: err}
 		}
+		return definitionMsg{symbol: symbol, tag: pickDefinition(defs, match), open: open}
+	}
+}
 
-		for i := start; i < end; i++ {
-			t := m.filteredTypes[i]
-			selected := i == m.dropdownIndex
-			isSelected := false
-			for _, ft := range m.fileTypes {
-				if ft == t {
-					isSelected = true
-					break
-				}
-			}
+// symbolAtMatch returns the identifier at the start of the match's first
+// submatch, or the submatch text itself if it isn't part of an identifier
+func symbolAtMatch(match search.Match) string {
+	if len(match.Submatches) == 0 {
+		return ""
+	}
+	sub := match.Submatches[0]
+	if symbol := tags.SymbolAt(match.LineText, sub.Start); symbol != "" {
+		return symbol
+	}
+	return strings.TrimSpace(sub.Match)
+}
 
-			prefix := "  "
-			if selected {
-				prefix = "> "
-			}
+// pickDefinition prefers a definition other than the match itself, so
+// jumping from a definition to its own line isn't a no-op when there are
+// several candidates
+func pickDefinition(defs []tags.Tag, match search.Match) tags.Tag {
+	for _, d := range defs {
+		if d.Path != match.Path || d.Line != match.LineNumber {
+			return d
+		}
+	}
+	return defs[0]
+}
 
-			suffix := ""
-			if isSelected {
-				suffix = " [✓]"
-			}
+// queryLanguageServer asks the language server for the references or
+// definition of the symbol under the selected match
+func (m *Model) queryLanguageServer(kind string) tea.Cmd {
+	if m.lsp == nil {
+		m.status.err = "LSP mode is off; start irg with --lsp"
+		return nil
+	}
+	match, ok := m.selectedMatch()
+	if !ok || len(match.Submatches) == 0 {
+		return nil
+	}
+
+	manager := m.lsp
+	cache := m.previewCache
+	symbol := symbolAtMatch(match)
+	column := match.Column()
+	lineText := strings.TrimRight(match.LineText, "\r\n")
+	m.status.message = fmt.Sprintf("Finding %s of %s...", kind, symbol)
 
-			line := prefix + t + suffix
-			if selected {
-				ds.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")).Render(line))
-			} else {
-				ds.WriteString(line)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), lspTimeout)
+		defer cancel()
+
+		if match.LineOffset != 0 {
+			// The result holds an excerpt of a long line, but the position
+			// is counted from the start of the whole line
+			fc, err := cache.GetFileContextWithMatches(match.Path, match.LineNumber, 0, nil)
+			if err != nil {
+				return lspResultsMsg{kind: kind, symbol: symbol, err: err}
+			}
+			if i := match.LineNumber - fc.StartLine; i >= 0 && i < len(fc.Lines) {
+				lineText = fc.Lines[i]
 			}
-			ds.WriteString("\n")
 		}
 
-		if len(m.filteredTypes) > m.dropdownMaxHeight {
-			ds.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(fmt.Sprintf("  [ %d/%d ]", m.dropdownIndex+1, len(m.filteredTypes))))
+		client, server, err := manager.ForFile(ctx, match.Path)
+		if err != nil {
+			return lspResultsMsg{kind: kind, symbol: symbol, server: server, err: err}
 		}
 
-		dropdown = dropdownStyle.Render(ds.String())
+		var locs []lsp.Location
+		if kind == "definition" {
+			locs, err = client.Definition(ctx, match.Path, match.LineNumber, column, lineText)
+		} else {
+			locs, err = client.References(ctx, match.Path, match.LineNumber, column, lineText)
+		}
+		if err != nil {
+			return lspResultsMsg{kind: kind, symbol: symbol, server: server, err: err}
+		}
+
+		matches := make([]search.Match, len(locs))
+		for i, loc := range locs {
+			matches[i] = search.Match{
+				Path:       loc.Path,
+				LineNumber: loc.Line,
+				LineText:   loc.Text,
+				Submatches: []search.Submatch{{
+					Match: loc.Text[loc.Column-1 : loc.EndColumn-1],
+					Start: loc.Column - 1,
+					End:   loc.EndColumn - 1,
+				}},
+			}
+		}
+		return lspResultsMsg{kind: kind, symbol: symbol, server: server, matches: matches}
 	}
+}
 
-	var helpText string
-	if len(m.results) > 0 {
-		helpText = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
-			"Keys: ↑/↓ or Ctrl+P/N (navigate) | Enter (open in editor) | Tab (switch input) | Ctrl+T (case: " + m.getCaseSensitivityName() + ") | Ctrl+H (syntax: " + m.getSyntaxHighlightingStatus() + ") | Ctrl+C twice (quit) | Tip: Specific file paths take precedence over type filters")
-	} else {
-		helpText = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
-			"Keys: Tab (switch input) | Ctrl+T (case: " + m.getCaseSensitivityName() + ") | Ctrl+H (syntax: " + m.getSyntaxHighlightingStatus() + ") | Ctrl+C twice (quit) | Tip: Specific file paths take precedence over type filters")
+// showLSPResults replaces the result list with locations from the language
+// server; typing a new pattern goes back to a normal search
+func (m *Model) showLSPResults(msg lspResultsMsg) {
+	m.releaseSearch()
+	if m.searchCancel != nil {
+		m.searchCancel()
 	}
-	if m.ctrlCPressed && time.Since(m.lastCtrlCTime) < 2*time.Second {
-		helpText = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
-			"Press Ctrl+C again to quit")
+	m.endCompare()
+	m.cancelCompare()
+	m.resultsDone = false
+	m.results.Reset()
+	m.summary.reset(".")
+	m.typeCounts.reset()
+	clear(m.marked)
+	clear(m.notes)
+	clear(m.fileInfos)
+	clear(m.lints)
+	m.recentOrder = nil
+	if err := m.results.Append(msg.matches...); err != nil {
+		m.status.err = err.Error()
 	}
-	var viewComponents []string
-	viewComponents = append(viewComponents, mainContent, inputRow)
-	if helpText != "" {
-		viewComponents = append(viewComponents, helpText)
+	m.refreshSummary()
+	m.list.cache.invalidate()
+	m.list.selected = 0
+	m.matchCount = m.results.Len()
+	m.searching = false
+	m.status.err = ""
+	m.status.message = fmt.Sprintf("%d %s of %s (%s)", len(msg.matches), msg.kind, msg.symbol, msg.server)
+	m.preview.clear()
+	m.updateResultsView()
+	m.updatePreviewView()
+}
+
+// searchAgain repeats the search for the typed pattern after a change to the
+// search settings; it does nothing while the pattern is empty
+func (m *Model) searchAgain() tea.Cmd {
+	pattern := m.inputs.pattern.Value()
+	if pattern == "" {
+		return nil
 	}
+	return m.executeSearch(pattern, m.inputs.path.Value())
+}
+
+// searchPath returns the path input, or the tree searched when it's empty:
+// the repository root when set, else the current directory
+func (m *Model) searchPath() string {
+	if path := m.inputs.path.Value(); path != "" {
+		return path
+	}
+	if m.repoRoot != "" {
+		return m.repoRoot
+	}
+	return "."
+}
 
-	view := lipgloss.JoinVertical(lipgloss.Left, viewComponents...)
+func (m *Model) executeSearch(pattern, path string) tea.Cmd {
+	if path == "" && m.repoRoot != "" {
+		path = m.repoRoot
+	}
+	if cmd, ok := m.guardSearch(pattern, path); !ok {
+		return cmd
+	}
+	m.rootPrompt = nil
 
-	if m.dropdownVisible {
-		// Append dropdown to help text area if it fits, or just render it below
-		// In a real TUI, we'd use relative positioning.
-		return lipgloss.JoinVertical(lipgloss.Left, view, dropdown)
+	// Cancel any existing search before starting a new one, letting go of
+	// one paused by its timeout first so the new one isn't started paused
+	m.releaseSearch()
+	if m.searchCancel != nil {
+		m.searchCancel()
 	}
 
-	if m.pathDropdownVisible {
-		dropdownStyle := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
-			Background(lipgloss.Color("235")).
-			Padding(0, 1).
-			Width(m.pathInput.Width + 2)
-
-		var ds strings.Builder
-		start := 0
-		if m.pathDropdownIndex >= pathDropdownMaxHeight {
-			start = m.pathDropdownIndex - pathDropdownMaxHeight + 1
+	m.endCompare()
+	m.rotateResults(pattern)
+	m.results.Reset()
+	path = m.caseFold.start(path, m.remote)
+	m.resultsPath = path
+	m.resultStamps = nil
+	m.summary.reset(path)
+	m.typeCounts.reset()
+	m.list.cache.invalidate()
+	clear(m.marked)
+	clear(m.notes)
+	clear(m.fileInfos)
+	clear(m.lints)
+	m.recentOrder = nil
+	m.list.xOffset = 0
+	m.list.selected = 0
+	m.matchCount = 0
+	m.estimated = false
+	m.searching = true
+	m.partial = false
+	m.status.clear()
+	m.gonePath = ""
+	m.searchStart = time.Now()
+	m.preview.clear()
+
+	m.searchCtx, m.searchCancel = context.WithCancel(context.Background())
+	if pattern != m.literalPattern {
+		m.literalPattern = ""
+	}
+	opts := m.searchOptions()
+	opts.FixedStrings = pattern != "" && pattern == m.literalPattern
+	m.shardProgress = nil
+	if opts.Shards > 0 {
+		m.shardProgress = &search.ShardProgress{}
+		opts.Progress = m.shardProgress
+	}
+
+	ctx := m.searchCtx
+	searcher := m.searcher
+	batching := m.batching
+	run := func() tea.Msg {
+		results := make(chan search.Match, 100)
+
+		err := searcher.Search(ctx, pattern, path, opts, results)
+		if err != nil {
+			return searchErrorMsg{err: err}
 		}
-		end := start + pathDropdownMaxHeight
-		if end > len(m.filteredPaths) {
-			end = len(m.filteredPaths)
+		return readResultBatch(ctx, results, opts.Progress, batching)
+	}
+	if pattern == "" {
+		return run
+	}
+	return tea.Batch(run, m.searchDeadline(ctx))
+}
+
+// updateSearchResults adds a batch of the running search to the results,
+// returning the command that reads the next one
+func (m *Model) updateSearchResults(msg searchResultMsg) tea.Cmd {
+	if cmd, ok := m.updateJobResults(msg); ok {
+		return cmd
+	}
+	// Batches still in flight from a replaced search
+	if msg.ctx != nil && msg.ctx != m.searchCtx {
+		return nil
+	}
+	if err := m.results.Append(m.visibleMatches(m.caseFold.dedupe(msg.matches))...); err != nil {
+		m.status.err = err.Error()
+	}
+	m.matchCount = m.results.Len()
+
+	var cmds []tea.Cmd
+	if msg.done {
+		m.searching = false
+		m.searchTime = time.Since(m.searchStart)
+		m.metrics.Observe("search", m.searchTime)
+		// Cancelled searches also report done; only finished ones run the hook
+		// and can be compared against later
+		if m.searchCtx != nil && m.searchCtx.Err() == nil {
+			m.resultsDone = true
+			cmds = append(cmds, m.runHook(hooks.EventSearchComplete, search.Match{}), m.countSearch(), m.recordHistory())
 		}
+	} else if msg.next != nil {
+		cmds = append(cmds, msg.next)
+	}
 
-		for i := start; i < end; i++ {
-			p := m.filteredPaths[i]
-			selected := i == m.pathDropdownIndex
-			icon := "📄"
-			if p.IsDir {
-				icon = "📁"
+	if m.results.Len() > maxResults {
+		if err := m.results.Truncate(maxResults); err != nil {
+			m.status.err = err.Error()
+		}
+	}
+	if msg.done && m.resultsDone && m.sortRecent && !m.remote {
+		m.sortByRecency()
+	}
+	if msg.done && m.resultsDone && m.results.Len() >= maxResults && !m.remote {
+		m.status.message = fmt.Sprintf("Showing the first %d results; Alt+W suggests directories and types to exclude", maxResults)
+	}
+
+	m.updateResultsView()
+	m.refreshSummary()
+	m.refreshTypeCounts()
+
+	if m.results.Len() > 0 && m.preview.path == "" {
+		cmds = append(cmds, m.loadPreview())
+	}
+	return tea.Batch(cmds...)
+}
+
+// readResultBatch collects the next batch of a running search. Batches are
+// flushed every so many matches or milliseconds, as batching says, to reduce
+// UI redraws while maintaining responsiveness; until the search is done the
+// message carries the command that reads the following batch. A sharded
+// search also flushes when another shard finishes, so its progress shows
+// even without new matches.
+func readResultBatch(ctx context.Context, results <-chan search.Match, progress *search.ShardProgress, batching *resultBatching) searchResultMsg {
+	var flushed time.Time
+	next := func() tea.Msg {
+		batching.observe(time.Since(flushed))
+		return readResultBatch(ctx, results, progress, batching)
+	}
+	reported, _ := progress.Counts()
+	size, interval := batching.limits()
+
+	var batch []search.Match
+	batchTicker := time.NewTicker(interval)
+	defer batchTicker.Stop()
+
+	for {
+		select {
+		case match, ok := <-results:
+			if !ok {
+				return searchResultMsg{matches: batch, done: true, ctx: ctx}
 			}
+			batch = append(batch, match)
 
-			prefix := "  "
-			if selected {
-				prefix = "> "
+			if len(batch) >= size {
+				flushed = time.Now()
+				return searchResultMsg{matches: batch, ctx: ctx, next: next}
 			}
 
-			line := prefix + icon + " " + p.Path
-			if selected {
-				ds.WriteString(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")).Render(line))
-			} else {
-				ds.WriteString(line)
+		case <-batchTicker.C:
+			if done, _ := progress.Counts(); len(batch) > 0 || done != reported {
+				flushed = time.Now()
+				return searchResultMsg{matches: batch, ctx: ctx, next: next}
 			}
-			ds.WriteString("\n")
+
+		case <-ctx.Done():
+			return searchResultMsg{matches: batch, done: true, ctx: ctx}
 		}
+	}
+}
+
+// searchOptions collects the current search settings
+func (m *Model) searchOptions() search.Options {
+	var spawned func()
+	if collector := m.metrics; collector != nil {
+		spawned = func() { collector.Inc("rg.spawn") }
+	}
+	return search.Options{
+		CaseSensitivity: m.caseSensitivity,
+		FileTypes:       m.fileTypes,
+		FileTypesNot:    append(slices.Clone(m.fileTypesNot), m.excludeTypes...),
+		GitTracked:      m.gitTracked,
+		NoIgnore:        m.noIgnore,
+		Multiline:       m.multiline,
+		WholeWord:       m.wholeWord,
+		Normalize:       m.normalize,
+		MaxDepth:        m.maxDepth,
+		Shards:          m.shards,
+		Sorted:          m.stableOrder,
+		Roots:           m.roots,
+		Context:         m.searchContextLines(),
+		CustomTypes:     m.customTypes,
+		Decoder:         m.decoder,
+		MaxLineLength:   m.maxLineLength,
+		ExcludeDirs:     m.excludeDirs,
+		ExcludeGlobs:    m.profileExcludes,
+		Spawned:         spawned,
+	}
+}
+
+// searchContextLines returns the context rg should collect for the list
+func (m *Model) searchContextLines() int {
+	if m.resultRows() == 1 {
+		return 0
+	}
+	return inlineContextLines
+}
+
+// SetCustomTypes defines extra file types, offered in the types dropdown
+// next to ripgrep's built-in ones
+func (m *Model) SetCustomTypes(types map[string][]string) {
+	if len(types) == 0 {
+		return
+	}
+	m.customTypes = types
+	if globs, err := search.LoadTypeGlobs(types); err == nil {
+		m.typeGlobs = globs
+		m.allTypes = sortedKeys(globs)
+	}
+}
+
+// SetInlineContext shows a line of context above and below each result
+func (m *Model) SetInlineContext(enabled bool) {
+	m.inlineContext = enabled
+}
+
+// SetShards splits searches across the top-level entries of the search path,
+// running up to n rg processes at once
+func (m *Model) SetShards(n int) {
+	m.shards = n
+}
+
+// SetMaxLineLength cuts result lines longer than n bytes down to an excerpt
+// around their match; 0 keeps the default
+func (m *Model) SetMaxLineLength(n int) {
+	if n <= 0 {
+		n = search.DefaultMaxLineLength
+	}
+	m.maxLineLength = n
+}
+
+// SetFileInfo shows the age and size of each result's file
+func (m *Model) SetFileInfo(enabled bool) {
+	m.showFileInfo = enabled
+}
+
+// SetSortRecent lists the results of the most recently modified files first
+// once a search finishes
+func (m *Model) SetSortRecent(enabled bool) {
+	m.sortRecent = enabled
+}
+
+// SetStableOrder returns results in the same order on every run of a search,
+// at the cost of ripgrep's parallelism
+func (m *Model) SetStableOrder(enabled bool) {
+	m.stableOrder = enabled
+}
 
-		if len(m.filteredPaths) > pathDropdownMaxHeight {
-			ds.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(fmt.Sprintf("  [ %d/%d ]", m.pathDropdownIndex+1, len(m.filteredPaths))))
+// SetRoots limits searches to the given files and directories; the path
+// input then narrows the list instead of naming the tree to search
+func (m *Model) SetRoots(roots []string) {
+	m.roots = roots
+}
+
+// SetRepoRoot searches root, the top of the git repository around the
+// working directory given relative to it, when the path input is empty
+func (m *Model) SetRepoRoot(root string) {
+	m.repoRoot = root
+	if root != "" {
+		m.inputs.path.Placeholder = "Path (repo root: " + displayPath(root) + ")"
+	}
+}
+
+// SetClassifier sets the classes results are dimmed, tagged and filtered by
+func (m *Model) SetClassifier(c *classify.Classifier) {
+	m.classifier = c
+}
+
+// SetDecoder searches compressed files or runs a preprocessor on files as
+// rg's --search-zip and --pre do, decoding them the same way for the preview
+// and the editor
+func (m *Model) SetDecoder(d search.Decoder) {
+	m.decoder = d
+	m.previewCache.SetDecoder(d)
+}
+
+// SetGitTracked restricts searches to files tracked by git
+func (m *Model) SetGitTracked(enabled bool) {
+	m.gitTracked = enabled
+}
+
+// SetNoIgnore searches files that .gitignore and similar files exclude
+func (m *Model) SetNoIgnore(enabled bool) {
+	m.noIgnore = enabled
+}
+
+// SetMultiline lets patterns match across line endings
+func (m *Model) SetMultiline(enabled bool) {
+	m.multiline = enabled
+}
+
+// SetWholeWord matches patterns only where they form whole words
+func (m *Model) SetWholeWord(enabled bool) {
+	m.wholeWord = enabled
+}
+
+// SetNormalize matches patterns against text in either Unicode normalization
+// form and shows result lines composed (NFC), so matches on decomposed
+// characters highlight whole
+func (m *Model) SetNormalize(enabled bool) {
+	m.normalize = enabled
+}
+
+// SetMaxDepth stops searches n directory levels below the search path; 0
+// searches at any depth
+func (m *Model) SetMaxDepth(n int) {
+	m.maxDepth = max(n, 0)
+}
+
+func (m *Model) SetFileTypes(types, typesNot []string) {
+	m.fileTypes = types
+	m.fileTypesNot = typesNot
+	if len(types) > 0 {
+		m.inputs.types.SetValue(strings.Join(types, ","))
+	}
+}
+
+func parseTypes(input string) []string {
+	if input == "" {
+		return nil
+	}
+	parts := strings.Split(input, ",")
+	var types []string
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			types = append(types, p)
+		}
+	}
+	return types
+}
+
+// ExportSet returns every result for export
+func (m Model) ExportSet() (export.Set, error) {
+	matches, err := m.results.Slice(0, m.results.Len())
+	if err != nil {
+		return export.Set{}, err
+	}
+	set := export.Set{Pattern: m.lastPattern, Matches: matches}
+	if len(m.notes) > 0 {
+		set.Notes = maps.Clone(m.notes)
+	}
+	return set, nil
+}
+
+// PrintSet returns the results to print or export: the marked ones when any
+// are marked, else all of them
+func (m Model) PrintSet() (export.Set, error) {
+	if len(m.marked) == 0 {
+		return m.ExportSet()
+	}
+	indices := slices.Sorted(maps.Keys(m.marked))
+	set := export.Set{Pattern: m.lastPattern, Notes: map[int]string{}}
+	for _, i := range indices {
+		match, err := m.results.Get(i)
+		if err != nil {
+			return export.Set{}, err
+		}
+		if note := m.notes[i]; note != "" {
+			set.Notes[len(set.Matches)] = note
 		}
+		set.Matches = append(set.Matches, match)
+	}
+	return set, nil
+}
+
+// Close releases resources held by the model, such as spilled result
+// files, and counts the last search in the usage stats
+func (m Model) Close() error {
+	if m.searchCancel != nil {
+		m.searchCancel()
+	}
+	// Canceling the context leaves killing rg to a goroutine irg may not
+	// outlive, so kill it here
+	m.searcher.Cancel()
+	for _, j := range m.jobs {
+		j.cancel()
+		j.searcher.Cancel()
+		j.results.Close()
+	}
+	if m.pathIndex != nil {
+		m.pathIndex.cancel()
+	}
+	m.lsp.Close()
+	if m.compare != nil {
+		m.compare.saved.Close()
+	}
+	if m.previous != nil {
+		m.previous.Close()
+	}
+	for _, path := range m.extracted {
+		os.Remove(path)
+	}
+	return errors.Join(m.flushStats(), m.flushHistory(), m.results.Close())
+}
+
+// Bind applies fzf-style key bindings such as "ctrl-o:open-editor,ctrl-q:ignore"
+func (m *Model) Bind(spec string) error {
+	return m.keys.bind(spec)
+}
+
+// SetClipboard overrides the detected clipboard backend
+func (m *Model) SetClipboard(cb clipboard.Backend) {
+	m.clipboard = cb
+}
+
+// UseSourcegraph sends searches to a Sourcegraph instance instead of ripgrep
+func (m *Model) UseSourcegraph(s *search.SourcegraphSearcher) {
+	m.searcher = s
+	m.remote = true
+	m.inputs.path.Placeholder = "Repo (e.g., github.com/org/.*)"
+}
+
+// SetSelectMode makes Enter accept the selected match and quit instead of
+// opening an editor
+func (m *Model) SetSelectMode(enabled bool) {
+	m.selectMode = enabled
+}
+
+// Accepted returns the match accepted with Enter in select mode
+func (m Model) Accepted() (search.Match, bool) {
+	if !m.accepted {
+		return search.Match{}, false
+	}
+	return m.selectedMatch()
+}
 
-		return lipgloss.JoinVertical(lipgloss.Left, view, dropdownStyle.Render(ds.String()))
+// SetHooks sets the lifecycle hooks to run
+func (m *Model) SetHooks(r *hooks.Runner) {
+	m.hooks = r
+}
+
+// SetPathIndex tunes the depth and skip patterns of the path suggestion
+// index; zero values keep the defaults
+func (m *Model) SetPathIndex(maxDepth int, skip []string) {
+	m.pathProvider.Configure(maxDepth, skip)
+}
+
+// SetLSP turns on the experimental language server mode
+func (m *Model) SetLSP(enabled bool) {
+	if enabled && m.lsp == nil {
+		m.lsp = lsp.NewManager(".")
 	}
+}
 
-	return view
+// SetQuickfixFile makes the export-quickfix action write to path instead of
+// errors.err
+func (m *Model) SetQuickfixFile(path string) {
+	if path != "" {
+		m.quickfixFile = path
+	}
+}
+
+// SetMetrics enables local performance metrics collection
+func (m *Model) SetMetrics(c *metrics.Collector) {
+	m.metrics = c
+}
+
+func (m *Model) SetCaseSensitivity(caseSensitivity search.CaseSensitivity) {
+	m.caseSensitivity = caseSensitivity
+}
+
+// SetState persists session state, such as case mode changes, for the
+// project rooted at project
+func (m *Model) SetState(store *state.Store, project string) {
+	m.state = store
+	m.project = project
+}
+
+// saveCaseMode records the current case mode as the project's choice
+func (m *Model) saveCaseMode() tea.Cmd {
+	if m.state == nil {
+		return nil
+	}
+	store, project, mode := m.state, m.project, m.caseSensitivity.String()
+	return func() tea.Msg {
+		return stateSavedMsg{err: store.UpdateProject(project, func(p *state.Project) {
+			p.CaseMode = mode
+		})}
+	}
+}
+
+// caseNote explains how smart case applied to the last pattern, since rg
+// silently switches to a case-sensitive search when it contains uppercase
+func (m *Model) caseNote() string {
+	if m.caseSensitivity != search.CaseSmart || m.remote || m.lastPattern == "" {
+		return ""
+	}
+	if search.SmartCaseSensitive(m.lastPattern) {
+		return "[smart case: sensitive]"
+	}
+	return ""
+}
+
+func (m *Model) getCaseSensitivityName() string {
+	switch m.caseSensitivity {
+	case search.CaseSmart:
+		return "Smart"
+	case search.CaseSensitive:
+		return "Sensitive"
+	case search.CaseInsensitive:
+		return "Insensitive"
+	default:
+		return "Smart"
+	}
+}
+
+func (m *Model) getSyntaxHighlightingStatus() string {
+	if m.highlighter.IsEnabled() {
+		return "On"
+	}
+	return "Off"
+}
+
+func (m Model) View() string {
+	defer m.metrics.Since("render.view", time.Now())
+
+	viewportHeight := m.calculateViewportHeight()
+	resultsStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Width(m.list.view.Width).
+		Height(viewportHeight)
+
+	previewStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Width(m.preview.view.Width).
+		Height(viewportHeight)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241"))
+
+	results := m.list.View()
+	if m.treeVisible {
+		results = m.renderTree(m.list.view.Width, viewportHeight)
+	}
+	panes := []string{
+		resultsStyle.Render(results),
+		previewStyle.Render(m.preview.View()),
+	}
+	if m.summaryVisible {
+		summaryStyle := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62")).
+			Width(m.summaryWidth()).
+			Height(viewportHeight)
+		panes = append([]string{summaryStyle.Render(m.renderSummary(m.summaryWidth(), viewportHeight))}, panes...)
+	}
+	mainContent := lipgloss.JoinHorizontal(lipgloss.Top, panes...)
+	inputRow := m.inputs.View(statusStyle.Render(m.statusLine()))
+	view := lipgloss.JoinVertical(lipgloss.Left, mainContent, inputRow, m.helpLine())
+
+	if m.settingsVisible {
+		return overlay(view, m.renderSettings(m.width-4, lipgloss.Height(mainContent)-1), 2, 1)
+	}
+	if m.narrowVisible {
+		return overlay(view, m.renderNarrow(m.width-4, lipgloss.Height(mainContent)-1), 2, 1)
+	}
+	if m.bookmarksVisible {
+		return overlay(view, m.renderBookmarks(m.width-4, lipgloss.Height(mainContent)-1), 2, 1)
+	}
+	if m.profilesVisible {
+		return overlay(view, m.renderProfiles(m.width-4, lipgloss.Height(mainContent)-1), 2, 1)
+	}
+	if m.jobsVisible {
+		return overlay(view, m.renderJobs(m.width-4, lipgloss.Height(mainContent)-1), 2, 1)
+	}
+	if m.explainVisible {
+		return overlay(view, m.renderExplain(m.width-4, lipgloss.Height(mainContent)-1), 2, 1)
+	}
+	return m.overlayDropdowns(view, lipgloss.Height(mainContent))
 }
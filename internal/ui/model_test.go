@@ -0,0 +1,684 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/clipboard"
+	"github.com/William9923/irg/internal/search"
+	"github.com/William9923/irg/internal/state"
+)
+
+func testMatches(start, n int) []search.Match {
+	matches := make([]search.Match, n)
+	for i := range matches {
+		matches[i] = search.Match{
+			Path:       fmt.Sprintf("file%d.go", start+i),
+			LineNumber: start + i + 1,
+			LineText:   fmt.Sprintf("match number %d", start+i),
+			Submatches: []search.Submatch{{Match: "match", Start: 0, End: 5}},
+		}
+	}
+	return matches
+}
+
+func newTestModel(t *testing.T) Model {
+	t.Helper()
+	m := NewModel()
+	t.Cleanup(func() { m.Close() })
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	return updated.(Model)
+}
+
+func TestUpdateResultsView_IncrementalMatchesFullRender(t *testing.T) {
+	m := newTestModel(t)
+
+	for i := 0; i < 5; i++ {
+		updated, _ := m.Update(searchResultMsg{matches: testMatches(i*10, 10)})
+		m = updated.(Model)
+	}
+	for i := 0; i < 7; i++ {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = updated.(Model)
+	}
+	incremental := m.list.View()
+
+	m.list.cache.invalidate()
+	m.updateResultsView()
+	full := m.list.View()
+
+	if incremental != full {
+		t.Errorf("incremental render differs from full render\nincremental:\n%s\nfull:\n%s", incremental, full)
+	}
+}
+
+func TestUpdateResultsView_OffscreenBatchKeepsCache(t *testing.T) {
+	m := newTestModel(t)
+
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 100)})
+	m = updated.(Model)
+	before := m.list.View()
+	cached := len(m.list.cache.lines)
+
+	updated, _ = m.Update(searchResultMsg{matches: testMatches(100, 100)})
+	m = updated.(Model)
+
+	if m.list.View() != before {
+		t.Error("appending results below the visible window changed the view")
+	}
+	if len(m.list.cache.lines) != cached {
+		t.Errorf("cached lines = %d, want %d", len(m.list.cache.lines), cached)
+	}
+}
+
+func TestPreviewLoaded_DropsStaleResponses(t *testing.T) {
+	m := newTestModel(t)
+
+	// Two results in the same file so the path check alone can't tell them apart
+	matches := testMatches(0, 2)
+	matches[1].Path = matches[0].Path
+	updated, _ := m.Update(searchResultMsg{matches: matches})
+	m = updated.(Model)
+
+	m.loadPreview()
+	stale := m.preview.token
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+
+	updated, _ = m.Update(previewLoadedMsg{token: stale, path: matches[0].Path, lines: []string{"stale"}, matchLine: 1})
+	m = updated.(Model)
+	if len(m.preview.lines) == 1 && m.preview.lines[0] == "stale" {
+		t.Error("stale preview response was applied")
+	}
+
+	updated, _ = m.Update(previewLoadedMsg{token: m.preview.token, path: matches[1].Path, lines: []string{"fresh"}, matchLine: 2})
+	m = updated.(Model)
+	if len(m.preview.lines) != 1 || m.preview.lines[0] != "fresh" {
+		t.Errorf("current preview response was not applied: %v", m.preview.lines)
+	}
+}
+
+func TestCopyPath_UsesClipboardBackend(t *testing.T) {
+	m := newTestModel(t)
+	cb := &clipboard.Mock{}
+	m.SetClipboard(cb)
+
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 3)})
+	m = updated.(Model)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if cmd == nil {
+		t.Fatal("copy-path returned no command")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+
+	if got := cb.Last(); got != "file0.go:1" {
+		t.Errorf("copied %q, want file0.go:1", got)
+	}
+	if m.status.message == "" {
+		t.Error("expected a status message after copying")
+	}
+}
+
+func TestLSPResults_ReplaceResultList(t *testing.T) {
+	m := newTestModel(t)
+
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 20), done: true})
+	m = updated.(Model)
+	m.list.selected = 5
+
+	refs := testMatches(100, 3)
+	updated, cmd := m.Update(lspResultsMsg{kind: "references", symbol: "match", server: "gopls", matches: refs})
+	m = updated.(Model)
+
+	if m.results.Len() != 3 || m.matchCount != 3 {
+		t.Fatalf("results = %d (count %d), want 3", m.results.Len(), m.matchCount)
+	}
+	if m.list.selected != 0 {
+		t.Errorf("selectedIndex = %d, want 0", m.list.selected)
+	}
+	if got, _ := m.selectedMatch(); got.Path != refs[0].Path {
+		t.Errorf("selected %s, want %s", got.Path, refs[0].Path)
+	}
+	if cmd == nil {
+		t.Error("expected a preview load for the first reference")
+	}
+}
+
+func TestReplace_TargetsMarkedFilesAndConfirms(t *testing.T) {
+	m := newTestModel(t)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 5), done: true})
+	m = updated.(Model)
+
+	// Mark results 1 and 3; marking advances the selection
+	m.list.selected = 1
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlAt})
+	m = updated.(Model)
+	m.list.selected = 3
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlAt})
+	m = updated.(Model)
+	if m.list.selected != 4 {
+		t.Errorf("selectedIndex = %d, want 4 after marking", m.list.selected)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = updated.(Model)
+	if m.replaceState != replacePrompt {
+		t.Fatalf("replaceState = %v, want prompt", m.replaceState)
+	}
+	if len(m.replaceFiles) != 2 || m.replaceFiles[0] != "file1.go" || m.replaceFiles[1] != "file3.go" {
+		t.Errorf("replaceFiles = %v, want [file1.go file3.go]", m.replaceFiles)
+	}
+
+	// Skip running the tool and feed a preview directly
+	m.replaceState = replacePreviewing
+	updated, _ = m.Update(replacePreviewMsg{diff: "--- file1.go\n+++ file1.go\n@@ -1,1 +1,1 @@\n-a\n+b\n"})
+	m = updated.(Model)
+	if m.replaceState != replaceConfirm || len(m.replaceDiff) != 5 {
+		t.Fatalf("replaceState = %v, diff = %v", m.replaceState, m.replaceDiff)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updated.(Model)
+	if m.replaceState != replaceOff {
+		t.Errorf("replaceState = %v, want off after declining", m.replaceState)
+	}
+	if m.inputs.pattern.Value() != "" {
+		t.Errorf("declining typed into the pattern: %q", m.inputs.pattern.Value())
+	}
+}
+
+func TestNote_MarksResultAndExports(t *testing.T) {
+	m := newTestModel(t)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 3), done: true})
+	m = updated.(Model)
+	m.list.selected = 1
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}, Alt: true})
+	m = updated.(Model)
+	if !m.noteEditing {
+		t.Fatal("Alt+N didn't open the note prompt")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("fix after lunch")})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+
+	if m.noteEditing {
+		t.Error("Enter didn't close the note prompt")
+	}
+	if m.inputs.pattern.Value() != "" {
+		t.Errorf("note typed into the pattern: %q", m.inputs.pattern.Value())
+	}
+	if !m.marked[1] {
+		t.Error("noted result isn't marked")
+	}
+	set, err := m.ExportSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Notes[1] != "fix after lunch" || len(set.Notes) != 1 {
+		t.Errorf("Notes = %v, want {1: fix after lunch}", set.Notes)
+	}
+
+	// Unmarking drops the note
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlAt})
+	m = updated.(Model)
+	if set, _ := m.ExportSet(); len(set.Notes) != 0 {
+		t.Errorf("Notes = %v after unmarking, want none", set.Notes)
+	}
+}
+
+func TestSelectMode_EnterAcceptsAndQuits(t *testing.T) {
+	m := newTestModel(t)
+	m.SetSelectMode(true)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 3), done: true})
+	m = updated.(Model)
+	m.list.selected = 2
+
+	if _, ok := m.Accepted(); ok {
+		t.Fatal("match accepted before Enter")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("expected a quit command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Enter in select mode didn't quit")
+	}
+	match, ok := m.Accepted()
+	if !ok || match.Path != "file2.go" {
+		t.Errorf("Accepted() = %v, %v; want file2.go", match.Path, ok)
+	}
+}
+
+func TestView_ShowsSmartCaseFlip(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    search.CaseSensitivity
+		pattern string
+		want    bool
+	}{
+		{"smart with uppercase", search.CaseSmart, "NewModel", true},
+		{"smart lowercase", search.CaseSmart, "newmodel", false},
+		{"explicit insensitive", search.CaseInsensitive, "NewModel", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			m.SetCaseSensitivity(tt.mode)
+			m.lastPattern = tt.pattern
+			updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 3), done: true})
+			m = updated.(Model)
+
+			if got := strings.Contains(m.View(), "smart case: sensitive"); got != tt.want {
+				t.Errorf("view shows smart case note = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleCase_SavesProjectMode(t *testing.T) {
+	store, err := state.OpenFile(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	m.SetState(store, "/src/irg")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(Model)
+	if m.caseSensitivity != search.CaseSensitive {
+		t.Fatalf("case = %v, want sensitive", m.caseSensitivity)
+	}
+	if cmd == nil {
+		t.Fatal("expected a command to save the case mode")
+	}
+	// With no pattern to re-run, the save is the only command
+	if msg, ok := cmd().(stateSavedMsg); !ok || msg.err != nil {
+		t.Fatalf("command returned %#v, want a successful stateSavedMsg", msg)
+	}
+
+	if got := store.Project("/src/irg").CaseMode; got != "sensitive" {
+		t.Errorf("saved case mode = %q, want sensitive", got)
+	}
+}
+
+func TestSearch_ReadsEveryBatch(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 350)...)
+
+	cmd := m.executeSearch("match", ".")
+	for m.searching {
+		msg, ok := cmd().(searchResultMsg)
+		if !ok {
+			t.Fatalf("search command returned %T", msg)
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+		cmd = msg.next
+		if !msg.done && cmd == nil {
+			t.Fatal("unfinished batch has no follow-up command")
+		}
+	}
+
+	if m.results.Len() != 350 {
+		t.Errorf("results = %d, want 350", m.results.Len())
+	}
+}
+
+func TestSearch_DropsBatchesFromReplacedSearch(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 150)...)
+
+	stale := m.executeSearch("old", ".")()
+	m.executeSearch("new", ".")
+
+	updated, _ := m.Update(stale)
+	m = updated.(Model)
+	if m.results.Len() != 0 || !m.searching {
+		t.Errorf("stale batch applied: results = %d, searching = %v", m.results.Len(), m.searching)
+	}
+}
+
+func TestRefreshPaths_ReplacesIndex(t *testing.T) {
+	m := newTestModel(t)
+	m.pathProvider = NewPathProvider(writeTree(t, map[string]string{"a.go": "", "b.go": ""}))
+
+	// The walk from Init is adopted, then superseded by a refresh
+	initial := m.loadPathsAsync()()
+	updated, _ := m.Update(initial)
+	m = updated.(Model)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyF5})
+	m = updated.(Model)
+	if cmd == nil || m.pathsLoaded || len(m.allPaths) != 0 {
+		t.Fatalf("refresh kept the old index: loaded = %v, paths = %d", m.pathsLoaded, len(m.allPaths))
+	}
+
+	updated, _ = m.Update(initial)
+	m = updated.(Model)
+	if len(m.allPaths) != 0 {
+		t.Errorf("batch from the replaced walk was applied")
+	}
+
+	for msg := cmd(); ; msg = cmd() {
+		updated, cmd = m.Update(msg)
+		m = updated.(Model)
+		if cmd == nil {
+			break
+		}
+	}
+	if len(m.allPaths) != 2 || m.pathsIndexing {
+		t.Errorf("paths = %d, indexing = %v; want 2, false", len(m.allPaths), m.pathsIndexing)
+	}
+	if m.status.message != "Indexed 2 paths" {
+		t.Errorf("status = %q", m.status.message)
+	}
+}
+
+func TestInlineContext_RendersFixedHeightRows(t *testing.T) {
+	m := newTestModel(t)
+	m.SetInlineContext(true)
+	if got := m.searchOptions().Context; got != inlineContextLines {
+		t.Fatalf("search context = %d, want %d", got, inlineContextLines)
+	}
+
+	matches := testMatches(0, 40)
+	matches[0].After = []string{"after first"}
+	matches[1].Before = []string{"before second"}
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	m = updated.(Model)
+
+	content := m.list.View()
+	if !strings.Contains(content, "   2- after first") || !strings.Contains(content, "   1- before second") {
+		t.Errorf("context lines missing from results:\n%s", content)
+	}
+
+	// Each result takes three rows, so the selection scrolls in whole results
+	for i := 0; i < 30; i++ {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = updated.(Model)
+	}
+	offset := m.resultsOffset()
+	if want := 30 - m.visibleResults()/2; offset != want {
+		t.Errorf("offset = %d, want %d", offset, want)
+	}
+	if got := m.list.view.TotalLineCount(); got < 3*m.visibleResults() || got > m.list.view.Height+1 {
+		t.Errorf("rendered %d rows for %d results in a %d row view", got, m.visibleResults(), m.list.view.Height)
+	}
+}
+
+func TestPreview_ScrollsToMatchBeyondPane(t *testing.T) {
+	m := newTestModel(t)
+	m.highlighter.SetEnabled(false)
+	line := strings.Repeat("x", 200) + "needle"
+	matches := []search.Match{{Path: "long.txt", LineNumber: 1, LineText: line,
+		Submatches: []search.Submatch{{Match: "needle", Start: 200, End: 206}}}}
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	m = updated.(Model)
+
+	m.loadPreview()
+	updated, _ = m.Update(previewLoadedMsg{token: m.preview.token, path: "long.txt", lines: []string{line}, startLine: 1, matchLine: 1, spans: [][]search.Submatch{matches[0].Submatches}})
+	m = updated.(Model)
+
+	if m.preview.xOffset == 0 {
+		t.Fatal("preview was not scrolled to the match")
+	}
+	view := m.preview.view.View()
+	if !strings.Contains(view, "needle") || !strings.Contains(view, "^^^^^^") {
+		t.Errorf("match or caret not visible in preview:\n%s", view)
+	}
+}
+
+func TestScrollHorizontal_KeepsGutterAndClamps(t *testing.T) {
+	m := newTestModel(t)
+	m.highlighter.SetEnabled(false)
+	matches := testMatches(0, 3)
+	matches[0].LineText = "start " + strings.Repeat("y", 100) + " tail"
+	matches[0].Submatches = nil
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	m = updated.(Model)
+	m.preview.lines = []string{"short"}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyShiftLeft})
+	m = updated.(Model)
+	if m.list.xOffset != 0 {
+		t.Fatalf("scrolled left past the start: %d", m.list.xOffset)
+	}
+
+	for i := 0; i < 50; i++ {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyShiftRight})
+		m = updated.(Model)
+	}
+	if want := 111 - m.resultTextWidth(); m.list.xOffset != want {
+		t.Errorf("resultsXOffset = %d, want %d", m.list.xOffset, want)
+	}
+	if m.preview.xOffset != 0 {
+		t.Errorf("previewXOffset = %d, want 0 for a line that fits", m.preview.xOffset)
+	}
+
+	view := m.list.View()
+	if !strings.Contains(view, "file0.go:1: ") || !strings.Contains(view, "y tail") || strings.Contains(view, "start") {
+		t.Errorf("results not scrolled behind a fixed gutter:\n%s", view)
+	}
+}
+
+func TestPaste_SearchesWithoutDebounce(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(testMatches(0, 3)...)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.searching {
+		t.Fatal("typing started a search before the debounce")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("nil pointer dereference"), Paste: true})
+	m = updated.(Model)
+	if !m.searching {
+		t.Fatal("a paste didn't start the search right away")
+	}
+	if cmd == nil {
+		t.Fatal("no search command")
+	}
+	if m.resultsPattern != "xnil pointer dereference" {
+		t.Errorf("searched %q", m.resultsPattern)
+	}
+}
+
+func TestPrintSet_MarkedSubset(t *testing.T) {
+	m := newTestModel(t)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 5), done: true})
+	m = updated.(Model)
+
+	set, err := m.PrintSet()
+	if err != nil || len(set.Matches) != 5 {
+		t.Fatalf("unmarked: %d matches, %v; want all 5", len(set.Matches), err)
+	}
+
+	m.marked = map[int]bool{3: true, 1: true}
+	m.notes = map[int]string{3: "fix"}
+	set, err = m.PrintSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Matches) != 2 || set.Matches[0].Path != "file1.go" || set.Matches[1].Path != "file3.go" {
+		t.Errorf("marked set = %+v, want file1.go and file3.go in order", set.Matches)
+	}
+	if set.Notes[1] != "fix" {
+		t.Errorf("notes = %v, want the note on the second printed match", set.Notes)
+	}
+}
+
+func TestExportQuickfix_MarkedResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "refactor.qf")
+	m := newTestModel(t)
+	m.SetQuickfixFile(path)
+	updated, _ := m.Update(searchResultMsg{matches: testMatches(0, 5), done: true})
+	m = updated.(Model)
+	m.marked = map[int]bool{3: true, 1: true}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlQ})
+	if cmd == nil {
+		t.Fatal("Ctrl+Q wrote nothing")
+	}
+	updated, _ = updated.(Model).Update(cmd())
+	m = updated.(Model)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "file1.go:2:1: match number 1\nfile3.go:4:1: match number 3\n"
+	if string(got) != want {
+		t.Errorf("quickfix file = %q, want %q", got, want)
+	}
+	if m.status.message != "Wrote 2 marked matches to "+path {
+		t.Errorf("status = %q", m.status.message)
+	}
+}
+
+func TestToggleNoIgnore_SearchesAgainWithIndicator(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher(testMatches(0, 3)...)
+	m.searcher = searcher
+	m.inputs.pattern.SetValue("match")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}, Alt: true})
+	m = runSearch(t, updated.(Model), searchCmd(t, cmd))
+
+	calls := searcher.Calls()
+	if len(calls) != 1 || !calls[0].Opts.NoIgnore {
+		t.Fatalf("calls = %+v, want one search with NoIgnore", calls)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "[no-ignore]") {
+		t.Errorf("status bar has no [no-ignore] tag:\n%s", view)
+	}
+}
+
+func TestSetMaxDepth_ForwardedWithIndicator(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher(testMatches(0, 3)...)
+	m.searcher = searcher
+	m.SetMaxDepth(3)
+
+	m = runSearch(t, m, m.executeSearch("needle", "."))
+
+	calls := searcher.Calls()
+	if len(calls) != 1 || calls[0].Opts.MaxDepth != 3 {
+		t.Fatalf("calls = %+v, want one search with MaxDepth 3", calls)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "[depth ≤3]") {
+		t.Errorf("status bar has no [depth ≤3] tag:\n%s", view)
+	}
+}
+
+func TestToggleWholeWord_SearchesAgainWithIndicator(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher(testMatches(0, 3)...)
+	m.searcher = searcher
+	m.inputs.pattern.SetValue("id")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	m = runSearch(t, updated.(Model), searchCmd(t, cmd))
+
+	calls := searcher.Calls()
+	if len(calls) != 1 || !calls[0].Opts.WholeWord {
+		t.Fatalf("calls = %+v, want one search with WholeWord", calls)
+	}
+	if m.inputs.pattern.Value() != "id" {
+		t.Errorf("pattern = %q, want Ctrl+W not to delete a word", m.inputs.pattern.Value())
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "[whole-word]") {
+		t.Errorf("status bar has no [whole-word] tag:\n%s", view)
+	}
+}
+
+func TestPreviewContext_ByGlob(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 60; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	for _, name := range []string{"app.log", "main.go", "settings.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestModel(t)
+	m.SetPreviewContext(3, []ContextRadius{
+		{Globs: []string{"*.log"}, Lines: 20},
+		{Globs: []string{"*.json", "*.yaml"}, Lines: 0},
+	})
+	for name, want := range map[string]int{"app.log": 41, "main.go": 7, "settings.json": 1} {
+		msg := m.loadPreviewAt(filepath.Join(dir, name), 30, nil, "")().(previewLoadedMsg)
+		if len(msg.lines) != want || msg.matchLine != 30 {
+			t.Errorf("%s preview = %d lines around line %d, want %d around 30", name, len(msg.lines), msg.matchLine, want)
+		}
+	}
+}
+
+func TestPreview_ShowsEnclosingDefinition(t *testing.T) {
+	lines := []string{"package main", "", "func (s *server) handle(w http.ResponseWriter) {"}
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "\tstep()")
+	}
+	lines = append(lines, "\tneedle()", "}")
+	path := filepath.Join(t.TempDir(), "server.go")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 240, Height: 40})
+	m = updated.(Model)
+	// The definition is well above the previewed context
+	updated, _ = m.Update(m.loadPreviewAt(path, 44, nil, "")())
+	m = updated.(Model)
+	if header := ansi.Strip(strings.SplitN(m.preview.view.View(), "\n", 2)[0]); !strings.Contains(header, "server.go in func (s *server) handle") {
+		t.Errorf("preview header = %q, want the enclosing method named", header)
+	}
+}
+
+func TestRepoRoot_SearchedWhenPathEmpty(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher(testMatches(0, 3)...)
+	m.searcher = searcher
+	m.SetRepoRoot("../..")
+
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Path (repo root: ../..)") {
+		t.Errorf("path input doesn't show the repo root default:\n%s", view)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("needle")})
+	m = updated.(Model)
+	if m.lastPath != "../.." {
+		t.Errorf("lastPath = %q, want the repo root", m.lastPath)
+	}
+	m = runSearch(t, m, m.executeSearch("needle", m.inputs.path.Value()))
+
+	calls := searcher.Calls()
+	if len(calls) != 1 || calls[0].Path != "../.." {
+		t.Fatalf("calls = %+v, want one search of ../..", calls)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "3 matches in repo root ../..") {
+		t.Errorf("status bar doesn't name the repo root:\n%s", view)
+	}
+
+	// A typed path is searched as given
+	m.inputs.path.SetValue("internal")
+	if got := m.searchPath(); got != "internal" {
+		t.Errorf("searchPath = %q with a typed path, want it", got)
+	}
+}
@@ -0,0 +1,30 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// SetMouse records whether the program was started capturing the mouse
+func (m *Model) SetMouse(enabled bool) {
+	m.mouse = enabled
+}
+
+// toggleMouse stops capturing the mouse, so the terminal can select text
+// with it as usual, or starts again
+func (m *Model) toggleMouse() tea.Cmd {
+	m.mouse = !m.mouse
+	if m.mouse {
+		m.status.message = "Mouse on: the wheel scrolls the results and dragging copies preview lines"
+	} else {
+		m.status.message = "Mouse off: the terminal selects text; Alt+H turns the mouse back on"
+	}
+	return m.mouseMode()
+}
+
+// mouseMode returns the command putting the terminal's mouse reporting in
+// the chosen mode. Bubble Tea turns reporting off for the editor, a diff
+// tool or Ctrl+Z and doesn't turn it back on, so it is restored after them.
+func (m *Model) mouseMode() tea.Cmd {
+	if m.mouse {
+		return tea.EnableMouseCellMotion
+	}
+	return tea.DisableMouse
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/config"
+)
+
+func TestToggleMouse_StaysOffAfterTheEditor(t *testing.T) {
+	m := newTestModel(t)
+	m.SetMouse(true)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}, Alt: true})
+	m = updated.(Model)
+	if m.mouse || cmd == nil || !reflect.DeepEqual(cmd(), tea.DisableMouse()) {
+		t.Fatal("Alt+H didn't stop capturing the mouse")
+	}
+
+	// Bubble Tea leaves reporting off after the editor; on or off, the
+	// chosen mode is put back
+	for _, msg := range []tea.Msg{editorFinishedMsg{}, tea.ResumeMsg{}} {
+		updated, cmd = m.Update(msg)
+		m = updated.(Model)
+		if cmd == nil || !reflect.DeepEqual(cmd(), tea.DisableMouse()) {
+			t.Errorf("%T didn't restore the mouse mode", msg)
+		}
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}, Alt: true})
+	m = updated.(Model)
+	if !m.mouse || cmd == nil || !reflect.DeepEqual(cmd(), tea.EnableMouseCellMotion()) {
+		t.Error("a second Alt+H didn't capture the mouse again")
+	}
+}
+
+func TestSettings_SavesMouse(t *testing.T) {
+	m := newTestModel(t)
+	m.SetMouse(true)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	m.SetConfigFile(path)
+	m.openSettings()
+	m.settingsIndex = slices.IndexFunc(settingItems, func(item settingItem) bool { return item.key == "mouse" })
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("s returned no save command")
+	}
+	m.Update(cmd())
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UI.Mouse == nil || *cfg.UI.Mouse {
+		t.Errorf("saved mouse = %v, want off", cfg.UI.Mouse)
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestToggleMultiline_SearchesAgainWithIndicator(t *testing.T) {
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher(testMatches(0, 3)...)
+	m.searcher = searcher
+	m.inputs.pattern.SetValue(`\{\n\}`)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}, Alt: true})
+	m = runSearch(t, updated.(Model), searchCmd(t, cmd))
+
+	calls := searcher.Calls()
+	if len(calls) != 1 || !calls[0].Opts.Multiline {
+		t.Fatalf("calls = %+v, want one search with Multiline", calls)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "[multiline]") {
+		t.Errorf("status bar has no [multiline] tag:\n%s", view)
+	}
+}
+
+func TestMultilineMatch_ShowsFirstLineAndHighlightsSpan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.go")
+	if err := os.WriteFile(path, []byte("package p\n\nfunc f() {\n\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	// Wide enough for the temporary path
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 400, Height: 30})
+	m = updated.(Model)
+	m.highlighter.SetEnabled(false)
+	match := search.Match{
+		Path:       path,
+		LineNumber: 3,
+		EndLine:    5,
+		LineText:   "func f() {\n\n}\n",
+		Submatches: []search.Submatch{{Match: "{\n\n}", Start: 9, End: 13}},
+	}
+	updated, _ = m.Update(searchResultMsg{matches: []search.Match{match}, done: true})
+	m = updated.(Model)
+
+	results := ansi.Strip(m.list.View())
+	if !strings.Contains(results, ":3-5: func f() {") {
+		t.Errorf("results row doesn't show the first line and the range:\n%s", results)
+	}
+
+	updated, _ = m.Update(m.loadPreview()())
+	m = updated.(Model)
+	if len(m.preview.lines) != 5 {
+		t.Fatalf("preview lines = %q, want the whole file", m.preview.lines)
+	}
+	for line := 1; line <= 5; line++ {
+		_, inMatch := m.preview.submatches(line)
+		if want := line >= 3; inMatch != want {
+			t.Errorf("line %d in match = %v, want %v", line, inMatch, want)
+		}
+	}
+	if last, _ := m.preview.submatches(5); len(last) != 1 || last[0].Match != "}" {
+		t.Errorf("submatches on line 5 = %+v, want the closing brace", last)
+	}
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+const (
+	narrowMinShare   = 0.1 // Smallest share of the results worth excluding
+	narrowMaxOptions = 5   // Suggestions of each kind
+)
+
+// narrowOption is one entry of the narrowing menu: a directory or a type
+// to leave out of the search, or undoing the exclusions made so far
+type narrowOption struct {
+	dir   string
+	typ   string
+	count int // Results in the directory or type
+}
+
+// label describes the option in the menu
+func (o narrowOption) label() string {
+	switch {
+	case o.dir != "":
+		return fmt.Sprintf("Exclude %s (%d results)", o.dir, o.count)
+	case o.typ != "":
+		return fmt.Sprintf("Exclude %s files (%d results)", o.typ, o.count)
+	}
+	return "Undo the exclusions"
+}
+
+// narrowSuggestions looks for directories and file types that hold a large
+// share of the results, the likeliest things to exclude when a search runs
+// into the result limit
+func (m *Model) narrowSuggestions() ([]narrowOption, error) {
+	matches, err := m.results.Slice(0, m.results.Len())
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]int)
+	exts := make(map[string]int)
+	examples := make(map[string]string) // A file with each extension
+	for _, match := range matches {
+		if entry := topLevelEntry(m.summary.root, match.Path); strings.HasSuffix(entry, "/") {
+			dirs[entry]++
+		}
+		ext := filepath.Ext(match.Path)
+		exts[ext]++
+		if _, ok := examples[ext]; !ok {
+			examples[ext] = match.Path
+		}
+	}
+
+	types := make(map[string]int)
+	for ext, n := range exts {
+		if typ := m.typeOfFile(examples[ext], ext); typ != "" && !slices.Contains(m.excludeTypes, typ) {
+			types[typ] += n
+		}
+	}
+
+	minCount := max(int(float64(len(matches))*narrowMinShare), 1)
+	var options []narrowOption
+	for _, entry := range topCounts(dirs, minCount) {
+		options = append(options, narrowOption{dir: entry.path, count: entry.count})
+	}
+	for _, entry := range topCounts(types, minCount) {
+		options = append(options, narrowOption{typ: entry.path, count: entry.count})
+	}
+	if len(m.excludeDirs) > 0 || len(m.excludeTypes) > 0 {
+		options = append(options, narrowOption{})
+	}
+	return options, nil
+}
+
+// topCounts returns up to narrowMaxOptions keys of counts with at least
+// minCount, largest first
+func topCounts(counts map[string]int, minCount int) []summaryEntry {
+	var out []summaryEntry
+	for key, n := range counts {
+		if n >= minCount {
+			out = append(out, summaryEntry{path: key, count: n})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].path < out[j].path
+	})
+	return out[:min(len(out), narrowMaxOptions)]
+}
+
+// typeOfFile names the ripgrep type of path, preferring one named after its
+// extension ext, or returns "" when no type covers it
+func (m *Model) typeOfFile(path, ext string) string {
+	if name := strings.TrimPrefix(ext, "."); name != "" && search.MatchesType(path, name, m.typeGlobs) {
+		return name
+	}
+	for _, typ := range m.allTypes {
+		if search.MatchesType(path, typ, m.typeGlobs) {
+			return typ
+		}
+	}
+	return ""
+}
+
+// openNarrow shows the narrowing menu for the current results
+func (m *Model) openNarrow() {
+	if m.remote {
+		m.status.err = "Remote searches can't be narrowed by exclusion"
+		return
+	}
+	options, err := m.narrowSuggestions()
+	if err != nil {
+		m.status.err = err.Error()
+		return
+	}
+	if len(options) == 0 {
+		m.status.message = "No directory or file type stands out in the results"
+		return
+	}
+	m.narrowOptions = options
+	m.narrowIndex = 0
+	m.narrowVisible = true
+}
+
+// updateNarrow handles key presses while the narrowing menu is shown
+func (m Model) updateNarrow(a action) (tea.Model, tea.Cmd) {
+	switch a {
+	case actionUp:
+		m.narrowIndex = (m.narrowIndex + len(m.narrowOptions) - 1) % len(m.narrowOptions)
+	case actionDown:
+		m.narrowIndex = (m.narrowIndex + 1) % len(m.narrowOptions)
+	case actionOpenEditor:
+		m.narrowVisible = false
+		return m, m.applyNarrow(m.narrowOptions[m.narrowIndex])
+	case actionClose, actionNarrow, actionQuit:
+		m.narrowVisible = false
+	}
+	return m, nil
+}
+
+// applyNarrow applies option and searches again
+func (m *Model) applyNarrow(option narrowOption) tea.Cmd {
+	switch {
+	case option.dir != "":
+		m.excludeDirs = append(m.excludeDirs, option.dir)
+	case option.typ != "":
+		m.excludeTypes = append(m.excludeTypes, option.typ)
+	default:
+		m.excludeDirs, m.excludeTypes = nil, nil
+	}
+	pattern := m.inputs.pattern.Value()
+	if pattern == "" {
+		return nil
+	}
+	return m.executeSearch(pattern, m.inputs.path.Value())
+}
+
+// exclusionInfo lists the exclusions for the status line
+func (m *Model) exclusionInfo() string {
+	excluded := append(slices.Clone(m.excludeDirs), m.excludeTypes...)
+	if len(excluded) == 0 {
+		return ""
+	}
+	return " [excluding " + strings.Join(excluded, ",") + "]"
+}
+
+// renderNarrow renders the narrowing menu, at most width cells wide and
+// height rows tall
+func (m *Model) renderNarrow(width, height int) string {
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	title := fmt.Sprintf("Narrow the search (%d results shown)", m.results.Len())
+	lines := []string{lipgloss.NewStyle().Bold(true).Render(title), ""}
+	for i, option := range m.narrowOptions {
+		if i == m.narrowIndex {
+			lines = append(lines, selectedStyle.Render("> "+option.label()))
+		} else {
+			lines = append(lines, "  "+option.label())
+		}
+	}
+	lines = append(lines, "", hintStyle.Render("↑/↓ select  Enter apply and search again  Esc close"))
+
+	// Border and padding take two rows and four columns
+	textWidth := max(width-4, 10)
+	if maxLines := max(height-2, 1); len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, textWidth, "…")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestNarrowSuggestions_DominantDirsAndTypes(t *testing.T) {
+	m := newTestModel(t)
+	// Types as rg lists them, so the test doesn't need rg installed
+	m.typeGlobs = map[string][]string{"js": {"*.js"}, "go": {"*.go"}, "md": {"*.md"}}
+	m.allTypes = sortedKeys(m.typeGlobs)
+	var matches []search.Match
+	for i := 0; i < 70; i++ {
+		matches = append(matches, search.Match{Path: fmt.Sprintf("vendor/lib%d.js", i), LineNumber: 1})
+	}
+	for i := 0; i < 25; i++ {
+		matches = append(matches, search.Match{Path: fmt.Sprintf("src/app%d.go", i), LineNumber: 1})
+	}
+	for i := 0; i < 5; i++ {
+		matches = append(matches, search.Match{Path: fmt.Sprintf("docs/page%d.md", i), LineNumber: 1})
+	}
+	if err := m.results.Append(matches...); err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := m.narrowSuggestions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, option := range options {
+		labels = append(labels, option.label())
+	}
+	want := []string{
+		"Exclude vendor/ (70 results)",
+		"Exclude src/ (25 results)",
+		"Exclude js files (70 results)",
+		"Exclude go files (25 results)",
+	}
+	if !slices.Equal(labels, want) {
+		t.Errorf("suggestions = %q, want %q", labels, want)
+	}
+}
+
+func TestNarrow_MenuExcludesAndUndoes(t *testing.T) {
+	m := newTestModel(t)
+	m.inputs.pattern.SetValue("needle")
+	if err := m.results.Append(search.Match{Path: "vendor/a.go", LineNumber: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}, Alt: true})
+	m = updated.(Model)
+	if !m.narrowVisible {
+		t.Fatal("Alt+W didn't open the narrowing menu")
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil || !m.searching {
+		t.Error("choosing an option didn't search again")
+	}
+	opts := m.searchOptions()
+	if !slices.Equal(opts.ExcludeDirs, []string{"vendor/"}) {
+		t.Errorf("ExcludeDirs = %q, want vendor/", opts.ExcludeDirs)
+	}
+
+	m.excludeTypes = []string{"go"}
+	if opts := m.searchOptions(); !slices.Contains(opts.FileTypesNot, "go") {
+		t.Errorf("FileTypesNot = %q, want go", opts.FileTypesNot)
+	}
+	m.applyNarrow(narrowOption{})
+	if len(m.excludeDirs) != 0 || len(m.excludeTypes) != 0 {
+		t.Errorf("exclusions %q %q left after undo", m.excludeDirs, m.excludeTypes)
+	}
+}
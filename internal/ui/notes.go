@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newNoteInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Note..."
+	ti.CharLimit = 120
+	ti.Width = 40
+	return ti
+}
+
+// startNote opens the note prompt for the selected result, marking it so the
+// note travels with the marked set
+func (m *Model) startNote() tea.Cmd {
+	if m.list.selected >= m.results.Len() {
+		return nil
+	}
+	m.noteIndex = m.list.selected
+	m.noteEditing = true
+	m.noteInput.SetValue(m.notes[m.noteIndex])
+	m.noteInput.CursorEnd()
+	m.inputs.pattern.Blur()
+	m.inputs.path.Blur()
+	m.inputs.types.Blur()
+	return m.noteInput.Focus()
+}
+
+// updateNote handles key presses while the note prompt is open. An empty
+// note removes the note but keeps the mark.
+func (m Model) updateNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.endNote()
+		return m, nil
+	case tea.KeyEnter:
+		note := strings.TrimSpace(m.noteInput.Value())
+		m.marked[m.noteIndex] = true
+		if note == "" {
+			delete(m.notes, m.noteIndex)
+		} else {
+			m.notes[m.noteIndex] = note
+		}
+		m.endNote()
+		m.list.cache.invalidate()
+		m.updateResultsView()
+		m.updatePreviewView()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.noteInput, cmd = m.noteInput.Update(msg)
+	return m, cmd
+}
+
+// endNote closes the note prompt and returns focus to the pattern
+func (m *Model) endNote() {
+	m.noteEditing = false
+	m.noteInput.Blur()
+	m.inputs.focused = focusPattern
+	m.inputs.pattern.Focus()
+}
+
+// noteStatus is shown in the status area while the note prompt is open
+func (m *Model) noteStatus() string {
+	label := "result"
+	if match, err := m.results.Get(m.noteIndex); err == nil {
+		label = fmt.Sprintf("%s:%d", displayPath(match.Path), match.LineNumber)
+	}
+	return fmt.Sprintf("Note for %s: %s  (Enter save, Esc cancel)", label, m.noteInput.View())
+}
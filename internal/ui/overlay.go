@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// overlayDropdown draws box over view with its bottom edge just above row
+// bottom, starting at column x. The box is shifted left and clipped to stay
+// inside the window.
+func (m *Model) overlayDropdown(view, box string, x, bottom int) string {
+	boxWidth := ansi.StringWidth(box[:strings.IndexByte(box+"\n", '\n')])
+	x = clamp(x, 0, max(m.width-boxWidth, 0))
+	y := max(bottom-strings.Count(box, "\n")-1, 0)
+	return overlay(view, box, x, y)
+}
+
+// overlay draws box over base with its top-left corner at column x, row y,
+// keeping the styling of the base text on either side of it
+func overlay(base, box string, x, y int) string {
+	lines := strings.Split(base, "\n")
+	for i, boxLine := range strings.Split(box, "\n") {
+		row := y + i
+		if row >= len(lines) {
+			break
+		}
+		line := lines[row]
+		left := ansi.Truncate(line, x, "")
+		if w := ansi.StringWidth(left); w < x {
+			left += strings.Repeat(" ", x-w)
+		}
+		right := cutLeft(line, x+ansi.StringWidth(boxLine))
+		lines[row] = left + ansi.ResetStyle + boxLine + ansi.ResetStyle + right
+	}
+	return strings.Join(lines, "\n")
+}
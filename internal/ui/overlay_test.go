@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestOverlay(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		box  string
+		x, y int
+		want string
+	}{
+		{"middle", "aaaaaa\nbbbbbb\ncccccc", "XY\nZW", 2, 1, "aaaaaa\nbbXYbb\nccZWcc"},
+		{"pads short lines", "a\nb", "XY", 3, 1, "a\nb  XY"},
+		{"clips at bottom", "aaaa\nbbbb", "XY\nZW", 0, 1, "aaaa\nXYbb"},
+		{"keeps base styling", "\x1b[31mredred\x1b[0m", "X", 2, 0, "reXred"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := overlay(tt.base, tt.box, tt.x, tt.y)
+			if ansi.Strip(got) != tt.want {
+				t.Errorf("overlay = %q, want %q", ansi.Strip(got), tt.want)
+			}
+		})
+	}
+	if got := overlay("\x1b[31mredred\x1b[0m", "X", 2, 0); !strings.Contains(got[strings.Index(got, "X"):], "\x1b[31m") {
+		t.Errorf("overlay dropped the styling right of the box: %q", got)
+	}
+}
+
+func TestDropdown_DoesNotResizePanes(t *testing.T) {
+	m := newTestModel(t)
+	m.allTypes = []string{"go", "gn", "gradle"}
+	heightBefore := m.list.view.Height
+	linesBefore := lipgloss.Height(m.View())
+
+	m.inputs.focused = focusTypes
+	m.inputs.pattern.Blur()
+	m.inputs.types.Focus()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	m = updated.(Model)
+	if !m.typeDropdown.visible {
+		t.Fatal("typing a type prefix didn't open the dropdown")
+	}
+
+	view := m.View()
+	if m.list.view.Height != heightBefore {
+		t.Errorf("results height = %d with the dropdown open, want %d", m.list.view.Height, heightBefore)
+	}
+	if got := lipgloss.Height(view); got != linesBefore {
+		t.Errorf("view is %d lines with the dropdown open, want %d", got, linesBefore)
+	}
+	if !strings.Contains(ansi.Strip(view), "gradle") {
+		t.Error("dropdown entries missing from the view")
+	}
+}
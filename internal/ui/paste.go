@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// normalizePaste turns pasted text that spans lines or has tabs, such as an
+// error message copied from a log, into a one-line literal pattern: runs of
+// whitespace collapse to one space, whitespace around it is trimmed and
+// regex metacharacters are escaped. Other text is returned unchanged, with
+// false.
+func normalizePaste(text string) (string, bool) {
+	if !strings.ContainsAny(text, "\r\n\t") {
+		return text, false
+	}
+	return regexp.QuoteMeta(strings.Join(strings.Fields(text), " ")), true
+}
+
+// flattenPaste is text as the pattern input would take it unnormalized,
+// with line breaks and tabs turned into spaces
+func flattenPaste(text string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ", "\t", " ").Replace(text)
+}
+
+// normalizePatternPaste normalizes a paste into the pattern input,
+// remembering the pattern it would have made so the normalization can be
+// undone, and reports whether it changed anything
+func (m *Model) normalizePatternPaste(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	normalized, changed := normalizePaste(string(msg.Runes))
+	if !changed {
+		return msg, false
+	}
+	before := []rune(m.inputs.pattern.Value())
+	pos := m.inputs.pattern.Position()
+	m.pasteRaw = string(before[:pos]) + flattenPaste(string(msg.Runes)) + string(before[pos:])
+	msg.Runes = []rune(normalized)
+	return msg, true
+}
+
+// pasteNormalizedActive reports whether the pattern is still the one a
+// normalized paste produced
+func (m *Model) pasteNormalizedActive() bool {
+	return m.pasteNormalized != "" && m.inputs.pattern.Value() == m.pasteNormalized
+}
+
+// restorePaste replaces the normalized pattern with the text as pasted and
+// searches it
+func (m *Model) restorePaste() tea.Cmd {
+	if !m.pasteNormalizedActive() {
+		return nil
+	}
+	raw := m.pasteRaw
+	m.pasteNormalized, m.pasteRaw = "", ""
+	m.inputs.pattern.SetValue(raw)
+	m.lastPattern = raw
+	return m.executeSearch(raw, m.inputs.path.Value())
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestNormalizePaste(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		changed bool
+	}{
+		{"nil pointer", "nil pointer", false},
+		{"a.b(c)", "a.b(c)", false},
+		{"  panic: bad\n\tat main.go:12\n", `panic: bad at main\.go:12`, true},
+		{"x\r\ny\t\tz", "x y z", true},
+		{"f(x)\n", `f\(x\)`, true},
+	}
+	for _, tt := range tests {
+		got, changed := normalizePaste(tt.in)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("normalizePaste(%q) = %q, %v; want %q, %v", tt.in, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+// searchCmd finds the search among the commands batched by cmd
+func searchCmd(t *testing.T, cmd tea.Cmd) tea.Cmd {
+	t.Helper()
+	pending := []tea.Cmd{cmd}
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		if next == nil {
+			continue
+		}
+		switch msg := next().(type) {
+		case tea.BatchMsg:
+			pending = append(pending, msg...)
+		case searchResultMsg:
+			return func() tea.Msg { return msg }
+		}
+	}
+	t.Fatal("no search started")
+	return nil
+}
+
+func TestPaste_NormalizesAndRestores(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("error: ")})
+	m = updated.(Model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("open(x)\n\tfailed"), Paste: true})
+	m = runSearch(t, updated.(Model), searchCmd(t, cmd))
+
+	if want := `error: open\(x\) failed`; m.inputs.pattern.Value() != want {
+		t.Errorf("pattern = %q, want %q", m.inputs.pattern.Value(), want)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "paste normalized") {
+		t.Errorf("no normalization indicator:\n%s", view)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}, Alt: true})
+	m = runSearch(t, updated.(Model), cmd)
+	if want := "error: open(x)  failed"; m.inputs.pattern.Value() != want || m.resultsPattern != want {
+		t.Errorf("restored pattern = %q, searched %q; want %q", m.inputs.pattern.Value(), m.resultsPattern, want)
+	}
+	if m.pasteNormalizedActive() {
+		t.Error("indicator still shown after restoring the paste")
+	}
+}
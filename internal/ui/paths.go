@@ -1,19 +1,25 @@
 package ui
 
 import (
+	"context"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+
+	"github.com/William9923/irg/internal/ignore"
 )
 
 const (
-	pathMaxDepth          = 5
+	defaultPathMaxDepth   = 5
 	pathCacheTTL          = 30 * time.Second
 	pathDropdownMaxHeight = 8
 	maxPathResults        = 50
+	pathBatchSize         = 500 // Entries per progressive index batch
 )
 
 type PathEntry struct {
@@ -22,8 +28,14 @@ type PathEntry struct {
 	Score int
 }
 
+// defaultPathSkip lists the dependency directories left out of the index
+// unless the config replaces it
+var defaultPathSkip = []string{"node_modules", "vendor"}
+
 type PathProvider struct {
 	root      string
+	maxDepth  int
+	skip      []string // Name globs left out of the index
 	cache     []PathEntry
 	cacheMu   sync.RWMutex
 	cacheTime time.Time
@@ -32,70 +44,151 @@ type PathProvider struct {
 
 func NewPathProvider(root string) *PathProvider {
 	return &PathProvider{
-		root: root,
-		ttl:  pathCacheTTL,
+		root:     root,
+		maxDepth: defaultPathMaxDepth,
+		skip:     defaultPathSkip,
+		ttl:      pathCacheTTL,
 	}
 }
 
+// Configure sets how deep the index goes and which names it skips. A zero
+// depth or nil skip list keeps the default. The cached index is dropped.
+func (p *PathProvider) Configure(maxDepth int, skip []string) {
+	p.maxDepth = defaultPathMaxDepth
+	if maxDepth > 0 {
+		p.maxDepth = maxDepth
+	}
+	p.skip = defaultPathSkip
+	if skip != nil {
+		p.skip = skip
+	}
+	p.Invalidate()
+}
+
+// skipped reports whether name matches one of the skip patterns
+func (p *PathProvider) skipped(name string) bool {
+	for _, pattern := range p.skip {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPaths returns the whole index, walking the tree if the cache is stale
 func (p *PathProvider) LoadPaths() []PathEntry {
-	p.cacheMu.RLock()
-	if time.Since(p.cacheTime) < p.ttl && p.cache != nil {
-		defer p.cacheMu.RUnlock()
-		return p.cache
+	paths := []PathEntry{}
+	for batch := range p.Stream(context.Background()) {
+		paths = append(paths, batch...)
 	}
-	p.cacheMu.RUnlock()
+	return paths
+}
 
-	p.cacheMu.Lock()
-	defer p.cacheMu.Unlock()
+// Stream indexes the tree in the background and sends the entries in
+// batches as they are found, so suggestions work before a large walk ends.
+// A fresh cached index is sent as one batch. The channel is closed when the
+// walk finishes or ctx is cancelled.
+func (p *PathProvider) Stream(ctx context.Context) <-chan []PathEntry {
+	out := make(chan []PathEntry, 1)
 
-	// Re-check after acquiring lock
-	if time.Since(p.cacheTime) < p.ttl && p.cache != nil {
-		return p.cache
+	p.cacheMu.RLock()
+	cached := p.cache
+	fresh := cached != nil && time.Since(p.cacheTime) < p.ttl
+	p.cacheMu.RUnlock()
+	if fresh {
+		out <- cached
+		close(out)
+		return out
 	}
 
-	paths := []PathEntry{}
-	p.walkDirectory(p.root, 0, &paths)
+	go func() {
+		defer close(out)
+		var all, batch []PathEntry
+		send := func() bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		complete := p.walkDirectory(ctx, p.root, "", 0, ignore.New(p.root), func(entry PathEntry) bool {
+			all = append(all, entry)
+			batch = append(batch, entry)
+			return len(batch) < pathBatchSize || send()
+		})
+		if !complete || (len(batch) > 0 && !send()) {
+			return
+		}
 
-	p.cache = paths
-	p.cacheTime = time.Now()
-	return paths
+		p.cacheMu.Lock()
+		p.cache = all
+		if p.cache == nil {
+			p.cache = []PathEntry{}
+		}
+		p.cacheTime = time.Now()
+		p.cacheMu.Unlock()
+	}()
+	return out
 }
 
-func (p *PathProvider) walkDirectory(root string, depth int, paths *[]PathEntry) {
-	if depth >= pathMaxDepth {
-		return
+// Invalidate drops the cached index so the next Stream walks the tree again
+func (p *PathProvider) Invalidate() {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+	p.cache = nil
+}
+
+// walkDirectory emits the entries below dir, skipping hidden files, the
+// configured skip patterns and anything the ignore files exclude. rel is dir
+// relative to the root, slash-separated. It returns false if ctx was
+// cancelled or emit asked to stop.
+func (p *PathProvider) walkDirectory(ctx context.Context, dir, rel string, depth int, ignored *ignore.Matcher, emit func(PathEntry) bool) bool {
+	if depth >= p.maxDepth {
+		return true
+	}
+	if ctx.Err() != nil {
+		return false
 	}
 
-	entries, err := os.ReadDir(root)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return
+		return true
 	}
 
 	for _, entry := range entries {
 		name := entry.Name()
 		// Skip hidden files and common large directories
-		if strings.HasPrefix(name, ".") ||
-			name == "node_modules" ||
-			name == "vendor" ||
-			name == ".git" {
+		if strings.HasPrefix(name, ".") || p.skipped(name) {
 			continue
 		}
 
-		path := filepath.Join(root, name)
-		if root == "." {
-			path = name
+		entryRel := name
+		if rel != "" {
+			entryRel = rel + "/" + name
 		}
-
 		isDir := entry.IsDir()
-		*paths = append(*paths, PathEntry{
-			Path:  path,
-			IsDir: isDir,
-		})
+		if ignored.Match(entryRel, isDir) {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if dir == "." {
+			path = name
+		}
+		if !emit(PathEntry{Path: path, IsDir: isDir}) {
+			return false
+		}
 
-		if isDir {
-			p.walkDirectory(path, depth+1, paths)
+		if isDir && !p.walkDirectory(ctx, path, entryRel, depth+1, ignored.ForDir(path, entryRel), emit) {
+			return false
 		}
 	}
+	return true
 }
 
 func (p *PathProvider) FilterPaths(input string, allPaths []PathEntry) []PathEntry {
@@ -123,8 +216,8 @@ func (p *PathProvider) FilterPaths(input string, allPaths []PathEntry) []PathEnt
 
 func scorePathMatch(input, path string) int {
 	// Normalize to forward slashes for consistent matching across platforms
-	input = strings.ToLower(filepath.ToSlash(input))
-	pathLower := strings.ToLower(filepath.ToSlash(path))
+	input = strings.ToLower(normalizeSeparators(input))
+	pathLower := strings.ToLower(normalizeSeparators(path))
 
 	if input == pathLower {
 		return 2000
@@ -134,7 +227,7 @@ func scorePathMatch(input, path string) int {
 		return 1000
 	}
 
-	filename := filepath.Base(pathLower)
+	filename := pathpkg.Base(pathLower)
 	if strings.HasPrefix(filename, input) {
 		return 800
 	}
@@ -148,7 +241,115 @@ func scorePathMatch(input, path string) int {
 		return 100
 	}
 
-	return 0
+	return fuzzyScore(input, normalizeSeparators(path))
+}
+
+// Fuzzy match scoring, in the spirit of fzf. Scores are normalized below the
+// substring tier of scorePathMatch.
+const (
+	fuzzyMatch       = 16 // Per matched character
+	fuzzyBoundary    = 10 // Match after a separator or at the start
+	fuzzyCamelCase   = 8  // Match on an uppercase letter after a lowercase one
+	fuzzyConsecutive = 6  // Match right after the previous match
+	fuzzyFilename    = 4  // Match within the last path element
+	fuzzyMaxScore    = 99
+)
+
+// fuzzyScore scores input as a case-insensitive subsequence of path, or
+// returns 0 if it isn't one. Like fzf's v1 algorithm, it finds the first
+// full match, then shrinks it from the end to the tightest window before
+// scoring it, so "iui" on "internal/ui" lands on the "ui" element.
+func fuzzyScore(input, path string) int {
+	in := []rune(input)
+	orig := []rune(path)
+	if len(in) == 0 || len(in) > len(orig) {
+		return 0
+	}
+	lower := make([]rune, len(orig))
+	for i, r := range orig {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// Forward: the end of the first complete subsequence
+	end, j := -1, 0
+	for i := 0; i < len(lower); i++ {
+		if lower[i] == in[j] {
+			j++
+			if j == len(in) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0
+	}
+
+	// Backward: the latest start that still matches before end
+	start := 0
+	j = len(in) - 1
+	for i := end; i >= 0; i-- {
+		if lower[i] == in[j] {
+			j--
+			if j < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	filenameStart := strings.LastIndex(path, "/") + 1
+	filenameStart = len([]rune(path[:filenameStart]))
+
+	score, prev := 0, -1
+	j = 0
+	for i := start; i <= end && j < len(in); i++ {
+		if lower[i] != in[j] {
+			continue
+		}
+		score += fuzzyMatch
+		switch {
+		case i == 0 || strings.ContainsRune("/_-. ", orig[i-1]):
+			score += fuzzyBoundary
+		case unicode.IsUpper(orig[i]) && unicode.IsLower(orig[i-1]):
+			score += fuzzyCamelCase
+		}
+		if prev >= 0 {
+			if prev == i-1 {
+				score += fuzzyConsecutive
+			} else {
+				score -= i - prev - 1 // Gap
+			}
+		}
+		if i >= filenameStart {
+			score += fuzzyFilename
+		}
+		prev = i
+		j++
+	}
+
+	// Map onto 1..fuzzyMaxScore; any match scores at least 1
+	best := len(in) * (fuzzyMatch + fuzzyBoundary + fuzzyConsecutive + fuzzyFilename)
+	if score < 0 {
+		score = 0
+	}
+	return min(1+score*(fuzzyMaxScore-1)/best, fuzzyMaxScore)
+}
+
+// normalizeSeparators converts Windows separators to forward slashes. On
+// Windows users may type either, so both are accepted; elsewhere a backslash
+// is a legal file name character and is left alone.
+func normalizeSeparators(p string) string {
+	if filepath.Separator == '\\' {
+		return strings.ReplaceAll(p, "\\", "/")
+	}
+	return p
+}
+
+// displayPath formats a result path for display, using forward slashes on
+// every platform so "path:line" stays unambiguous next to drive letters
+func displayPath(p string) string {
+	return normalizeSeparators(p)
 }
 
 func sortPathMatches(matches []PathEntry) {
@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestScorePathMatch(t *testing.T) {
+	type testCase struct {
+		input string
+		path  string
+		want  int
+	}
+	tests := []testCase{
+		{"internal/ui", filepath.Join("internal", "ui"), 2000},
+		{"internal/", filepath.Join("internal", "ui"), 1000},
+		{"model", filepath.Join("internal", "ui", "model.go"), 800},
+		{"ui", filepath.Join("internal", "ui", "model.go"), 500},
+		{"ter", filepath.Join("internal", "ui"), 100},
+		{"zzz", filepath.Join("internal", "ui"), 0},
+	}
+	if filepath.Separator == '\\' {
+		// Either separator may be typed on Windows
+		tests = append(tests,
+			testCase{`internal\ui`, `internal\ui`, 2000},
+			testCase{`Internal/UI\mod`, `internal\ui\model.go`, 1000},
+		)
+	}
+	for _, tt := range tests {
+		if got := scorePathMatch(tt.input, tt.path); got != tt.want {
+			t.Errorf("scorePathMatch(%q, %q) = %d, want %d", tt.input, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  string
+		match bool
+	}{
+		{"initials across elements", "iui", "internal/ui", true},
+		{"camel case humps", "mvc", "src/ModelViewController.java", true},
+		{"out of order", "uii", "internal/ui", false},
+		{"longer than path", "internal/ui/x", "internal/ui", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fuzzyScore(tt.input, tt.path)
+			if (got > 0) != tt.match {
+				t.Errorf("fuzzyScore(%q, %q) = %d, want match %v", tt.input, tt.path, got, tt.match)
+			}
+			if got >= 100 {
+				t.Errorf("fuzzyScore(%q, %q) = %d, want below the substring tier", tt.input, tt.path, got)
+			}
+		})
+	}
+}
+
+func TestFuzzyScore_Ranking(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		better, worse string
+	}{
+		{"boundaries beat scattered letters", "iui", "internal/ui", "pkg/iaudit"},
+		{"camel case beats mid-word", "mv", "ModelView.java", "lambvar.java"},
+		{"tight window beats long gap", "sch", "search/cache.go", "src/long/path/to/chart.go"},
+		{"filename beats directory", "cfg", "app/config.go", "cafe/fig/x.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, worse := fuzzyScore(tt.input, tt.better), fuzzyScore(tt.input, tt.worse)
+			if better <= worse {
+				t.Errorf("fuzzyScore(%q): %q = %d, %q = %d; want the first higher", tt.input, tt.better, better, tt.worse, worse)
+			}
+		})
+	}
+}
+
+func TestFilterPaths_FuzzyAfterSubstring(t *testing.T) {
+	p := NewPathProvider(".")
+	paths := []PathEntry{
+		{Path: "pkg/iaudit"},
+		{Path: "internal/ui", IsDir: true},
+		{Path: "cmd/guix"},
+		{Path: "docs"},
+	}
+
+	got := p.FilterPaths("ui", paths)
+	want := []string{"internal/ui", "cmd/guix", "pkg/iaudit"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d matches (%v), want %v", len(got), got, want)
+	}
+	for i, w := range want {
+		if got[i].Path != w {
+			t.Errorf("match %d = %q, want %q", i, got[i].Path, w)
+		}
+	}
+}
+
+func TestLoadPaths_HonorsIgnoreFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":          "build/\n*.log\n",
+		"main.go":             "",
+		"debug.log":           "",
+		"build/out.go":        "",
+		"web/.gitignore":      "dist\n",
+		"web/app.js":          "",
+		"web/dist/bundle.js":  "",
+		"node_modules/x/y.js": "",
+	})
+
+	var got []string
+	for _, entry := range NewPathProvider(root).LoadPaths() {
+		rel, _ := filepath.Rel(root, entry.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+
+	want := []string{"main.go", "web", "web/app.js"}
+	if len(got) != len(want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("paths = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestStream_SendsBatchesThenCaches(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < pathBatchSize+10; i++ {
+		files[fmt.Sprintf("pkg/f%03d.go", i)] = ""
+	}
+	p := NewPathProvider(writeTree(t, files))
+
+	batches, total := 0, 0
+	for batch := range p.Stream(context.Background()) {
+		batches++
+		total += len(batch)
+	}
+	if batches < 2 || total != pathBatchSize+11 {
+		t.Errorf("got %d paths in %d batches, want %d in at least 2", total, batches, pathBatchSize+11)
+	}
+
+	batches = 0
+	for range p.Stream(context.Background()) {
+		batches++
+	}
+	if batches != 1 {
+		t.Errorf("cached index sent in %d batches, want 1", batches)
+	}
+
+	p.Invalidate()
+	batches = 0
+	for range p.Stream(context.Background()) {
+		batches++
+	}
+	if batches < 2 {
+		t.Errorf("invalidated index sent in %d batches, want a fresh walk", batches)
+	}
+}
+
+func TestStream_StopsWhenCancelled(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 3*pathBatchSize; i++ {
+		files[fmt.Sprintf("d%02d/f%04d.go", i%26, i)] = ""
+	}
+	p := NewPathProvider(writeTree(t, files))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := p.Stream(ctx)
+	<-ch
+	cancel()
+	for range ch {
+	}
+
+	p.cacheMu.RLock()
+	defer p.cacheMu.RUnlock()
+	if p.cache != nil {
+		t.Error("cancelled walk filled the cache")
+	}
+}
+
+func TestConfigure_DepthAndSkip(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/b/c/d/e/f/deep.go": "",
+		"bazel-out/gen.go":    "",
+		"node_modules/x/y.js": "",
+		"src/main.go":         "",
+	})
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		skip     []string
+		want     []string // Paths that must be present
+		absent   []string
+	}{
+		{"defaults", 0, nil, []string{"src/main.go", "bazel-out/gen.go"}, []string{"a/b/c/d/e/f", "node_modules"}},
+		{"deeper", 8, nil, []string{"a/b/c/d/e/f/deep.go"}, []string{"node_modules"}},
+		{"custom skip", 0, []string{"bazel-*"}, []string{"node_modules/x/y.js"}, []string{"bazel-out"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPathProvider(root)
+			p.Configure(tt.maxDepth, tt.skip)
+
+			got := map[string]bool{}
+			for _, entry := range p.LoadPaths() {
+				rel, _ := filepath.Rel(root, entry.Path)
+				got[filepath.ToSlash(rel)] = true
+			}
+			for _, path := range tt.want {
+				if !got[path] {
+					t.Errorf("%s missing from the index", path)
+				}
+			}
+			for _, path := range tt.absent {
+				if got[path] {
+					t.Errorf("%s should not be indexed", path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The benchmarks below drive the Model the way Bubble Tea does: a message goes
+// through Update and the resulting model is rendered with View. Search output is
+// faked by feeding searchResultMsg batches directly, so no rg process is spawned
+// and the numbers reflect UI cost only. Run with:
+//
+//	go test -run '^$' -bench . -benchmem ./internal/ui
+
+const (
+	benchWidth     = 200
+	benchHeight    = 50
+	benchBatchSize = 100
+)
+
+func newBenchModel(b *testing.B) Model {
+	b.Helper()
+	m := NewModel()
+	b.Cleanup(func() { m.Close() })
+	return drive(m, tea.WindowSizeMsg{Width: benchWidth, Height: benchHeight})
+}
+
+// drive feeds msgs through Update and renders after each one, discarding commands
+func drive(m Model, msgs ...tea.Msg) Model {
+	for _, msg := range msgs {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+		_ = m.View()
+	}
+	return m
+}
+
+func seedResults(m Model, n int) Model {
+	for i := 0; i < n; i += benchBatchSize {
+		m = drive(m, searchResultMsg{matches: testMatches(i, benchBatchSize)})
+	}
+	return drive(m, searchResultMsg{done: true})
+}
+
+func BenchmarkKeystrokeToRender(b *testing.B) {
+	m := seedResults(newBenchModel(b), 1000)
+	typeKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%2 == 0 {
+			m = drive(m, typeKey)
+		} else {
+			m = drive(m, backspace)
+		}
+	}
+}
+
+func BenchmarkStreamingBatchToRender(b *testing.B) {
+	m := newBenchModel(b)
+	batches := make([]searchResultMsg, maxResults/benchBatchSize)
+	for i := range batches {
+		batches[i] = searchResultMsg{matches: testMatches(i*benchBatchSize, benchBatchSize)}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := i % len(batches)
+		if batch == 0 {
+			m.results.Reset()
+			m.list.cache.invalidate()
+		}
+		m = drive(m, batches[batch])
+	}
+}
+
+func BenchmarkNavigationToRender(b *testing.B) {
+	m := seedResults(newBenchModel(b), maxResults)
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	up := tea.KeyMsg{Type: tea.KeyUp}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Walk down and back up so the selection stays inside the result set
+		if (i/500)%2 == 0 {
+			m = drive(m, down)
+		} else {
+			m = drive(m, up)
+		}
+	}
+}
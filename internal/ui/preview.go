@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/classify"
+	"github.com/William9923/irg/internal/highlight"
+	"github.com/William9923/irg/internal/scope"
+	"github.com/William9923/irg/internal/search"
+)
+
+// ContextRadius is the number of context lines previewed around matches in
+// files matching Globs, which follow the class globs: a file name pattern,
+// or a trailing path pattern when they contain a slash
+type ContextRadius struct {
+	Globs []string
+	Lines int
+}
+
+// SetPreviewContext previews lines of context around a match, or the Lines
+// of the first of radii with a glob matching its file. lines of 0 keeps the
+// default of 5.
+func (m *Model) SetPreviewContext(lines int, radii []ContextRadius) {
+	m.previewRadius = previewContext
+	if lines > 0 {
+		m.previewRadius = lines
+	}
+	m.previewRadii = radii
+}
+
+// previewRadiusFor returns the context lines to preview around a match in
+// path
+func (m *Model) previewRadiusFor(path string) int {
+	p := strings.TrimPrefix(strings.ReplaceAll(path, "\\", "/"), "./")
+	for _, radius := range m.previewRadii {
+		for _, glob := range radius.Globs {
+			if classify.Match(glob, p) {
+				return radius.Lines
+			}
+		}
+	}
+	return m.previewRadius
+}
+
+// previewPane shows the lines around the selected result, or around a
+// definition or reference, with the match highlighted. It also holds the
+// line selection of copy mode.
+type previewPane struct {
+	view    viewport.Model
+	token   int // Tells the latest load from earlier ones still in flight
+	path    string
+	note    string // Shown after the path, e.g. "definition of Foo"
+	scope   string // Definition enclosing the previewed match, e.g. "func main"
+	lines   []string
+	start   int                 // Line number of lines[0]
+	match   int                 // Line number where the match starts
+	spans   [][]search.Submatch // Submatches of each line of the match
+	xOffset int                 // Cells the text is scrolled right
+	copy    copySelection       // Line selection of copy mode
+}
+
+// previewDecor is what the preview pane takes from the rest of the UI to
+// render its lines
+type previewDecor struct {
+	highlighter *highlight.Highlighter
+	colors      Colors
+	header      string // Styled notes shown after the path, such as lint findings
+	display     func(text string, submatches []search.Submatch) (string, []search.Submatch)
+}
+
+// Update shows a loaded preview, scrolled to its start and sideways to the
+// match. It reports false for a load the selection has moved on from, even
+// one of the same path.
+func (p *previewPane) Update(msg previewLoadedMsg) bool {
+	if msg.token != p.token {
+		return false
+	}
+	p.copy = copySelection{}
+	p.view.SetYOffset(0)
+	p.path = msg.path
+	p.note = msg.note
+	p.scope = msg.scope
+	p.lines = msg.lines
+	p.start = msg.startLine
+	p.match = msg.matchLine
+	p.spans = msg.spans
+	p.xOffset = 0
+	if i := p.match - p.start; i >= 0 && i < len(p.lines) {
+		submatches, _ := p.submatches(p.match)
+		p.xOffset = matchOffset(p.lines[i], submatches, p.codeWidth())
+	}
+	return true
+}
+
+// clear empties the pane and invalidates loads in flight
+func (p *previewPane) clear() {
+	p.token++
+	p.path = ""
+	p.note = ""
+	p.scope = ""
+	p.lines = nil
+	p.spans = nil
+}
+
+// submatches returns the submatches on line lineNum, and whether it is a
+// line of the previewed match
+func (p *previewPane) submatches(lineNum int) ([]search.Submatch, bool) {
+	i := lineNum - p.match
+	if i < 0 || i >= len(p.spans) {
+		return nil, false
+	}
+	return p.spans[i], true
+}
+
+// codeWidth returns the cells available for text beside the gutter
+func (p *previewPane) codeWidth() int {
+	return p.view.Width - previewGutter
+}
+
+// View renders the visible part of the pane
+func (p previewPane) View() string {
+	return p.view.View()
+}
+
+// updatePreviewLoaded shows the context loaded for the selection, unless the
+// selection has moved on since
+func (m *Model) updatePreviewLoaded(msg previewLoadedMsg) {
+	if !m.preview.Update(msg) {
+		return
+	}
+	if msg.gone {
+		m.reportGone(msg.path)
+	}
+	m.updatePreviewView()
+}
+
+// loadPreview loads the context around the selected result into the preview
+func (m *Model) loadPreview() tea.Cmd {
+	match, ok := m.selectedMatch()
+	if !ok {
+		return nil
+	}
+	return m.loadPreviewSpan(match.Path, match.LineNumber, match.SubmatchesByLine(), "")
+}
+
+// loadPreviewAt loads the context around path:line into the preview pane.
+// Any response still in flight for an earlier request is discarded.
+func (m *Model) loadPreviewAt(path string, line int, submatches []search.Submatch, note string) tea.Cmd {
+	return m.loadPreviewSpan(path, line, [][]search.Submatch{submatches}, note)
+}
+
+// loadPreviewSpan loads the context around a match starting at path:line
+// into the preview pane. spans holds the submatches of each of its lines, one
+// per line it covers.
+func (m *Model) loadPreviewSpan(path string, line int, spans [][]search.Submatch, note string) tea.Cmd {
+	m.preview.token++
+	token := m.preview.token
+	cache := m.previewCache
+	provider, remote := m.searcher.(contextProvider)
+	collector := m.metrics
+	radius := m.previewRadiusFor(path)
+
+	return func() tea.Msg {
+		start := time.Now()
+		var ctx *search.FileContext
+		var err error
+		if remote {
+			ctx, err = provider.FileContext(context.Background(), path, line, radius, spans[0])
+		} else {
+			ctx, err = cache.GetFileContextSpan(path, line, line+len(spans)-1, radius, spans[0])
+		}
+		collector.Since("preview.load", start)
+		if !remote && isGone(err) {
+			return previewLoadedMsg{token: token, path: path, note: note, lines: goneLines, startLine: 1, gone: true}
+		}
+		if err != nil {
+			return previewLoadedMsg{token: token, path: path, note: note, lines: []string{"Error loading preview: " + err.Error()}, startLine: 1, matchLine: 1, spans: [][]search.Submatch{nil}}
+		}
+
+		var enclosing string
+		if !remote && scope.Supported(path) {
+			if above, err := cache.GetLines(path, line-scope.Lookback, line); err == nil {
+				enclosing = scope.Enclosing(path, above)
+			}
+		}
+
+		return previewLoadedMsg{
+			token:     token,
+			path:      path,
+			note:      note,
+			scope:     enclosing,
+			lines:     ctx.Lines,
+			startLine: ctx.StartLine,
+			matchLine: ctx.MatchLine,
+			spans:     append([][]search.Submatch{ctx.Submatches}, spans[1:]...),
+		}
+	}
+}
+
+// updatePreviewView renders the loaded context into the preview pane
+func (m *Model) updatePreviewView() {
+	if m.replaceState == replaceConfirm {
+		m.renderReplaceDiff()
+		return
+	}
+	var header string
+	if note := m.notes[m.list.selected]; note != "" && m.preview.path != "" {
+		header += lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Render(" ✎ " + note)
+	}
+	if info := m.lintInfo(); info != "" {
+		header += lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Render(info)
+	}
+	m.preview.render(previewDecor{highlighter: m.highlighter, colors: m.colors, header: header, display: m.displayLine})
+}
+
+// render renders the loaded lines into the pane's viewport
+func (p *previewPane) render(d previewDecor) {
+	if len(p.lines) == 0 {
+		p.view.SetContent("No preview available")
+		return
+	}
+
+	var sb strings.Builder
+	normalLineNumStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Width(4)
+	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	matchLineNumStyle := lipgloss.NewStyle().Background(lipgloss.Color(d.colors.PreviewMatchBackground)).Foreground(lipgloss.Color("0")).Bold(true).Width(4)
+	matchTextHighlightStyle := lipgloss.NewStyle().Background(lipgloss.Color(d.colors.PreviewMatchBackground)).Foreground(lipgloss.Color(d.colors.PreviewMatchForeground)).Bold(true)
+
+	sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true).Render(displayPath(p.path)))
+	if p.scope != "" {
+		sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Render(" in " + p.scope))
+	}
+	if p.note != "" {
+		sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(" (" + p.note + ")"))
+	}
+	if p.xOffset > 0 {
+		sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(fmt.Sprintf(" [from col %d]", p.xOffset+1)))
+	}
+	sb.WriteString(d.header)
+	sb.WriteString("\n")
+	sb.WriteString(separatorStyle.Render(strings.Repeat("─", p.view.Width-2)))
+	sb.WriteString("\n")
+
+	codeWidth := p.codeWidth()
+	highlighted := d.highlighter.IsEnabled() && d.highlighter.IsSupported(p.path)
+	copySelectedStyle := lipgloss.NewStyle().Reverse(true)
+	for i, line := range p.lines {
+		lineNum := p.start + i
+		if p.copy.selected(i) {
+			gutter := fmt.Sprintf("%4d", lineNum)
+			if i == p.copy.cursor {
+				gutter = fmt.Sprintf(">%3d", lineNum)
+			}
+			sb.WriteString(copySelectedStyle.Render(gutter) + " " + copySelectedStyle.Render(clipLine(line, p.xOffset, codeWidth)))
+			sb.WriteString("\n")
+			continue
+		}
+
+		submatches, matched := p.submatches(lineNum)
+		line, submatches = d.display(line, submatches)
+		processedLine := line
+		if highlighted {
+			processedLine = d.highlighter.Highlight(line, p.path)
+		}
+
+		if matched {
+			styledLineNum := matchLineNumStyle.Render(fmt.Sprintf("%4d", lineNum))
+
+			var highlightedLine string
+			if highlighted {
+				// For syntax-highlighted lines, just use a subtle background for the entire line
+				// instead of trying to highlight specific matches within colored text
+				highlightedLine = lipgloss.NewStyle().Background(lipgloss.Color("236")).Render(processedLine)
+			} else {
+				// For plain text, use the existing match highlighting
+				highlightedLine = highlightMatches(processedLine, submatches, matchTextHighlightStyle)
+			}
+
+			sb.WriteString(styledLineNum + " " + clipLine(highlightedLine, p.xOffset, codeWidth))
+			// Carets mark where a match spanning several lines starts
+			if carets := caretRow(line, submatches, p.xOffset, codeWidth); carets != "" && !p.copy.active && lineNum == p.match {
+				sb.WriteString("\n" + carets)
+			}
+		} else {
+			normalLineNum := normalLineNumStyle.Render(fmt.Sprintf("%4d", lineNum))
+			sb.WriteString(normalLineNum + " " + clipLine(processedLine, p.xOffset, codeWidth))
+		}
+		sb.WriteString("\n")
+	}
+
+	p.view.SetContent(sb.String())
+	if p.copy.active {
+		// Keep the cursor line in view
+		row := previewHeaderRows + p.copy.cursor
+		if row < p.view.YOffset {
+			p.view.SetYOffset(row)
+		} else if row >= p.view.YOffset+p.view.Height {
+			p.view.SetYOffset(row - p.view.Height + 1)
+		}
+	}
+}
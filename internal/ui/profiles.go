@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Profile is a named set of file types to search and globs of files and
+// directories to leave out, curated for a language ecosystem
+type Profile struct {
+	Name    string
+	Types   []string
+	Exclude []string
+}
+
+// builtinProfiles are offered unless the config replaces them by name
+var builtinProfiles = []Profile{
+	{Name: "go", Types: []string{"go"}, Exclude: []string{"vendor", "testdata"}},
+	{Name: "java", Types: []string{"java", "kotlin"}, Exclude: []string{"target", "build", ".gradle"}},
+	{Name: "node", Types: []string{"js", "ts"}, Exclude: []string{"node_modules", "dist", "build", "coverage", "*.min.js"}},
+	{Name: "python", Types: []string{"py"}, Exclude: []string{".venv", "venv", "__pycache__", ".tox", "build", "dist"}},
+	{Name: "ruby", Types: []string{"ruby"}, Exclude: []string{"vendor", "tmp", "log"}},
+	{Name: "rust", Types: []string{"rust"}, Exclude: []string{"target"}},
+}
+
+// profileMarkers are the files that give a directory's ecosystem away, in
+// the order they are looked for
+var profileMarkers = []struct {
+	file    string
+	profile string
+}{
+	{"go.mod", "go"},
+	{"Cargo.toml", "rust"},
+	{"package.json", "node"},
+	{"pyproject.toml", "python"},
+	{"setup.py", "python"},
+	{"requirements.txt", "python"},
+	{"pom.xml", "java"},
+	{"build.gradle", "java"},
+	{"build.gradle.kts", "java"},
+	{"Gemfile", "ruby"},
+}
+
+// SetProfiles offers custom profiles next to the built-in ones, replacing
+// those of the same name
+func (m *Model) SetProfiles(custom []Profile) {
+	profiles := slices.Clone(builtinProfiles)
+	for _, p := range custom {
+		if i := slices.IndexFunc(profiles, func(b Profile) bool { return b.Name == p.Name }); i >= 0 {
+			profiles[i] = p
+		} else {
+			profiles = append(profiles, p)
+		}
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+	m.profiles = profiles
+}
+
+// SetProfile applies the profile name to the first search; "auto" picks the
+// one detected in the search path, if any. Types given with --type win over
+// the profile's.
+func (m *Model) SetProfile(name string) error {
+	if name == "auto" {
+		name = m.detectProfile(m.currentDir())
+		if name == "" {
+			return nil
+		}
+	}
+	p := m.findProfile(name)
+	if p == nil {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	if len(m.fileTypes) == 0 {
+		m.setTypes(p.Types)
+	}
+	m.profile = p.Name
+	m.profileExcludes = p.Exclude
+	return nil
+}
+
+// findProfile returns the profile called name, or nil
+func (m *Model) findProfile(name string) *Profile {
+	if i := slices.IndexFunc(m.profiles, func(p Profile) bool { return p.Name == name }); i >= 0 {
+		return &m.profiles[i]
+	}
+	return nil
+}
+
+// detectProfile names the profile of the ecosystem dir belongs to, judged
+// by its marker files, or returns "" when none is recognized
+func (m *Model) detectProfile(dir string) string {
+	for _, marker := range profileMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker.file)); err == nil && m.findProfile(marker.profile) != nil {
+			return marker.profile
+		}
+	}
+	return ""
+}
+
+// openProfiles shows the profile picker with the active profile selected,
+// or else the one detected in the search path. The first entry turns the
+// profile off.
+func (m *Model) openProfiles() {
+	if m.remote {
+		m.status.err = "Profiles don't apply to remote searches"
+		return
+	}
+	m.profilesVisible = true
+	m.profileDetected = m.detectProfile(m.currentDir())
+	selected := m.profile
+	if selected == "" {
+		selected = m.profileDetected
+	}
+	m.profileIndex = 0
+	for i, p := range m.profiles {
+		if p.Name == selected {
+			m.profileIndex = i + 1
+		}
+	}
+}
+
+// updateProfiles handles key presses while the profile picker is shown
+func (m Model) updateProfiles(a action) (tea.Model, tea.Cmd) {
+	entries := len(m.profiles) + 1
+	switch a {
+	case actionUp:
+		m.profileIndex = (m.profileIndex + entries - 1) % entries
+	case actionDown:
+		m.profileIndex = (m.profileIndex + 1) % entries
+	case actionOpenEditor:
+		m.profilesVisible = false
+		if m.profileIndex == 0 {
+			return m, m.applyProfile(nil)
+		}
+		return m, m.applyProfile(&m.profiles[m.profileIndex-1])
+	case actionClose, actionProfiles, actionQuit:
+		m.profilesVisible = false
+	}
+	return m, nil
+}
+
+// applyProfile puts p's types in the types input, leaves its globs out of
+// searches and searches again. A nil p turns the active profile off,
+// clearing the types it set unless they were edited since.
+func (m *Model) applyProfile(p *Profile) tea.Cmd {
+	if p == nil {
+		if active := m.findProfile(m.profile); active != nil && slices.Equal(m.fileTypes, active.Types) {
+			m.setTypes(nil)
+		}
+		m.profile, m.profileExcludes = "", nil
+		m.status.message = "Profile off"
+	} else {
+		m.setTypes(p.Types)
+		m.profile, m.profileExcludes = p.Name, p.Exclude
+		m.status.message = "Searching with the " + p.Name + " profile"
+	}
+	pattern := m.inputs.pattern.Value()
+	if pattern == "" {
+		return nil
+	}
+	return m.executeSearch(pattern, m.inputs.path.Value())
+}
+
+// setTypes puts types in the types input as if typed there
+func (m *Model) setTypes(types []string) {
+	m.inputs.types.SetValue(strings.Join(types, ","))
+	m.inputs.types.CursorEnd()
+	m.fileTypes = types
+	m.lastFileTypes = types
+}
+
+// profileInfo names the active profile for the status line
+func (m *Model) profileInfo() string {
+	if m.profile == "" {
+		return ""
+	}
+	return " [profile " + m.profile + "]"
+}
+
+// label describes p in the profile picker
+func (p Profile) label() string {
+	var parts []string
+	if len(p.Types) > 0 {
+		parts = append(parts, "types "+strings.Join(p.Types, ","))
+	}
+	if len(p.Exclude) > 0 {
+		parts = append(parts, "excluding "+strings.Join(p.Exclude, ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// renderProfiles renders the profile picker, at most width cells wide and
+// height rows tall
+func (m *Model) renderProfiles(width, height int) string {
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	nameWidth := ansi.StringWidth("None")
+	for _, p := range m.profiles {
+		nameWidth = max(nameWidth, ansi.StringWidth(p.Name))
+	}
+	labels := []string{"None" + strings.Repeat(" ", nameWidth-4) + "  all files"}
+	for _, p := range m.profiles {
+		label := p.Name + strings.Repeat(" ", nameWidth-ansi.StringWidth(p.Name)) + "  " + p.label()
+		if p.Name == m.profileDetected {
+			label += " (detected)"
+		}
+		labels = append(labels, label)
+	}
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Search profiles"), ""}
+	for i, label := range labels {
+		switch {
+		case i == m.profileIndex:
+			lines = append(lines, selectedStyle.Render("> "+label))
+		case i > 0 && m.profiles[i-1].Name == m.profile:
+			lines = append(lines, "* "+label)
+		default:
+			lines = append(lines, "  "+label)
+		}
+	}
+	lines = append(lines, "", hintStyle.Render("↑/↓ select  Enter apply and search again  Esc close"))
+
+	// Border and padding take two rows and four columns
+	textWidth := max(width-4, 10)
+	if maxLines := max(height-2, 1); len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, textWidth, "…")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestProfiles_PickerAppliesDetectedProfile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	searcher := search.NewMockSearcher(testMatches(0, 1)...)
+	m.searcher = searcher
+	m.inputs.path.SetValue(dir)
+	m.inputs.pattern.SetValue("useState")
+	m.lastPattern = "useState"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}, Alt: true})
+	m = updated.(Model)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "> node") || !strings.Contains(view, "(detected)") {
+		t.Fatalf("picker doesn't preselect the detected node profile:\n%s", view)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runSearch(t, updated.(Model), cmd)
+	if m.profilesVisible || m.inputs.types.Value() != "js,ts" {
+		t.Errorf("types = %q, picker open = %v; want the node types filled in", m.inputs.types.Value(), m.profilesVisible)
+	}
+	calls := searcher.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("%d searches, want 1", len(calls))
+	}
+	opts := calls[0].Opts
+	if !reflect.DeepEqual(opts.FileTypes, []string{"js", "ts"}) || !reflect.DeepEqual(opts.ExcludeGlobs, builtinProfiles[2].Exclude) {
+		t.Errorf("searched types %q excluding %q, want the node profile's", opts.FileTypes, opts.ExcludeGlobs)
+	}
+	if !strings.Contains(m.statusLine(), "[profile node]") {
+		t.Errorf("status = %q, want the profile named", m.statusLine())
+	}
+
+	// None turns the profile off along with the types it set
+	m.openProfiles()
+	m.profileIndex = 0
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = runSearch(t, updated.(Model), cmd)
+	if last := searcher.Calls()[1].Opts; m.profile != "" || m.inputs.types.Value() != "" || last.FileTypes != nil || last.ExcludeGlobs != nil {
+		t.Errorf("profile %q, types %q and %q excluded after turning it off", m.profile, m.inputs.types.Value(), last.ExcludeGlobs)
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	m := newTestModel(t)
+	m.SetProfiles([]Profile{
+		{Name: "go", Types: []string{"go", "proto"}, Exclude: []string{"third_party"}},
+		{Name: "docs", Types: []string{"md"}},
+	})
+	if names := len(m.profiles); names != len(builtinProfiles)+1 {
+		t.Fatalf("%d profiles, want the built-ins plus docs", names)
+	}
+
+	if err := m.SetProfile("go"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.fileTypes, []string{"go", "proto"}) || !reflect.DeepEqual(m.searchOptions().ExcludeGlobs, []string{"third_party"}) {
+		t.Errorf("types %q excluding %q, want the configured go profile to replace the built-in", m.fileTypes, m.searchOptions().ExcludeGlobs)
+	}
+
+	// Types from --type win over the profile's
+	m = newTestModel(t)
+	m.SetFileTypes([]string{"c"}, nil)
+	if err := m.SetProfile("rust"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.fileTypes, []string{"c"}) || m.profile != "rust" {
+		t.Errorf("types %q with profile %q, want c searched with the rust exclusions", m.fileTypes, m.profile)
+	}
+
+	if err := m.SetProfile("cobol"); err == nil || !strings.Contains(err.Error(), "cobol") {
+		t.Errorf("err = %v, want the unknown profile reported", err)
+	}
+
+	// auto without marker files leaves the search alone
+	m = newTestModel(t)
+	m.inputs.path.SetValue(t.TempDir())
+	if err := m.SetProfile("auto"); err != nil || m.profile != "" {
+		t.Errorf("auto in an empty directory chose %q (err %v), want no profile", m.profile, err)
+	}
+}
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// refreshedMsg carries the matches of the files that changed while the
+// editor or a replacement ran, searched again
+type refreshedMsg struct {
+	ctx     context.Context // Search the results belong to
+	changed []string
+	gone    []string // Files renamed or deleted since
+	matches []search.Match
+	status  string // Shown before the refresh summary
+	err     error
+}
+
+// stampResults records the size and modification time of every file in the
+// results, so the files changed later can be searched again on their own
+func (m *Model) stampResults() {
+	m.resultStamps = nil
+	if !m.refreshable() {
+		return
+	}
+	matches, err := m.results.Slice(0, m.results.Len())
+	if err != nil {
+		return
+	}
+	m.resultStamps = make(map[string]fileInfo)
+	for _, match := range matches {
+		if _, ok := m.resultStamps[match.Path]; !ok {
+			m.resultStamps[match.Path] = statFile(match.Path)
+		}
+	}
+}
+
+// refreshable reports whether the results are those of a finished local
+// search, which a search of some of their files can update
+func (m *Model) refreshable() bool {
+	return m.resultsDone && !m.searching && !m.remote && m.compare == nil && m.resultsPattern != ""
+}
+
+// refreshChanged searches the files stamped by stampResults that have since
+// changed again, to merge their new matches into the results; files that no
+// longer exist lose theirs. status is shown with the outcome. It reports
+// false if the results weren't stamped or have been replaced since.
+func (m *Model) refreshChanged(status string) (tea.Cmd, bool) {
+	stamps := m.resultStamps
+	m.resultStamps = nil
+	if stamps == nil || !m.refreshable() {
+		return nil, false
+	}
+	var changed, gone []string
+	for path, stamp := range stamps {
+		now := statFile(path)
+		switch {
+		case !now.ok:
+			gone = append(gone, path)
+		case now.size != stamp.size || !now.modTime.Equal(stamp.modTime):
+			changed = append(changed, path)
+		default:
+			continue
+		}
+		m.previewCache.Invalidate(path)
+		delete(m.fileInfos, path)
+		delete(m.lints, path)
+	}
+	if len(changed) == 0 && len(gone) == 0 {
+		if status != "" {
+			m.status.message = status
+		}
+		return nil, true
+	}
+	slices.Sort(changed)
+	slices.Sort(gone)
+
+	ctx, searcher := m.searchCtx, m.searcher
+	pattern, path := m.resultsPattern, m.resultsPath
+	opts := m.searchOptions()
+	opts.FixedStrings = pattern == m.literalPattern
+	opts.Roots = changed
+	opts.Shards = 0
+	return func() tea.Msg {
+		msg := refreshedMsg{ctx: ctx, changed: changed, gone: gone, status: status}
+		if len(changed) == 0 {
+			return msg
+		}
+		results := make(chan search.Match, 100)
+		if msg.err = searcher.Search(ctx, pattern, path, opts, results); msg.err != nil {
+			return msg
+		}
+		for match := range results {
+			msg.matches = append(msg.matches, match)
+		}
+		if ctx.Err() != nil {
+			msg.err = ctx.Err()
+		}
+		return msg
+	}, true
+}
+
+// updateRefreshed merges the matches of the changed files into the results
+// in place of their old ones, keeping marks and notes on the results of the
+// other files
+func (m *Model) updateRefreshed(msg refreshedMsg) tea.Cmd {
+	if msg.ctx != m.searchCtx || !m.refreshable() {
+		return nil
+	}
+	if msg.err != nil {
+		m.status.err = msg.err.Error()
+		return nil
+	}
+	old, err := m.results.Slice(0, m.results.Len())
+	if err != nil {
+		m.status.err = err.Error()
+		return nil
+	}
+	fresh := make(map[string][]search.Match, len(msg.changed))
+	for _, match := range m.visibleMatches(m.caseFold.dedupe(msg.matches)) {
+		fresh[match.Path] = append(fresh[match.Path], match)
+	}
+	replaced := make(map[string]bool, len(msg.changed)+len(msg.gone))
+	for _, path := range append(slices.Clone(msg.changed), msg.gone...) {
+		replaced[path] = true
+	}
+
+	merged := make([]search.Match, 0, len(old))
+	moved := make(map[int]int, len(old)) // Old index to new, for kept results
+	selected := -1
+	for i, match := range old {
+		if !replaced[match.Path] {
+			moved[i] = len(merged)
+			merged = append(merged, match)
+			continue
+		}
+		if i == m.list.selected {
+			selected = len(merged)
+		}
+		// The file's new matches go where its first old one was
+		merged = append(merged, fresh[match.Path]...)
+		delete(fresh, match.Path)
+	}
+	if len(merged) > maxResults {
+		merged = merged[:maxResults]
+	}
+
+	m.cancelCompare()
+	m.results.Reset()
+	if err := m.results.Append(merged...); err != nil {
+		m.status.err = err.Error()
+	}
+	marked := make(map[int]bool, len(m.marked))
+	for i := range m.marked {
+		if k, ok := moved[i]; ok {
+			marked[k] = true
+		}
+	}
+	notes := make(map[int]string, len(m.notes))
+	for i, note := range m.notes {
+		if k, ok := moved[i]; ok {
+			notes[k] = note
+		}
+	}
+	m.marked, m.notes = marked, notes
+	if k, ok := moved[m.list.selected]; ok {
+		selected = k
+	}
+	m.list.selected = clamp(selected, 0, max(len(merged)-1, 0))
+	m.recentOrder = nil
+	if m.sortRecent {
+		m.sortByRecency()
+	}
+	m.matchCount = m.results.Len()
+	m.list.cache.invalidate()
+	m.summary.reset(m.summary.root)
+	m.refreshSummary()
+	m.typeCounts.reset()
+	m.refreshTypeCounts()
+
+	var parts []string
+	if msg.status != "" {
+		parts = append(parts, msg.status)
+	} else if len(msg.changed) > 0 {
+		parts = append(parts, fmt.Sprintf("Updated %d changed files", len(msg.changed)))
+	}
+	if len(msg.gone) > 0 {
+		parts = append(parts, fmt.Sprintf("%d renamed or deleted files dropped", len(msg.gone)))
+	}
+	m.status.message = strings.Join(parts, "; ")
+	m.preview.clear()
+	m.updateResultsView()
+	m.updatePreviewView()
+	return m.loadPreview()
+}
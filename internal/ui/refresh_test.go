@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestRefresh_SearchesOnlyChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go"), filepath.Join(dir, "c.go")
+	for _, path := range []string{a, b, c} {
+		if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	searcher := search.NewMockSearcher(
+		search.Match{Path: a, LineNumber: 1, LineText: "needle\n"},
+		search.Match{Path: b, LineNumber: 1, LineText: "needle\n"},
+		search.Match{Path: b, LineNumber: 2, LineText: "needle\n"},
+		search.Match{Path: c, LineNumber: 1, LineText: "needle\n"},
+	)
+	m := newTestModel(t)
+	m.searcher = searcher
+	m.lastPattern = "needle"
+	m = runSearch(t, m, m.executeSearch("needle", dir))
+	m.marked = map[int]bool{0: true, 1: true}
+	m.list.selected = 3
+
+	m.stampResults()
+	// The editor rewrites b and renames c away
+	if err := os.WriteFile(b, []byte("\n\n\n\nneedle again\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(c, filepath.Join(dir, "d.go")); err != nil {
+		t.Fatal(err)
+	}
+	searcher.Matches = []search.Match{{Path: b, LineNumber: 5, LineText: "needle again\n"}}
+
+	updated, cmd := m.Update(editorFinishedMsg{})
+	m = updated.(Model)
+	msg, ok := refreshOf(cmd)
+	if !ok {
+		t.Fatal("returning from the editor didn't search the changed files")
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(Model)
+
+	calls := searcher.Calls()
+	if last := calls[len(calls)-1]; !reflect.DeepEqual(last.Opts.Roots, []string{b}) || last.Path != dir || last.Pattern != "needle" {
+		t.Errorf("refresh searched %q in %q for %q, want only the changed file", last.Opts.Roots, last.Path, last.Pattern)
+	}
+	got, err := m.results.Slice(0, m.results.Len())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []search.Match{
+		{Path: a, LineNumber: 1, LineText: "needle\n"},
+		{Path: b, LineNumber: 5, LineText: "needle again\n"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %+v, want %+v", got, want)
+	}
+	// The mark on a stays; b's results were replaced
+	if !reflect.DeepEqual(m.marked, map[int]bool{0: true}) || m.list.selected != 1 {
+		t.Errorf("marked = %v, selected = %d; want a marked and the selection on the result after c's", m.marked, m.list.selected)
+	}
+	if !strings.Contains(m.status.message, "1 renamed or deleted files dropped") {
+		t.Errorf("status = %q, want the renamed file reported", m.status.message)
+	}
+}
+
+func TestRefresh_UnchangedFilesSearchNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte("needle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	searcher := search.NewMockSearcher(search.Match{Path: path, LineNumber: 1, LineText: "needle\n"})
+	m := newTestModel(t)
+	m.searcher = searcher
+	m.lastPattern = "needle"
+	m = runSearch(t, m, m.executeSearch("needle", "."))
+
+	m.stampResults()
+	_, cmd := m.Update(editorFinishedMsg{})
+	if _, ok := refreshOf(cmd); ok || len(searcher.Calls()) != 1 {
+		t.Errorf("an editor session that changed nothing searched again (%d searches)", len(searcher.Calls()))
+	}
+}
+
+// refreshOf runs the commands of cmd, the response to returning from the
+// editor, and returns the refresh among their messages
+func refreshOf(cmd tea.Cmd) (refreshedMsg, bool) {
+	if cmd == nil {
+		return refreshedMsg{}, false
+	}
+	msgs := []tea.Msg{cmd()}
+	if batch, ok := msgs[0].(tea.BatchMsg); ok {
+		msgs = msgs[:0]
+		for _, c := range batch {
+			msgs = append(msgs, c())
+		}
+	}
+	for _, msg := range msgs {
+		if refreshed, ok := msg.(refreshedMsg); ok {
+			return refreshed, true
+		}
+	}
+	return refreshedMsg{}, false
+}
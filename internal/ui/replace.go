@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/replace"
+)
+
+// replaceState tracks the replace flow: prompt for the replacement, preview
+// the diff, then confirm
+type replaceState int
+
+const (
+	replaceOff replaceState = iota
+	replacePrompt
+	replacePreviewing
+	replaceConfirm
+	replaceApplying
+)
+
+type replacePreviewMsg struct {
+	diff string
+	err  error
+}
+
+type replaceAppliedMsg struct {
+	files int
+	err   error
+}
+
+func newReplaceInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Replacement..."
+	ti.CharLimit = 256
+	ti.Width = 30
+	return ti
+}
+
+// startReplace opens the replacement prompt for the marked results, or all
+// results when none are marked
+func (m *Model) startReplace() tea.Cmd {
+	if m.remote {
+		m.status.err = "Replace only works on local files"
+		return nil
+	}
+	files, err := m.replaceTargets()
+	if err != nil {
+		m.status.err = err.Error()
+		return nil
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	m.replaceFiles = files
+	m.replaceState = replacePrompt
+	m.replaceInput.SetValue("")
+	m.inputs.pattern.Blur()
+	m.inputs.path.Blur()
+	m.inputs.types.Blur()
+	return m.replaceInput.Focus()
+}
+
+// replaceTargets returns the distinct files of the marked results, or of
+// every result when nothing is marked
+func (m *Model) replaceTargets() ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(i int) error {
+		match, err := m.results.Get(i)
+		if err != nil {
+			return err
+		}
+		if !seen[match.Path] {
+			seen[match.Path] = true
+			files = append(files, match.Path)
+		}
+		return nil
+	}
+
+	if len(m.marked) > 0 {
+		for i := range m.marked {
+			if err := add(i); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for i := 0; i < m.results.Len(); i++ {
+			if err := add(i); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// updateReplace handles key presses while the replace flow is active
+func (m Model) updateReplace(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.replaceState {
+	case replacePrompt:
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			m.endReplace()
+			return m, nil
+		case tea.KeyEnter:
+			m.replaceState = replacePreviewing
+			m.status.message = "Previewing replacement..."
+			tool, files := m.replaceTool, m.replaceFiles
+			pattern, replacement := m.lastPattern, m.replaceInput.Value()
+			return m, func() tea.Msg {
+				diff, err := tool.Preview(context.Background(), files, pattern, replacement)
+				return replacePreviewMsg{diff: diff, err: err}
+			}
+		}
+		var cmd tea.Cmd
+		m.replaceInput, cmd = m.replaceInput.Update(msg)
+		return m, cmd
+
+	case replaceConfirm:
+		switch msg.String() {
+		case "y", "Y":
+			m.stampResults()
+			m.replaceState = replaceApplying
+			m.status.message = "Applying replacement..."
+			tool, files := m.replaceTool, m.replaceFiles
+			pattern, replacement := m.lastPattern, m.replaceInput.Value()
+			return m, func() tea.Msg {
+				err := tool.Apply(context.Background(), files, pattern, replacement)
+				return replaceAppliedMsg{files: len(files), err: err}
+			}
+		case "n", "N", "esc", "ctrl+c":
+			m.endReplace()
+			m.status.message = "Replace cancelled"
+			m.updatePreviewView()
+			return m, nil
+		case "up", "ctrl+p":
+			m.preview.view.LineUp(1)
+		case "down", "ctrl+n":
+			m.preview.view.LineDown(1)
+		case "pgup":
+			m.preview.view.HalfViewUp()
+		case "pgdown":
+			m.preview.view.HalfViewDown()
+		}
+	}
+	// Ignore keys while the tool is running
+	return m, nil
+}
+
+// handleReplaceMsg processes results from the replace tool
+func (m *Model) handleReplaceMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case replacePreviewMsg:
+		if m.replaceState != replacePreviewing {
+			return nil
+		}
+		if msg.err != nil {
+			m.endReplace()
+			m.status.err = msg.err.Error()
+			return nil
+		}
+		if msg.diff == "" {
+			m.endReplace()
+			m.status.message = "Replacement makes no changes"
+			return nil
+		}
+		m.replaceState = replaceConfirm
+		m.replaceDiff = strings.Split(strings.TrimRight(msg.diff, "\n"), "\n")
+		m.status.message = ""
+		m.renderReplaceDiff()
+		return nil
+
+	case replaceAppliedMsg:
+		m.endReplace()
+		if msg.err != nil {
+			m.resultStamps = nil
+			m.status.err = msg.err.Error()
+			return nil
+		}
+		status := fmt.Sprintf("Rewrote %d files", msg.files)
+		if cmd, ok := m.refreshChanged(status); ok {
+			return cmd
+		}
+		for _, f := range m.replaceFiles {
+			m.previewCache.Invalidate(f)
+		}
+		cmd := m.executeSearch(m.inputs.pattern.Value(), m.inputs.path.Value())
+		m.status.message = status
+		return cmd
+	}
+	return nil
+}
+
+// endReplace leaves the replace flow and returns focus to the pattern
+func (m *Model) endReplace() {
+	m.replaceState = replaceOff
+	m.replaceDiff = nil
+	m.replaceInput.Blur()
+	m.inputs.focused = focusPattern
+	m.inputs.pattern.Focus()
+	m.status.message = ""
+	m.updatePreviewView()
+}
+
+// renderReplaceDiff shows the pending diff in the preview pane
+func (m *Model) renderReplaceDiff() {
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+	hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("13"))
+	addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	delStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("Replace in %d files", len(m.replaceFiles))))
+	sb.WriteString("\n")
+	for _, line := range m.replaceDiff {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = headerStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = hunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = addStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = delStyle.Render(line)
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	m.preview.view.SetContent(sb.String())
+	m.preview.view.GotoTop()
+}
+
+// replaceStatus is shown in the status area while the replace flow is active
+func (m *Model) replaceStatus() string {
+	switch m.replaceState {
+	case replacePrompt:
+		return fmt.Sprintf("Replace /%s/ in %d files with: %s  (Enter preview, Esc cancel)",
+			m.lastPattern, len(m.replaceFiles), m.replaceInput.View())
+	case replacePreviewing:
+		return "Previewing replacement..."
+	case replaceConfirm:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true).Render(
+			fmt.Sprintf("Apply to %d files? (y/n, ↑/↓ scroll diff)", len(m.replaceFiles)))
+	case replaceApplying:
+		return "Applying replacement..."
+	}
+	return ""
+}
+
+// SetReplaceCommand sets the rewriting tool template used by the replace action
+func (m *Model) SetReplaceCommand(command string) {
+	m.replaceTool = replace.New(command)
+}
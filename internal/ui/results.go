@@ -0,0 +1,474 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+const (
+	pageStep  = 10 // Results moved by Page Up and Page Down
+	wheelStep = 3  // Results moved by a notch of the mouse wheel
+)
+
+// resultsList is the pane listing the results. It keeps the selection and
+// renders only the window of results around it, caching their lines; the
+// results themselves live in the model's result store.
+type resultsList struct {
+	view     viewport.Model
+	selected int // Index of the selected result
+	xOffset  int // Cells the result text is scrolled right
+	cache    resultsRenderCache
+}
+
+// Update moves the selection for the movement keys, among count results.
+// It reports whether a was one, and whether the selection moved.
+func (l *resultsList) Update(a action, count int) (handled, moved bool) {
+	switch a {
+	case actionUp:
+		return true, l.move(-1, count)
+	case actionDown:
+		return true, l.move(1, count)
+	case actionPageUp:
+		return true, l.move(-pageStep, count)
+	case actionPageDown:
+		return true, l.move(pageStep, count)
+	}
+	return false, false
+}
+
+// move moves the selection by delta among count results, stopping at either
+// end, and reports whether it moved
+func (l *resultsList) move(delta, count int) bool {
+	i := clamp(l.selected+delta, 0, max(count-1, 0))
+	if i == l.selected {
+		return false
+	}
+	l.selected = i
+	return true
+}
+
+// visible returns how many results of rows rows each fit in the pane
+func (l *resultsList) visible(rows int) int {
+	return max(1, l.view.Height/rows)
+}
+
+// offset returns the index of the first of count results shown, keeping the
+// selection centered where possible
+func (l *resultsList) offset(count, rows int) int {
+	if l.selected < 0 || count == 0 {
+		return 0
+	}
+
+	visible := l.visible(rows)
+	centerOffset := l.selected - visible/2
+
+	// Calculate the maximum valid offset to prevent scrolling past content
+	// Content has count results, viewport shows visible of them
+	// Maximum offset is when the last result is at the bottom of the viewport
+	maxOffset := count - visible
+
+	// Clamp the offset to valid range [0, maxOffset]
+	// Similar to Telescope in Neovim: ensure last item is always visible
+	offset := centerOffset
+	if offset < 0 {
+		offset = 0
+	}
+	// If maxOffset <= 0, all content fits in viewport, so offset should be 0
+	// Otherwise, clamp to maxOffset to ensure we don't scroll past the end
+	if offset > maxOffset {
+		if maxOffset < 0 {
+			offset = 0
+		} else {
+			offset = maxOffset
+		}
+	}
+	return offset
+}
+
+// render renders the window of count results, rows rows each, into the
+// pane. line renders result i; lines of unselected results are cached until
+// the cache is invalidated.
+func (l *resultsList) render(count, rows int, line func(i int, selected bool) (string, error)) error {
+	// Only the visible window is rendered: older results may live on disk
+	// in the result store, so paging them all in on every update is wasteful.
+	offset := l.offset(count, rows)
+	visible := l.visible(rows)
+	end := min(offset+visible, count)
+	shown := max(end-offset, 0)
+
+	c := &l.cache
+	if c.width != l.view.Width {
+		c.invalidate()
+		c.width = l.view.Width
+	}
+	if c.valid && c.offset == offset && c.height == visible &&
+		c.count == shown && c.selected == l.selected {
+		// New results landed outside the visible window; nothing to redraw
+		return nil
+	}
+
+	var sb strings.Builder
+	var err error
+	for i := offset; i < end; i++ {
+		text, ok := c.lines[i]
+		if i == l.selected || !ok {
+			if text, err = line(i, i == l.selected); err != nil {
+				break
+			}
+			if i != l.selected {
+				c.lines[i] = text
+			}
+		}
+		sb.WriteString(text)
+		sb.WriteString("\n")
+	}
+
+	c.offset = offset
+	c.height = visible
+	c.count = shown
+	c.selected = l.selected
+	c.valid = true
+	c.prune(offset, end, visible)
+
+	l.view.SetContent(sb.String())
+	l.view.SetYOffset(0)
+	return err
+}
+
+// View renders the visible part of the pane
+func (l resultsList) View() string {
+	return l.view.View()
+}
+
+// updateResults handles the keys that move in the results list, mark results
+// and open them. It reports whether a was used.
+func (m *Model) updateResults(a action) (tea.Cmd, bool) {
+	if handled, moved := m.list.Update(a, m.results.Len()); handled {
+		if !moved {
+			return nil, true
+		}
+		m.updateResultsView()
+		return m.loadPreview(), true
+	}
+	switch a {
+	case actionToggleMark:
+		return m.toggleMark(), true
+	case actionOpenEditor:
+		if _, ok := m.selectedMatch(); !ok {
+			return nil, true
+		}
+		if m.selectMode {
+			m.accepted = true
+			return tea.Quit, true
+		}
+		return m.openInEditor(), true
+	}
+	return nil, false
+}
+
+// moveSelection moves the selection by delta results, stopping at either
+// end, and previews the newly selected result
+func (m *Model) moveSelection(delta int) tea.Cmd {
+	if !m.list.move(delta, m.results.Len()) {
+		return nil
+	}
+	m.updateResultsView()
+	return m.loadPreview()
+}
+
+// toggleMark marks the selected result, or unmarks it dropping its note, and
+// moves on to the next one
+func (m *Model) toggleMark() tea.Cmd {
+	if m.list.selected >= m.results.Len() {
+		return nil
+	}
+	if m.marked[m.list.selected] {
+		delete(m.marked, m.list.selected)
+		delete(m.notes, m.list.selected)
+	} else {
+		m.marked[m.list.selected] = true
+	}
+	m.list.cache.invalidate()
+	if cmd := m.moveSelection(1); cmd != nil {
+		return cmd
+	}
+	m.updateResultsView()
+	return nil
+}
+
+// displayLine returns a result or preview line as shown, composed (NFC)
+// with its submatches moved along when normalization is on
+func (m *Model) displayLine(text string, submatches []search.Submatch) (string, []search.Submatch) {
+	if !m.normalize {
+		return text, submatches
+	}
+	return search.NormalizeLine(text, submatches)
+}
+
+// highlightMatches applies highlighting to matched text using submatch
+// positions. A match ending before combining marks, such as the e of an e
+// followed by an accent, takes them in, so a character isn't split across
+// styles.
+func highlightMatches(text string, submatches []search.Submatch, highlightStyle lipgloss.Style) string {
+	if len(submatches) == 0 {
+		return text
+	}
+
+	// Sort submatches by start position to handle overlaps correctly
+	sortedMatches := make([]search.Submatch, len(submatches))
+	copy(sortedMatches, submatches)
+
+	// Simple bubble sort since we typically have few submatches
+	for i := 0; i < len(sortedMatches); i++ {
+		for j := i + 1; j < len(sortedMatches); j++ {
+			if sortedMatches[i].Start > sortedMatches[j].Start {
+				sortedMatches[i], sortedMatches[j] = sortedMatches[j], sortedMatches[i]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	lastEnd := 0
+
+	for _, match := range sortedMatches {
+		// Handle bounds checking
+		start := match.Start
+		end := match.End
+		if start < 0 || end < 0 || start >= len(text) || end > len(text) || start >= end {
+			continue
+		}
+		end = combiningEnd(text, end)
+		start = max(start, lastEnd)
+		if start >= end {
+			continue
+		}
+
+		// Add text before this match
+		if start > lastEnd {
+			sb.WriteString(text[lastEnd:start])
+		}
+
+		// Add highlighted match text
+		matchText := text[start:end]
+		sb.WriteString(highlightStyle.Render(matchText))
+
+		lastEnd = end
+	}
+
+	// Add remaining text after last match
+	if lastEnd < len(text) {
+		sb.WriteString(text[lastEnd:])
+	}
+
+	return sb.String()
+}
+
+// combiningEnd moves end in text past any combining marks following it
+func combiningEnd(text string, end int) int {
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !unicode.In(r, unicode.Mn, unicode.Me) {
+			break
+		}
+		end += size
+	}
+	return end
+}
+
+// resultsRenderCache remembers the unselected rendering of each result line and
+// what the results view last showed, so streaming batches and selection moves
+// only restyle the lines that actually changed
+type resultsRenderCache struct {
+	lines    map[int]string
+	width    int
+	offset   int
+	height   int
+	count    int
+	selected int
+	valid    bool
+}
+
+func newResultsRenderCache() resultsRenderCache {
+	return resultsRenderCache{lines: make(map[int]string)}
+}
+
+func (c *resultsRenderCache) invalidate() {
+	for k := range c.lines {
+		delete(c.lines, k)
+	}
+	c.valid = false
+}
+
+// prune drops cached lines far outside the visible window
+func (c *resultsRenderCache) prune(offset, end, height int) {
+	if len(c.lines) <= 4*height {
+		return
+	}
+	for k := range c.lines {
+		if k < offset-height || k >= end+height {
+			delete(c.lines, k)
+		}
+	}
+}
+
+// updateResultsView renders the visible window of results into the results
+// pane
+func (m *Model) updateResultsView() {
+	err := m.list.render(m.results.Len(), m.resultRows(), func(i int, selected bool) (string, error) {
+		match, err := m.results.Get(i)
+		if err != nil {
+			return "", err
+		}
+		return m.renderResultLine(match, selected, m.marked[i], m.notes[i] != "", m.compare.kind(i)), nil
+	})
+	if err != nil {
+		m.status.err = err.Error()
+	}
+}
+
+// renderResultLine renders a single entry of the results list
+func (m *Model) renderResultLine(match search.Match, selected, marked, noted bool, diff diffKind) string {
+	pathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	lineNumStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	selectedStyle := m.selectedStyle()
+	matchHighlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.colors.Match)).Bold(true)
+	selectedMatchHighlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.colors.Match)).Bold(true)
+
+	// A match spanning several lines shows its first, with the range of
+	// lines it covers
+	match = match.Head()
+	lineNumber := fmt.Sprintf("%d", match.LineNumber)
+	if match.EndLine > match.LineNumber {
+		lineNumber += fmt.Sprintf("-%d", match.EndLine)
+	}
+	lineText := strings.TrimRight(match.LineText, "\n\r")
+	class := m.classifier.Classify(match.Path)
+	maxTextLen := m.resultTextWidth() - classTagWidth(class)
+	badge := ""
+	if m.showFileInfo && !m.remote {
+		badge = m.fileInfoBadge(match.Path)
+		maxTextLen -= fileInfoWidth
+	}
+
+	lineText, submatches, before, after, indent := m.dedentResult(lineText, match)
+	lineText, submatches = m.displayLine(lineText, submatches)
+	if indent != "" {
+		maxTextLen -= ansi.StringWidth(indentMarker)
+	}
+
+	var highlightedText string
+	if selected {
+		highlightedText = highlightMatches(lineText, submatches, selectedMatchHighlightStyle)
+	} else {
+		highlightedText = highlightMatches(lineText, submatches, matchHighlightStyle)
+	}
+	// The path and line number stay put when the text is scrolled sideways
+	highlightedText = indent + clipLine(highlightedText, m.list.xOffset, maxTextLen)
+
+	line := fmt.Sprintf("%s:%s: %s",
+		pathStyle.Render(displayPath(match.Path)),
+		lineNumStyle.Render(lineNumber),
+		highlightedText)
+	line = styleClass(line, class)
+	line = badge + line
+
+	mark := " "
+	if marked {
+		mark = lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Render("*")
+	}
+	if noted {
+		mark = lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Render("✎")
+	}
+	switch diff {
+	case diffAdded:
+		mark += lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("+")
+	case diffRemoved:
+		mark += lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("-")
+	}
+	row := " " + mark + line
+	if selected {
+		row = selectedStyle.Render(">" + mark + line)
+	}
+	if m.resultRows() == 1 {
+		return row
+	}
+	return m.renderContext(before, match.LineNumber-len(match.Before), true) + "\n" +
+		row + "\n" +
+		m.renderContext(after, match.LastLine()+1, false)
+}
+
+// renderContext renders the dimmed context rows shown around a result in the
+// style of rg's context output, padded to inlineContextLines rows so every
+// result takes the same height. Before-context is padded from the top.
+func (m *Model) renderContext(lines []string, first int, before bool) string {
+	if len(lines) > inlineContextLines {
+		if before {
+			first += len(lines) - inlineContextLines
+			lines = lines[len(lines)-inlineContextLines:]
+		} else {
+			lines = lines[:inlineContextLines]
+		}
+	}
+
+	contextStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	var rows []string
+	for i, text := range lines {
+		text, _ = m.displayLine(text, nil)
+		text = clipLine(text, m.list.xOffset, m.resultTextWidth())
+		rows = append(rows, contextStyle.Render(fmt.Sprintf("   %d- %s", first+i, text)))
+	}
+
+	padding := make([]string, inlineContextLines-len(lines))
+	if before {
+		rows = append(padding, rows...)
+	} else {
+		rows = append(rows, padding...)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// resultTextWidth returns the cells left for the matched line after the
+// path and line number of a result
+func (m *Model) resultTextWidth() int {
+	return m.list.view.Width - 20
+}
+
+// resultRows returns the number of rows each result takes in the list
+func (m *Model) resultRows() int {
+	if !m.inlineContext || m.remote {
+		return 1
+	}
+	return 1 + 2*inlineContextLines
+}
+
+// visibleResults returns how many results fit in the results view
+func (m *Model) visibleResults() int {
+	return m.list.visible(m.resultRows())
+}
+
+// resultsOffset returns the index of the first result shown in the results view,
+// keeping the selection centered where possible
+func (m *Model) resultsOffset() int {
+	return m.list.offset(m.results.Len(), m.resultRows())
+}
+
+// selectedMatch returns the currently selected result, if any
+func (m *Model) selectedMatch() (search.Match, bool) {
+	if m.list.selected < 0 || m.list.selected >= m.results.Len() {
+		return search.Match{}, false
+	}
+	match, err := m.results.Get(m.list.selected)
+	if err != nil {
+		return search.Match{}, false
+	}
+	return match, true
+}
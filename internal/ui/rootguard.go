@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// fileCounter is implemented by backends that can tell how many files a
+// search would look at
+type fileCounter interface {
+	CountFiles(ctx context.Context, path string, opts search.Options, limit int) (int, bool, error)
+}
+
+// rootPrompt is a search held back until it's confirmed, because it would
+// go through the whole filesystem, the home directory or a tree of many
+// files
+type rootPrompt struct {
+	pattern  string
+	path     string
+	root     string // The path made absolute, as confirmed
+	reason   string
+	counting bool // The files under root are being counted first
+}
+
+// rootCountedMsg carries the number of files under a search root
+type rootCountedMsg struct {
+	token int
+	root  string
+	more  bool // There are more than the confirmFiles limit
+	err   error
+}
+
+// SetConfirmFiles asks before searching a tree of more than n files,
+// counted with rg --files before the first search of each path. With 0 only
+// searches of the filesystem root and the home directory ask.
+func (m *Model) SetConfirmFiles(n int) {
+	m.confirmFiles = n
+}
+
+// guardSearch holds back a search of pattern under path that needs
+// confirming first, reporting false along with the command counting the
+// files under path when that's still to be done
+func (m *Model) guardSearch(pattern, path string) (tea.Cmd, bool) {
+	if pattern == "" || m.remote || m.gitTracked || len(m.roots) > 0 {
+		// Git and --paths-from list the files to search
+		return nil, true
+	}
+	root := searchRoot(path)
+	if m.confirmedRoots[root] {
+		return nil, true
+	}
+	if reason := rootReason(root); reason != "" {
+		m.holdSearch(rootPrompt{pattern: pattern, path: path, root: root, reason: reason})
+		return nil, false
+	}
+	counter, ok := m.searcher.(fileCounter)
+	if m.confirmFiles <= 0 || !ok {
+		return nil, true
+	}
+	if more, counted := m.countedRoots[root]; counted {
+		if !more {
+			return nil, true
+		}
+		m.holdSearch(rootPrompt{pattern: pattern, path: path, root: root, reason: m.manyFiles()})
+		return nil, false
+	}
+	if p := m.rootPrompt; p != nil && p.counting && p.root == root {
+		// Still counting; search for what was typed since once it's done
+		p.pattern, p.path = pattern, path
+		return nil, false
+	}
+
+	m.holdSearch(rootPrompt{pattern: pattern, path: path, root: root, counting: true})
+	m.rootToken++
+	token, limit, opts := m.rootToken, m.confirmFiles, m.searchOptions()
+	return func() tea.Msg {
+		_, more, err := counter.CountFiles(context.Background(), path, opts, limit)
+		return rootCountedMsg{token: token, root: root, more: more, err: err}
+	}, false
+}
+
+// holdSearch shows the prompt for a held-back search
+func (m *Model) holdSearch(p rootPrompt) {
+	m.rootPrompt = &p
+	m.estimated = false
+}
+
+// updateRootCounted runs the held-back search once its files are counted,
+// or asks first when there are too many. A failed count doesn't hold it up.
+func (m *Model) updateRootCounted(msg rootCountedMsg) tea.Cmd {
+	p := m.rootPrompt
+	if msg.token != m.rootToken || p == nil || !p.counting {
+		return nil
+	}
+	if msg.err == nil {
+		if m.countedRoots == nil {
+			m.countedRoots = make(map[string]bool)
+		}
+		m.countedRoots[msg.root] = msg.more
+	}
+	if msg.err == nil && msg.more {
+		p.counting = false
+		p.reason = m.manyFiles()
+		return nil
+	}
+	m.rootPrompt = nil
+	if msg.err != nil {
+		// Go ahead as if the tree were small
+		m.confirmRoot(msg.root)
+	}
+	return m.executeSearch(p.pattern, p.path)
+}
+
+// updateRootPrompt handles the key answering the prompt: y or Enter runs
+// the search, n or Esc drops it, and any other key dismisses the prompt and
+// is handled as usual
+func (m *Model) updateRootPrompt(msg tea.KeyMsg) (tea.Cmd, bool) {
+	p := m.rootPrompt
+	m.rootPrompt = nil
+	switch msg.String() {
+	case "y", "enter":
+		m.confirmRoot(p.root)
+		return m.executeSearch(p.pattern, p.path), true
+	case "n", "esc":
+		m.status.message = "Search not started"
+		return nil, true
+	}
+	return nil, false
+}
+
+// confirmRoot lets searches under root go ahead for the rest of the session
+func (m *Model) confirmRoot(root string) {
+	if m.confirmedRoots == nil {
+		m.confirmedRoots = make(map[string]bool)
+	}
+	m.confirmedRoots[root] = true
+}
+
+// rootStatus asks to confirm the held-back search
+func (m *Model) rootStatus() string {
+	p := m.rootPrompt
+	if p.counting {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
+			fmt.Sprintf("Counting the files in %s...", displayPath(p.root)))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+		fmt.Sprintf("Search %s, %s? y: search | n: cancel", displayPath(p.root), p.reason))
+}
+
+// manyFiles describes a tree over the confirmFiles limit
+func (m *Model) manyFiles() string {
+	return fmt.Sprintf("more than %d files", m.confirmFiles)
+}
+
+// searchRoot returns path made absolute, with symlinks resolved when it
+// can be
+func searchRoot(path string) string {
+	if path == "" {
+		path = "."
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// rootReason says why searching root needs confirming, or returns "" when
+// it's an ordinary directory
+func rootReason(root string) string {
+	if root == filepath.VolumeName(root)+string(filepath.Separator) {
+		return "the whole filesystem"
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" && root == searchRoot(home) {
+		return "your whole home directory"
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestRootGuard_AsksBeforeSearchingHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	mock := search.NewMockSearcher(testMatches(0, 1)...)
+	m := newTestModel(t)
+	m.searcher = mock
+
+	if cmd := m.executeSearch("needle", home); cmd != nil || m.rootPrompt == nil {
+		t.Fatal("a search of the home directory started without asking")
+	}
+	if status := ansi.Strip(m.statusLine()); !strings.Contains(status, "your whole home directory? y: search | n: cancel") {
+		t.Errorf("status = %q, want the prompt", status)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updated.(Model)
+	if cmd != nil || m.rootPrompt != nil || len(mock.Calls()) != 0 {
+		t.Fatal("n didn't drop the search")
+	}
+
+	m.executeSearch("needle", home)
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("y didn't start the search")
+	}
+	m = runSearch(t, m, cmd)
+	if calls := mock.Calls(); len(calls) != 1 || calls[0].Pattern != "needle" || m.matchCount != 1 {
+		t.Fatalf("calls = %+v, want the held search run", calls)
+	}
+
+	// Confirmed once, the home directory is searched without asking again
+	if m.executeSearch("other", home) == nil || m.rootPrompt != nil {
+		t.Error("asked again for a confirmed root")
+	}
+}
+
+// countingSearcher reports whether a tree has more files than the limit
+type countingSearcher struct {
+	*search.MockSearcher
+	more   bool
+	counts *int
+}
+
+func (s countingSearcher) CountFiles(ctx context.Context, path string, opts search.Options, limit int) (int, bool, error) {
+	*s.counts++
+	return limit, s.more, nil
+}
+
+func TestRootGuard_CountsFilesFirst(t *testing.T) {
+	for _, more := range []bool{false, true} {
+		counts := 0
+		mock := search.NewMockSearcher(testMatches(0, 1)...)
+		m := newTestModel(t)
+		m.searcher = countingSearcher{MockSearcher: mock, more: more, counts: &counts}
+		m.SetConfirmFiles(1000)
+		dir := t.TempDir()
+
+		count := m.executeSearch("ne", dir)
+		if count == nil || m.rootPrompt == nil || !m.rootPrompt.counting {
+			t.Fatal("the search started before the files were counted")
+		}
+		// Typing on while counting searches for the latest pattern
+		if m.executeSearch("needle", dir) != nil {
+			t.Error("counted the files twice")
+		}
+
+		updated, cmd := m.Update(count())
+		m = updated.(Model)
+		if !more {
+			if cmd == nil || m.rootPrompt != nil {
+				t.Fatal("a small tree wasn't searched right away")
+			}
+		} else {
+			if cmd != nil || m.rootPrompt == nil {
+				t.Fatal("a large tree was searched without asking")
+			}
+			if status := ansi.Strip(m.statusLine()); !strings.Contains(status, "more than 1000 files") {
+				t.Errorf("status = %q, want the file count given as the reason", status)
+			}
+			updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			m = updated.(Model)
+		}
+		m = runSearch(t, m, cmd)
+		if calls := mock.Calls(); len(calls) != 1 || calls[0].Pattern != "needle" {
+			t.Errorf("more = %v: calls = %+v, want one search for the latest pattern", more, calls)
+		}
+
+		// The count is kept for the next search of the same tree
+		m.executeSearch("next", dir)
+		if counts != 1 || m.rootPrompt != nil {
+			t.Errorf("more = %v: %d counts, prompt %v; want the tree counted once and not asked about again", more, counts, m.rootPrompt)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/config"
+	"github.com/William9923/irg/internal/editor"
+	"github.com/William9923/irg/internal/search"
+)
+
+// maxShards caps the shards setting; more rg processes than this only add
+// contention
+const maxShards = 64
+
+type settingKind int
+
+const (
+	settingToggle settingKind = iota
+	settingChoice             // Steps through a fixed list
+	settingNumber
+	settingText
+)
+
+// settingItem is one row of the settings screen, saved as key in the
+// section table of config.toml
+type settingItem struct {
+	label   string
+	section string
+	key     string
+	kind    settingKind
+}
+
+var settingItems = []settingItem{
+	{label: "Case", section: "search", key: "case", kind: settingChoice},
+	{label: "Git-tracked files only", section: "search", key: "git-tracked", kind: settingToggle},
+	{label: "Stable order", section: "search", key: "stable-order", kind: settingToggle},
+	{label: "Inline context", section: "search", key: "inline-context", kind: settingToggle},
+	{label: "Shards", section: "search", key: "shards", kind: settingNumber},
+	{label: "Search ignored files", section: "search", key: "no-ignore", kind: settingToggle},
+	{label: "Multiline patterns", section: "search", key: "multiline", kind: settingToggle},
+	{label: "Whole words only", section: "search", key: "word-regexp", kind: settingToggle},
+	{label: "Unicode normalization", section: "search", key: "normalize", kind: settingToggle},
+	{label: "Max depth", section: "search", key: "max-depth", kind: settingNumber},
+	{label: "Syntax highlighting", section: "preview", key: "syntax", kind: settingToggle},
+	{label: "Theme", section: "preview", key: "theme", kind: settingChoice},
+	{label: "Mouse", section: "ui", key: "mouse", kind: settingToggle},
+	{label: "Editor", section: "editor", key: "command", kind: settingText},
+}
+
+var caseModes = []search.CaseSensitivity{search.CaseSmart, search.CaseSensitive, search.CaseInsensitive}
+
+type settingsSavedMsg struct {
+	path string
+	err  error
+}
+
+func newSettingsInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "$EDITOR"
+	ti.CharLimit = 256
+	ti.Width = 30
+	return ti
+}
+
+// SetConfigFile sets the config file the settings screen saves to
+func (m *Model) SetConfigFile(path string) {
+	m.configPath = path
+}
+
+// SetEditorCommand opens results with command, such as "code --wait",
+// instead of $EDITOR
+func (m *Model) SetEditorCommand(command string) {
+	m.editorCommand = command
+}
+
+// SetTheme sets the chroma style of the preview
+func (m *Model) SetTheme(theme string) {
+	m.highlighter.SetStyle(theme)
+}
+
+// SetSyntaxHighlighting turns syntax highlighting in the preview on or off
+func (m *Model) SetSyntaxHighlighting(enabled bool) {
+	m.highlighter.SetEnabled(enabled)
+}
+
+// openSettings shows the settings screen
+func (m *Model) openSettings() {
+	m.settingsVisible = true
+	m.settingsIndex = 0
+	m.typeDropdown.hide()
+	m.pathDropdown.hide()
+}
+
+// updateSettings handles key presses while the settings screen is open
+func (m Model) updateSettings(msg tea.KeyMsg, a action) (tea.Model, tea.Cmd) {
+	if m.settingsEditing {
+		return m.updateSettingsInput(msg)
+	}
+
+	item := settingItems[m.settingsIndex]
+	switch msg.String() {
+	case " ", "right", "l":
+		return m, m.changeSetting(item, 1)
+	case "left", "h":
+		return m, m.changeSetting(item, -1)
+	case "s", "ctrl+s":
+		return m, m.saveSettings()
+	case "q":
+		m.settingsVisible = false
+		return m, nil
+	}
+
+	switch a {
+	case actionUp:
+		m.settingsIndex = (m.settingsIndex + len(settingItems) - 1) % len(settingItems)
+	case actionDown:
+		m.settingsIndex = (m.settingsIndex + 1) % len(settingItems)
+	case actionOpenEditor:
+		return m, m.changeSetting(item, 1)
+	case actionClose, actionSettings, actionQuit:
+		m.settingsVisible = false
+	}
+	return m, nil
+}
+
+// updateSettingsInput handles key presses while a text setting is edited
+func (m Model) updateSettingsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.settingsEditing = false
+		m.settingsInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		command := strings.TrimSpace(m.settingsInput.Value())
+		if command != "" {
+			if _, err := editor.FromCommand(command); err != nil {
+				m.status.err = err.Error()
+				return m, nil
+			}
+		}
+		m.settingsEditing = false
+		m.settingsInput.Blur()
+		m.status.err = ""
+		if command != m.editorCommand {
+			m.editorCommand = command
+			m.settingsChanged[m.settingsIndex] = true
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.settingsInput, cmd = m.settingsInput.Update(msg)
+	return m, cmd
+}
+
+// changeSetting flips a toggle or steps a choice or number by step, applying
+// it right away; text settings open an input instead
+func (m *Model) changeSetting(item settingItem, step int) tea.Cmd {
+	var cmds []tea.Cmd
+	switch item.key {
+	case "case":
+		i := slices.Index(caseModes, m.caseSensitivity)
+		m.caseSensitivity = caseModes[(i+step+len(caseModes))%len(caseModes)]
+		cmds = append(cmds, m.saveCaseMode())
+	case "git-tracked":
+		m.gitTracked = !m.gitTracked
+	case "no-ignore":
+		m.noIgnore = !m.noIgnore
+	case "multiline":
+		m.multiline = !m.multiline
+	case "word-regexp":
+		m.wholeWord = !m.wholeWord
+	case "normalize":
+		m.normalize = !m.normalize
+		m.list.cache.invalidate()
+		m.updateResultsView()
+		m.updatePreviewView()
+	case "max-depth":
+		m.maxDepth = max(m.maxDepth+step, 0)
+	case "stable-order":
+		m.stableOrder = !m.stableOrder
+	case "inline-context":
+		m.inlineContext = !m.inlineContext
+		m.list.cache.invalidate()
+		m.updateResultsView()
+	case "shards":
+		m.shards = min(max(m.shards+step, 0), maxShards)
+	case "syntax":
+		m.highlighter.SetEnabled(!m.highlighter.IsEnabled())
+		m.updatePreviewView()
+	case "theme":
+		m.stepTheme(step)
+	case "mouse":
+		cmds = append(cmds, m.toggleMouse())
+	case "command":
+		m.settingsEditing = true
+		m.settingsInput.SetValue(m.editorCommand)
+		m.settingsInput.CursorEnd()
+		return m.settingsInput.Focus()
+	}
+	m.settingsChanged[m.settingsIndex] = true
+
+	if item.section == "search" {
+		cmds = append(cmds, m.searchAgain())
+	}
+	return tea.Batch(cmds...)
+}
+
+// settingValue returns the current value of item as it is saved
+func (m *Model) settingValue(item settingItem) any {
+	switch item.key {
+	case "case":
+		return m.caseSensitivity.String()
+	case "git-tracked":
+		return m.gitTracked
+	case "no-ignore":
+		return m.noIgnore
+	case "multiline":
+		return m.multiline
+	case "word-regexp":
+		return m.wholeWord
+	case "normalize":
+		return m.normalize
+	case "max-depth":
+		return m.maxDepth
+	case "stable-order":
+		return m.stableOrder
+	case "inline-context":
+		return m.inlineContext
+	case "shards":
+		return m.shards
+	case "syntax":
+		return m.highlighter.IsEnabled()
+	case "theme":
+		return m.highlighter.GetStyle()
+	case "command":
+		return m.editorCommand
+	case "mouse":
+		return m.mouse
+	}
+	return nil
+}
+
+// settingText formats the current value of item for the settings screen
+func (m *Model) settingText(item settingItem) string {
+	switch value := m.settingValue(item).(type) {
+	case bool:
+		if value {
+			return "on"
+		}
+		return "off"
+	case int:
+		if value == 0 {
+			return "off"
+		}
+		return fmt.Sprint(value)
+	case string:
+		if value == "" && item.kind == settingText {
+			return "$EDITOR"
+		}
+		return value
+	}
+	return ""
+}
+
+// saveSettings writes the settings changed on the screen to the config file,
+// leaving the others as they are in the file
+func (m *Model) saveSettings() tea.Cmd {
+	if m.configPath == "" {
+		m.status.err = "No config file to save settings to"
+		return nil
+	}
+	var settings []config.Setting
+	for i, item := range settingItems {
+		if m.settingsChanged[i] {
+			settings = append(settings, config.Setting{Section: item.section, Key: item.key, Value: m.settingValue(item)})
+		}
+	}
+	if len(settings) == 0 {
+		m.status.message = "No settings changed"
+		return nil
+	}
+	path := m.configPath
+	return func() tea.Msg {
+		return settingsSavedMsg{path: path, err: config.Save(path, settings)}
+	}
+}
+
+// renderSettings renders the settings screen, at most width cells wide and
+// height rows tall
+func (m *Model) renderSettings(width, height int) string {
+	labelWidth := 0
+	for _, item := range settingItems {
+		labelWidth = max(labelWidth, lipgloss.Width(item.label))
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	changedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Settings"), ""}
+	for i, item := range settingItems {
+		value := m.settingText(item)
+		if m.settingsEditing && i == m.settingsIndex {
+			value = m.settingsInput.View()
+		} else if m.settingsChanged[i] {
+			value = changedStyle.Render(value + " *")
+		}
+		line := fmt.Sprintf("  %-*s  %s", labelWidth, item.label, value)
+		if i == m.settingsIndex {
+			line = selectedStyle.Render("> "+fmt.Sprintf("%-*s", labelWidth, item.label)) + "  " + value
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "")
+	if m.configPath != "" {
+		lines = append(lines, hintStyle.Render("Saves to "+m.configPath))
+	}
+	if m.settingsEditing {
+		lines = append(lines, hintStyle.Render("Enter apply, Esc cancel"))
+	} else {
+		lines = append(lines, hintStyle.Render("↑/↓ select  Enter/←/→ change  s save  Esc close"))
+	}
+
+	// Border and padding take two rows and four columns
+	textWidth := max(width-4, 10)
+	if maxLines := max(height-2, 1); len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, textWidth, "…")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
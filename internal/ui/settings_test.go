@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/config"
+)
+
+func TestSettings_ChangeAndSave(t *testing.T) {
+	m := newTestModel(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("# mine\n[paths]\nmax-depth = 4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m.SetConfigFile(path)
+
+	press := func(msg tea.KeyMsg) tea.Cmd {
+		t.Helper()
+		updated, cmd := m.Update(msg)
+		m = updated.(Model)
+		return cmd
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}, Alt: true})
+	if !m.settingsVisible {
+		t.Fatal("Alt+O didn't open the settings screen")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Git-tracked files only") || !strings.Contains(view, path) {
+		t.Errorf("settings screen missing rows or config path:\n%s", view)
+	}
+
+	// Git-tracked is the second row; Enter toggles it right away
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.gitTracked {
+		t.Fatal("Enter didn't toggle git-tracked")
+	}
+	// Shards go up with Right and never below zero
+	for i := 0; i < 3; i++ {
+		press(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	press(tea.KeyMsg{Type: tea.KeyLeft})
+	press(tea.KeyMsg{Type: tea.KeyRight})
+	press(tea.KeyMsg{Type: tea.KeyRight})
+	if m.shards != 2 {
+		t.Fatalf("shards = %d, want 2", m.shards)
+	}
+
+	cmd := press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if cmd == nil {
+		t.Fatal("s returned no save command")
+	}
+	updated, _ := m.Update(cmd())
+	m = updated.(Model)
+	if m.status.err != "" || len(m.settingsChanged) != 0 {
+		t.Fatalf("save failed: %q, unsaved %v", m.status.err, m.settingsChanged)
+	}
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if !cfg.Search.GitTracked || cfg.Search.Shards != 2 || cfg.Paths.MaxDepth != 4 {
+		t.Errorf("saved config = %+v", cfg)
+	}
+	// Settings that weren't touched stay out of the file
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "stable-order") || !strings.HasPrefix(string(data), "# mine") {
+		t.Errorf("saved file:\n%s", data)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.settingsVisible {
+		t.Error("Esc didn't close the settings screen")
+	}
+}
+
+func TestSettings_EditorCommand(t *testing.T) {
+	m := newTestModel(t)
+	m.openSettings()
+	m.settingsIndex = len(settingItems) - 1
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.settingsEditing {
+		t.Fatal("Enter didn't start editing the editor command")
+	}
+	m.settingsInput.SetValue("no-such-editor-irg --wait")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.settingsEditing || m.editorCommand != "" || m.status.err == "" {
+		t.Errorf("missing editor accepted: editing %v, command %q, error %q", m.settingsEditing, m.editorCommand, m.status.err)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.settingsEditing || !m.settingsVisible {
+		t.Error("Esc should cancel the edit and keep the screen open")
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/stats"
+)
+
+// settledSearch is the last finished search, counted in the stats once it's
+// clear it wasn't just a step while typing a longer pattern
+type settledSearch struct {
+	pattern string
+	search  stats.Search
+}
+
+type statsSavedMsg struct {
+	err error
+}
+
+// SetStats records finished searches in the stats file at path; "" turns
+// recording off
+func (m *Model) SetStats(path string) {
+	m.statsPath = path
+}
+
+// countSearch holds the search that just finished back from the stats, and
+// returns a command counting the one before it, unless the new pattern only
+// extends or trims it, as typing does
+func (m *Model) countSearch() tea.Cmd {
+	if m.statsPath == "" || m.remote || m.resultsPattern == "" {
+		return nil
+	}
+	s := stats.Search{Types: slices.Clone(m.fileTypes), Results: m.results.Len()}
+	if len(m.roots) == 0 {
+		if abs, err := filepath.Abs(m.lastPath); err == nil {
+			s.Dir = abs
+		}
+	}
+	current := &settledSearch{pattern: m.resultsPattern, search: s}
+
+	previous := m.statsPending
+	m.statsPending = current
+	if previous == nil || previous.refinedBy(current) {
+		return nil
+	}
+	file, counted := m.statsPath, previous.search
+	return func() tea.Msg {
+		return statsSavedMsg{err: stats.Record(file, counted)}
+	}
+}
+
+// refinedBy reports whether next searched the same scope for a pattern
+// that starts with this one's, or that this one's starts with
+func (s *settledSearch) refinedBy(next *settledSearch) bool {
+	return s.search.Dir == next.search.Dir &&
+		slices.Equal(s.search.Types, next.search.Types) &&
+		(strings.HasPrefix(next.pattern, s.pattern) || strings.HasPrefix(s.pattern, next.pattern))
+}
+
+// flushStats counts the last finished search, when irg exits
+func (m *Model) flushStats() error {
+	if m.statsPending == nil {
+		return nil
+	}
+	pending := m.statsPending.search
+	m.statsPending = nil
+	return stats.Record(m.statsPath, pending)
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/William9923/irg/internal/stats"
+)
+
+func TestCountSearch_SkipsPatternsWhileTyping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	m := newTestModel(t)
+	m.SetStats(path)
+	m.lastPath = "."
+
+	for _, pattern := range []string{"f", "fo", "foo", "fo", "bar"} {
+		m.resultsPattern = pattern
+		if cmd := m.countSearch(); cmd != nil {
+			if msg := cmd().(statsSavedMsg); msg.err != nil {
+				t.Fatal(msg.err)
+			}
+		}
+	}
+	if err := m.flushStats(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := stats.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "fo" again is a step back while editing "foo"; "bar" is counted on exit
+	if s.Searches != 2 {
+		t.Errorf("counted %d searches, want 2 (fo and bar)", s.Searches)
+	}
+	abs, _ := filepath.Abs(".")
+	if s.Dirs[abs] != 2 {
+		t.Errorf("dirs = %v, want %s twice", s.Dirs, abs)
+	}
+}
@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusBar is the line beside the inputs telling what is going on. It
+// holds the last error or message, shown until the next search or key
+// clears it.
+type statusBar struct {
+	err     string
+	message string
+}
+
+// Update shows the outcome of msg when reporting it is all msg needs, as for
+// a finished export or a failed save. It reports whether msg was one of them.
+func (s *statusBar) Update(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case clipboardMsg:
+		if msg.err != nil {
+			s.err = fmt.Sprintf("Clipboard error: %v", msg.err)
+		} else {
+			s.message = "Copied " + truncateStatus(msg.text)
+		}
+	case exportFinishedMsg:
+		if msg.err != nil {
+			s.err = fmt.Sprintf("Export error: %v", msg.err)
+		} else {
+			kind := "matches"
+			if msg.marked {
+				kind = "marked matches"
+			}
+			s.message = fmt.Sprintf("Wrote %d %s to %s", msg.count, kind, msg.path)
+		}
+	case hookFinishedMsg:
+		s.fail(msg.err)
+	case stateSavedMsg:
+		s.fail(msg.err)
+	case statsSavedMsg:
+		s.fail(msg.err)
+	case historySavedMsg:
+		s.fail(msg.err)
+	case bookmarksSavedMsg:
+		s.fail(msg.err)
+	default:
+		return false
+	}
+	return true
+}
+
+// fail shows err, if any
+func (s *statusBar) fail(err error) {
+	if err != nil {
+		s.err = err.Error()
+	}
+}
+
+// clear drops the last error and message
+func (s *statusBar) clear() {
+	s.err = ""
+	s.message = ""
+}
+
+// View renders the bar after indicators: activity, the prompt or search in
+// progress, if any, else the last error or message, else what results
+// describes
+func (s statusBar) View(indicators, activity string, results func() string) string {
+	switch {
+	case activity != "":
+		return indicators + activity
+	case s.err != "":
+		return indicators + lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(s.err)
+	case s.message != "":
+		return indicators + lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(s.message)
+	}
+	return indicators + results()
+}
+
+// statusLine describes what is going on beside the inputs: the prompt or
+// mode in progress, the running search, the last message, or the results of
+// the last search
+func (m *Model) statusLine() string {
+	return m.status.View(m.macroIndicator()+m.jobsIndicator(), m.activityStatus(), m.resultsStatus)
+}
+
+// activityStatus describes the prompt, mode or search in progress, if any
+func (m *Model) activityStatus() string {
+	var status string
+	if m.replaceState != replaceOff {
+		status = m.replaceStatus()
+	} else if m.noteEditing {
+		status = m.noteStatus()
+	} else if m.macroPrompt {
+		status = m.macroStatus()
+	} else if m.preview.copy.active {
+		status = m.copyStatus()
+	} else if m.rootPrompt != nil {
+		status = m.rootStatus()
+	} else if m.estimated {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
+			fmt.Sprintf("~%d matches among the last results...", m.estimate))
+	} else if m.searchHeld {
+		status = m.heldStatus()
+	} else if m.searching {
+		status = "Searching..."
+		if done, total := m.shardProgress.Counts(); total > 0 {
+			status += fmt.Sprintf(" (%d/%d shards)", done, total)
+		}
+	}
+	return status
+}
+
+// resultsStatus summarizes the results of the last search
+func (m *Model) resultsStatus() string {
+	var status string
+	if m.matchCount > 0 {
+		pathInfo := m.lastPath
+		if m.remote {
+			pathInfo = "Sourcegraph"
+			if m.lastPath != "." {
+				pathInfo += " repo:" + m.lastPath
+			}
+		} else if len(m.roots) > 0 {
+			pathInfo = fmt.Sprintf("%d listed paths", len(m.roots))
+			if m.lastPath != "." {
+				pathInfo += " under " + m.lastPath
+			}
+		} else if pathInfo == "." {
+			pathInfo = "current directory"
+		} else if m.repoRoot != "" && pathInfo == m.repoRoot {
+			pathInfo = "repo root " + displayPath(m.repoRoot)
+		}
+		typeInfo := ""
+		if len(m.fileTypes) > 0 {
+			typeInfo = fmt.Sprintf(" [📁 %s]", strings.Join(m.fileTypes, ","))
+		}
+		if m.gitTracked {
+			typeInfo += " [git-tracked]"
+		}
+		if m.noIgnore {
+			typeInfo += " [no-ignore]"
+		}
+		if m.multiline {
+			typeInfo += " [multiline]"
+		}
+		if m.wholeWord {
+			typeInfo += " [whole-word]"
+		}
+		if m.maxDepth > 0 {
+			typeInfo += fmt.Sprintf(" [depth ≤%d]", m.maxDepth)
+		}
+		if m.lastPattern == m.literalPattern {
+			typeInfo += " [literal]"
+		}
+		if m.pasteNormalizedActive() {
+			typeInfo += " [paste normalized]"
+		}
+		if m.recentOrder != nil {
+			typeInfo += " [newest first]"
+		}
+		if m.partial {
+			typeInfo += " [partial]"
+		}
+		typeInfo += m.profileInfo()
+		typeInfo += m.exclusionInfo()
+		if hidden := m.hiddenClasses(); len(hidden) > 0 {
+			typeInfo += " [hiding " + strings.Join(hidden, ",") + "]"
+		}
+
+		statusParts := []string{fmt.Sprintf("%d matches in %s%s (%s)",
+			m.matchCount, pathInfo, typeInfo, m.searchTime.Round(time.Millisecond))}
+		if note := m.caseNote(); note != "" {
+			statusParts = append(statusParts, note)
+		}
+
+		if len(m.fileTypes) > 0 && m.lastPath != "" && m.lastPath != "." {
+			// Check if path looks like a specific file (has extension, not ending with /)
+			if strings.Contains(filepath.Base(m.lastPath), ".") && !strings.HasSuffix(m.lastPath, "/") {
+				// Path is likely a specific file - type filter may not apply
+				statusParts = append(statusParts, "ℹ️  Type filter overridden by file path")
+			}
+		}
+
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(strings.Join(statusParts, " "))
+	} else if m.lastPattern != "" {
+		status = "No matches"
+		if note := m.caseNote(); note != "" {
+			status += " " + note
+		}
+		if m.literalSuggested() {
+			status += lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+				" | Alt+L: retry as a literal string")
+		}
+		if m.pasteNormalizedActive() {
+			status += lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+				" | paste normalized, Alt+V: search it as pasted")
+		}
+	}
+
+	return status
+}
+
+// helpLine lists the main keys below the inputs, or asks to confirm quitting
+func (m *Model) helpLine() string {
+	if m.ctrlCPressed && time.Since(m.lastCtrlCTime) < 2*time.Second {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("Press Ctrl+C again to quit")
+	}
+	keys := "Keys: "
+	if m.results.Len() > 0 {
+		keys += "↑/↓ or Ctrl+P/N (navigate) | Enter (open in editor) | Ctrl+Y (copy path) | Ctrl+Q (quickfix) | "
+	}
+	keys += "Tab (switch input) | Ctrl+T (case: " + m.getCaseSensitivityName() + ") | Ctrl+H (syntax: " + m.getSyntaxHighlightingStatus() + ") | Ctrl+C twice (quit) | Tip: Specific file paths take precedence over type filters"
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(keys)
+}
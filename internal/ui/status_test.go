@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestStatusBar_ShowsActivityThenErrorThenMessage(t *testing.T) {
+	var s statusBar
+	results := func() string { return "3 matches" }
+	if got := s.View("", "", results); got != "3 matches" {
+		t.Errorf("idle status = %q, want the results", got)
+	}
+
+	if !s.Update(clipboardMsg{text: "main.go:3"}) {
+		t.Fatal("a clipboard result wasn't taken")
+	}
+	if got := ansi.Strip(s.View("", "", results)); got != "Copied main.go:3" {
+		t.Errorf("status = %q, want the copy reported", got)
+	}
+	s.Update(stateSavedMsg{err: errors.New("disk full")})
+	if got := ansi.Strip(s.View("● ", "", results)); got != "● disk full" {
+		t.Errorf("status = %q, want the error after the indicators", got)
+	}
+	if got := s.View("", "Searching...", results); got != "Searching..." {
+		t.Errorf("status = %q, want the search in progress first", got)
+	}
+
+	s.clear()
+	if s.Update(searchTimeoutMsg{}) {
+		t.Error("a message with more to do than report was taken")
+	}
+	if got := s.View("", "", results); !strings.Contains(got, "3 matches") {
+		t.Errorf("cleared status = %q, want the results", got)
+	}
+}
+
+func TestResultsList_MovesWithinTheResults(t *testing.T) {
+	var l resultsList
+	if handled, moved := l.Update(actionUp, 3); !handled || moved {
+		t.Errorf("up at the top: handled %v, moved %v; want handled without moving", handled, moved)
+	}
+	if _, moved := l.Update(actionPageDown, 3); !moved || l.selected != 2 {
+		t.Errorf("page down: moved %v to %d, want the last result", moved, l.selected)
+	}
+	if handled, _ := l.Update(actionQuit, 3); handled {
+		t.Error("quit was taken as a movement key")
+	}
+}
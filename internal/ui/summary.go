@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+const (
+	summaryMinWidth = 20
+	summaryMaxWidth = 40
+)
+
+// dirSummary counts results per top-level entry under the search root, and
+// per file for the results tree. It catches up with the result store
+// incrementally as batches arrive.
+type dirSummary struct {
+	root    string
+	counts  map[string]int
+	files   map[string]fileCount
+	counted int // Results already counted
+}
+
+// fileCount is the number of results in a file, and where the first is
+type fileCount struct {
+	count int
+	first int
+}
+
+// summaryEntry is one row of the summary panel
+type summaryEntry struct {
+	path  string
+	count int
+}
+
+// reset clears the counts for results of a search under root
+func (s *dirSummary) reset(root string) {
+	s.root = cleanRoot(root)
+	s.counts = nil
+	s.files = nil
+	s.counted = 0
+}
+
+// update counts the results added to store since the last update
+func (s *dirSummary) update(store *search.ResultStore) error {
+	if s.counted > store.Len() {
+		s.reset(s.root)
+	}
+	matches, err := store.Slice(s.counted, store.Len())
+	if err != nil {
+		return err
+	}
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+		s.files = make(map[string]fileCount)
+	}
+	for i, match := range matches {
+		s.counts[topLevelEntry(s.root, match.Path)]++
+		f, ok := s.files[match.Path]
+		if !ok {
+			f.first = s.counted + i
+		}
+		f.count++
+		s.files[match.Path] = f
+	}
+	s.counted = store.Len()
+	return nil
+}
+
+// entries returns the counted entries, most matches first
+func (s *dirSummary) entries() []summaryEntry {
+	out := make([]summaryEntry, 0, len(s.counts))
+	for p, n := range s.counts {
+		out = append(out, summaryEntry{path: p, count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].path < out[j].path
+	})
+	return out
+}
+
+// cleanRoot normalizes a search path, with "" for the current directory
+func cleanRoot(root string) string {
+	root = path.Clean(normalizeSeparators(root))
+	if root == "." {
+		return ""
+	}
+	return root
+}
+
+// topLevelEntry returns the first path element of p below root, joined back
+// onto root so it can be searched directly. Directories end in a slash.
+func topLevelEntry(root, p string) string {
+	p = strings.TrimPrefix(normalizeSeparators(p), "./")
+	rest := p
+	if root != "" {
+		if r, ok := strings.CutPrefix(p, root+"/"); ok {
+			rest = r
+		} else {
+			// Outside the root, e.g. a searched file; group by its own top level
+			root = ""
+		}
+	}
+	first, _, isDir := strings.Cut(rest, "/")
+	if root != "" {
+		first = root + "/" + first
+	}
+	if isDir {
+		first += "/"
+	}
+	return first
+}
+
+// summaryWidth returns the width of the summary panel's contents
+func (m *Model) summaryWidth() int {
+	w := m.width / 5
+	if w < summaryMinWidth {
+		w = summaryMinWidth
+	}
+	if w > summaryMaxWidth {
+		w = summaryMaxWidth
+	}
+	return w
+}
+
+// toggleSummary shows or hides the per-directory summary panel
+func (m *Model) toggleSummary() {
+	m.summaryVisible = !m.summaryVisible
+	m.summaryIndex = 0
+	if m.summaryVisible {
+		m.refreshSummary()
+	}
+	m.layout()
+}
+
+// refreshSummary counts new results if the summary panel or the results
+// tree is shown
+func (m *Model) refreshSummary() {
+	if !m.summaryVisible && !m.treeVisible {
+		return
+	}
+	if err := m.summary.update(m.results); err != nil {
+		m.status.err = err.Error()
+	}
+}
+
+// updateSummary handles navigation keys while the summary panel is shown. It
+// reports false for keys the panel doesn't use, such as typing.
+func (m Model) updateSummary(msg tea.KeyMsg, a action) (tea.Model, tea.Cmd, bool) {
+	entries := m.summary.entries()
+	switch a {
+	case actionUp:
+		if m.summaryIndex > 0 {
+			m.summaryIndex--
+		}
+	case actionDown:
+		if m.summaryIndex < len(entries)-1 {
+			m.summaryIndex++
+		}
+	case actionClose, actionToggleSummary:
+		m.toggleSummary()
+	case actionOpenEditor:
+		if m.summaryIndex >= len(entries) {
+			return m, nil, true
+		}
+		// Narrow the search to the chosen entry
+		dir := entries[m.summaryIndex].path
+		m.toggleSummary()
+		m.inputs.path.SetValue(dir)
+		m.inputs.path.SetCursor(len(dir))
+		m.lastPath = dir
+		if pattern := m.inputs.pattern.Value(); pattern != "" {
+			return m, m.executeSearch(pattern, dir), true
+		}
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// renderSummary renders the summary panel's contents
+func (m *Model) renderSummary(width, height int) string {
+	entries := m.summary.entries()
+	if len(entries) == 0 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("No matches")
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	selectedStyle := m.selectedStyle()
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("By directory"))
+	sb.WriteString("\n")
+
+	rows := height - 1
+	start := 0
+	if m.summaryIndex >= rows {
+		start = m.summaryIndex - rows + 1
+	}
+	end := start + rows
+	if end > len(entries) {
+		end = len(entries)
+	}
+	for i := start; i < end; i++ {
+		e := entries[i]
+		count := fmt.Sprintf("%d", e.count)
+		name := e.path
+		if limit := width - len(count) - 3; limit > 1 && len(name) > limit {
+			name = "…" + name[len(name)-limit+1:]
+		}
+		pad := width - 2 - len(name) - len(count)
+		if pad < 1 {
+			pad = 1
+		}
+		line := name + strings.Repeat(" ", pad) + countStyle.Render(count)
+		if i == m.summaryIndex {
+			sb.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			sb.WriteString("  " + line)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
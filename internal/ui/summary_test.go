@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestTopLevelEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		path string
+		want string
+	}{
+		{"current directory", "", "./internal/ui/model.go", "internal/"},
+		{"file at root", "", "main.go", "main.go"},
+		{"below root", "internal", "internal/ui/model.go", "internal/ui/"},
+		{"file directly in root", "internal", "internal/doc.go", "internal/doc.go"},
+		{"outside root", "internal", "cmd/irg/main.go", "cmd/"},
+		{"nested root", "internal/ui", "internal/ui/testdata/a.txt", "internal/ui/testdata/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topLevelEntry(cleanRoot(tt.root), tt.path); got != tt.want {
+				t.Errorf("topLevelEntry(%q, %q) = %q, want %q", tt.root, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirSummary_CountsIncrementally(t *testing.T) {
+	store := search.NewResultStore()
+	defer store.Close()
+	var s dirSummary
+	s.reset(".")
+
+	store.Append(search.Match{Path: "./a/x.go"}, search.Match{Path: "./b/y.go"})
+	if err := s.update(store); err != nil {
+		t.Fatal(err)
+	}
+	store.Append(search.Match{Path: "./a/z.go"}, search.Match{Path: "./c.go"})
+	if err := s.update(store); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []summaryEntry{{"a/", 2}, {"b/", 1}, {"c.go", 1}}
+	if got := s.entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
+
+func TestSummary_EnterNarrowsSearch(t *testing.T) {
+	m := newTestModel(t)
+	m.inputs.pattern.SetValue("match")
+	m = finishSearch(t, m, "match", []search.Match{
+		{Path: "./internal/ui/model.go", LineNumber: 1, LineText: "match"},
+		{Path: "./internal/ui/keys.go", LineNumber: 1, LineText: "match"},
+		{Path: "./main.go", LineNumber: 1, LineText: "match"},
+	})
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}, Alt: true})
+	m = updated.(Model)
+	if !m.summaryVisible {
+		t.Fatal("summary panel not shown")
+	}
+	if view := m.View(); !strings.Contains(view, "internal/") || !strings.Contains(view, "By directory") {
+		t.Errorf("summary panel missing from view:\n%s", view)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.summaryVisible {
+		t.Error("summary panel still shown after Enter")
+	}
+	if got := m.inputs.path.Value(); got != "internal/" {
+		t.Errorf("path input = %q, want internal/", got)
+	}
+	if cmd == nil || !m.searching {
+		t.Error("expected a narrowed search to start")
+	}
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pausable is implemented by backends that can hold a running search while
+// irg is suspended
+type pausable interface {
+	Pause()
+	Resume()
+}
+
+// suspend backgrounds irg like Ctrl+Z does for other terminal programs.
+// The running search is paused first and continues on resume, so it
+// doesn't keep using the CPU while irg is stopped.
+func (m *Model) suspend() tea.Cmd {
+	if runtime.GOOS == "windows" {
+		m.status.err = "Suspending isn't supported on Windows"
+		return nil
+	}
+	if p, ok := m.searcher.(pausable); ok {
+		p.Pause()
+	}
+	m.suspendedAt = time.Now()
+	return tea.Suspend
+}
+
+// resume continues the search paused by suspend, unless its timeout had
+// paused it before. The time spent suspended doesn't count toward the
+// search's duration.
+func (m *Model) resume() {
+	if !m.searchHeld {
+		if p, ok := m.searcher.(pausable); ok {
+			p.Resume()
+		}
+		if m.searching && !m.suspendedAt.IsZero() {
+			m.searchStart = m.searchStart.Add(time.Since(m.suspendedAt))
+		}
+	}
+	m.suspendedAt = time.Time{}
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// pausingSearcher records Pause and Resume calls
+type pausingSearcher struct {
+	*search.MockSearcher
+	paused *bool
+}
+
+func (s pausingSearcher) Pause()  { *s.paused = true }
+func (s pausingSearcher) Resume() { *s.paused = false }
+
+func TestSuspend_PausesSearchUntilResume(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no job control on Windows")
+	}
+	paused := false
+	m := newTestModel(t)
+	m.searcher = pausingSearcher{MockSearcher: search.NewMockSearcher(), paused: &paused}
+	m.searching = true
+	m.searchStart = time.Now().Add(-time.Second)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Ctrl+Z returned no command")
+	}
+	if _, ok := cmd().(tea.SuspendMsg); !ok || !paused {
+		t.Fatalf("paused = %v, want the search paused and irg suspended", paused)
+	}
+
+	m.suspendedAt = m.suspendedAt.Add(-time.Hour)
+	updated, _ = m.Update(tea.ResumeMsg{})
+	m = updated.(Model)
+	if paused {
+		t.Error("search still paused after resume")
+	}
+	if elapsed := time.Since(m.searchStart); elapsed > time.Minute {
+		t.Errorf("search has run %v, want the hour suspended left out", elapsed)
+	}
+}
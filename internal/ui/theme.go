@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/config"
+	"github.com/William9923/irg/internal/highlight"
+)
+
+// themeSaveDelay lets a run of theme changes settle before the last one is
+// written to the config file
+const themeSaveDelay = time.Second
+
+// themeSaveMsg saves the theme chosen with the token, unless another was
+// chosen since
+type themeSaveMsg struct{ token int }
+
+// themeSavedMsg reports the theme written to the config file
+type themeSavedMsg struct {
+	theme string
+	path  string
+	err   error
+}
+
+// stepTheme switches the preview to the chroma style step places from the
+// current one, in name order, and returns its name
+func (m *Model) stepTheme(step int) string {
+	themes := highlight.Styles()
+	i := slices.Index(themes, m.highlighter.GetStyle())
+	if i < 0 && step < 0 {
+		i = 0
+	}
+	theme := themes[(i+step+len(themes))%len(themes)]
+	m.highlighter.SetStyle(theme)
+	m.updatePreviewView()
+	return theme
+}
+
+// cycleTheme switches the preview to the next theme, saving it as the
+// theme in the config file once the switching stops
+func (m *Model) cycleTheme() tea.Cmd {
+	theme := m.stepTheme(1)
+	m.status.err = ""
+	m.status.message = "Theme: " + theme
+	if !m.highlighter.IsEnabled() {
+		m.status.message += " (syntax highlighting is off; Ctrl+H turns it on)"
+	}
+	if m.configPath == "" {
+		return nil
+	}
+	m.themeToken++
+	token := m.themeToken
+	return tea.Tick(themeSaveDelay, func(time.Time) tea.Msg { return themeSaveMsg{token: token} })
+}
+
+// saveTheme writes the current theme to the config file, if it's still the
+// one chosen with msg's token
+func (m *Model) saveTheme(msg themeSaveMsg) tea.Cmd {
+	if msg.token != m.themeToken || m.configPath == "" {
+		return nil
+	}
+	path, theme := m.configPath, m.highlighter.GetStyle()
+	return func() tea.Msg {
+		err := config.Save(path, []config.Setting{{Section: "preview", Key: "theme", Value: theme}})
+		return themeSavedMsg{theme: theme, path: path, err: err}
+	}
+}
+
+// updateThemeSaved reports where the theme was saved
+func (m *Model) updateThemeSaved(msg themeSavedMsg) {
+	if msg.err != nil {
+		m.status.err = msg.err.Error()
+		return
+	}
+	if m.status.err == "" && !m.searching {
+		m.status.message = fmt.Sprintf("Theme %s saved to %s", msg.theme, displayPath(msg.path))
+	}
+}
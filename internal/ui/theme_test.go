@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/config"
+	"github.com/William9923/irg/internal/highlight"
+)
+
+func TestCycleTheme_SavesTheLastChoice(t *testing.T) {
+	m := newTestModel(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("# mine\n[preview]\ntheme = \"monokai\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m.SetConfigFile(path)
+	m.highlighter.SetEnabled(true)
+	m.highlighter.SetStyle("monokai")
+
+	press := func() tea.Cmd {
+		t.Helper()
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyF3})
+		m = updated.(Model)
+		return cmd
+	}
+	first := press()
+	second := press()
+
+	themes := highlight.Styles()
+	want := themes[0]
+	for i, name := range themes {
+		if name == "monokai" {
+			want = themes[(i+2)%len(themes)]
+		}
+	}
+	if got := m.highlighter.GetStyle(); got != want {
+		t.Fatalf("theme after two presses = %q, want %q", got, want)
+	}
+	if !strings.Contains(m.status.message, want) {
+		t.Errorf("status = %q, want it naming %q", m.status.message, want)
+	}
+
+	// Only the last change in a run is saved
+	if updated, cmd := m.Update(first()); cmd != nil {
+		t.Fatal("a superseded theme change was saved")
+	} else {
+		m = updated.(Model)
+	}
+	updated, cmd := m.Update(second())
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("the last theme change wasn't saved")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if m.status.err != "" {
+		t.Fatalf("save failed: %s", m.status.err)
+	}
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if cfg.Preview.Theme != want {
+		t.Errorf("saved theme = %q, want %q", cfg.Preview.Theme, want)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "# mine") {
+		t.Errorf("saved file lost its comment:\n%s", data)
+	}
+}
+
+func TestCycleTheme_NotesHighlightingOff(t *testing.T) {
+	m := newTestModel(t)
+	m.highlighter.SetEnabled(false)
+	if cmd := m.cycleTheme(); cmd != nil {
+		t.Error("cycling with no config file returned a save command")
+	}
+	if !strings.Contains(m.status.message, "Ctrl+H") {
+		t.Errorf("status = %q, want a note that highlighting is off", m.status.message)
+	}
+}
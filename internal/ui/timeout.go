@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchTimeoutMsg reports that the search started with ctx has run for the
+// search timeout
+type searchTimeoutMsg struct {
+	ctx context.Context
+}
+
+// SetSearchTimeout stops searches running longer than d, keeping the results
+// found so far; 0 lets them run to the end
+func (m *Model) SetSearchTimeout(d time.Duration) {
+	m.searchTimeout = max(d, 0)
+}
+
+// searchDeadline returns the command reporting when the search started with
+// ctx times out, or nil without a timeout
+func (m *Model) searchDeadline(ctx context.Context) tea.Cmd {
+	if m.searchTimeout <= 0 {
+		return nil
+	}
+	return tea.Tick(m.searchTimeout, func(time.Time) tea.Msg {
+		return searchTimeoutMsg{ctx: ctx}
+	})
+}
+
+// stopSearch stops the search that timed out, marking its results partial.
+// rg is paused rather than killed, so continueSearch can pick up where it
+// stopped; searches that can't be paused are canceled.
+func (m *Model) stopSearch(msg searchTimeoutMsg) {
+	if msg.ctx != m.searchCtx || !m.searching || m.searchHeld || msg.ctx == m.continuedCtx {
+		return
+	}
+	m.partial = true
+	if p, ok := m.searcher.(pausable); ok && runtime.GOOS != "windows" {
+		p.Pause()
+		m.searchHeld = true
+		m.heldAt = time.Now()
+		return
+	}
+	m.searchCancel()
+	m.status.message = fmt.Sprintf("Search stopped after %s; the results are partial | Alt+Z: search again to the end", m.searchTimeout)
+}
+
+// continueSearch lets the search stopped by its timeout run to the end:
+// a paused one resumes where it was, a canceled one starts over without
+// the timeout
+func (m *Model) continueSearch() tea.Cmd {
+	if m.searchHeld {
+		m.releaseSearch()
+		m.partial = false
+		m.continuedCtx = m.searchCtx
+		return nil
+	}
+	if !m.partial {
+		m.status.message = "The search wasn't stopped by the timeout"
+		return nil
+	}
+	cmd := m.searchAgain()
+	m.continuedCtx = m.searchCtx
+	return cmd
+}
+
+// releaseSearch resumes the rg processes paused by stopSearch. The time they
+// were paused doesn't count toward the search's duration.
+func (m *Model) releaseSearch() {
+	if !m.searchHeld {
+		return
+	}
+	if p, ok := m.searcher.(pausable); ok {
+		p.Resume()
+	}
+	m.searchStart = m.searchStart.Add(time.Since(m.heldAt))
+	m.searchHeld = false
+}
+
+// heldStatus describes the search paused by its timeout
+func (m *Model) heldStatus() string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+		fmt.Sprintf("Search stopped after %s with %d matches; the results are partial | Alt+Z: continue searching", m.searchTimeout, m.matchCount))
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func TestSearchTimeout_PausesUntilContinued(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no job control on Windows")
+	}
+	paused := false
+	m := newTestModel(t)
+	m.searcher = pausingSearcher{MockSearcher: search.NewMockSearcher(testMatches(0, 3)...), paused: &paused}
+	m.SetSearchTimeout(10 * time.Second)
+	batch, ok := m.executeSearch("match", ".")().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("search command returned %T, want the search batched with its deadline", batch)
+	}
+
+	updated, _ := m.Update(searchTimeoutMsg{ctx: m.searchCtx})
+	m = updated.(Model)
+	if !paused || !m.searchHeld {
+		t.Fatal("the timeout didn't pause the search")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Search stopped after 10s") || !strings.Contains(view, "Alt+Z: continue searching") {
+		t.Errorf("status doesn't offer to continue:\n%s", view)
+	}
+
+	// Coming back from Ctrl+Z leaves it paused
+	updated, _ = m.Update(tea.ResumeMsg{})
+	m = updated.(Model)
+	if !paused {
+		t.Error("resuming irg continued the search stopped by its timeout")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}, Alt: true})
+	m = updated.(Model)
+	if paused || m.searchHeld || m.partial {
+		t.Fatalf("paused = %v, held = %v, partial = %v after Alt+Z; want the search running", paused, m.searchHeld, m.partial)
+	}
+	// The continued search has no timeout any more
+	updated, _ = m.Update(searchTimeoutMsg{ctx: m.searchCtx})
+	m = updated.(Model)
+	if paused {
+		t.Error("a continued search was stopped again")
+	}
+
+	m = runSearch(t, m, batch[0])
+	if !m.resultsDone || m.matchCount != 3 {
+		t.Errorf("done = %v with %d matches, want the search finished with all 3", m.resultsDone, m.matchCount)
+	}
+}
+
+func TestSearchTimeout_CancelsWhenItCantPause(t *testing.T) {
+	searcher := search.NewMockSearcher(testMatches(0, 3)...)
+	m := newTestModel(t)
+	m.searcher = searcher
+	m.SetSearchTimeout(time.Second)
+	m.inputs.pattern.SetValue("match")
+	m.lastPattern = "match"
+	batch := m.executeSearch("match", ".")().(tea.BatchMsg)
+
+	updated, _ := m.Update(searchTimeoutMsg{ctx: m.searchCtx})
+	m = runSearch(t, updated.(Model), batch[0])
+	if m.resultsDone || !m.partial {
+		t.Fatalf("done = %v, partial = %v; want the timed out search stopped short", m.resultsDone, m.partial)
+	}
+	if status := m.statusLine(); !strings.Contains(status, "partial") || !strings.Contains(status, "Alt+Z") {
+		t.Errorf("status = %q, want the results marked partial", status)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}, Alt: true})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Alt+Z didn't search again")
+	}
+	batch = cmd().(tea.BatchMsg)
+	updated, _ = m.Update(searchTimeoutMsg{ctx: m.searchCtx})
+	m = runSearch(t, updated.(Model), batch[0])
+	if len(searcher.Calls()) != 2 || !m.resultsDone || m.partial {
+		t.Errorf("%d searches, done = %v; want the search run again to the end despite the timeout", len(searcher.Calls()), m.resultsDone)
+	}
+}
@@ -0,0 +1,236 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// treeNode is a directory or a file of the results tree
+type treeNode struct {
+	name     string // Below the parent; directories holding only a directory are joined, as in "internal/ui"
+	path     string // A file's path as in the results; a directory's ends in a slash
+	dir      bool
+	count    int
+	first    int // Where a file's first result is
+	children []*treeNode
+	dirs     map[string]*treeNode // Child directories by name, while building
+}
+
+// treeRow is a line of the results tree as shown
+type treeRow struct {
+	*treeNode
+	depth  int
+	parent int // Row of the enclosing directory, or -1
+}
+
+// buildTree arranges the files with results into directories below root,
+// directories first and each level sorted by name
+func buildTree(root string, files map[string]fileCount) *treeNode {
+	top := &treeNode{dir: true, dirs: make(map[string]*treeNode)}
+	for path, f := range files {
+		rel := strings.TrimPrefix(normalizeSeparators(path), "./")
+		prefix := ""
+		if root != "" {
+			if r, ok := strings.CutPrefix(rel, root+"/"); ok {
+				rel = r
+				prefix = root + "/"
+			}
+		}
+		parts := strings.Split(rel, "/")
+		node := top
+		for _, part := range parts[:len(parts)-1] {
+			prefix += part + "/"
+			child, ok := node.dirs[part]
+			if !ok {
+				child = &treeNode{name: part, path: prefix, dir: true, dirs: make(map[string]*treeNode)}
+				node.dirs[part] = child
+				node.children = append(node.children, child)
+			}
+			child.count += f.count
+			node = child
+		}
+		node.children = append(node.children, &treeNode{name: parts[len(parts)-1], path: path, count: f.count, first: f.first})
+	}
+	top.finish()
+	return top
+}
+
+// finish sorts the children of n and joins directories that hold nothing
+// but another directory
+func (n *treeNode) finish() {
+	n.dirs = nil
+	for i, child := range n.children {
+		for child.dir && len(child.children) == 1 && child.children[0].dir {
+			only := child.children[0]
+			only.name = child.name + "/" + only.name
+			child = only
+		}
+		n.children[i] = child
+		child.finish()
+	}
+	sort.Slice(n.children, func(i, j int) bool {
+		a, b := n.children[i], n.children[j]
+		if a.dir != b.dir {
+			return a.dir
+		}
+		return a.name < b.name
+	})
+}
+
+// treeRows lists the rows of the results tree, leaving out what's inside
+// collapsed directories
+func (m *Model) treeRows() []treeRow {
+	var rows []treeRow
+	var walk func(n *treeNode, depth, parent int)
+	walk = func(n *treeNode, depth, parent int) {
+		for _, child := range n.children {
+			rows = append(rows, treeRow{treeNode: child, depth: depth, parent: parent})
+			if child.dir && !m.treeCollapsed[child.path] {
+				walk(child, depth+1, len(rows)-1)
+			}
+		}
+	}
+	walk(buildTree(m.summary.root, m.summary.files), 0, -1)
+	return rows
+}
+
+// toggleTree shows the results as a tree of their files in place of the
+// list, or the list again. The tree opens on the selected result's file.
+func (m *Model) toggleTree() {
+	m.treeVisible = !m.treeVisible
+	if !m.treeVisible {
+		m.updateResultsView()
+		return
+	}
+	m.refreshSummary()
+	m.treeIndex = 0
+	if m.list.selected < m.results.Len() {
+		if match, err := m.results.Get(m.list.selected); err == nil {
+			for i, row := range m.treeRows() {
+				if !row.dir && row.path == match.Path {
+					m.treeIndex = i
+					break
+				}
+			}
+		}
+	}
+}
+
+// updateTree handles the keys moving in the results tree. It reports false
+// for keys the tree doesn't use, such as typing.
+func (m Model) updateTree(msg tea.KeyMsg, a action) (tea.Model, tea.Cmd, bool) {
+	rows := m.treeRows()
+	if m.treeIndex >= len(rows) {
+		m.treeIndex = max(len(rows)-1, 0)
+	}
+	switch {
+	case a == actionUp:
+		return m, m.moveTree(rows, -1), true
+	case a == actionDown:
+		return m, m.moveTree(rows, 1), true
+	case a == actionPageUp:
+		return m, m.moveTree(rows, -pageStep), true
+	case a == actionPageDown:
+		return m, m.moveTree(rows, pageStep), true
+	case a == actionClose || a == actionToggleTree:
+		m.toggleTree()
+		return m, nil, true
+	case len(rows) == 0:
+		return m, nil, false
+	}
+
+	row := rows[m.treeIndex]
+	switch {
+	case a == actionOpenEditor && row.dir:
+		m.setCollapsed(row.path, !m.treeCollapsed[row.path])
+	case a == actionOpenEditor:
+		// Back to the list, at the file's results
+		m.toggleTree()
+		return m, m.moveSelection(row.first - m.list.selected), true
+	case msg.String() == "right" && row.dir:
+		m.setCollapsed(row.path, false)
+	case msg.String() == "left" && row.dir && !m.treeCollapsed[row.path]:
+		m.setCollapsed(row.path, true)
+	case msg.String() == "left" && row.parent >= 0:
+		m.treeIndex = row.parent
+	case msg.String() == "left" || msg.String() == "right":
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// moveTree moves the tree's cursor by delta rows. On a file the list's
+// selection follows, to its first result, so the preview shows it.
+func (m *Model) moveTree(rows []treeRow, delta int) tea.Cmd {
+	if len(rows) == 0 {
+		return nil
+	}
+	m.treeIndex = clamp(m.treeIndex+delta, 0, len(rows)-1)
+	if row := rows[m.treeIndex]; !row.dir && row.first != m.list.selected {
+		return m.moveSelection(row.first - m.list.selected)
+	}
+	return nil
+}
+
+// setCollapsed folds the directory at path, or unfolds it
+func (m *Model) setCollapsed(path string, collapsed bool) {
+	if m.treeCollapsed == nil {
+		m.treeCollapsed = make(map[string]bool)
+	}
+	if collapsed {
+		m.treeCollapsed[path] = true
+	} else {
+		delete(m.treeCollapsed, path)
+	}
+}
+
+// renderTree renders the results tree in the results pane, width cells wide
+// and height rows tall
+func (m *Model) renderTree(width, height int) string {
+	rows := m.treeRows()
+	if len(rows) == 0 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("No matches")
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	selectedStyle := m.selectedStyle()
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("Files (%d)", len(m.summary.files))))
+	sb.WriteString("\n")
+
+	visible := max(height-1, 1)
+	start := max(m.treeIndex-visible+1, 0)
+	end := min(start+visible, len(rows))
+	for i := start; i < end; i++ {
+		row := rows[i]
+		marker := "  "
+		if row.dir {
+			marker = "▾ "
+			if m.treeCollapsed[row.path] {
+				marker = "▸ "
+			}
+		}
+		count := fmt.Sprintf("%d", row.count)
+		name := ansi.Truncate(strings.Repeat("  ", row.depth)+marker+row.name, max(width-len(count)-3, 1), "…")
+		pad := max(width-2-ansi.StringWidth(name)-len(count), 1)
+		if i == m.treeIndex {
+			sb.WriteString(selectedStyle.Render("> " + name + strings.Repeat(" ", pad) + count))
+		} else {
+			if row.dir {
+				name = dirStyle.Render(name)
+			}
+			sb.WriteString("  " + name + strings.Repeat(" ", pad) + countStyle.Render(count))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
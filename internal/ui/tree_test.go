@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+func treeMatches() []search.Match {
+	var matches []search.Match
+	for _, path := range []string{"top.go", "internal/ui/view.go", "internal/ui/view.go", "internal/ui/keys.go", "cmd/main.go"} {
+		matches = append(matches, search.Match{Path: path, LineNumber: len(matches) + 1, LineText: "needle"})
+	}
+	return matches
+}
+
+func TestBuildTree_JoinsSingleDirectories(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(treeMatches()...)
+	m = runSearch(t, m, m.executeSearch("needle", "."))
+	m.toggleTree()
+
+	var got []string
+	for _, row := range m.treeRows() {
+		got = append(got, strings.Repeat("  ", row.depth)+row.name+" "+string(rune('0'+row.count)))
+	}
+	want := []string{"cmd 1", "  main.go 1", "internal/ui 3", "  keys.go 1", "  view.go 2", "top.go 1"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+func TestTree_NavigatesAndLoadsFileResults(t *testing.T) {
+	m := newTestModel(t)
+	m.searcher = search.NewMockSearcher(treeMatches()...)
+	m = runSearch(t, m, m.executeSearch("needle", "."))
+	m.list.selected = 2 // Second match in view.go
+
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	press(tea.KeyMsg{Type: tea.KeyF2})
+	if !m.treeVisible || m.treeIndex != 4 {
+		t.Fatalf("tree shown %v on row %d, want it opened on view.go's row 4", m.treeVisible, m.treeIndex)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Files (4)") || !strings.Contains(view, "▾ internal/ui") {
+		t.Errorf("tree missing from the results pane:\n%s", view)
+	}
+
+	// Moving onto a file selects its first result
+	press(tea.KeyMsg{Type: tea.KeyUp})
+	if m.list.selected != 3 {
+		t.Errorf("selected %d on keys.go, want its result 3", m.list.selected)
+	}
+
+	// Left from a file goes to its directory, then folds it
+	press(tea.KeyMsg{Type: tea.KeyLeft})
+	press(tea.KeyMsg{Type: tea.KeyLeft})
+	if m.treeIndex != 2 || !m.treeCollapsed["internal/ui/"] || len(m.treeRows()) != 4 {
+		t.Fatalf("row %d, folded %v; want internal/ui folded", m.treeIndex, m.treeCollapsed)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.treeCollapsed["internal/ui/"] {
+		t.Error("Enter on a folded directory didn't unfold it")
+	}
+
+	// Enter on a file goes back to the list at its results
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.treeVisible || m.list.selected != 1 {
+		t.Errorf("tree shown %v, selected %d; want the list at view.go's first result", m.treeVisible, m.list.selected)
+	}
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+// typeCounts counts results per file so the types dropdown can show how many
+// would remain under each type filter. Like dirSummary it catches up with
+// the result store incrementally.
+type typeCounts struct {
+	files   map[string]int // Results per path
+	counted int            // Results already counted
+	byType  map[string]int // Memoized per type until more results arrive
+}
+
+// reset clears the counts for a new result list
+func (c *typeCounts) reset() {
+	c.files = nil
+	c.counted = 0
+	c.byType = nil
+}
+
+// update counts the results added to store since the last update
+func (c *typeCounts) update(store *search.ResultStore) error {
+	if c.counted > store.Len() {
+		c.reset()
+	}
+	if c.files != nil && c.counted == store.Len() {
+		return nil
+	}
+	matches, err := store.Slice(c.counted, store.Len())
+	if err != nil {
+		return err
+	}
+	if c.files == nil {
+		c.files = make(map[string]int)
+	}
+	for _, match := range matches {
+		c.files[match.Path]++
+	}
+	c.counted = store.Len()
+	c.byType = make(map[string]int)
+	return nil
+}
+
+// count returns the number of counted results in files of typeName
+func (c *typeCounts) count(typeName string, globs map[string][]string) int {
+	if n, ok := c.byType[typeName]; ok {
+		return n
+	}
+	n := 0
+	for path, matches := range c.files {
+		if search.MatchesType(path, typeName, globs) {
+			n += matches
+		}
+	}
+	if c.byType != nil {
+		c.byType[typeName] = n
+	}
+	return n
+}
+
+// refreshTypeCounts brings the dropdown counts up to date while it is shown
+func (m *Model) refreshTypeCounts() {
+	if !m.typeDropdown.visible {
+		return
+	}
+	if err := m.typeCounts.update(m.results); err != nil {
+		m.status.err = err.Error()
+	}
+}
+
+// sortedKeys returns the type names of globs in order
+func sortedKeys(globs map[string][]string) []string {
+	names := make([]string, 0, len(globs))
+	for name := range globs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// typeDetail renders what follows a type name in the dropdown: the number
+// of current results in its files, then the globs it covers, cut to width
+func (m Model) typeDetail(typeName string, width int) string {
+	var detail string
+	if m.results.Len() > 0 && m.typeCounts.files != nil {
+		detail = fmt.Sprintf(" (%d)", m.typeCounts.count(typeName, m.typeGlobs))
+	}
+	if globs := m.typeGlobs[typeName]; len(globs) > 0 {
+		detail += " " + lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(strings.Join(globs, " "))
+	}
+	return ansi.Truncate(detail, width, "…")
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/William9923/irg/internal/search"
+)
+
+var testTypeGlobs = map[string][]string{
+	"go":       {"*.go"},
+	"markdown": {"*.markdown", "*.md", "*.mdown"},
+	"make":     {"Makefile", "*.mk"},
+}
+
+func TestTypeCounts_CountsIncrementally(t *testing.T) {
+	store := search.NewResultStore()
+	defer store.Close()
+	store.Append(
+		search.Match{Path: "main.go"},
+		search.Match{Path: "main.go"},
+		search.Match{Path: "README.md"},
+	)
+
+	var c typeCounts
+	if err := c.update(store); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.count("go", testTypeGlobs); got != 2 {
+		t.Errorf("go = %d, want 2", got)
+	}
+
+	store.Append(search.Match{Path: "ui/model.go"}, search.Match{Path: "Makefile"})
+	if err := c.update(store); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]int{"go": 3, "markdown": 1, "make": 1, "rust": 0}
+	for name, want := range tests {
+		if got := c.count(name, testTypeGlobs); got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestTypesDropdown_ShowsGlobsAndCounts(t *testing.T) {
+	m := newTestModel(t)
+	m.typeGlobs = testTypeGlobs
+	m.allTypes = sortedKeys(testTypeGlobs)
+
+	matches := testMatches(0, 3)
+	matches[2].Path = "notes.md"
+	updated, _ := m.Update(searchResultMsg{matches: matches, done: true})
+	m = updated.(Model)
+
+	m.inputs.focused = focusTypes
+	m.inputs.types.Focus()
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = updated.(Model)
+
+	view := m.View()
+	for _, want := range []string{"markdown (1) *.markdown", "make (0) Makefile *.mk"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("dropdown missing %q:\n%s", want, view)
+		}
+	}
+}
@@ -1,15 +1,40 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 
+	"github.com/William9923/irg/internal/classify"
+	"github.com/William9923/irg/internal/config"
+	"github.com/William9923/irg/internal/dashboard"
+	"github.com/William9923/irg/internal/export"
+	"github.com/William9923/irg/internal/history"
+	"github.com/William9923/irg/internal/hooks"
+	"github.com/William9923/irg/internal/lint"
+	"github.com/William9923/irg/internal/metrics"
 	"github.com/William9923/irg/internal/search"
+	"github.com/William9923/irg/internal/server"
+	"github.com/William9923/irg/internal/shell"
+	"github.com/William9923/irg/internal/state"
+	"github.com/William9923/irg/internal/stats"
+	"github.com/William9923/irg/internal/tmux"
+	"github.com/William9923/irg/internal/todos"
 	"github.com/William9923/irg/internal/ui"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type arrayFlags []string
@@ -24,44 +49,969 @@ func (i *arrayFlags) Set(value string) error {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServe(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "count" {
+		os.Exit(runCount(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		os.Exit(runStats(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "todos" {
+		os.Exit(runTodos(os.Args[2:]))
+	}
+
+	var configFlag = flag.String("config", "", "Path to the config file (default: $XDG_CONFIG_HOME/irg/config.toml)")
+	var noProjectConfigFlag = flag.Bool("no-project-config", false, "Don't apply the .irg.toml found in the current directory or its parents")
 	var caseFlag = flag.String("case", "smart", "Case sensitivity mode: smart, sensitive, insensitive")
 	var typeFlags arrayFlags
 	var typeNotFlags arrayFlags
+	var bindFlags arrayFlags
 	flag.Var(&typeFlags, "type", "Include only files of type (can be used multiple times)")
 	flag.Var(&typeNotFlags, "type-not", "Exclude files of type (can be used multiple times)")
+	var profileFlag = flag.String("profile", "", "Search with a language profile's file types and exclusions: go, java, node, python, ruby, rust, a configured one, or auto to detect it (pick at runtime with Alt+G)")
+	flag.Var(&bindFlags, "bind", "Bind keys to actions, fzf-style: KEY:ACTION[,KEY:ACTION...] (can be used multiple times)")
+	var inlineContextFlag = flag.Bool("inline-context", false, "Show a line of context above and below each result (toggle at runtime with Alt+X)")
+	var fileInfoFlag = flag.Bool("file-info", false, "Show how long ago each result's file was modified and its size (toggle at runtime with Alt+I)")
+	var sortRecentFlag = flag.Bool("sort-recent", false, "List results of the most recently modified files first once a search finishes (toggle at runtime with Alt+A)")
+	var shardsFlag = flag.Int("shards", 0, "Split searches across the top-level directories of the path, running up to N rg processes at once (for huge monorepos)")
+	var maxDepthFlag = flag.Int("max-depth", 0, "Search at most N directory levels below the path, like rg --max-depth (default: any depth)")
+	var timeoutFlag = flag.Duration("timeout", 0, "Stop searches running longer than this, such as 10s, keeping the partial results (continue with Alt+Z; default: no limit)")
+	var maxLineLengthFlag = flag.Int("max-line-length", 0, "Cut result lines longer than N bytes down to an excerpt around the match (default 1000)")
+	var stableOrderFlag = flag.Bool("stable-order", false, "Sort results by path so repeated searches list them in the same order (slower: rg runs single-threaded)")
+	var searchZipFlag = flag.Bool("search-zip", false, "Search inside compressed files (gzip, bzip2, xz, lz4, lzma, brotli, zstd)")
+	var preFlag = flag.String("pre", "", "Search the output of COMMAND PATH instead of each file, e.g. a script that lists zip or tar members")
+	var preGlobFlags arrayFlags
+	flag.Var(&preGlobFlags, "pre-glob", "Only run --pre on files matching this glob (can be used multiple times)")
+	var pathsFromFlag = flag.String("paths-from", "", "Search only the newline-separated paths listed in this file (- for stdin)")
+	var baseDirFlag = flag.String("base-dir", "", "Resolve relative --paths-from paths against this directory instead of the current one")
+	var gitTrackedFlag = flag.Bool("git-tracked", false, "Search only files tracked by git (toggle at runtime with Ctrl+G)")
+	var noIgnoreFlag = flag.Bool("no-ignore", false, "Search files excluded by .gitignore, .ignore and similar files, like rg --no-ignore (toggle at runtime with Alt+U)")
+	var wordRegexpFlag bool
+	flag.BoolVar(&wordRegexpFlag, "word-regexp", false, "Match the pattern only as a whole word, like rg --word-regexp (toggle at runtime with Ctrl+W)")
+	flag.BoolVar(&wordRegexpFlag, "w", false, "Short for --word-regexp")
+	var repoRootFlag = flag.Bool("repo-root", false, "Search from the top of the git repository around the current directory when the path is left empty")
+	var normalizeFlag = flag.Bool("normalize", false, "Match the pattern against text in either Unicode normalization form (NFC or NFD) and show result lines composed")
+	var multilineFlag = flag.Bool("multiline", false, "Let patterns match across lines, like rg --multiline; \\n matches a line ending (toggle at runtime with Alt+J)")
+	var sourcegraphFlag = flag.Bool("sourcegraph", false, "Search a Sourcegraph instance (SRC_ENDPOINT, SRC_ACCESS_TOKEN) instead of local files")
+	var selectFlag = flag.Bool("select", false, "Print the match chosen with Enter as path:line and exit, instead of opening an editor")
+	var shellInitFlag = flag.String("shell-init", "", "Print a key binding script for a shell (bash, fish, zsh) and exit")
+	var tmuxPopupFlag = flag.Bool("tmux-popup", false, "Run in a tmux popup and print the selection like --select (falls back to --select outside tmux)")
+	var tmuxPopupSizeFlag = flag.String("tmux-popup-size", tmux.DefaultSize, "Size of the --tmux-popup window: N, N%, or WxH")
+	var lspFlag = flag.Bool("lsp", false, "Experimental: use language servers (gopls) for references and definitions (Alt+R)")
+	var metricsFlag = flag.Bool("metrics", false, "Collect local performance metrics and write them to a file on exit")
+	var metricsFileFlag = flag.String("metrics-file", "irg-metrics.json", "File to write metrics to when --metrics is set")
+	var outputFlag = flag.String("output", "", "Print final results (or the marked ones) on exit in this format: sarif, quickfix, json")
+	var jsonFlag = flag.Bool("json", false, "Print final results (or the marked ones) on exit as JSON lines; short for --output=json")
+	var outputFileFlag = flag.String("output-file", "", "Write --output results to this file instead of stdout")
+	var quickfixFileFlag = flag.String("quickfix-file", export.QuickfixFile, "File Ctrl+Q writes the marked (or all) results to in quickfix format")
+	var announceFlag = flag.String("announce", "", "Write the selected result to this file, named pipe or fd:N descriptor on every selection change, for screen readers")
+	var printOnExitFlag = flag.Bool("print-on-exit", false, "On exit, print the final results (or the marked ones) to the terminal, so they stay visible after the UI closes")
 	flag.Parse()
 
-	if _, err := exec.LookPath("rg"); err != nil {
+	if *shellInitFlag != "" {
+		script, err := shell.Init(*shellInitFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --shell-init: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(script)
+		return
+	}
+
+	if *tmuxPopupFlag {
+		if tmux.InTmux() {
+			os.Exit(runTmuxPopup(*tmuxPopupSizeFlag))
+		}
+		*selectFlag = true
+	}
+
+	if _, err := exec.LookPath("rg"); err != nil && !*sourcegraphFlag {
 		fmt.Fprintln(os.Stderr, "Error: ripgrep (rg) is not installed or not in PATH")
 		fmt.Fprintln(os.Stderr, "Please install ripgrep: https://github.com/BurntSushi/ripgrep#installation")
 		os.Exit(1)
 	}
 
-	var caseSensitivity search.CaseSensitivity
-	switch strings.ToLower(*caseFlag) {
-	case "smart":
-		caseSensitivity = search.CaseSmart
-	case "sensitive":
-		caseSensitivity = search.CaseSensitive
-	case "insensitive":
-		caseSensitivity = search.CaseInsensitive
-	default:
+	caseSensitivity, err := search.ParseCaseSensitivity(*caseFlag)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error: --case must be one of: smart, sensitive, insensitive")
 		os.Exit(1)
 	}
 
+	if *shardsFlag < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --shards must not be negative")
+		os.Exit(1)
+	}
+	if *maxDepthFlag < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-depth must not be negative")
+		os.Exit(1)
+	}
+	if *maxLineLengthFlag < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-line-length must not be negative")
+		os.Exit(1)
+	}
+
+	if len(preGlobFlags) > 0 && *preFlag == "" {
+		fmt.Fprintln(os.Stderr, "Error: --pre-glob needs --pre")
+		os.Exit(1)
+	}
+
+	var roots []string
+	if *pathsFromFlag != "" {
+		if *sourcegraphFlag {
+			fmt.Fprintln(os.Stderr, "Error: --paths-from can't be combined with --sourcegraph")
+			os.Exit(1)
+		}
+		roots, err = search.ReadRootsFile(*pathsFromFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --paths-from: %v\n", err)
+			os.Exit(1)
+		}
+		if len(roots) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --paths-from: no paths in %s\n", *pathsFromFlag)
+			os.Exit(1)
+		}
+		if *baseDirFlag != "" {
+			if info, err := os.Stat(*baseDirFlag); err != nil || !info.IsDir() {
+				fmt.Fprintf(os.Stderr, "Error: --base-dir: %s is not a directory\n", *baseDirFlag)
+				os.Exit(1)
+			}
+		}
+		roots, err = search.ResolveRoots(roots, *baseDirFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --paths-from: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *baseDirFlag != "" {
+		fmt.Fprintln(os.Stderr, "Error: --base-dir needs --paths-from")
+		os.Exit(1)
+	}
+
+	if *jsonFlag {
+		if *outputFlag != "" && *outputFlag != string(export.FormatJSON) {
+			fmt.Fprintf(os.Stderr, "Error: --json conflicts with --output=%s\n", *outputFlag)
+			os.Exit(1)
+		}
+		*outputFlag = string(export.FormatJSON)
+	}
+	var outputFormat export.Format
+	if *outputFlag != "" {
+		format, err := export.ParseFormat(*outputFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --output: %v\n", err)
+			os.Exit(1)
+		}
+		outputFormat = format
+	}
+
+	cfg, cfgErr := loadConfig(*configFlag, !*noProjectConfigFlag)
+	if cfgErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", cfgErr)
+		os.Exit(1)
+	}
+
+	// Session state is a convenience; irg runs without it if it's unreadable
+	store, err := state.Open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	project := state.ProjectRoot(".")
+	if !flagSet("case") {
+		// The mode saved for this project beats the configured default
+		if configured, err := search.ParseCaseSensitivity(cfg.Search.Case); err == nil {
+			caseSensitivity = configured
+		}
+		if saved, err := search.ParseCaseSensitivity(store.Project(project).CaseMode); err == nil {
+			caseSensitivity = saved
+		}
+	}
+
 	model := ui.NewModel()
+	model.SetState(store, project)
+	if cfg.Stats.Record == nil || *cfg.Stats.Record {
+		if path, err := stats.Path(); err == nil {
+			model.SetStats(path)
+		}
+	}
+	if cfg.History.Record == nil || *cfg.History.Record {
+		// Like session state, the history is a convenience; an unreadable
+		// one leaves recall to this session's searches
+		if path, err := history.Path(); err == nil {
+			if entries, err := history.Read(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			} else {
+				model.SetHistory(path, entries)
+			}
+		}
+	}
+	model.SetConfigFile(configFile(*configFlag))
+	model.SetEditorCommand(cfg.Editor.Command)
+	if cfg.Preview.Theme != "" {
+		model.SetTheme(cfg.Preview.Theme)
+	}
+	if cfg.Preview.Syntax != nil {
+		model.SetSyntaxHighlighting(*cfg.Preview.Syntax)
+	}
+	if len(cfg.Bookmarks) > 0 {
+		model.SetBookmarks(bookmarks(cfg.Bookmarks))
+	}
+	model.SetPreviewContext(cfg.Preview.Context, previewRadii(cfg.Preview.Contexts))
+	model.SetHooks(hooks.New(cfg.Hooks))
+	model.SetReplaceCommand(cfg.Replace.Command)
+	model.SetDiffCommand(cfg.Diff.Command)
+	model.SetLinters(linters(cfg.Lint))
+	model.SetPathIndex(cfg.Paths.MaxDepth, cfg.Paths.Skip)
+	model.SetCustomTypes(cfg.Types)
+	if len(cfg.Classes) > 0 {
+		model.SetClassifier(classify.New(resultClasses(cfg.Classes)))
+	}
 	model.SetCaseSensitivity(caseSensitivity)
 	model.SetFileTypes(typeFlags, typeNotFlags)
+	model.SetProfiles(profiles(cfg.Profiles))
+	profile := cfg.Search.Profile
+	if flagSet("profile") {
+		profile = *profileFlag
+	}
+	if profile != "" {
+		if err := model.SetProfile(profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	model.SetRoots(roots)
+	if boolOption("repo-root", *repoRootFlag, cfg.Search.RepoRoot) && len(roots) == 0 && !*sourcegraphFlag {
+		model.SetRepoRoot(repoRoot(project))
+	}
+	model.SetDecoder(search.Decoder{SearchZip: *searchZipFlag, Pre: *preFlag, PreGlobs: preGlobFlags})
+	model.SetGitTracked(boolOption("git-tracked", *gitTrackedFlag, cfg.Search.GitTracked))
+	model.SetNoIgnore(boolOption("no-ignore", *noIgnoreFlag, cfg.Search.NoIgnore))
+	model.SetMultiline(boolOption("multiline", *multilineFlag, cfg.Search.Multiline))
+	wholeWord := cfg.Search.WordRegexp
+	if flagSet("word-regexp") || flagSet("w") {
+		wholeWord = wordRegexpFlag
+	}
+	model.SetWholeWord(wholeWord)
+	model.SetNormalize(boolOption("normalize", *normalizeFlag, cfg.Search.Normalize))
+	model.SetStableOrder(boolOption("stable-order", *stableOrderFlag, cfg.Search.StableOrder))
+	model.SetInlineContext(boolOption("inline-context", *inlineContextFlag, cfg.Search.InlineContext))
+	model.SetFileInfo(boolOption("file-info", *fileInfoFlag, cfg.Search.FileInfo))
+	model.SetSortRecent(boolOption("sort-recent", *sortRecentFlag, cfg.Search.SortRecent))
+	if flagSet("shards") {
+		model.SetShards(*shardsFlag)
+	} else {
+		model.SetShards(cfg.Search.Shards)
+	}
+	if flagSet("max-depth") {
+		model.SetMaxDepth(*maxDepthFlag)
+	} else {
+		model.SetMaxDepth(cfg.Search.MaxDepth)
+	}
+	if flagSet("max-line-length") {
+		model.SetMaxLineLength(*maxLineLengthFlag)
+	} else {
+		model.SetMaxLineLength(cfg.Search.MaxLineLength)
+	}
+	if flagSet("timeout") {
+		model.SetSearchTimeout(*timeoutFlag)
+	} else {
+		model.SetSearchTimeout(cfg.Search.SearchTimeout())
+	}
+	model.SetConfirmFiles(cfg.Search.ConfirmFiles)
+	model.SetResultBatching(cfg.Search.BatchSize, cfg.Search.SearchBatchInterval(), cfg.Search.BatchTuning == nil || *cfg.Search.BatchTuning)
+	model.SetLSP(*lspFlag)
+	model.SetSelectMode(*selectFlag)
+	model.SetQuickfixFile(*quickfixFileFlag)
+	if *sourcegraphFlag {
+		sg, err := search.NewSourcegraphSearcherFromEnv()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --sourcegraph: %v\n", err)
+			os.Exit(1)
+		}
+		model.UseSourcegraph(sg)
+	}
+	if cfg.UI.Bind != "" {
+		if err := model.Bind(cfg.UI.Bind); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: config [ui] bind: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, spec := range bindFlags {
+		if err := model.Bind(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --bind: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	p := tea.NewProgram(
-		model,
-		tea.WithAltScreen(),
-		tea.WithMouseCellMotion(),
-	)
+	if *announceFlag != "" {
+		out, err := openAnnounce(*announceFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --announce: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+		model.SetAnnouncer(out)
+	}
+
+	var collector *metrics.Collector
+	if *metricsFlag {
+		collector = metrics.New()
+		model.SetMetrics(collector)
+	}
+
+	mouse := cfg.UI.Mouse == nil || *cfg.UI.Mouse
+	model.SetMouse(mouse)
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if mouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	if *selectFlag || (outputFormat != "" && *outputFileFlag == "") {
+		// Results go to stdout, so draw the UI on the terminal itself to keep
+		// pipelines like `vim -q <(irg --output=quickfix)` and shell widgets'
+		// $(irg --select) clean
+		if tty, err := os.OpenFile(terminalPath(), os.O_RDWR, 0); err == nil {
+			defer tty.Close()
+			opts = append(opts, tea.WithOutput(tty), tea.WithInputTTY())
+			// Detect colors from the terminal, not the redirected stdout
+			lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(tty))
+		}
+	}
+
+	// Colors are checked against the terminal the UI is drawn on, so this
+	// follows the renderer setup above
+	model.SetColors(checkColors(cfg.Colors))
 
-	if _, err := p.Run(); err != nil {
+	if *pathsFromFlag == "-" {
+		// Stdin carried the path list, so read keys from the terminal
+		opts = append(opts, tea.WithInputTTY())
+	}
+
+	p := tea.NewProgram(model, opts...)
+	signaled := quitOnSignal(p)
+
+	finalModel, err := p.Run()
+	// Nothing is printed for a search stopped by a signal: the terminal
+	// may be gone, and a caller killing irg doesn't want its output
+	stopped := signaled()
+	selected := false
+	if m, ok := finalModel.(ui.Model); ok {
+		if match, ok := m.Accepted(); ok && err == nil && stopped == nil {
+			fmt.Printf("%s:%d\n", match.Path, match.LineNumber)
+			selected = true
+		}
+		if outputFormat != "" && err == nil && stopped == nil {
+			if oerr := writeOutput(m, outputFormat, *outputFileFlag); oerr != nil {
+				fmt.Fprintf(os.Stderr, "Error writing results: %v\n", oerr)
+			}
+		}
+		if err == nil && stopped == nil {
+			// Keep stdout clean when it carries results
+			hookOut := os.Stdout
+			if *selectFlag || (outputFormat != "" && *outputFileFlag == "") {
+				hookOut = os.Stderr
+			}
+			if *printOnExitFlag {
+				if perr := printResults(m, hookOut); perr != nil {
+					fmt.Fprintf(os.Stderr, "Error printing results: %v\n", perr)
+				}
+			}
+			if herr := m.RunExitHook(hookOut); herr != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", herr)
+			}
+		}
+		m.Close()
+	}
+	if collector != nil {
+		if werr := collector.WriteFile(*metricsFileFlag); werr != nil {
+			fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", werr)
+		} else {
+			fmt.Fprintf(os.Stderr, "Metrics written to %s\n", *metricsFileFlag)
+		}
+	}
+	if sig, ok := stopped.(syscall.Signal); ok {
+		os.Exit(128 + int(sig))
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running irg: %v\n", err)
 		os.Exit(1)
 	}
+	if *selectFlag && !selected {
+		// Like fzf, exit non-zero when nothing was chosen so widgets can tell
+		os.Exit(130)
+	}
+}
+
+// openAnnounce opens the --announce target for appending: fd:N names a
+// descriptor irg inherited, such as 3 in `irg --announce=fd:3 3> >(reader)`, and
+// anything else a file or named pipe
+func openAnnounce(target string) (*os.File, error) {
+	if fd, ok := strings.CutPrefix(target, "fd:"); ok {
+		n, err := strconv.Atoi(fd)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid descriptor %q", fd)
+		}
+		return os.NewFile(uintptr(n), target), nil
+	}
+	return os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+}
+
+// quitOnSignal quits p when irg is told to terminate or loses its terminal,
+// as when its tab is closed, so the cleanup after the UI still runs: rg
+// processes are killed, state is saved and temporary files are removed. A
+// second signal kills the UI without waiting for it. The returned function
+// reports the signal received, or nil.
+func quitOnSignal(p *tea.Program) func() os.Signal {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP)
+	var received atomic.Value
+	go func() {
+		sig := <-sigs
+		received.Store(sig)
+		p.Quit()
+		<-sigs
+		p.Kill()
+	}()
+	return func() os.Signal {
+		sig, _ := received.Load().(os.Signal)
+		return sig
+	}
+}
+
+// runServe implements `irg serve`: answer requests on a Unix socket, or on
+// stdin/stdout with --stdio, until interrupted, returning the exit code
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("irg serve", flag.ExitOnError)
+	socketFlag := fs.String("socket", server.DefaultSocketPath(), "Unix socket to listen on")
+	stdioFlag := fs.Bool("stdio", false, "Serve a single client on stdin/stdout instead of a socket")
+	msgpackFlag := fs.Bool("msgpack", false, "Speak msgpack-RPC (as used by Neovim) instead of JSON lines")
+	fs.Parse(args)
+
+	if _, err := exec.LookPath("rg"); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ripgrep (rg) is not installed or not in PATH")
+		return 1
+	}
+
+	protocol := server.ProtocolJSON
+	if *msgpackFlag {
+		protocol = server.ProtocolMsgpack
+	}
+	srv := server.New(server.NewService(), protocol)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *stdioFlag {
+		srv.ServeConn(ctx, server.Stdio())
+		return 0
+	}
+
+	ln, err := server.Listen(*socketFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: serve: %v\n", err)
+		return 1
+	}
+	// Closing the listener removes the socket file
+	defer ln.Close()
+
+	fmt.Fprintf(os.Stderr, "irg: listening on %s\n", *socketFlag)
+	if err := srv.Serve(ctx, ln); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runCount implements `irg count`: a live table of match counts for a list
+// of patterns, or a single tab-separated report with --print
+func runCount(args []string) int {
+	fs := flag.NewFlagSet("irg count", flag.ExitOnError)
+	var patternFlags, typeFlags, typeNotFlags arrayFlags
+	fs.Var(&patternFlags, "e", "Pattern to count (can be used multiple times)")
+	patternsFileFlag := fs.String("patterns-file", "", "Read patterns to count from this file, one per line (- for stdin)")
+	pathFlag := fs.String("path", ".", "Directory or file to search")
+	fs.Var(&typeFlags, "type", "Include only files of type (can be used multiple times)")
+	fs.Var(&typeNotFlags, "type-not", "Exclude files of type (can be used multiple times)")
+	caseFlag := fs.String("case", "smart", "Case sensitivity mode: smart, sensitive, insensitive")
+	gitTrackedFlag := fs.Bool("git-tracked", false, "Count only in files tracked by git")
+	noIgnoreFlag := fs.Bool("no-ignore", false, "Count in files excluded by .gitignore and similar files too")
+	multilineFlag := fs.Bool("multiline", false, "Let the pattern match across lines")
+	var wordRegexpFlag bool
+	fs.BoolVar(&wordRegexpFlag, "word-regexp", false, "Count the pattern only as a whole word")
+	fs.BoolVar(&wordRegexpFlag, "w", false, "Short for --word-regexp")
+	maxDepthFlag := fs.Int("max-depth", 0, "Count at most N directory levels below the path (default: any depth)")
+	intervalFlag := fs.Duration("interval", 0, "Count again at this interval, e.g. 30s (default: only when r is pressed)")
+	printFlag := fs.Bool("print", false, "Print the counts once as matches<TAB>files<TAB>pattern lines and exit")
+	fs.Parse(args)
+
+	if _, err := exec.LookPath("rg"); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ripgrep (rg) is not installed or not in PATH")
+		return 1
+	}
+	caseSensitivity, err := search.ParseCaseSensitivity(*caseFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: --case must be one of: smart, sensitive, insensitive")
+		return 1
+	}
+	if *maxDepthFlag < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-depth must not be negative")
+		return 1
+	}
+
+	patterns := []string(patternFlags)
+	if *patternsFileFlag != "" {
+		in := os.Stdin
+		if *patternsFileFlag != "-" {
+			f, err := os.Open(*patternsFileFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --patterns-file: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			in = f
+		}
+		read, err := dashboard.ReadPatterns(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --patterns-file: %v\n", err)
+			return 1
+		}
+		patterns = append(patterns, read...)
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no patterns to count; pass -e PATTERN or --patterns-file")
+		return 1
+	}
+
+	opts := search.Options{
+		CaseSensitivity: caseSensitivity,
+		FileTypes:       typeFlags,
+		FileTypesNot:    typeNotFlags,
+		GitTracked:      *gitTrackedFlag,
+		NoIgnore:        *noIgnoreFlag,
+		Multiline:       *multilineFlag,
+		WholeWord:       wordRegexpFlag,
+		MaxDepth:        *maxDepthFlag,
+	}
+	if cfg, err := loadConfig("", true); err == nil {
+		opts.CustomTypes = cfg.Types
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *printFlag {
+		if err := dashboard.Print(ctx, os.Stdout, patterns, *pathFlag, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	programOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithContext(ctx)}
+	if *patternsFileFlag == "-" {
+		// Stdin carried the patterns, so read keys from the terminal
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	model := dashboard.New(patterns, *pathFlag, opts, *intervalFlag)
+	if _, err := tea.NewProgram(model, programOpts...).Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		fmt.Fprintf(os.Stderr, "Error running irg count: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runTodos implements `irg todos`: a dashboard of the TODO, FIXME and HACK
+// comments grouped by tag and owner, or a report of them with --print or
+// --output
+func runTodos(args []string) int {
+	fs := flag.NewFlagSet("irg todos", flag.ExitOnError)
+	var tagFlags, typeFlags, typeNotFlags arrayFlags
+	fs.Var(&tagFlags, "tag", "Collect comments marked with this word instead of TODO, FIXME and HACK (can be used multiple times)")
+	pathFlag := fs.String("path", ".", "Directory or file to search")
+	fs.Var(&typeFlags, "type", "Include only files of type (can be used multiple times)")
+	fs.Var(&typeNotFlags, "type-not", "Exclude files of type (can be used multiple times)")
+	gitTrackedFlag := fs.Bool("git-tracked", false, "Search only files tracked by git")
+	noIgnoreFlag := fs.Bool("no-ignore", false, "Search files excluded by .gitignore and similar files too")
+	maxDepthFlag := fs.Int("max-depth", 0, "Search at most N directory levels below the path (default: any depth)")
+	printFlag := fs.Bool("print", false, "Print the comments once as a Markdown report grouped by tag and owner and exit")
+	outputFlag := fs.String("output", "", "Print the comments once in this format and exit: sarif, quickfix, json")
+	outputFileFlag := fs.String("output-file", "", "Write --print or --output to this file instead of stdout")
+	fs.Parse(args)
+
+	if _, err := exec.LookPath("rg"); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ripgrep (rg) is not installed or not in PATH")
+		return 1
+	}
+	if *maxDepthFlag < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-depth must not be negative")
+		return 1
+	}
+	var format export.Format
+	if *outputFlag != "" {
+		var err error
+		if format, err = export.ParseFormat(*outputFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --output: %v\n", err)
+			return 1
+		}
+		if *printFlag {
+			fmt.Fprintf(os.Stderr, "Error: --print conflicts with --output=%s\n", *outputFlag)
+			return 1
+		}
+	}
+
+	opts := search.Options{
+		FileTypes:    typeFlags,
+		FileTypesNot: typeNotFlags,
+		GitTracked:   *gitTrackedFlag,
+		NoIgnore:     *noIgnoreFlag,
+		MaxDepth:     *maxDepthFlag,
+	}
+	tags := todos.DefaultTags
+	editorCommand := ""
+	if cfg, err := loadConfig("", true); err == nil {
+		opts.CustomTypes = cfg.Types
+		if len(cfg.Todos.Tags) > 0 {
+			tags = cfg.Todos.Tags
+		}
+		editorCommand = cfg.Editor.Command
+	}
+	if len(tagFlags) > 0 {
+		if slices.Contains(tagFlags, "") {
+			fmt.Fprintln(os.Stderr, "Error: --tag must not be empty")
+			return 1
+		}
+		tags = tagFlags
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *printFlag || format != "" {
+		items, err := todos.Collect(ctx, search.NewRipgrepSearcher(), *pathFlag, tags, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		out := os.Stdout
+		if *outputFileFlag != "" {
+			if out, err = os.Create(*outputFileFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+		if *printFlag {
+			err = todos.Print(out, todos.Groups(items, tags))
+		} else {
+			set := export.Set{Pattern: todos.Pattern(tags)}
+			for _, item := range items {
+				set.Matches = append(set.Matches, item.Match)
+			}
+			err = export.Write(out, format, set)
+		}
+		if out != os.Stdout {
+			if cerr := out.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	model := todos.New(*pathFlag, tags, opts, editorCommand)
+	if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		fmt.Fprintf(os.Stderr, "Error running irg todos: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runStats implements `irg stats`: print the usage statistics recorded by
+// past sessions, or delete them with --reset
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("irg stats", flag.ExitOnError)
+	topFlag := fs.Int("top", 10, "Number of directories and types to list")
+	resetFlag := fs.Bool("reset", false, "Delete the recorded statistics")
+	fs.Parse(args)
+
+	path, err := stats.Path()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *resetFlag {
+		if err := stats.Reset(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	s, err := stats.Read(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := stats.Print(os.Stdout, s, *topFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// boolOption returns the value of the named flag when it was given, and the
+// configured default otherwise
+func boolOption(name string, value, configured bool) bool {
+	if flagSet(name) {
+		return value
+	}
+	return configured
+}
+
+// repoRoot returns project, the top of the git repository around the working
+// directory, relative to it; "" outside a repository or at its top
+func repoRoot(project string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(wd, project)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return rel
+}
+
+// configFile returns the config file settings are saved to: path when given,
+// else the default location, or "" when that can't be found
+func configFile(path string) string {
+	if path != "" {
+		return path
+	}
+	path, err := config.Path()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// checkColors applies the configured colors over the defaults and checks
+// that they are readable, fixing or reporting the ones that aren't
+func checkColors(c config.Colors) ui.Colors {
+	colors := ui.DefaultColors.Merge(ui.Colors{
+		SelectedBackground:     c.SelectedBackground,
+		Match:                  c.Match,
+		PreviewMatchForeground: c.PreviewMatchForeground,
+		PreviewMatchBackground: c.PreviewMatchBackground,
+	})
+	if c.Contrast == "off" {
+		return colors
+	}
+
+	background := c.Background
+	if background == "" {
+		background = "#000000"
+		if !lipgloss.HasDarkBackground() {
+			background = "#ffffff"
+		}
+	}
+	checked, problems := colors.CheckContrast(background, c.Contrast != "warn")
+	if c.Contrast == "warn" {
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "Warning: colors: %s\n", problem)
+		}
+	}
+	return checked
+}
+
+// loadConfig reads the user config from path, or the default location when
+// path is empty, and applies the nearest project config over it
+func loadConfig(path string, project bool) (*config.Config, error) {
+	var cfg *config.Config
+	var err error
+	if path != "" {
+		cfg, err = config.LoadFile(path)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil || !project {
+		return cfg, err
+	}
+
+	projectPath, err := config.FindProject(".")
+	if err != nil || projectPath == "" {
+		return cfg, err
+	}
+	projectCfg, err := config.LoadFile(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range cfg.Merge(projectCfg) {
+		fmt.Fprintf(os.Stderr, "Warning: %s: ignoring [%s]; it is only read from your own config file\n", projectPath, key)
+	}
+	return cfg, nil
+}
+
+// resultClasses converts the configured classes, ordered by name so the
+// first match is stable
+func resultClasses(classes map[string]config.Class) []classify.Class {
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]classify.Class, 0, len(names))
+	for _, name := range names {
+		style := classify.Style(classes[name].Style)
+		if style == "" {
+			style = classify.StyleDim
+		}
+		list = append(list, classify.Class{Name: name, Globs: classes[name].Globs, Style: style})
+	}
+	return list
+}
+
+// bookmarks converts the configured bookmarks, expanding a leading ~ to the
+// home directory
+func bookmarks(configured map[string]string) []ui.Bookmark {
+	home, _ := os.UserHomeDir()
+	list := make([]ui.Bookmark, 0, len(configured))
+	for name, path := range configured {
+		if rest, ok := strings.CutPrefix(path, "~"); ok && home != "" && (rest == "" || os.IsPathSeparator(rest[0])) {
+			path = filepath.Join(home, rest)
+		}
+		list = append(list, ui.Bookmark{Name: name, Path: path})
+	}
+	return list
+}
+
+// profiles converts the configured search profiles
+func profiles(configured map[string]config.Profile) []ui.Profile {
+	list := make([]ui.Profile, 0, len(configured))
+	for name, p := range configured {
+		list = append(list, ui.Profile{Name: name, Types: p.Types, Exclude: p.Exclude})
+	}
+	return list
+}
+
+// linters converts the configured linters, keeping their order
+func linters(configured []config.Linter) []lint.Linter {
+	list := make([]lint.Linter, len(configured))
+	for i, l := range configured {
+		list[i] = lint.Linter{Globs: l.Globs, Command: l.Command}
+	}
+	return list
+}
+
+// previewRadii converts the configured preview contexts, keeping their order
+func previewRadii(contexts []config.PreviewContext) []ui.ContextRadius {
+	radii := make([]ui.ContextRadius, len(contexts))
+	for i, c := range contexts {
+		radii[i] = ui.ContextRadius{Globs: c.Globs, Lines: c.Lines}
+	}
+	return radii
+}
+
+// terminalPath names the controlling terminal's device
+func terminalPath() string {
+	if runtime.GOOS == "windows" {
+		return "CONOUT$"
+	}
+	return "/dev/tty"
+}
+
+// runTmuxPopup re-runs irg with the same arguments in a tmux popup and prints
+// its selection, returning the exit code
+func runTmuxPopup(size string) int {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --tmux-popup: %v\n", err)
+		return 1
+	}
+
+	output, err := tmux.Popup(executable, popupArgs(os.Args[1:]), size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --tmux-popup: %v\n", err)
+		return 1
+	}
+	if strings.TrimSpace(output) == "" {
+		return 130
+	}
+	fmt.Print(output)
+	return 0
+}
+
+// popupArgs drops the --tmux-popup flags from args and makes sure the popup
+// runs in --select mode
+func popupArgs(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			out = append(out, args[i])
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		switch name {
+		case "tmux-popup":
+			continue
+		case "tmux-popup-size":
+			if !hasValue {
+				i++ // Skip the separate value
+			}
+			continue
+		case "select":
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return append([]string{"--select"}, out...)
+}
+
+// printOnExitLimit caps --print-on-exit, so a huge result set doesn't
+// flood the scrollback
+const printOnExitLimit = 1000
+
+// printResults prints the final results, or the marked ones, to out once
+// the UI has left the alternate screen, colored when out is a terminal
+func printResults(m ui.Model, out *os.File) error {
+	set, err := m.PrintSet()
+	if err != nil || len(set.Matches) == 0 {
+		return err
+	}
+	return export.Print(out, set, printOnExitLimit, isTerminal(out) && os.Getenv("NO_COLOR") == "")
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeOutput exports the final result set, or its marked results, to path,
+// or stdout if path is empty
+func writeOutput(m ui.Model, format export.Format, path string) error {
+	set, err := m.PrintSet()
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return export.Write(os.Stdout, format, set)
+	}
+	return export.WriteFile(path, format, set)
 }